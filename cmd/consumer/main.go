@@ -2,21 +2,54 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
+	httpapi "draftea-challenge/internal/adapters/http"
+	"draftea-challenge/internal/adapters/http/handlers"
+	"draftea-challenge/internal/adapters/messaging"
 	"draftea-challenge/internal/adapters/messaging/rabbitmq"
+	"draftea-challenge/internal/adapters/persistence/postgres"
+	"draftea-challenge/internal/application/audit"
+	"draftea-challenge/internal/application/inbox"
+	"draftea-challenge/internal/application/metrics"
+	"draftea-challenge/internal/application/projections"
+	"draftea-challenge/internal/platform/clock"
 	"draftea-challenge/internal/platform/config"
+	"draftea-challenge/internal/platform/db"
+	"draftea-challenge/internal/platform/factory"
 	"draftea-challenge/internal/platform/logger"
+	"draftea-challenge/internal/platform/server"
 
-	amqp "github.com/rabbitmq/amqp091-go"
 	"go.uber.org/zap"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "replay":
+			if err := replay(os.Args[2:]); err != nil {
+				log.Fatalf("replay failed: %v", err)
+			}
+			return
+		case "rebuild-projection":
+			if err := rebuildProjection(os.Args[2:]); err != nil {
+				log.Fatalf("projection rebuild failed: %v", err)
+			}
+			return
+		case "verify-audit":
+			if err := verifyAudit(os.Args[2:]); err != nil {
+				log.Fatalf("audit verification failed: %v", err)
+			}
+			return
+		}
+	}
 	if err := run(); err != nil {
 		log.Fatalf("consumer exited with error: %v", err)
 	}
@@ -34,112 +67,275 @@ func run() error {
 	}
 	defer func() { _ = zapLogger.Sync() }()
 
+	dbConn, dbCleanup, err := db.NewPostgres(cfg.DB, zapLogger)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = dbCleanup() }()
+
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
-	rabbitCfg := rabbitmq.Config{
-		URL:                   cfg.Rabbit.URL,
-		Exchange:              cfg.Rabbit.Exchange,
-		MetricsQueue:          cfg.Rabbit.MetricsQueue,
-		AuditQueue:            cfg.Rabbit.AuditQueue,
-		PublishConfirmTimeout: cfg.Rabbit.PublishConfirmTimeout,
-	}
-
-	metricsConsumer, metricsCleanup, err := connectConsumerWithRetry(
-		ctx,
-		rabbitCfg,
-		cfg.Rabbit.MetricsQueue,
-		zapLogger,
-		cfg.Rabbit.RelayMaxRetries,
-		cfg.Rabbit.RelayInitialBackoff,
-		cfg.Rabbit.RelayMaxBackoff,
-	)
+	persistence := postgres.NewPostgresPersistence(dbConn)
+	aggregator := metrics.NewAggregator(persistence, clock.SystemClock{}, cfg.Metrics.Windows)
+	metricsInbox := inbox.NewInbox[*postgres.PostgresPersistence](persistence, clock.SystemClock{}, cfg.Rabbit.MetricsQueue)
+	auditLog := audit.NewLog(persistence, clock.SystemClock{})
+	dailySpend := projections.NewDailySpendProjection(persistence, clock.SystemClock{})
+
+	metricsConsumer, metricsCleanup, err := factory.ConnectConsumer(ctx, cfg, cfg.Rabbit.MetricsQueue, cfg.Rabbit.MetricsConsumer, zapLogger)
 	if err != nil {
 		return err
 	}
 	defer func() { _ = metricsCleanup() }()
 
-	auditConsumer, auditCleanup, err := connectConsumerWithRetry(
-		ctx,
-		rabbitCfg,
-		cfg.Rabbit.AuditQueue,
-		zapLogger,
-		cfg.Rabbit.RelayMaxRetries,
-		cfg.Rabbit.RelayInitialBackoff,
-		cfg.Rabbit.RelayMaxBackoff,
-	)
+	auditConsumer, auditCleanup, err := factory.ConnectConsumer(ctx, cfg, cfg.Rabbit.AuditQueue, cfg.Rabbit.AuditConsumer, zapLogger)
 	if err != nil {
 		return err
 	}
 	defer func() { _ = auditCleanup() }()
 
+	zapLogger.Info("consumers started", zap.String("metrics_queue", cfg.Rabbit.MetricsQueue), zap.String("audit_queue", cfg.Rabbit.AuditQueue))
+
+	// Start returns once the consumer stopped and its in-flight handlers are done.
+	var wg sync.WaitGroup
+	wg.Add(2)
 	go func() {
-		err := metricsConsumer.Start(ctx, func(ctx context.Context, msg amqp.Delivery) error {
-			zapLogger.Info("metrics event", zap.String("routing_key", msg.RoutingKey), zap.ByteString("body", msg.Body))
-			return nil
-		})
-		if err != nil {
+		defer wg.Done()
+		if err := metricsConsumer.Start(ctx, metricsHandler(aggregator, metricsInbox)); err != nil {
 			zapLogger.Error("metrics consumer stopped", zap.Error(err))
 		}
 	}()
 
 	go func() {
-		err := auditConsumer.Start(ctx, func(ctx context.Context, msg amqp.Delivery) error {
-			zapLogger.Info("audit event", zap.String("routing_key", msg.RoutingKey), zap.ByteString("body", msg.Body))
-			return nil
-		})
-		if err != nil {
+		defer wg.Done()
+		if err := auditConsumer.Start(ctx, auditHandler(auditLog, zapLogger)); err != nil {
 			zapLogger.Error("audit consumer stopped", zap.Error(err))
 		}
 	}()
 
+	if cfg.Inbox.RetentionInterval > 0 && cfg.Inbox.Retention > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			factory.RunInboxRetention(ctx, metricsInbox, cfg.Inbox, zapLogger)
+		}()
+	}
+
+	if cfg.Projections.Interval > 0 {
+		runner := projectionRunner(cfg, persistence, dailySpend)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runner.Run(ctx, cfg.Projections.Interval, func(results []projections.Result, err error) {
+				if err != nil {
+					zapLogger.Warn("projection runner failed", zap.Error(err))
+				}
+				for _, res := range results {
+					if res.Applied > 0 || res.Skipped > 0 {
+						zapLogger.Info("projection updated", zap.String("projection", res.Projection), zap.Int("applied", res.Applied), zap.Int("skipped", res.Skipped))
+					}
+				}
+			})
+		}()
+	}
+
+	if cfg.Metrics.HTTPAddr != "" {
+		router := httpapi.NewMetricsRouter(httpapi.MetricsRouterDeps{
+			Logger:            zapLogger,
+			APIKey:            cfg.App.APIKey,
+			RequestTimeout:    cfg.App.RequestTimeout,
+			MetricsHandler:    handlers.NewMetricsHandler(aggregator),
+			DailySpendHandler: handlers.NewDailySpendHandler(dailySpend),
+		})
+		srv := server.New(cfg.Metrics.HTTPAddr, router, cfg.App.ShutdownTimeout)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			zapLogger.Info("metrics endpoint listening", zap.String("addr", cfg.Metrics.HTTPAddr))
+			if err := srv.Run(ctx); err != nil {
+				zapLogger.Error("metrics endpoint stopped", zap.Error(err))
+			}
+		}()
+	}
+
 	<-ctx.Done()
-	zapLogger.Info("consumers shutting down")
+	zapLogger.Info("consumers shutting down, draining in-flight messages")
+	wg.Wait()
+	zapLogger.Info("consumers stopped")
 	return nil
 }
 
-func connectConsumerWithRetry(
-	ctx context.Context,
-	cfg rabbitmq.Config,
-	queue string,
-	log *zap.Logger,
-	maxRetries int,
-	initialBackoff time.Duration,
-	maxBackoff time.Duration,
-) (*rabbitmq.Consumer, func() error, error) {
-	attempts := maxRetries + 1
-	if attempts <= 0 {
-		attempts = 1
-	}
-	backoff := initialBackoff
-	if backoff <= 0 {
-		backoff = 200 * time.Millisecond
-	}
-	if maxBackoff <= 0 {
-		maxBackoff = 2 * time.Second
-	}
-
-	var lastErr error
-	for i := 0; i < attempts; i++ {
-		consumer, cleanup, err := rabbitmq.NewConsumer(cfg, queue, log)
-		if err == nil {
-			return consumer, cleanup, nil
+// replay moves dead-lettered messages of a consumer queue back to it:
+//
+//	consumer replay -queue audit.queue -limit 100
+func replay(args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	queue := fs.String("queue", "", "consumer queue whose dead letters are replayed (e.g. "+cfg.Rabbit.AuditQueue+")")
+	limit := fs.Int("limit", 0, "maximum number of messages to replay, 0 for all those in the DLQ at start")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *queue == "" {
+		return fmt.Errorf("-queue is required")
+	}
+	if !factory.IsRabbitBroker(cfg) {
+		return fmt.Errorf("replay needs the rabbit broker, configured broker is %q", cfg.Broker.Kind)
+	}
+
+	zapLogger, err := logger.New(logger.Config{Level: cfg.Logger.Level, Development: cfg.Logger.Development})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = zapLogger.Sync() }()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	replayed, err := rabbitmq.ReplayDeadLetters(ctx, factory.RabbitConfig(cfg), *queue, *limit, zapLogger)
+	zapLogger.Info("dead letters replayed", zap.String("queue", *queue), zap.Int("replayed", replayed))
+	return err
+}
+
+// rebuildProjection rewinds a projection and replays the outbox history into it:
+//
+//	consumer rebuild-projection -name daily_spend [-from 2024-03-01]
+//
+// Without -from the projection is rebuilt from the first event.
+func rebuildProjection(args []string) error {
+	fs := flag.NewFlagSet("rebuild-projection", flag.ContinueOnError)
+	name := fs.String("name", "", "projection to rebuild (daily_spend)")
+	from := fs.String("from", "", "replay events from this day (YYYY-MM-DD) or time (RFC 3339); empty rebuilds everything")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" {
+		return fmt.Errorf("-name is required")
+	}
+	var since time.Time
+	if *from != "" {
+		var err error
+		if since, err = time.Parse("2006-01-02", *from); err != nil {
+			if since, err = time.Parse(time.RFC3339, *from); err != nil {
+				return fmt.Errorf("invalid -from %q: %w", *from, err)
+			}
 		}
-		lastErr = err
-		log.Warn("rabbitmq consumer connect failed", zap.Error(err), zap.String("queue", queue))
-		if i == attempts-1 {
-			break
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	zapLogger, err := logger.New(logger.Config{Level: cfg.Logger.Level, Development: cfg.Logger.Development})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = zapLogger.Sync() }()
+
+	dbConn, dbCleanup, err := db.NewPostgres(cfg.DB, zapLogger)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = dbCleanup() }()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	persistence := postgres.NewPostgresPersistence(dbConn)
+	runner := projectionRunner(cfg, persistence, projections.NewDailySpendProjection(persistence, clock.SystemClock{}))
+	res, err := runner.Replay(ctx, *name, since)
+	zapLogger.Info("projection rebuilt",
+		zap.String("projection", *name),
+		zap.Time("from", since),
+		zap.Int("applied", res.Applied),
+		zap.Int("skipped", res.Skipped),
+		zap.Time("position", res.Position.CreatedAt),
+	)
+	return err
+}
+
+// metricsHandler adds payment events to the KPI windows and the hourly rollups. The inbox commits
+// the rollup with the message ID, so redeliveries are not counted twice.
+func metricsHandler(aggregator *metrics.Aggregator, metricsInbox *inbox.Inbox[*postgres.PostgresPersistence]) messaging.Handler {
+	return messaging.WithInbox(metricsInbox, func(ctx context.Context, tx *postgres.PostgresPersistence, ev *messaging.Event) error {
+		record, err := aggregator.Stage(ctx, tx, ev.Data)
+		if err != nil {
+			return err
 		}
-		select {
-		case <-time.After(backoff):
-		case <-ctx.Done():
-			return nil, nil, ctx.Err()
+		inbox.AfterCommit(ctx, record)
+		return nil
+	})
+}
+
+// auditHandler appends events to the hash-chained audit log; events it already holds are skipped.
+func auditHandler(auditLog *audit.Log, log *zap.Logger) messaging.Handler {
+	return func(ctx context.Context, ev *messaging.Event) error {
+		recorded, err := auditLog.Record(ctx, ev.Data)
+		if err != nil {
+			return err
 		}
-		next := backoff * 2
-		if next > maxBackoff {
-			next = maxBackoff
+		if !recorded {
+			log.Debug("audit event skipped", zap.String("type", ev.Type), zap.String("id", ev.ID))
 		}
-		backoff = next
+		return nil
+	}
+}
+
+func projectionRunner(cfg config.Config, persistence *postgres.PostgresPersistence, list ...projections.Projection) *projections.Runner {
+	return projections.NewRunner(persistence, persistence, clock.SystemClock{}, projections.Config{
+		BatchSize: cfg.Projections.BatchSize,
+		Lag:       cfg.Projections.Lag,
+	}, list...)
+}
+
+// verifyAudit walks the audit log hash chain and fails if any entry does not verify:
+//
+//	consumer verify-audit [-batch 1000]
+func verifyAudit(args []string) error {
+	fs := flag.NewFlagSet("verify-audit", flag.ContinueOnError)
+	batch := fs.Int("batch", 1000, "entries read per query")
+	if err := fs.Parse(args); err != nil {
+		return err
 	}
-	return nil, nil, lastErr
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	zapLogger, err := logger.New(logger.Config{Level: cfg.Logger.Level, Development: cfg.Logger.Development})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = zapLogger.Sync() }()
+
+	dbConn, dbCleanup, err := db.NewPostgres(cfg.DB, zapLogger)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = dbCleanup() }()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	report, err := audit.NewLog(postgres.NewPostgresPersistence(dbConn), clock.SystemClock{}).Verify(ctx, *batch)
+	if err != nil {
+		return err
+	}
+	for _, b := range report.Breaks {
+		zapLogger.Error("audit chain break", zap.Int64("seq", b.Seq), zap.String("event_id", b.EventID), zap.String("reason", b.Reason))
+	}
+	zapLogger.Info("audit chain verified",
+		zap.Int64("checked", report.Checked),
+		zap.Int64("last_seq", report.LastSeq),
+		zap.String("last_hash", report.LastHash),
+		zap.Int("breaks", report.BreakCount),
+	)
+	if !report.OK() {
+		return fmt.Errorf("%d broken entries, first at seq %d", report.BreakCount, report.Breaks[0].Seq)
+	}
+	return nil
 }
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"draftea-challenge/internal/adapters/messaging"
+	"draftea-challenge/internal/adapters/messaging/memory"
+	"draftea-challenge/internal/adapters/persistence/postgres"
+	"draftea-challenge/internal/application/audit"
+	"draftea-challenge/internal/application/events"
+	"draftea-challenge/internal/application/inbox"
+	"draftea-challenge/internal/application/metrics"
+	"draftea-challenge/internal/application/outbox/relay"
+	"draftea-challenge/internal/platform/clock"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// TestRelayToConsumersOnMemoryBroker runs the outbox relay and the metrics and audit handlers of this
+// command on the in-memory broker, against a file-backed SQLite database.
+func TestRelayToConsumersOnMemoryBroker(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "e2e.db")+"?_busy_timeout=5000"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("sql db: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	if err := postgres.AutoMigrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	persistence := postgres.NewPostgresPersistence(db)
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	providerID := uuid.New()
+	payment := func(amount, fee int64, status string) events.Payment {
+		return events.Payment{TransactionID: uuid.New(), UserID: uuid.New(), ProviderID: providerID, Amount: amount, Fee: fee, Currency: "USD", Status: status}
+	}
+	for _, ev := range []events.Event{
+		events.PaymentCompleted{Payment: payment(1000, 30, "APPROVED")},
+		events.PaymentCompleted{Payment: payment(500, 15, "APPROVED")},
+		events.PaymentFailed{Payment: payment(700, 0, "DECLINED"), Reason: "declined"},
+	} {
+		stored, err := events.NewOutboxEvent(ctx, uuid.New(), now, ev)
+		if err != nil {
+			t.Fatalf("outbox event: %v", err)
+		}
+		if err := persistence.CreateEvent(ctx, stored); err != nil {
+			t.Fatalf("create event: %v", err)
+		}
+	}
+
+	const metricsQueue, auditQueue = "metrics.queue", "audit.queue"
+	broker := memory.NewBroker(memory.Config{
+		Bindings: messaging.Bindings(messaging.Queues{Metrics: metricsQueue, Audit: auditQueue}),
+		Source:   "/test",
+	})
+	aggregator := metrics.NewAggregator(persistence, clock.SystemClock{}, []time.Duration{time.Hour})
+	auditLog := audit.NewLog(persistence, clock.SystemClock{})
+	metricsInbox := inbox.NewInbox[*postgres.PostgresPersistence](persistence, clock.SystemClock{}, metricsQueue)
+
+	consumeCtx, stop := context.WithCancel(ctx)
+	defer stop()
+	var wg sync.WaitGroup
+	for queue, handler := range map[string]messaging.Handler{
+		metricsQueue: metricsHandler(aggregator, metricsInbox),
+		auditQueue:   auditHandler(auditLog, zap.NewNop()),
+	} {
+		consumer := broker.Consumer(queue, 2)
+		wg.Add(1)
+		go func(handler messaging.Handler) {
+			defer wg.Done()
+			if err := consumer.Start(consumeCtx, handler); err != nil {
+				t.Errorf("consumer stopped: %v", err)
+			}
+		}(handler)
+	}
+
+	res, err := relay.NewRelay(persistence, broker, clock.SystemClock{}, relay.Config{}).ProcessOnce(ctx)
+	if err != nil || res.Published != 3 {
+		t.Fatalf("expected 3 events published, got %+v %v", res, err)
+	}
+	waitDrained(t, broker, metricsQueue, auditQueue)
+
+	pending, err := persistence.GetPendingEvents(ctx, 10)
+	if err != nil || len(pending) != 0 {
+		t.Fatalf("expected the outbox drained, got %d %v", len(pending), err)
+	}
+	entries, err := persistence.ListAuditEntries(ctx, 0, 10)
+	if err != nil || len(entries) != 3 {
+		t.Fatalf("expected 3 audit entries, got %d %v", len(entries), err)
+	}
+	// A broker redelivery is neither counted again nor appended to the audit log twice.
+	first, err := persistence.GetEventByID(ctx, entries[0].EventID)
+	if err != nil {
+		t.Fatalf("get event: %v", err)
+	}
+	if err := broker.Publish(ctx, "", first); err != nil {
+		t.Fatalf("republish: %v", err)
+	}
+	waitDrained(t, broker, metricsQueue, auditQueue)
+
+	stop()
+	wg.Wait()
+
+	hourly, err := persistence.ListHourly(ctx, now.Truncate(time.Hour), now.Truncate(time.Hour).Add(time.Hour))
+	if err != nil {
+		t.Fatalf("list hourly: %v", err)
+	}
+	totals := make(map[string]metrics.Totals)
+	for _, r := range hourly {
+		totals[r.Status] = r.Totals
+	}
+	if got := totals["APPROVED"]; got.Count != 2 || got.Amount != 1500 || got.Fee != 45 {
+		t.Fatalf("unexpected approved rollup %+v", got)
+	}
+	if got := totals["DECLINED"]; got.Count != 1 || got.Amount != 700 {
+		t.Fatalf("unexpected declined rollup %+v", got)
+	}
+	if entries, err = persistence.ListAuditEntries(ctx, 0, 10); err != nil || len(entries) != 3 {
+		t.Fatalf("expected the redelivery skipped by the audit log, got %d %v", len(entries), err)
+	}
+	report, err := auditLog.Verify(ctx, 10)
+	if err != nil || !report.OK() {
+		t.Fatalf("expected the audit chain to verify, got %+v %v", report, err)
+	}
+	for _, queue := range []string{metricsQueue, auditQueue} {
+		if dead := broker.DeadLetters(queue); len(dead) != 0 {
+			t.Fatalf("unexpected dead letters on %s: %+v", queue, dead)
+		}
+	}
+}
+
+// waitDrained waits until every queue has nothing pending.
+func waitDrained(t *testing.T, b *memory.Broker, queues ...string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for _, queue := range queues {
+		for b.Pending(queue) > 0 {
+			if time.Now().After(deadline) {
+				t.Fatalf("queue %s not drained, %d pending", queue, b.Pending(queue))
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"draftea-challenge/internal/adapters/messaging"
+	"draftea-challenge/internal/adapters/persistence/postgres"
+	"draftea-challenge/internal/platform/config"
+	"draftea-challenge/internal/platform/factory"
+
+	"go.uber.org/zap"
+)
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatalf("notifications worker exited with error: %v", err)
+	}
+}
+
+func run() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	worker, err := factory.BuildNotificationWorker(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = worker.Cleanup() }()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	consumer, consumerCleanup, err := factory.ConnectConsumer(ctx, cfg, cfg.Rabbit.NotificationsQueue, cfg.Rabbit.NotificationsConsumer, worker.Logger)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = consumerCleanup() }()
+
+	var wg sync.WaitGroup
+	if cfg.Inbox.RetentionInterval > 0 && cfg.Inbox.Retention > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			factory.RunInboxRetention(ctx, worker.Inbox, cfg.Inbox, worker.Logger)
+		}()
+	}
+
+	worker.Logger.Info("notifications worker started", zap.String("queue", cfg.Rabbit.NotificationsQueue), zap.String("sink", cfg.Notifications.Sink))
+	// The notification is sent inside the inbox transaction: a failed send rolls the inbox row back
+	// so the broker retries it, and a redelivered event that was already sent is skipped.
+	err = consumer.Start(ctx, messaging.WithInbox(worker.Inbox, func(ctx context.Context, _ *postgres.PostgresPersistence, ev *messaging.Event) error {
+		sent, err := worker.Dispatcher.Notify(ctx, ev.Data)
+		if err != nil {
+			worker.Logger.Error("notification error", zap.Error(err), zap.String("type", ev.Type), zap.String("id", ev.ID))
+			return err
+		}
+		if sent {
+			worker.Logger.Debug("notification sent", zap.String("type", ev.Type), zap.String("id", ev.ID))
+		}
+		return nil
+	}))
+	stop()
+	wg.Wait()
+	if err != nil {
+		return err
+	}
+
+	worker.Logger.Info("notifications worker shutting down")
+	return nil
+}
@@ -2,18 +2,20 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
-	"draftea-challenge/internal/adapters/messaging/rabbitmq"
 	"draftea-challenge/internal/adapters/persistence/postgres"
+	"draftea-challenge/internal/application/outbox"
 	"draftea-challenge/internal/application/outbox/relay"
 	"draftea-challenge/internal/platform/clock"
 	"draftea-challenge/internal/platform/config"
 	"draftea-challenge/internal/platform/db"
+	"draftea-challenge/internal/platform/factory"
 	"draftea-challenge/internal/platform/logger"
 
 	"go.uber.org/zap"
@@ -46,76 +48,101 @@ func run() error {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
-	rabbitCfg := rabbitmq.Config{
-		URL:                   cfg.Rabbit.URL,
-		Exchange:              cfg.Rabbit.Exchange,
-		MetricsQueue:          cfg.Rabbit.MetricsQueue,
-		AuditQueue:            cfg.Rabbit.AuditQueue,
-		PublishConfirmTimeout: cfg.Rabbit.PublishConfirmTimeout,
-	}
-
-	publisher, publisherCleanup, err := rabbitmq.NewPublisher(rabbitCfg, zapLogger)
+	publisher, publisherCleanup, err := connectPublisherWithRetry(
+		ctx,
+		cfg,
+		zapLogger,
+		cfg.Rabbit.RelayMaxRetries,
+		cfg.Rabbit.RelayInitialBackoff,
+		cfg.Rabbit.RelayMaxBackoff,
+	)
 	if err != nil {
-		publisher, publisherCleanup, err = connectPublisherWithRetry(
-			ctx,
-			rabbitCfg,
-			zapLogger,
-			cfg.Rabbit.RelayMaxRetries,
-			cfg.Rabbit.RelayInitialBackoff,
-			cfg.Rabbit.RelayMaxBackoff,
-		)
-		if err != nil {
-			return err
-		}
+		return err
 	}
 	defer func() { _ = publisherCleanup() }()
 
 	outboxRepo := postgres.NewPostgresPersistence(dbConn)
 
 	relayWorker := relay.NewRelay(outboxRepo, publisher, clock.SystemClock{}, relay.Config{
-		BatchSize:      cfg.Rabbit.RelayBatchSize,
-		MaxInFlight:    cfg.Rabbit.RelayMaxInFlight,
-		MaxRetries:     cfg.Rabbit.RelayMaxRetries,
-		InitialBackoff: cfg.Rabbit.RelayInitialBackoff,
-		MaxBackoff:     cfg.Rabbit.RelayMaxBackoff,
+		ID:              relayInstanceID(),
+		Lease:           cfg.Rabbit.RelayLease,
+		BatchSize:       cfg.Rabbit.RelayBatchSize,
+		MaxInFlight:     cfg.Rabbit.RelayMaxInFlight,
+		MaxRetries:      cfg.Rabbit.RelayMaxRetries,
+		InitialBackoff:  cfg.Rabbit.RelayInitialBackoff,
+		MaxBackoff:      cfg.Rabbit.RelayMaxBackoff,
+		MaxAttempts:     cfg.Rabbit.RelayMaxAttempts,
+		RetryBackoff:    cfg.Rabbit.RelayRetryBackoff,
+		RetryMaxBackoff: cfg.Rabbit.RelayRetryMaxBackoff,
+		PollInterval:    cfg.Rabbit.RelayPollInterval,
+		MaxPollInterval: cfg.Rabbit.RelayMaxPollInterval,
 	})
 
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
+	if cfg.Outbox.RetentionInterval > 0 {
+		retention := outbox.NewRetentionService(outboxRepo, clock.SystemClock{}, outbox.RetentionConfig{
+			Retention:        cfg.Outbox.Retention,
+			ArchiveRetention: cfg.Outbox.ArchiveRetention,
+			BatchSize:        cfg.Outbox.RetentionBatchSize,
+		})
+		go runRetention(ctx, retention, cfg.Outbox.RetentionInterval, zapLogger)
+	}
+
+	wakeups := make(chan struct{}, 1)
+	if cfg.Rabbit.RelayNotifyChannel != "" {
+		go db.NewListener(cfg.DB, cfg.Rabbit.RelayNotifyChannel, zapLogger).Listen(ctx, wakeups)
+	}
+
+	zapLogger.Info("outbox relay started",
+		zap.String("notify_channel", cfg.Rabbit.RelayNotifyChannel),
+		zap.Duration("poll_interval", cfg.Rabbit.RelayPollInterval),
+	)
+	relayWorker.Run(ctx, wakeups, func(result relay.ProcessResult, err error) {
+		if err != nil {
+			zapLogger.Error("outbox relay error", zap.Error(err), zap.Int("claimed", result.Claimed), zap.Int("published", result.Published))
+			return
+		}
+		zapLogger.Debug("outbox relay processed batch", zap.Int("claimed", result.Claimed), zap.Int("published", result.Published))
+	})
+	zapLogger.Info("outbox relay shutting down")
+	return nil
+}
 
-	zapLogger.Info("outbox relay started")
+// runRetention archives sent outbox events every interval until ctx is done.
+func runRetention(ctx context.Context, retention *outbox.RetentionService, interval time.Duration, log *zap.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 	for {
+		result, err := retention.RunOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Error("outbox retention error", zap.Error(err), zap.Int("archived", result.Archived), zap.Int("purged", result.Purged))
+		} else if result.Archived > 0 || result.Purged > 0 {
+			log.Info("outbox retention", zap.Int("archived", result.Archived), zap.Int("purged", result.Purged))
+		}
 		select {
 		case <-ctx.Done():
-			zapLogger.Info("outbox relay shutting down")
-			return nil
+			return
 		case <-ticker.C:
-			pending, err := outboxRepo.GetPendingEvents(ctx, cfg.Rabbit.RelayBatchSize)
-			if err != nil {
-				zapLogger.Error("outbox relay fetch error", zap.Error(err))
-				continue
-			}
-			zapLogger.Debug("outbox relay tick", zap.Int("pending", len(pending)))
-			if len(pending) == 0 {
-				continue
-			}
-			if err := relayWorker.ProcessOnce(ctx); err != nil {
-				zapLogger.Error("outbox relay error", zap.Error(err))
-				continue
-			}
-			zapLogger.Debug("outbox relay processed batch", zap.Int("count", len(pending)))
 		}
 	}
 }
 
+// relayInstanceID identifies this replica in outbox claims.
+func relayInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "relay"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
 func connectPublisherWithRetry(
 	ctx context.Context,
-	cfg rabbitmq.Config,
+	cfg config.Config,
 	log *zap.Logger,
 	maxRetries int,
 	initialBackoff time.Duration,
 	maxBackoff time.Duration,
-) (*rabbitmq.Publisher, func() error, error) {
+) (outbox.MessagePublisher, func() error, error) {
 	attempts := maxRetries + 1
 	if attempts <= 0 {
 		attempts = 1
@@ -130,12 +157,12 @@ func connectPublisherWithRetry(
 
 	var lastErr error
 	for i := 0; i < attempts; i++ {
-		publisher, cleanup, err := rabbitmq.NewPublisher(cfg, log)
+		publisher, cleanup, err := factory.BuildPublisher(cfg, log)
 		if err == nil {
 			return publisher, cleanup, nil
 		}
 		lastErr = err
-		log.Warn("rabbitmq publisher connect failed", zap.Error(err))
+		log.Warn("publisher connect failed", zap.Error(err))
 		if i == attempts-1 {
 			break
 		}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"draftea-challenge/internal/platform/config"
+	"draftea-challenge/internal/platform/factory"
+
+	"go.uber.org/zap"
+)
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatalf("scheduler exited with error: %v", err)
+	}
+}
+
+func run() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	scheduler, err := factory.BuildScheduler(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = scheduler.Cleanup() }()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	pollInterval := cfg.Scheduler.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = 10 * time.Second
+	}
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	scheduler.Logger.Info("scheduler started", zap.Duration("poll_interval", pollInterval))
+	for {
+		select {
+		case <-ctx.Done():
+			scheduler.Logger.Info("scheduler shutting down")
+			return nil
+		case <-ticker.C:
+			result, err := scheduler.Runner.RunOnce(ctx)
+			if err != nil {
+				scheduler.Logger.Error("scheduler run error", zap.Error(err))
+			}
+			if result.Claimed > 0 {
+				scheduler.Logger.Info("scheduler processed schedules",
+					zap.Int("claimed", result.Claimed),
+					zap.Int("succeeded", result.Succeeded),
+					zap.Int("failed", result.Failed),
+				)
+			}
+		}
+	}
+}
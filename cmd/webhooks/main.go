@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"draftea-challenge/internal/adapters/messaging"
+	"draftea-challenge/internal/application/webhooks"
+	"draftea-challenge/internal/platform/config"
+	"draftea-challenge/internal/platform/factory"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatalf("webhooks worker exited with error: %v", err)
+	}
+}
+
+func run() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	worker, err := factory.BuildWebhookWorker(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = worker.Cleanup() }()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	consumer, consumerCleanup, err := factory.ConnectConsumer(ctx, cfg, cfg.Rabbit.WebhooksQueue, cfg.Rabbit.WebhooksConsumer, worker.Logger)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = consumerCleanup() }()
+
+	worker.Logger.Info("webhooks worker started", zap.String("queue", cfg.Rabbit.WebhooksQueue))
+	if cfg.Webhooks.RetryInterval > 0 {
+		go runRetries(ctx, worker.Dispatcher, cfg.Webhooks.RetryInterval, worker.Logger)
+	}
+	err = consumer.Start(ctx, func(ctx context.Context, msg *messaging.Event) error {
+		ev := toEvent(msg)
+		if err := worker.Dispatcher.Dispatch(ctx, ev); err != nil {
+			worker.Logger.Error("webhook dispatch error", zap.Error(err), zap.String("event_id", ev.ID.String()))
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	worker.Logger.Info("webhooks worker shutting down")
+	return nil
+}
+
+// runRetries sends the webhook retries that came due every interval until ctx is done.
+func runRetries(ctx context.Context, dispatcher *webhooks.Dispatcher, interval time.Duration, log *zap.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		retried, err := dispatcher.RetryDue(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Error("webhook retry error", zap.Error(err), zap.Int("retried", retried))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// toEvent maps a broker event to a webhook event. Events published by the relay carry
+// the outbox event ID; otherwise a stable ID is derived from the type and data.
+func toEvent(ev *messaging.Event) *webhooks.Event {
+	id, err := uuid.Parse(ev.ID)
+	if err != nil {
+		id = uuid.NewSHA1(uuid.NameSpaceOID, append([]byte(ev.Type+":"), ev.Data...))
+	}
+	occurredAt := ev.Time
+	if occurredAt.IsZero() {
+		occurredAt = time.Now().UTC()
+	}
+	return &webhooks.Event{ID: id, Type: ev.Type, Payload: ev.Data, OccurredAt: occurredAt}
+}
@@ -88,6 +88,7 @@ func New(cfg Config) *Client {
 }
 
 type gatewayRequest struct {
+	PaymentID         string `json:"payment_id"` // echoed back in asynchronous callbacks
 	ProviderID        string `json:"provider_id"`
 	ExternalReference string `json:"external_reference"`
 	Amount            int64  `json:"amount"`
@@ -112,6 +113,7 @@ func (c *Client) ProcessPayment(ctx context.Context, p *payment.Payment) (string
 	}
 
 	payload := gatewayRequest{
+		PaymentID:         p.ID.String(),
 		ProviderID:        p.ProviderID.String(),
 		ExternalReference: p.ExternalReference,
 		Amount:            p.Amount,
@@ -146,6 +148,10 @@ func (c *Client) ProcessPayment(ctx context.Context, p *payment.Payment) (string
 				case http.StatusOK:
 					c.breaker.success()
 					return out.Status, nil
+				case http.StatusAccepted:
+					// Accepted for asynchronous processing; the outcome arrives via webhook.
+					c.breaker.success()
+					return "pending", nil
 				case http.StatusBadRequest:
 					c.breaker.success()
 					return "declined", nil
@@ -0,0 +1,152 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"draftea-challenge/internal/application/wallets"
+	"draftea-challenge/internal/domain/errors"
+
+	"github.com/google/uuid"
+)
+
+// FundingClient implements the funding gateway (card/bank charges for top-ups) over HTTP.
+// It shares the retry, circuit breaker and in-flight limits of the payment client.
+type FundingClient struct {
+	*Client
+}
+
+// NewFunding creates a new funding gateway client.
+func NewFunding(cfg Config) *FundingClient {
+	return &FundingClient{Client: New(cfg)}
+}
+
+type fundingRequest struct {
+	TransactionID string `json:"transaction_id"`
+	UserID        string `json:"user_id"`
+	Amount        int64  `json:"amount"`
+	Currency      string `json:"currency"`
+	Source        string `json:"source"`
+}
+
+// Charge asks the gateway to charge the funding source. The transaction ID is sent as
+// Idempotency-Key so retried charges are never applied twice.
+func (c *FundingClient) Charge(ctx context.Context, charge *wallets.FundingCharge) (wallets.FundingStatus, error) {
+	body, err := json.Marshal(fundingRequest{
+		TransactionID: charge.TransactionID.String(),
+		UserID:        charge.UserID.String(),
+		Amount:        charge.Amount,
+		Currency:      charge.Currency,
+		Source:        charge.Source,
+	})
+	if err != nil {
+		return "", errors.NewInternalError("failed to encode funding request")
+	}
+	return c.call(ctx, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/fund", c.baseURL), bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", charge.TransactionID.String())
+		return req, nil
+	})
+}
+
+// ChargeStatus returns the current status of the charge for a top-up transaction.
+func (c *FundingClient) ChargeStatus(ctx context.Context, transactionID uuid.UUID) (wallets.FundingStatus, error) {
+	return c.call(ctx, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/fund/%s", c.baseURL, transactionID), nil)
+	})
+}
+
+// call sends the request built by newReq with retries and maps the response to a funding status.
+func (c *FundingClient) call(ctx context.Context, newReq func() (*http.Request, error)) (wallets.FundingStatus, error) {
+	if !c.breaker.allow() {
+		return "", errors.NewGatewayError("funding gateway circuit breaker open")
+	}
+
+	select {
+	case c.semaphore <- struct{}{}:
+		defer func() { <-c.semaphore }()
+	case <-ctx.Done():
+		return "", errors.NewGatewayTimeoutError("funding gateway timeout")
+	}
+
+	var lastErr error
+	backoff := c.backoff.initial
+
+	for attempt := 0; attempt <= c.retries; attempt++ {
+		req, err := newReq()
+		if err != nil {
+			return "", errors.NewInternalError("failed to create funding request")
+		}
+
+		status, err := c.send(req)
+		if err == nil {
+			c.breaker.success()
+			return status, nil
+		}
+		lastErr = err
+		if domErr, ok := err.(errors.Error); ok && domErr.Code == errors.CodeNotFound {
+			c.breaker.success()
+			return "", err
+		}
+
+		c.breaker.failure()
+
+		if attempt == c.retries || !isRetryable(lastErr) {
+			break
+		}
+
+		wait := jitter(backoff)
+		if wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return "", errors.NewGatewayTimeoutError("funding gateway timeout")
+			}
+		}
+		backoff = nextBackoff(backoff, c.backoff.max)
+	}
+
+	if lastErr == nil {
+		lastErr = errors.NewGatewayError("funding gateway error")
+	}
+	return "", lastErr
+}
+
+func (c *FundingClient) send(req *http.Request) (wallets.FundingStatus, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", errors.NewGatewayTimeoutError("funding gateway timeout")
+	}
+	defer resp.Body.Close()
+
+	var out gatewayResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", errors.NewGatewayError("invalid funding gateway response")
+	}
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusAccepted:
+		switch wallets.FundingStatus(out.Status) {
+		case wallets.FundingApproved, wallets.FundingPending, wallets.FundingDeclined:
+			return wallets.FundingStatus(out.Status), nil
+		}
+		return "", errors.NewGatewayError("unexpected funding status")
+	case http.StatusBadRequest, http.StatusPaymentRequired:
+		return wallets.FundingDeclined, nil
+	case http.StatusNotFound:
+		return "", errors.NewNotFoundError("funding charge not found")
+	case http.StatusGatewayTimeout:
+		return "", errors.NewGatewayTimeoutError("funding gateway timeout")
+	default:
+		return "", errors.NewGatewayError("funding gateway error")
+	}
+}
+
+var _ wallets.FundingGateway = (*FundingClient)(nil)
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"draftea-challenge/internal/adapters/http/presenter"
+	"draftea-challenge/internal/application/batches"
+	"draftea-challenge/internal/domain/batch"
+	"draftea-challenge/internal/domain/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxBatchBodyBytes bounds the size of an uploaded batch (JSON or CSV).
+const maxBatchBodyBytes = 1 << 20
+
+// BatchService defines the batch payment usecases used by the handler.
+type BatchService interface {
+	SubmitBatch(ctx context.Context, req *batches.SubmitBatchRequest) (*batches.BatchResponse, error)
+	GetBatch(ctx context.Context, userID, batchID uuid.UUID) (*batches.BatchResponse, error)
+}
+
+// BatchHandler handles batch payment endpoints.
+type BatchHandler struct {
+	service BatchService
+}
+
+// NewBatchHandler creates a BatchHandler.
+func NewBatchHandler(service BatchService) *BatchHandler {
+	return &BatchHandler{service: service}
+}
+
+type batchRequest struct {
+	Items []batchItemRequest `json:"items"`
+}
+
+type batchItemRequest struct {
+	ProviderID        string `json:"provider_id"`
+	ExternalReference string `json:"external_reference"`
+	Amount            int64  `json:"amount"`
+	Currency          string `json:"currency"`
+	IdempotencyKey    string `json:"idempotency_key"`
+}
+
+// PaymentAction handles POST /wallets/{user_id}/payments:{action}.
+// Gin cannot escape ':' in static segments, so custom methods are routed here.
+func (h *BatchHandler) PaymentAction(c *gin.Context) {
+	if c.Param("action") != ":batch" {
+		presenter.WriteError(c, errors.NewNotFoundError("route not found"))
+		return
+	}
+	h.SubmitBatch(c)
+}
+
+// SubmitBatch handles POST /wallets/{user_id}/payments:batch.
+func (h *BatchHandler) SubmitBatch(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBatchBodyBytes)
+	var (
+		raw []batchItemRequest
+		err error
+	)
+	if strings.HasPrefix(c.ContentType(), "text/csv") {
+		raw, err = parseBatchCSV(c.Request.Body)
+	} else {
+		var body batchRequest
+		err = c.ShouldBindJSON(&body)
+		raw = body.Items
+	}
+	if err != nil {
+		presenter.WriteError(c, errors.NewValidationError("invalid request body", map[string]interface{}{"error": err.Error()}))
+		return
+	}
+
+	items := make([]batches.BatchItemRequest, 0, len(raw))
+	details := make(map[string]interface{})
+	for i, it := range raw {
+		itemDetails := make(map[string]interface{})
+		providerID, err := uuid.Parse(it.ProviderID)
+		if err != nil {
+			itemDetails["provider_id"] = it.ProviderID
+		}
+		if it.ExternalReference == "" {
+			itemDetails["external_reference"] = "required"
+		}
+		if it.Amount <= 0 {
+			itemDetails["amount"] = it.Amount
+		}
+		if it.Currency == "" {
+			itemDetails["currency"] = "required"
+		}
+		if len(itemDetails) > 0 {
+			details[strconv.Itoa(i)] = itemDetails
+			continue
+		}
+		items = append(items, batches.BatchItemRequest{
+			ProviderID:        providerID,
+			ExternalReference: it.ExternalReference,
+			Amount:            it.Amount,
+			Currency:          it.Currency,
+			IdempotencyKey:    it.IdempotencyKey,
+		})
+	}
+	if len(details) > 0 {
+		presenter.WriteError(c, errors.NewValidationError("invalid batch items", details))
+		return
+	}
+
+	resp, err := h.service.SubmitBatch(c.Request.Context(), &batches.SubmitBatchRequest{
+		UserID:         userID,
+		IdempotencyKey: c.GetHeader("Idempotency-Key"),
+		Items:          items,
+	})
+	if err != nil {
+		presenter.WriteError(c, err)
+		return
+	}
+
+	status := http.StatusOK
+	if resp.Status != batch.StatusCompleted {
+		status = http.StatusAccepted
+	}
+	c.JSON(status, resp)
+}
+
+// GetBatch handles GET /wallets/{user_id}/payments/batches/{batch_id}.
+func (h *BatchHandler) GetBatch(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+	batchID, err := uuid.Parse(c.Param("batch_id"))
+	if err != nil {
+		presenter.WriteError(c, errors.NewValidationError("invalid batch_id", map[string]interface{}{"batch_id": c.Param("batch_id")}))
+		return
+	}
+
+	resp, err := h.service.GetBatch(c.Request.Context(), userID, batchID)
+	if err != nil {
+		presenter.WriteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// parseBatchCSV reads a CSV upload with a header row naming the columns
+// provider_id, external_reference, amount, currency and, optionally, idempotency_key.
+func parseBatchCSV(r io.Reader) ([]batchItemRequest, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read csv header: %w", err)
+	}
+	cols := make(map[string]int, len(header))
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"provider_id", "external_reference", "amount", "currency"} {
+		if _, ok := cols[required]; !ok {
+			return nil, fmt.Errorf("csv header missing column %q", required)
+		}
+	}
+	field := func(record []string, name string) string {
+		if i, ok := cols[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var items []batchItemRequest
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		amount, err := strconv.ParseInt(field(record, "amount"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid amount %q", line, field(record, "amount"))
+		}
+		items = append(items, batchItemRequest{
+			ProviderID:        field(record, "provider_id"),
+			ExternalReference: field(record, "external_reference"),
+			Amount:            amount,
+			Currency:          field(record, "currency"),
+			IdempotencyKey:    field(record, "idempotency_key"),
+		})
+	}
+	return items, nil
+}
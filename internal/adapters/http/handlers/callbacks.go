@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"draftea-challenge/internal/adapters/http/presenter"
+	"draftea-challenge/internal/application/callbacks"
+	"draftea-challenge/internal/domain/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxWebhookBodyBytes bounds the size of an inbound gateway callback.
+const maxWebhookBodyBytes = 64 << 10
+
+// GatewayCallbackService defines the inbound webhook usecase used by the handler.
+type GatewayCallbackService interface {
+	HandleGatewayCallback(ctx context.Context, req *callbacks.CallbackRequest) (*callbacks.CallbackResponse, error)
+}
+
+// GatewayWebhookHandler handles asynchronous callbacks sent by the payment gateway.
+type GatewayWebhookHandler struct {
+	service GatewayCallbackService
+}
+
+// NewGatewayWebhookHandler creates a GatewayWebhookHandler.
+func NewGatewayWebhookHandler(service GatewayCallbackService) *GatewayWebhookHandler {
+	return &GatewayWebhookHandler{service: service}
+}
+
+// HandleGatewayWebhook handles POST /webhooks/gateway.
+// The raw body is kept as received because the signature covers its exact bytes.
+func (h *GatewayWebhookHandler) HandleGatewayWebhook(c *gin.Context) {
+	payload, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookBodyBytes))
+	if err != nil {
+		presenter.WriteError(c, errors.NewValidationError("invalid request body", map[string]interface{}{"error": err.Error()}))
+		return
+	}
+
+	resp, err := h.service.HandleGatewayCallback(c.Request.Context(), &callbacks.CallbackRequest{
+		Payload:   payload,
+		Timestamp: c.GetHeader("X-Gateway-Timestamp"),
+		Signature: c.GetHeader("X-Gateway-Signature"),
+	})
+	if err != nil {
+		presenter.WriteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
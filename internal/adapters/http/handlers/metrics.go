@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"draftea-challenge/internal/adapters/http/presenter"
+	"draftea-challenge/internal/application/metrics"
+	"draftea-challenge/internal/domain/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsService defines the payment KPI queries used by the handler.
+type MetricsService interface {
+	Snapshot(window time.Duration) (*metrics.SnapshotResponse, error)
+	ListHourly(ctx context.Context, from, to time.Time) (*metrics.ListHourlyResponse, error)
+}
+
+// MetricsHandler serves the payment KPIs aggregated by the metrics consumer.
+type MetricsHandler struct {
+	service MetricsService
+}
+
+// NewMetricsHandler creates a MetricsHandler.
+func NewMetricsHandler(service MetricsService) *MetricsHandler {
+	return &MetricsHandler{service: service}
+}
+
+// Windows handles GET /metrics/payments.
+func (h *MetricsHandler) Windows(c *gin.Context) {
+	window, err := parseInterval(c.Query("window"))
+	if err != nil {
+		presenter.WriteError(c, errors.NewValidationError("invalid window", map[string]interface{}{"window": c.Query("window")}))
+		return
+	}
+
+	resp, err := h.service.Snapshot(window)
+	if err != nil {
+		presenter.WriteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Hourly handles GET /metrics/payments/hourly.
+func (h *MetricsHandler) Hourly(c *gin.Context) {
+	from, fromErr := parseTimeQuery(c, "from")
+	to, toErr := parseTimeQuery(c, "to")
+	if fromErr != nil || toErr != nil {
+		presenter.WriteError(c, errors.NewValidationError("invalid time range, use RFC 3339", map[string]interface{}{"from": c.Query("from"), "to": c.Query("to")}))
+		return
+	}
+
+	resp, err := h.service.ListHourly(c.Request.Context(), from, to)
+	if err != nil {
+		presenter.WriteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func parseTimeQuery(c *gin.Context, key string) (time.Time, error) {
+	val := c.Query(key)
+	if val == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, val)
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"draftea-challenge/internal/adapters/http/presenter"
+	"draftea-challenge/internal/application/notifications"
+	"draftea-challenge/internal/domain/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// NotificationPreferencesService defines the notification preferences usecases used by the handler.
+type NotificationPreferencesService interface {
+	GetPreferences(ctx context.Context, userID uuid.UUID) (*notifications.Preferences, error)
+	UpdatePreferences(ctx context.Context, userID uuid.UUID, req *notifications.PreferencesRequest) (*notifications.Preferences, error)
+}
+
+// NotificationPreferencesHandler handles a user's notification preferences endpoints.
+type NotificationPreferencesHandler struct {
+	service NotificationPreferencesService
+}
+
+// NewNotificationPreferencesHandler creates a NotificationPreferencesHandler.
+func NewNotificationPreferencesHandler(service NotificationPreferencesService) *NotificationPreferencesHandler {
+	return &NotificationPreferencesHandler{service: service}
+}
+
+// GetPreferences handles GET /wallets/{user_id}/notification-preferences.
+func (h *NotificationPreferencesHandler) GetPreferences(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	resp, err := h.service.GetPreferences(c.Request.Context(), userID)
+	if err != nil {
+		presenter.WriteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// UpdatePreferences handles PUT /wallets/{user_id}/notification-preferences.
+func (h *NotificationPreferencesHandler) UpdatePreferences(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	var body notifications.PreferencesRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		presenter.WriteError(c, errors.NewValidationError("invalid request body", map[string]interface{}{"error": err.Error()}))
+		return
+	}
+
+	resp, err := h.service.UpdatePreferences(c.Request.Context(), userID, &body)
+	if err != nil {
+		presenter.WriteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"draftea-challenge/internal/adapters/http/presenter"
+	"draftea-challenge/internal/application/outbox"
+	"draftea-challenge/internal/domain/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// DeadLetterService defines the outbox dead letter usecases used by the handler.
+type DeadLetterService interface {
+	ListDeadLetters(ctx context.Context, limit, offset int) (*outbox.ListDeadLettersResponse, error)
+	Requeue(ctx context.Context, eventID uuid.UUID) (*outbox.OutboxEvent, error)
+}
+
+// OutboxHandler handles admin endpoints for outbox events the relay gave up on.
+type OutboxHandler struct {
+	service DeadLetterService
+}
+
+// NewOutboxHandler creates an OutboxHandler.
+func NewOutboxHandler(service DeadLetterService) *OutboxHandler {
+	return &OutboxHandler{service: service}
+}
+
+// ListDeadLetters handles GET /admin/outbox/dead-letters.
+func (h *OutboxHandler) ListDeadLetters(c *gin.Context) {
+	limit, offset, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+
+	resp, err := h.service.ListDeadLetters(c.Request.Context(), limit, offset)
+	if err != nil {
+		presenter.WriteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Requeue handles POST /admin/outbox/dead-letters/{event_id}/requeue.
+func (h *OutboxHandler) Requeue(c *gin.Context) {
+	eventID, err := uuid.Parse(c.Param("event_id"))
+	if err != nil {
+		presenter.WriteError(c, errors.NewValidationError("invalid event_id", map[string]interface{}{"event_id": c.Param("event_id")}))
+		return
+	}
+
+	resp, err := h.service.Requeue(c.Request.Context(), eventID)
+	if err != nil {
+		presenter.WriteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"draftea-challenge/internal/adapters/http/presenter"
+	"draftea-challenge/internal/application/payments"
+	"draftea-challenge/internal/domain/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// PaymentReviewHandler handles admin endpoints for payments held by risk screening.
+type PaymentReviewHandler struct {
+	service interface {
+		ReviewHeldPayment(ctx context.Context, txID uuid.UUID, approve bool) (*payments.ProcessPaymentResponse, error)
+	}
+}
+
+// NewPaymentReviewHandler creates a PaymentReviewHandler.
+func NewPaymentReviewHandler(service interface {
+	ReviewHeldPayment(ctx context.Context, txID uuid.UUID, approve bool) (*payments.ProcessPaymentResponse, error)
+}) *PaymentReviewHandler {
+	return &PaymentReviewHandler{service: service}
+}
+
+// Approve handles POST /admin/payments/{transaction_id}/approve.
+func (h *PaymentReviewHandler) Approve(c *gin.Context) {
+	h.review(c, true)
+}
+
+// Reject handles POST /admin/payments/{transaction_id}/reject.
+func (h *PaymentReviewHandler) Reject(c *gin.Context) {
+	h.review(c, false)
+}
+
+func (h *PaymentReviewHandler) review(c *gin.Context, approve bool) {
+	txID, err := uuid.Parse(c.Param("transaction_id"))
+	if err != nil {
+		presenter.WriteError(c, errors.NewValidationError("invalid transaction_id", map[string]interface{}{"transaction_id": c.Param("transaction_id")}))
+		return
+	}
+
+	resp, err := h.service.ReviewHeldPayment(c.Request.Context(), txID, approve)
+	if err != nil {
+		presenter.WriteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
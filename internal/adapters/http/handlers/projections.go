@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"draftea-challenge/internal/adapters/http/presenter"
+	"draftea-challenge/internal/application/projections"
+	"draftea-challenge/internal/domain/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// DailySpendService defines the daily spend read model queries used by the handler.
+type DailySpendService interface {
+	List(ctx context.Context, userID uuid.UUID, from, to time.Time) (*projections.ListDailySpendResponse, error)
+}
+
+// DailySpendHandler serves the per-user daily spend projection.
+type DailySpendHandler struct {
+	service DailySpendService
+}
+
+// NewDailySpendHandler creates a DailySpendHandler.
+func NewDailySpendHandler(service DailySpendService) *DailySpendHandler {
+	return &DailySpendHandler{service: service}
+}
+
+// List handles GET /users/{user_id}/daily-spend.
+func (h *DailySpendHandler) List(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+	from, fromErr := parseDateQuery(c, "from")
+	to, toErr := parseDateQuery(c, "to")
+	if fromErr != nil || toErr != nil {
+		presenter.WriteError(c, errors.NewValidationError("invalid date range, use YYYY-MM-DD", map[string]interface{}{"from": c.Query("from"), "to": c.Query("to")}))
+		return
+	}
+
+	resp, err := h.service.List(c.Request.Context(), userID, from, to)
+	if err != nil {
+		presenter.WriteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func parseDateQuery(c *gin.Context, key string) (time.Time, error) {
+	val := c.Query(key)
+	if val == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse("2006-01-02", val)
+}
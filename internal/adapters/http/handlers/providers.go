@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"draftea-challenge/internal/adapters/http/presenter"
+	"draftea-challenge/internal/application/providers"
+	"draftea-challenge/internal/domain/errors"
+	"draftea-challenge/internal/domain/provider"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ProviderService defines the provider catalog usecases used by the handler.
+type ProviderService interface {
+	CreateProvider(ctx context.Context, req *providers.ProviderRequest) (*provider.Provider, error)
+	GetProvider(ctx context.Context, providerID uuid.UUID) (*provider.Provider, error)
+	ListProviders(ctx context.Context, limit, offset int) (*providers.ListProvidersResponse, error)
+	UpdateProvider(ctx context.Context, providerID uuid.UUID, req *providers.ProviderRequest) (*provider.Provider, error)
+	DeleteProvider(ctx context.Context, providerID uuid.UUID) error
+}
+
+// ProviderHandler handles admin endpoints for the provider catalog.
+type ProviderHandler struct {
+	service ProviderService
+}
+
+// NewProviderHandler creates a ProviderHandler.
+func NewProviderHandler(service ProviderService) *ProviderHandler {
+	return &ProviderHandler{service: service}
+}
+
+// CreateProvider handles POST /admin/providers.
+func (h *ProviderHandler) CreateProvider(c *gin.Context) {
+	var body providers.ProviderRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		presenter.WriteError(c, errors.NewValidationError("invalid request body", map[string]interface{}{"error": err.Error()}))
+		return
+	}
+
+	resp, err := h.service.CreateProvider(c.Request.Context(), &body)
+	if err != nil {
+		presenter.WriteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// ListProviders handles GET /admin/providers.
+func (h *ProviderHandler) ListProviders(c *gin.Context) {
+	limit, limitErr := parseIntQuery(c, "limit", 20)
+	offset, offsetErr := parseIntQuery(c, "offset", 0)
+	if limitErr != nil || offsetErr != nil || limit < 0 || offset < 0 {
+		details := make(map[string]interface{})
+		if limitErr != nil || limit < 0 {
+			details["limit"] = c.Query("limit")
+		}
+		if offsetErr != nil || offset < 0 {
+			details["offset"] = c.Query("offset")
+		}
+		presenter.WriteError(c, errors.NewValidationError("invalid pagination params", details))
+		return
+	}
+
+	resp, err := h.service.ListProviders(c.Request.Context(), limit, offset)
+	if err != nil {
+		presenter.WriteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetProvider handles GET /admin/providers/{provider_id}.
+func (h *ProviderHandler) GetProvider(c *gin.Context) {
+	providerID, ok := parseProviderID(c)
+	if !ok {
+		return
+	}
+
+	resp, err := h.service.GetProvider(c.Request.Context(), providerID)
+	if err != nil {
+		presenter.WriteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// UpdateProvider handles PUT /admin/providers/{provider_id}.
+func (h *ProviderHandler) UpdateProvider(c *gin.Context) {
+	providerID, ok := parseProviderID(c)
+	if !ok {
+		return
+	}
+
+	var body providers.ProviderRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		presenter.WriteError(c, errors.NewValidationError("invalid request body", map[string]interface{}{"error": err.Error()}))
+		return
+	}
+
+	resp, err := h.service.UpdateProvider(c.Request.Context(), providerID, &body)
+	if err != nil {
+		presenter.WriteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// DeleteProvider handles DELETE /admin/providers/{provider_id}.
+func (h *ProviderHandler) DeleteProvider(c *gin.Context) {
+	providerID, ok := parseProviderID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteProvider(c.Request.Context(), providerID); err != nil {
+		presenter.WriteError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func parseProviderID(c *gin.Context) (uuid.UUID, bool) {
+	providerID, err := uuid.Parse(c.Param("provider_id"))
+	if err != nil {
+		presenter.WriteError(c, errors.NewValidationError("invalid provider_id", map[string]interface{}{"provider_id": c.Param("provider_id")}))
+		return uuid.Nil, false
+	}
+	return providerID, true
+}
@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"draftea-challenge/internal/adapters/http/presenter"
+	"draftea-challenge/internal/application/schedules"
+	"draftea-challenge/internal/domain/errors"
+	"draftea-challenge/internal/domain/schedule"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ScheduleService defines the scheduled payments usecases used by the handler.
+type ScheduleService interface {
+	CreateSchedule(ctx context.Context, req *schedules.CreateScheduleRequest) (*schedules.ScheduleResponse, error)
+	GetSchedule(ctx context.Context, userID, scheduleID uuid.UUID) (*schedules.ScheduleResponse, error)
+	ListSchedules(ctx context.Context, userID uuid.UUID, limit, offset int) (*schedules.ListSchedulesResponse, error)
+	UpdateSchedule(ctx context.Context, req *schedules.UpdateScheduleRequest) (*schedules.ScheduleResponse, error)
+	CancelSchedule(ctx context.Context, userID, scheduleID uuid.UUID) (*schedules.ScheduleResponse, error)
+}
+
+// ScheduleHandler handles scheduled payment endpoints.
+type ScheduleHandler struct {
+	service ScheduleService
+}
+
+// NewScheduleHandler creates a ScheduleHandler.
+func NewScheduleHandler(service ScheduleService) *ScheduleHandler {
+	return &ScheduleHandler{service: service}
+}
+
+type scheduleRequest struct {
+	ProviderID        string     `json:"provider_id"`
+	ExternalReference string     `json:"external_reference"`
+	Amount            int64      `json:"amount"`
+	Currency          string     `json:"currency"`
+	Cron              string     `json:"cron"`
+	Interval          string     `json:"interval"`
+	StartAt           *time.Time `json:"start_at"`
+	EndDate           *time.Time `json:"end_date"`
+}
+
+type updateScheduleRequest struct {
+	ExternalReference string     `json:"external_reference"`
+	Amount            int64      `json:"amount"`
+	Cron              string     `json:"cron"`
+	Interval          string     `json:"interval"`
+	EndDate           *time.Time `json:"end_date"`
+	Status            string     `json:"status"`
+}
+
+// CreateSchedule handles POST /wallets/{user_id}/schedules.
+func (h *ScheduleHandler) CreateSchedule(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	var body scheduleRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		presenter.WriteError(c, errors.NewValidationError("invalid request body", map[string]interface{}{"error": err.Error()}))
+		return
+	}
+
+	details := make(map[string]interface{})
+	providerID, err := uuid.Parse(body.ProviderID)
+	if err != nil {
+		details["provider_id"] = body.ProviderID
+	}
+	if body.ExternalReference == "" {
+		details["external_reference"] = "required"
+	}
+	if body.Amount <= 0 {
+		details["amount"] = body.Amount
+	}
+	if body.Currency == "" {
+		details["currency"] = "required"
+	}
+	interval, err := parseInterval(body.Interval)
+	if err != nil {
+		details["interval"] = body.Interval
+	}
+	if body.Cron == "" && body.Interval == "" {
+		details["cron"] = "cron or interval required"
+	}
+	if len(details) > 0 {
+		presenter.WriteError(c, errors.NewValidationError("invalid schedule request", details))
+		return
+	}
+
+	resp, err := h.service.CreateSchedule(c.Request.Context(), &schedules.CreateScheduleRequest{
+		UserID:            userID,
+		ProviderID:        providerID,
+		ExternalReference: body.ExternalReference,
+		Amount:            body.Amount,
+		Currency:          body.Currency,
+		CronExpr:          body.Cron,
+		Interval:          interval,
+		StartAt:           body.StartAt,
+		EndDate:           body.EndDate,
+	})
+	if err != nil {
+		presenter.WriteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// ListSchedules handles GET /wallets/{user_id}/schedules.
+func (h *ScheduleHandler) ListSchedules(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	limit, limitErr := parseIntQuery(c, "limit", 20)
+	offset, offsetErr := parseIntQuery(c, "offset", 0)
+	if limitErr != nil || offsetErr != nil || limit < 0 || offset < 0 {
+		details := make(map[string]interface{})
+		if limitErr != nil || limit < 0 {
+			details["limit"] = c.Query("limit")
+		}
+		if offsetErr != nil || offset < 0 {
+			details["offset"] = c.Query("offset")
+		}
+		presenter.WriteError(c, errors.NewValidationError("invalid pagination params", details))
+		return
+	}
+
+	resp, err := h.service.ListSchedules(c.Request.Context(), userID, limit, offset)
+	if err != nil {
+		presenter.WriteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetSchedule handles GET /wallets/{user_id}/schedules/{schedule_id}.
+func (h *ScheduleHandler) GetSchedule(c *gin.Context) {
+	userID, scheduleID, ok := parseScheduleIDs(c)
+	if !ok {
+		return
+	}
+
+	resp, err := h.service.GetSchedule(c.Request.Context(), userID, scheduleID)
+	if err != nil {
+		presenter.WriteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// UpdateSchedule handles PUT /wallets/{user_id}/schedules/{schedule_id}.
+func (h *ScheduleHandler) UpdateSchedule(c *gin.Context) {
+	userID, scheduleID, ok := parseScheduleIDs(c)
+	if !ok {
+		return
+	}
+
+	var body updateScheduleRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		presenter.WriteError(c, errors.NewValidationError("invalid request body", map[string]interface{}{"error": err.Error()}))
+		return
+	}
+
+	details := make(map[string]interface{})
+	if body.Amount < 0 {
+		details["amount"] = body.Amount
+	}
+	interval, err := parseInterval(body.Interval)
+	if err != nil {
+		details["interval"] = body.Interval
+	}
+	if len(details) > 0 {
+		presenter.WriteError(c, errors.NewValidationError("invalid schedule request", details))
+		return
+	}
+
+	resp, err := h.service.UpdateSchedule(c.Request.Context(), &schedules.UpdateScheduleRequest{
+		UserID:            userID,
+		ScheduleID:        scheduleID,
+		ExternalReference: body.ExternalReference,
+		Amount:            body.Amount,
+		CronExpr:          body.Cron,
+		Interval:          interval,
+		EndDate:           body.EndDate,
+		Status:            schedule.Status(body.Status),
+	})
+	if err != nil {
+		presenter.WriteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// CancelSchedule handles DELETE /wallets/{user_id}/schedules/{schedule_id}.
+func (h *ScheduleHandler) CancelSchedule(c *gin.Context) {
+	userID, scheduleID, ok := parseScheduleIDs(c)
+	if !ok {
+		return
+	}
+
+	resp, err := h.service.CancelSchedule(c.Request.Context(), userID, scheduleID)
+	if err != nil {
+		presenter.WriteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func parseUserID(c *gin.Context) (uuid.UUID, bool) {
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		presenter.WriteError(c, errors.NewValidationError("invalid user_id", map[string]interface{}{"user_id": c.Param("user_id")}))
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+func parseScheduleIDs(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	scheduleID, err := uuid.Parse(c.Param("schedule_id"))
+	if err != nil {
+		presenter.WriteError(c, errors.NewValidationError("invalid schedule_id", map[string]interface{}{"schedule_id": c.Param("schedule_id")}))
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, scheduleID, true
+}
+
+func parseInterval(val string) (time.Duration, error) {
+	if val == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(val)
+}
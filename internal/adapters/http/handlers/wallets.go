@@ -8,6 +8,7 @@ import (
 	"draftea-challenge/internal/adapters/http/presenter"
 	"draftea-challenge/internal/application/wallets"
 	"draftea-challenge/internal/domain/errors"
+	"draftea-challenge/internal/domain/transaction"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -23,6 +24,7 @@ type WalletHandler struct {
 	}
 	topUpService interface {
 		TopUp(ctx context.Context, req *wallets.TopUpRequest) (*wallets.TopUpResponse, error)
+		ConfirmTopUp(ctx context.Context, userID, txID uuid.UUID) (*wallets.TopUpResponse, error)
 	}
 	listService interface {
 		ListWallets(ctx context.Context, req *wallets.ListWalletsRequest) (*wallets.ListWalletsResponse, error)
@@ -42,6 +44,7 @@ func NewWalletHandler(
 	},
 	topUpService interface {
 		TopUp(ctx context.Context, req *wallets.TopUpRequest) (*wallets.TopUpResponse, error)
+		ConfirmTopUp(ctx context.Context, userID, txID uuid.UUID) (*wallets.TopUpResponse, error)
 	},
 	listService interface {
 		ListWallets(ctx context.Context, req *wallets.ListWalletsRequest) (*wallets.ListWalletsResponse, error)
@@ -124,6 +127,7 @@ func (h *WalletHandler) TopUp(c *gin.Context) {
 	var body struct {
 		Amount   int64  `json:"amount"`
 		Currency string `json:"currency"`
+		Source   string `json:"source"`
 	}
 	if err := c.ShouldBindJSON(&body); err != nil {
 		presenter.WriteError(c, errors.NewValidationError("invalid request body", map[string]interface{}{"error": err.Error()}))
@@ -143,16 +147,48 @@ func (h *WalletHandler) TopUp(c *gin.Context) {
 	}
 
 	resp, err := h.topUpService.TopUp(c.Request.Context(), &wallets.TopUpRequest{
-		UserID:   userID,
-		Amount:   body.Amount,
-		Currency: body.Currency,
+		UserID:         userID,
+		Amount:         body.Amount,
+		Currency:       body.Currency,
+		Source:         body.Source,
+		IdempotencyKey: c.GetHeader("Idempotency-Key"),
 	})
 	if err != nil {
 		presenter.WriteError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, resp)
+	c.JSON(topUpStatusCode(resp), resp)
+}
+
+// ConfirmTopUp handles POST /wallets/{user_id}/top-up/{transaction_id}/confirm.
+func (h *WalletHandler) ConfirmTopUp(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		presenter.WriteError(c, errors.NewValidationError("invalid user_id", map[string]interface{}{"user_id": c.Param("user_id")}))
+		return
+	}
+	txID, err := uuid.Parse(c.Param("transaction_id"))
+	if err != nil {
+		presenter.WriteError(c, errors.NewValidationError("invalid transaction_id", map[string]interface{}{"transaction_id": c.Param("transaction_id")}))
+		return
+	}
+
+	resp, err := h.topUpService.ConfirmTopUp(c.Request.Context(), userID, txID)
+	if err != nil {
+		presenter.WriteError(c, err)
+		return
+	}
+
+	c.JSON(topUpStatusCode(resp), resp)
+}
+
+// topUpStatusCode answers 202 while the funding charge is still pending.
+func topUpStatusCode(resp *wallets.TopUpResponse) int {
+	if resp.Status == string(transaction.StatusPending) {
+		return http.StatusAccepted
+	}
+	return http.StatusOK
 }
 
 // ListWallets handles GET /wallets.
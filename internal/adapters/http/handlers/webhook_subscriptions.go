@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"draftea-challenge/internal/adapters/http/presenter"
+	"draftea-challenge/internal/application/webhooks"
+	"draftea-challenge/internal/domain/errors"
+	"draftea-challenge/internal/domain/webhook"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// WebhookSubscriptionService defines the merchant webhook subscription usecases used by the handler.
+type WebhookSubscriptionService interface {
+	CreateSubscription(ctx context.Context, req *webhooks.SubscriptionRequest) (*webhooks.CreateSubscriptionResponse, error)
+	GetSubscription(ctx context.Context, subscriptionID uuid.UUID) (*webhook.Subscription, error)
+	ListSubscriptions(ctx context.Context, limit, offset int) (*webhooks.ListSubscriptionsResponse, error)
+	UpdateSubscription(ctx context.Context, subscriptionID uuid.UUID, req *webhooks.SubscriptionRequest) (*webhook.Subscription, error)
+	DeleteSubscription(ctx context.Context, subscriptionID uuid.UUID) error
+	ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, limit, offset int) (*webhooks.ListDeliveriesResponse, error)
+}
+
+// WebhookReplayer re-sends a stored event to a subscription.
+type WebhookReplayer interface {
+	Replay(ctx context.Context, subscriptionID, eventID uuid.UUID) (*webhook.Delivery, error)
+}
+
+// WebhookSubscriptionHandler handles admin endpoints for merchant webhook subscriptions.
+type WebhookSubscriptionHandler struct {
+	service  WebhookSubscriptionService
+	replayer WebhookReplayer
+}
+
+// NewWebhookSubscriptionHandler creates a WebhookSubscriptionHandler.
+func NewWebhookSubscriptionHandler(service WebhookSubscriptionService, replayer WebhookReplayer) *WebhookSubscriptionHandler {
+	return &WebhookSubscriptionHandler{service: service, replayer: replayer}
+}
+
+type replayRequest struct {
+	EventID string `json:"event_id"`
+}
+
+// CreateSubscription handles POST /admin/webhooks.
+func (h *WebhookSubscriptionHandler) CreateSubscription(c *gin.Context) {
+	var body webhooks.SubscriptionRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		presenter.WriteError(c, errors.NewValidationError("invalid request body", map[string]interface{}{"error": err.Error()}))
+		return
+	}
+
+	resp, err := h.service.CreateSubscription(c.Request.Context(), &body)
+	if err != nil {
+		presenter.WriteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// ListSubscriptions handles GET /admin/webhooks.
+func (h *WebhookSubscriptionHandler) ListSubscriptions(c *gin.Context) {
+	limit, offset, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+
+	resp, err := h.service.ListSubscriptions(c.Request.Context(), limit, offset)
+	if err != nil {
+		presenter.WriteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetSubscription handles GET /admin/webhooks/{subscription_id}.
+func (h *WebhookSubscriptionHandler) GetSubscription(c *gin.Context) {
+	subscriptionID, ok := parseSubscriptionID(c)
+	if !ok {
+		return
+	}
+
+	resp, err := h.service.GetSubscription(c.Request.Context(), subscriptionID)
+	if err != nil {
+		presenter.WriteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// UpdateSubscription handles PUT /admin/webhooks/{subscription_id}.
+func (h *WebhookSubscriptionHandler) UpdateSubscription(c *gin.Context) {
+	subscriptionID, ok := parseSubscriptionID(c)
+	if !ok {
+		return
+	}
+
+	var body webhooks.SubscriptionRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		presenter.WriteError(c, errors.NewValidationError("invalid request body", map[string]interface{}{"error": err.Error()}))
+		return
+	}
+
+	resp, err := h.service.UpdateSubscription(c.Request.Context(), subscriptionID, &body)
+	if err != nil {
+		presenter.WriteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// DeleteSubscription handles DELETE /admin/webhooks/{subscription_id}.
+func (h *WebhookSubscriptionHandler) DeleteSubscription(c *gin.Context) {
+	subscriptionID, ok := parseSubscriptionID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteSubscription(c.Request.Context(), subscriptionID); err != nil {
+		presenter.WriteError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListDeliveries handles GET /admin/webhooks/{subscription_id}/deliveries.
+func (h *WebhookSubscriptionHandler) ListDeliveries(c *gin.Context) {
+	subscriptionID, ok := parseSubscriptionID(c)
+	if !ok {
+		return
+	}
+	limit, offset, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+
+	resp, err := h.service.ListDeliveries(c.Request.Context(), subscriptionID, limit, offset)
+	if err != nil {
+		presenter.WriteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Replay handles POST /admin/webhooks/{subscription_id}/replay.
+func (h *WebhookSubscriptionHandler) Replay(c *gin.Context) {
+	subscriptionID, ok := parseSubscriptionID(c)
+	if !ok {
+		return
+	}
+
+	var body replayRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		presenter.WriteError(c, errors.NewValidationError("invalid request body", map[string]interface{}{"error": err.Error()}))
+		return
+	}
+	eventID, err := uuid.Parse(body.EventID)
+	if err != nil {
+		presenter.WriteError(c, errors.NewValidationError("invalid event_id", map[string]interface{}{"event_id": body.EventID}))
+		return
+	}
+
+	resp, err := h.replayer.Replay(c.Request.Context(), subscriptionID, eventID)
+	if err != nil {
+		presenter.WriteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func parseSubscriptionID(c *gin.Context) (uuid.UUID, bool) {
+	subscriptionID, err := uuid.Parse(c.Param("subscription_id"))
+	if err != nil {
+		presenter.WriteError(c, errors.NewValidationError("invalid subscription_id", map[string]interface{}{"subscription_id": c.Param("subscription_id")}))
+		return uuid.Nil, false
+	}
+	return subscriptionID, true
+}
+
+// parsePagination reads limit/offset query params, writing a validation error when invalid.
+func parsePagination(c *gin.Context) (int, int, bool) {
+	limit, limitErr := parseIntQuery(c, "limit", 20)
+	offset, offsetErr := parseIntQuery(c, "offset", 0)
+	if limitErr != nil || offsetErr != nil || limit < 0 || offset < 0 {
+		details := make(map[string]interface{})
+		if limitErr != nil || limit < 0 {
+			details["limit"] = c.Query("limit")
+		}
+		if offsetErr != nil || offset < 0 {
+			details["offset"] = c.Query("offset")
+		}
+		presenter.WriteError(c, errors.NewValidationError("invalid pagination params", details))
+		return 0, 0, false
+	}
+	return limit, offset, true
+}
@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"strings"
+
 	"draftea-challenge/internal/adapters/http/presenter"
 	"draftea-challenge/internal/domain/errors"
 
@@ -9,13 +11,20 @@ import (
 
 const apiKeyHeader = "X-API-Key"
 
-// APIKeyAuth enforces a static API key if configured.
-func APIKeyAuth(expectedKey string) gin.HandlerFunc {
+// APIKeyAuth enforces a static API key if configured. Paths under exemptPrefixes
+// (e.g. signed webhooks) authenticate by other means and skip the check.
+func APIKeyAuth(expectedKey string, exemptPrefixes ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if expectedKey == "" {
 			c.Next()
 			return
 		}
+		for _, prefix := range exemptPrefixes {
+			if strings.HasPrefix(c.Request.URL.Path, prefix) {
+				c.Next()
+				return
+			}
+		}
 
 		if c.GetHeader(apiKeyHeader) != expectedKey {
 			presenter.WriteError(c, errors.NewUnauthorizedError("invalid api key"))
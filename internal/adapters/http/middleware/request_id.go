@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"draftea-challenge/internal/application/ports"
+
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -11,7 +13,7 @@ const (
 	requestIDKey    = "request_id"
 )
 
-// RequestID ensures each request has a request ID.
+// RequestID ensures each request has a request ID and propagates it as the correlation ID.
 func RequestID() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id := c.GetHeader(RequestIDHeader)
@@ -19,6 +21,8 @@ func RequestID() gin.HandlerFunc {
 			id = uuid.NewString()
 		}
 		c.Set(requestIDKey, id)
+		// Usecases read it from the request context to correlate the events they emit.
+		c.Request = c.Request.WithContext(ports.WithCorrelationID(c.Request.Context(), id))
 		c.Writer.Header().Set(RequestIDHeader, id)
 		c.Next()
 	}
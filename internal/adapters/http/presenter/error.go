@@ -39,6 +39,10 @@ func statusFor(code string) int {
 		return http.StatusNotFound
 	case errors.CodeInsufficientFunds:
 		return http.StatusConflict
+	case errors.CodeLimitExceeded:
+		return http.StatusUnprocessableEntity
+	case errors.CodeRiskDenied:
+		return http.StatusForbidden
 	case errors.CodeGatewayTimeout:
 		return http.StatusGatewayTimeout
 	case errors.CodeGatewayError:
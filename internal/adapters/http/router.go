@@ -13,11 +13,19 @@ import (
 
 // RouterDeps defines dependencies needed to build the router.
 type RouterDeps struct {
-	Logger         *zap.Logger
-	APIKey         string
-	RequestTimeout time.Duration
-	PaymentHandler *handlers.PaymentHandler
-	WalletHandler  *handlers.WalletHandler
+	Logger              *zap.Logger
+	APIKey              string
+	RequestTimeout      time.Duration
+	PaymentHandler      *handlers.PaymentHandler
+	WalletHandler       *handlers.WalletHandler
+	ReviewHandler       *handlers.PaymentReviewHandler
+	ScheduleHandler     *handlers.ScheduleHandler
+	BatchHandler        *handlers.BatchHandler
+	ProviderHandler     *handlers.ProviderHandler
+	WebhookHandler      *handlers.GatewayWebhookHandler
+	SubscriptionHandler *handlers.WebhookSubscriptionHandler
+	OutboxHandler       *handlers.OutboxHandler
+	NotificationHandler *handlers.NotificationPreferencesHandler
 }
 
 // NewRouter builds the Gin engine with middleware and routes.
@@ -27,7 +35,7 @@ func NewRouter(deps RouterDeps) *gin.Engine {
 	router.Use(
 		cors.New(cors.Config{
 			AllowOrigins: []string{"*"},
-			AllowMethods: []string{"GET", "POST", "OPTIONS"},
+			AllowMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 			AllowHeaders: []string{
 				"Content-Type",
 				"Idempotency-Key",
@@ -40,7 +48,7 @@ func NewRouter(deps RouterDeps) *gin.Engine {
 		middleware.RequestID(),
 		middleware.Recovery(deps.Logger),
 		middleware.Logger(deps.Logger),
-		middleware.APIKeyAuth(deps.APIKey),
+		middleware.APIKeyAuth(deps.APIKey, "/webhooks/"),
 		middleware.Timeout(deps.RequestTimeout),
 	)
 
@@ -53,9 +61,72 @@ func NewRouter(deps RouterDeps) *gin.Engine {
 
 	walletsGroup := router.Group("/wallets/:user_id")
 	walletsGroup.POST("/payments", deps.PaymentHandler.CreatePayment)
+	walletsGroup.POST("/payments:action", deps.BatchHandler.PaymentAction)
+	walletsGroup.GET("/payments/batches/:batch_id", deps.BatchHandler.GetBatch)
 	walletsGroup.GET("/balance", deps.WalletHandler.GetBalance)
 	walletsGroup.GET("/transactions", deps.WalletHandler.ListTransactions)
 	walletsGroup.POST("/top-up", deps.WalletHandler.TopUp)
+	walletsGroup.POST("/top-up/:transaction_id/confirm", deps.WalletHandler.ConfirmTopUp)
+	walletsGroup.POST("/schedules", deps.ScheduleHandler.CreateSchedule)
+	walletsGroup.GET("/schedules", deps.ScheduleHandler.ListSchedules)
+	walletsGroup.GET("/schedules/:schedule_id", deps.ScheduleHandler.GetSchedule)
+	walletsGroup.PUT("/schedules/:schedule_id", deps.ScheduleHandler.UpdateSchedule)
+	walletsGroup.DELETE("/schedules/:schedule_id", deps.ScheduleHandler.CancelSchedule)
+	walletsGroup.GET("/notification-preferences", deps.NotificationHandler.GetPreferences)
+	walletsGroup.PUT("/notification-preferences", deps.NotificationHandler.UpdatePreferences)
+
+	adminGroup := router.Group("/admin")
+	adminGroup.POST("/payments/:transaction_id/approve", deps.ReviewHandler.Approve)
+	adminGroup.POST("/payments/:transaction_id/reject", deps.ReviewHandler.Reject)
+	adminGroup.POST("/providers", deps.ProviderHandler.CreateProvider)
+	adminGroup.GET("/providers", deps.ProviderHandler.ListProviders)
+	adminGroup.GET("/providers/:provider_id", deps.ProviderHandler.GetProvider)
+	adminGroup.PUT("/providers/:provider_id", deps.ProviderHandler.UpdateProvider)
+	adminGroup.DELETE("/providers/:provider_id", deps.ProviderHandler.DeleteProvider)
+	adminGroup.POST("/webhooks", deps.SubscriptionHandler.CreateSubscription)
+	adminGroup.GET("/webhooks", deps.SubscriptionHandler.ListSubscriptions)
+	adminGroup.GET("/webhooks/:subscription_id", deps.SubscriptionHandler.GetSubscription)
+	adminGroup.PUT("/webhooks/:subscription_id", deps.SubscriptionHandler.UpdateSubscription)
+	adminGroup.DELETE("/webhooks/:subscription_id", deps.SubscriptionHandler.DeleteSubscription)
+	adminGroup.GET("/webhooks/:subscription_id/deliveries", deps.SubscriptionHandler.ListDeliveries)
+	adminGroup.POST("/webhooks/:subscription_id/replay", deps.SubscriptionHandler.Replay)
+	adminGroup.GET("/outbox/dead-letters", deps.OutboxHandler.ListDeadLetters)
+	adminGroup.POST("/outbox/dead-letters/:event_id/requeue", deps.OutboxHandler.Requeue)
+
+	// Gateway callbacks are authenticated by their HMAC signature instead of the API key.
+	router.POST("/webhooks/gateway", deps.WebhookHandler.HandleGatewayWebhook)
+
+	return router
+}
+
+// MetricsRouterDeps defines dependencies of the consumer's HTTP endpoint.
+type MetricsRouterDeps struct {
+	Logger            *zap.Logger
+	APIKey            string
+	RequestTimeout    time.Duration
+	MetricsHandler    *handlers.MetricsHandler
+	DailySpendHandler *handlers.DailySpendHandler
+}
+
+// NewMetricsRouter builds the Gin engine served by cmd/consumer.
+func NewMetricsRouter(deps MetricsRouterDeps) *gin.Engine {
+	router := gin.New()
+
+	router.Use(
+		middleware.RequestID(),
+		middleware.Recovery(deps.Logger),
+		middleware.Logger(deps.Logger),
+		middleware.APIKeyAuth(deps.APIKey, "/healthz"),
+		middleware.Timeout(deps.RequestTimeout),
+	)
+
+	router.GET("/healthz", func(c *gin.Context) {
+		c.JSON(200, gin.H{"status": "ok"})
+	})
+
+	router.GET("/metrics/payments", deps.MetricsHandler.Windows)
+	router.GET("/metrics/payments/hourly", deps.MetricsHandler.Hourly)
+	router.GET("/users/:user_id/daily-spend", deps.DailySpendHandler.List)
 
 	return router
 }
@@ -0,0 +1,72 @@
+package messaging
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// SpecVersion is the CloudEvents version of the events published by the relay.
+const SpecVersion = "1.0"
+
+// In binary content mode the context attributes travel as message headers named after the
+// attribute with a protocol prefix (ce- on AMQP and NATS, ce_ on Kafka), and the message body is
+// the event data.
+const (
+	attrSpecVersion = "specversion"
+	attrID          = "id"
+	attrSource      = "source"
+	attrType        = "type"
+	attrTime        = "time"
+)
+
+// BinaryHeaders returns the context attributes of e as binary-mode headers named prefix+attribute.
+func BinaryHeaders(e Event, prefix string) map[string]string {
+	return map[string]string{
+		prefix + attrSpecVersion: e.SpecVersion,
+		prefix + attrID:          e.ID,
+		prefix + attrSource:      e.Source,
+		prefix + attrType:        e.Type,
+		prefix + attrTime:        e.Time.Format(time.RFC3339Nano),
+	}
+}
+
+// IsBinary reports whether a message carries binary-mode CloudEvents headers. header returns the
+// value of a message header, or "" when it is missing.
+func IsBinary(header func(name string) string, prefix string) bool {
+	return header(prefix+attrSpecVersion) != ""
+}
+
+// DecodeBinary builds the event of a binary-mode message from its headers, content type and body.
+func DecodeBinary(header func(name string) string, prefix, contentType string, data []byte) (*Event, error) {
+	ev := &Event{
+		SpecVersion:     header(prefix + attrSpecVersion),
+		ID:              header(prefix + attrID),
+		Source:          header(prefix + attrSource),
+		Type:            header(prefix + attrType),
+		DataContentType: contentType,
+		Data:            json.RawMessage(data),
+	}
+	if raw := header(prefix + attrTime); raw != "" {
+		t, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			return nil, fmt.Errorf("decode %s header: %w", prefix+attrTime, err)
+		}
+		ev.Time = t
+	}
+	if err := ev.Validate(); err != nil {
+		return nil, err
+	}
+	return ev, nil
+}
+
+// Validate checks the specversion and the required context attributes of the event.
+func (e *Event) Validate() error {
+	if e.SpecVersion != SpecVersion {
+		return fmt.Errorf("unsupported cloudevents specversion %q", e.SpecVersion)
+	}
+	if e.ID == "" || e.Source == "" || e.Type == "" {
+		return fmt.Errorf("cloudevent is missing id, source or type")
+	}
+	return nil
+}
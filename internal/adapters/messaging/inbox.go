@@ -0,0 +1,31 @@
+package messaging
+
+import (
+	"context"
+
+	"draftea-challenge/internal/application/inbox"
+
+	"github.com/google/uuid"
+)
+
+// InboxHandler processes an event with repositories bound to the inbox transaction.
+type InboxHandler[R any] func(ctx context.Context, repos R, event *Event) error
+
+// WithInbox wraps handler so each event is applied once per consumer: its writes through repos and
+// the processed_messages row commit together, and duplicates are acked without calling handler.
+// Events without an ID are keyed by their type and data.
+func WithInbox[R any](in *inbox.Inbox[R], handler InboxHandler[R]) Handler {
+	return func(ctx context.Context, event *Event) error {
+		_, err := in.Process(ctx, messageID(event), event.Type, func(ctx context.Context, repos R) error {
+			return handler(ctx, repos, event)
+		})
+		return err
+	}
+}
+
+func messageID(event *Event) string {
+	if event.ID != "" {
+		return event.ID
+	}
+	return uuid.NewSHA1(uuid.NameSpaceOID, append([]byte(event.Type+":"), event.Data...)).String()
+}
@@ -0,0 +1,251 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"draftea-challenge/internal/adapters/messaging"
+
+	kafkago "github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// Headers added to dead letters.
+const (
+	headerRetryCount     = "x-retry-count"
+	headerLastError      = "x-last-error"
+	headerDeadLetteredAt = "x-dead-lettered-at"
+)
+
+// messageReader is the part of kafka.Reader used to consume a consumer group.
+type messageReader interface {
+	FetchMessage(ctx context.Context) (kafkago.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafkago.Message) error
+	Close() error
+}
+
+// Consumer reads the event topic as the consumer group of one queue. It runs one reader per worker;
+// the group spreads the partitions over them and each reader handles its messages in order. A failed
+// message is retried in place after RetryDelay, which holds back its partition, and after MaxAttempts
+// runs it is written to the dead-letter topic of the queue. Offsets are committed only once a message
+// was handled, skipped or dead-lettered.
+type Consumer struct {
+	readers      []messageReader
+	deadLetters  messageWriter
+	queue        string
+	patterns     []string
+	drainTimeout time.Duration
+	maxAttempts  int
+	retryDelay   time.Duration
+	log          *zap.Logger
+}
+
+// ConsumerOptions configures how a Consumer reads its queue.
+type ConsumerOptions struct {
+	// Workers is the number of readers in the consumer group; more than the topic partitions leaves
+	// some of them idle.
+	Workers int
+	// DrainTimeout bounds how long in-flight handlers may run after shutdown starts;
+	// their context is cancelled afterwards.
+	DrainTimeout time.Duration
+}
+
+// NewConsumer creates the event and dead-letter topics if needed and joins the consumer group of
+// queue.
+func NewConsumer(cfg Config, queue string, opts ConsumerOptions, log *zap.Logger) (*Consumer, func() error, error) {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+	drainTimeout := opts.DrainTimeout
+	if drainTimeout <= 0 {
+		drainTimeout = 30 * time.Second
+	}
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+	retryDelay := cfg.RetryDelay
+	if retryDelay <= 0 {
+		retryDelay = 10 * time.Second
+	}
+
+	patterns := messaging.QueuePatterns(cfg.Bindings, queue)
+	if len(patterns) == 0 {
+		return nil, nil, fmt.Errorf("queue %q has no bindings", queue)
+	}
+	if err := ensureTopics(cfg, cfg.Topic, DeadLetterTopic(queue)); err != nil {
+		return nil, nil, err
+	}
+
+	consumer := &Consumer{
+		deadLetters:  newWriter(cfg, DeadLetterTopic(queue)),
+		queue:        queue,
+		patterns:     patterns,
+		drainTimeout: drainTimeout,
+		maxAttempts:  maxAttempts,
+		retryDelay:   retryDelay,
+		log:          log,
+	}
+	for i := 0; i < workers; i++ {
+		consumer.readers = append(consumer.readers, kafkago.NewReader(kafkago.ReaderConfig{
+			Brokers: cfg.Brokers,
+			GroupID: queue,
+			Topic:   cfg.Topic,
+			// A new group starts at the beginning of the topic, like a queue that was bound before
+			// the first publish.
+			StartOffset: kafkago.FirstOffset,
+		}))
+	}
+
+	cleanup := func() error {
+		var firstErr error
+		for _, r := range consumer.readers {
+			if err := r.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		if err := consumer.deadLetters.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if firstErr != nil {
+			log.Warn("failed to close kafka consumer", zap.Error(firstErr), zap.String("queue", queue))
+		}
+		return firstErr
+	}
+	return consumer, cleanup, nil
+}
+
+// Start consumes messages until ctx is done, decoding each one as a CloudEvent before calling the
+// handler. Messages that cannot be decoded are dead-lettered right away. Fetch errors are retried
+// with backoff. Start returns nil after a graceful shutdown.
+func (c *Consumer) Start(ctx context.Context, handler messaging.Handler) error {
+	handlerCtx, cancel := messaging.DrainContext(ctx, c.drainTimeout)
+	defer cancel()
+
+	c.log.Info("consumer subscribed", zap.String("queue", c.queue), zap.Int("workers", len(c.readers)))
+	var wg sync.WaitGroup
+	for _, r := range c.readers {
+		wg.Add(1)
+		go func(r messageReader) {
+			defer wg.Done()
+			c.read(ctx, handlerCtx, r, handler)
+		}(r)
+	}
+	wg.Wait()
+	return nil
+}
+
+// read hands the messages of one reader to the handler until ctx is done.
+func (c *Consumer) read(ctx, handlerCtx context.Context, r messageReader, handler messaging.Handler) {
+	backoff := time.Second
+	for {
+		msg, err := r.FetchMessage(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			c.log.Warn("consumer fetch error", zap.String("queue", c.queue), zap.Error(err), zap.Duration("retry_in", backoff))
+			if !sleep(ctx, backoff) {
+				return
+			}
+			backoff *= 2
+			if backoff > 30*time.Second {
+				backoff = 30 * time.Second
+			}
+			continue
+		}
+		backoff = time.Second
+		if !c.handle(ctx, handlerCtx, msg, handler) {
+			// Shutdown interrupted a retry: the offset is not committed and the message is fetched
+			// again on the next start.
+			return
+		}
+		if err := r.CommitMessages(handlerCtx, msg); err != nil {
+			c.log.Warn("failed to commit offset", zap.Error(err), zap.String("queue", c.queue), zap.Int64("offset", msg.Offset))
+		}
+	}
+}
+
+// handle runs the handler until it succeeds or maxAttempts runs failed, then dead-letters the
+// message. Messages routed to other queues are skipped. It returns false when ctx is done before the
+// message was settled.
+func (c *Consumer) handle(ctx, handlerCtx context.Context, msg kafkago.Message, handler messaging.Handler) bool {
+	event, err := decodeEvent(msg)
+	if err != nil {
+		c.log.Warn("dead-lettering undecodable message", zap.Error(err), zap.String("queue", c.queue), zap.Int64("offset", msg.Offset))
+		return c.deadLetter(ctx, msg, 0, err)
+	}
+	if !c.routes(event.Type) {
+		return true
+	}
+	for attempt := 1; ; attempt++ {
+		err := handler(handlerCtx, event)
+		if err == nil {
+			return true
+		}
+		if attempt >= c.maxAttempts {
+			c.log.Warn("dead-lettering message after max attempts", zap.Error(err), zap.String("queue", c.queue), zap.String("message_id", event.ID), zap.Int("attempts", attempt))
+			return c.deadLetter(ctx, msg, attempt, err)
+		}
+		c.log.Warn("consumer handler error, retrying", zap.Error(err), zap.String("queue", c.queue), zap.String("message_id", event.ID), zap.Int("attempt", attempt))
+		if !sleep(ctx, c.retryDelay) {
+			return false
+		}
+		event.Redelivered = true
+	}
+}
+
+// deadLetter writes a copy of msg to the dead-letter topic, retrying until it is stored: committing
+// past a message that is in neither topic would lose it. It returns false when ctx is done first.
+func (c *Consumer) deadLetter(ctx context.Context, msg kafkago.Message, attempts int, cause error) bool {
+	out := kafkago.Message{Key: msg.Key, Value: msg.Value, Time: msg.Time}
+	out.Headers = append(out.Headers, msg.Headers...)
+	out.Headers = append(out.Headers,
+		kafkago.Header{Key: headerRetryCount, Value: []byte(strconv.Itoa(attempts))},
+		kafkago.Header{Key: headerLastError, Value: []byte(truncate(cause.Error(), 1000))},
+		kafkago.Header{Key: headerDeadLetteredAt, Value: []byte(time.Now().UTC().Format(time.RFC3339))},
+	)
+	for {
+		err := c.deadLetters.WriteMessages(ctx, out)
+		if err == nil {
+			return true
+		}
+		c.log.Error("failed to dead-letter message, retrying", zap.Error(err), zap.String("queue", c.queue), zap.String("target", DeadLetterTopic(c.queue)))
+		if !sleep(ctx, c.retryDelay) {
+			return false
+		}
+	}
+}
+
+// routes reports whether a binding of the queue matches the event type.
+func (c *Consumer) routes(eventType string) bool {
+	for _, pattern := range c.patterns {
+		if messaging.MatchTopic(pattern, eventType) {
+			return true
+		}
+	}
+	return false
+}
+
+// sleep waits d and reports whether ctx is still running.
+func sleep(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
+
+var _ messaging.Consumer = (*Consumer)(nil)
@@ -0,0 +1,169 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"draftea-challenge/internal/adapters/messaging"
+
+	kafkago "github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// fakeReader hands out its messages in order and then blocks until ctx is done.
+type fakeReader struct {
+	mu        sync.Mutex
+	messages  []kafkago.Message
+	committed []int64
+	drained   chan struct{}
+}
+
+func newFakeReader(msgs ...kafkago.Message) *fakeReader {
+	for i := range msgs {
+		msgs[i].Offset = int64(i)
+	}
+	return &fakeReader{messages: msgs, drained: make(chan struct{})}
+}
+
+func (r *fakeReader) FetchMessage(ctx context.Context) (kafkago.Message, error) {
+	r.mu.Lock()
+	if len(r.messages) > 0 {
+		msg := r.messages[0]
+		r.messages = r.messages[1:]
+		r.mu.Unlock()
+		return msg, nil
+	}
+	r.mu.Unlock()
+	<-ctx.Done()
+	return kafkago.Message{}, ctx.Err()
+}
+
+func (r *fakeReader) CommitMessages(ctx context.Context, msgs ...kafkago.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, msg := range msgs {
+		r.committed = append(r.committed, msg.Offset)
+	}
+	if len(r.messages) == 0 {
+		select {
+		case <-r.drained:
+		default:
+			close(r.drained)
+		}
+	}
+	return nil
+}
+
+func (r *fakeReader) Close() error { return nil }
+
+func testConsumer(r messageReader, dlq messageWriter) *Consumer {
+	return &Consumer{
+		readers:      []messageReader{r},
+		deadLetters:  dlq,
+		queue:        "metrics.queue",
+		patterns:     []string{"payment.*"},
+		drainTimeout: time.Second,
+		maxAttempts:  3,
+		retryDelay:   time.Millisecond,
+		log:          zap.NewNop(),
+	}
+}
+
+// run starts c, waits until the reader committed its last message and stops it.
+func run(t *testing.T, c *Consumer, r *fakeReader, handler messaging.Handler) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- c.Start(ctx, handler) }()
+	select {
+	case <-r.drained:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("consumer did not commit every message")
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("start: %v", err)
+	}
+}
+
+func TestConsumerSkipsEventsOfOtherQueues(t *testing.T) {
+	r := newFakeReader(
+		encodeEvent(testEvent("refund.created"), "/s"),
+		encodeEvent(testEvent("payment.completed"), "/s"),
+	)
+	c := testConsumer(r, &fakeWriter{})
+	var handled []string
+	run(t, c, r, func(ctx context.Context, ev *messaging.Event) error {
+		handled = append(handled, ev.Type)
+		return nil
+	})
+	if len(handled) != 1 || handled[0] != "payment.completed" {
+		t.Fatalf("unexpected handled events %v", handled)
+	}
+	if len(r.committed) != 2 {
+		t.Fatalf("expected both offsets committed, got %v", r.committed)
+	}
+}
+
+func TestConsumerRetriesThenDeadLetters(t *testing.T) {
+	r := newFakeReader(encodeEvent(testEvent("payment.completed"), "/s"))
+	dlq := &fakeWriter{}
+	c := testConsumer(r, dlq)
+	attempts := 0
+	var redelivered bool
+	run(t, c, r, func(ctx context.Context, ev *messaging.Event) error {
+		attempts++
+		redelivered = ev.Redelivered
+		return errors.New("db down")
+	})
+	if attempts != 3 || !redelivered {
+		t.Fatalf("expected 3 attempts with redelivery flag, got %d (%v)", attempts, redelivered)
+	}
+	if len(dlq.written) != 1 || len(r.committed) != 1 {
+		t.Fatalf("expected dead letter then commit, got %d dead letters, commits %v", len(dlq.written), r.committed)
+	}
+	dead := dlq.written[0]
+	if headerValue(dead.Headers, headerLastError) != "db down" || headerValue(dead.Headers, headerRetryCount) != "3" || headerValue(dead.Headers, "ce_type") != "payment.completed" {
+		t.Fatalf("unexpected dead letter headers %+v", dead.Headers)
+	}
+}
+
+func TestConsumerDeadLettersUndecodableMessages(t *testing.T) {
+	r := newFakeReader(kafkago.Message{Value: []byte("{}")})
+	dlq := &fakeWriter{}
+	c := testConsumer(r, dlq)
+	run(t, c, r, func(ctx context.Context, ev *messaging.Event) error {
+		t.Fatalf("handler called for an undecodable message")
+		return nil
+	})
+	if len(dlq.written) != 1 || len(r.committed) != 1 {
+		t.Fatalf("expected dead letter then commit, got %d dead letters, commits %v", len(dlq.written), r.committed)
+	}
+}
+
+func TestShutdownDuringRetryLeavesOffsetUncommitted(t *testing.T) {
+	r := newFakeReader(encodeEvent(testEvent("payment.completed"), "/s"))
+	c := testConsumer(r, &fakeWriter{})
+	c.retryDelay = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	failed := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Start(ctx, func(ctx context.Context, ev *messaging.Event) error {
+			close(failed)
+			return errors.New("db down")
+		})
+	}()
+	<-failed
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if len(r.committed) != 0 {
+		t.Fatalf("expected no commit, got %v", r.committed)
+	}
+}
@@ -0,0 +1,184 @@
+// Package kafka publishes and consumes events on Kafka. Every event goes to one topic keyed by its
+// aggregate, so the events of an aggregate keep their order within a partition. Every consumer queue
+// is a consumer group on that topic that skips the event types its bindings do not route to it.
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"draftea-challenge/internal/adapters/messaging"
+	"draftea-challenge/internal/application/outbox"
+
+	"github.com/google/uuid"
+	kafkago "github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+const (
+	contentTypeJSON = "application/json"
+
+	// headerPrefix prefixes the CloudEvents attribute headers (Kafka binding, binary mode).
+	headerPrefix      = "ce_"
+	headerContentType = "content-type"
+)
+
+// Config configures the Kafka brokers, topics and consumer retries.
+type Config struct {
+	Brokers []string
+	Topic   string
+	// Partitions and ReplicationFactor are used when the topics are created at startup.
+	Partitions        int
+	ReplicationFactor int
+	Bindings          []messaging.Binding
+	Source            string // CloudEvents source of published events
+	// MaxAttempts is how many times a consumer handles a message before dead-lettering it; failed
+	// attempts wait RetryDelay.
+	MaxAttempts int
+	RetryDelay  time.Duration
+}
+
+// messageWriter is the part of kafka.Writer used to produce.
+type messageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafkago.Message) error
+	Close() error
+}
+
+// Publisher writes events to the topic and waits for every in-sync replica to acknowledge them.
+type Publisher struct {
+	writer messageWriter
+	source string
+}
+
+// NewPublisher creates the event topic and the dead-letter topics of the bound queues, and returns a
+// publisher to the event topic.
+func NewPublisher(cfg Config, log *zap.Logger) (*Publisher, func() error, error) {
+	topics := []string{cfg.Topic}
+	seen := make(map[string]bool)
+	for _, b := range cfg.Bindings {
+		if !seen[b.Queue] {
+			seen[b.Queue] = true
+			topics = append(topics, DeadLetterTopic(b.Queue))
+		}
+	}
+	if err := ensureTopics(cfg, topics...); err != nil {
+		return nil, nil, err
+	}
+
+	writer := newWriter(cfg, cfg.Topic)
+	cleanup := func() error {
+		if err := writer.Close(); err != nil {
+			log.Warn("failed to close kafka writer", zap.Error(err))
+			return err
+		}
+		return nil
+	}
+	return &Publisher{writer: writer, source: cfg.Source}, cleanup, nil
+}
+
+// Publish writes the event as a binary-mode CloudEvent keyed by its aggregate. There is a single
+// topic, so exchange is ignored.
+func (p *Publisher) Publish(ctx context.Context, exchange string, event *outbox.OutboxEvent) error {
+	if err := p.writer.WriteMessages(ctx, encodeEvent(event, p.source)); err != nil {
+		return fmt.Errorf("publish %s to kafka: %w", event.ID, err)
+	}
+	return nil
+}
+
+// encodeEvent builds the Kafka message of an outbox event. Events without an aggregate are keyed by
+// their own ID.
+func encodeEvent(event *outbox.OutboxEvent, source string) kafkago.Message {
+	ce := messaging.NewEvent(event, source)
+	key := event.AggregateID
+	if key == uuid.Nil {
+		key = event.ID
+	}
+	msg := kafkago.Message{
+		Key:   []byte(key.String()),
+		Value: []byte(event.Payload),
+		Time:  ce.Time,
+	}
+	for k, v := range messaging.BinaryHeaders(ce, headerPrefix) {
+		msg.Headers = append(msg.Headers, kafkago.Header{Key: k, Value: []byte(v)})
+	}
+	msg.Headers = append(msg.Headers, kafkago.Header{Key: headerContentType, Value: []byte(contentTypeJSON)})
+	return msg
+}
+
+// decodeEvent reads a binary-mode CloudEvent. The routing key is the event type.
+func decodeEvent(msg kafkago.Message) (*messaging.Event, error) {
+	header := func(name string) string { return headerValue(msg.Headers, name) }
+	ev, err := messaging.DecodeBinary(header, headerPrefix, header(headerContentType), msg.Value)
+	if err != nil {
+		return nil, err
+	}
+	ev.RoutingKey = ev.Type
+	return ev, nil
+}
+
+func headerValue(headers []kafkago.Header, key string) string {
+	for _, h := range headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// DeadLetterTopic returns the topic dead letters of queue are written to.
+func DeadLetterTopic(queue string) string { return queue + ".dlq" }
+
+func newWriter(cfg Config, topic string) *kafkago.Writer {
+	return kafkago.NewWriter(kafkago.WriterConfig{
+		Brokers:      cfg.Brokers,
+		Topic:        topic,
+		Balancer:     &kafkago.Hash{},
+		RequiredAcks: -1,
+		// Publishes are synchronous; a short batch timeout keeps their latency low.
+		BatchTimeout: 10 * time.Millisecond,
+	})
+}
+
+// ensureTopics creates the missing topics through the controller broker.
+func ensureTopics(cfg Config, topics ...string) error {
+	if len(cfg.Brokers) == 0 {
+		return fmt.Errorf("kafka: no brokers configured")
+	}
+	partitions := cfg.Partitions
+	if partitions <= 0 {
+		partitions = 1
+	}
+	replication := cfg.ReplicationFactor
+	if replication <= 0 {
+		replication = 1
+	}
+
+	conn, err := kafkago.Dial("tcp", cfg.Brokers[0])
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+	controller, err := conn.Controller()
+	if err != nil {
+		return err
+	}
+	controllerConn, err := kafkago.Dial("tcp", net.JoinHostPort(controller.Host, strconv.Itoa(controller.Port)))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = controllerConn.Close() }()
+
+	// One request per topic, so a topic that already exists (left as it is) cannot hide the error of
+	// another one.
+	for _, topic := range topics {
+		if err := controllerConn.CreateTopics(kafkago.TopicConfig{Topic: topic, NumPartitions: partitions, ReplicationFactor: replication}); err != nil {
+			return fmt.Errorf("create kafka topic %s: %w", topic, err)
+		}
+	}
+	return nil
+}
+
+var _ outbox.MessagePublisher = (*Publisher)(nil)
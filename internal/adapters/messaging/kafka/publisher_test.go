@@ -0,0 +1,79 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"draftea-challenge/internal/application/outbox"
+
+	"github.com/google/uuid"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+type fakeWriter struct {
+	written []kafkago.Message
+	err     error
+}
+
+func (f *fakeWriter) WriteMessages(ctx context.Context, msgs ...kafkago.Message) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.written = append(f.written, msgs...)
+	return nil
+}
+
+func (f *fakeWriter) Close() error { return nil }
+
+func testEvent(eventType string) *outbox.OutboxEvent {
+	return &outbox.OutboxEvent{
+		ID:          uuid.New(),
+		EventType:   eventType,
+		Payload:     `{"amount":100}`,
+		AggregateID: uuid.New(),
+		CreatedAt:   time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestPublishKeysByAggregate(t *testing.T) {
+	w := &fakeWriter{}
+	p := &Publisher{writer: w, source: "/draftea/payments"}
+	event := testEvent("payment.completed")
+
+	if err := p.Publish(context.Background(), "", event); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	if len(w.written) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(w.written))
+	}
+	msg := w.written[0]
+	if string(msg.Key) != event.AggregateID.String() || string(msg.Value) != event.Payload {
+		t.Fatalf("unexpected message key %s value %s", msg.Key, msg.Value)
+	}
+	if headerValue(msg.Headers, "ce_id") != event.ID.String() || headerValue(msg.Headers, "ce_type") != "payment.completed" || headerValue(msg.Headers, "content-type") != contentTypeJSON {
+		t.Fatalf("unexpected headers %+v", msg.Headers)
+	}
+
+	decoded, err := decodeEvent(msg)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if decoded.ID != event.ID.String() || decoded.Source != "/draftea/payments" || decoded.RoutingKey != "payment.completed" || !decoded.Time.Equal(event.CreatedAt) {
+		t.Fatalf("unexpected event %+v", decoded)
+	}
+
+	// Events without an aggregate are keyed by their own ID.
+	event.AggregateID = uuid.Nil
+	if msg := encodeEvent(event, "/s"); string(msg.Key) != event.ID.String() {
+		t.Fatalf("expected event ID key, got %s", msg.Key)
+	}
+}
+
+func TestPublishReportsWriteErrors(t *testing.T) {
+	p := &Publisher{writer: &fakeWriter{err: errors.New("leader not available")}}
+	if err := p.Publish(context.Background(), "", testEvent("payment.completed")); err == nil {
+		t.Fatalf("expected publish error")
+	}
+}
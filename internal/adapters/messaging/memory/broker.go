@@ -0,0 +1,233 @@
+// Package memory is an in-process broker with the routing, retry and dead-letter behaviour of the
+// RabbitMQ topology. Publisher and consumers must share the Broker, so it is meant for tests that
+// run them in one process; broker.kind does not offer it. Messages are lost when the process exits.
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"draftea-challenge/internal/adapters/messaging"
+	"draftea-challenge/internal/application/outbox"
+)
+
+// Config configures the in-memory broker.
+type Config struct {
+	Bindings []messaging.Binding
+	Source   string // CloudEvents source of published events
+	// MaxAttempts is how many times a message is handled before it is dead-lettered; failed
+	// attempts wait RetryDelay before the message is queued again.
+	MaxAttempts int
+	RetryDelay  time.Duration
+}
+
+// DeadLetter is a message that failed every attempt.
+type DeadLetter struct {
+	Event     messaging.Event
+	Attempts  int
+	LastError string
+}
+
+// Broker routes published events to its queues by event type.
+type Broker struct {
+	bindings    []messaging.Binding
+	source      string
+	maxAttempts int
+	retryDelay  time.Duration
+
+	mu     sync.Mutex
+	queues map[string]*queue
+}
+
+type queue struct {
+	ready    chan struct{} // holds a token while messages are waiting
+	messages []*message
+	inFlight int
+	dead     []DeadLetter
+}
+
+type message struct {
+	event    messaging.Event
+	attempts int
+}
+
+// NewBroker creates a broker with a queue per bound queue name.
+func NewBroker(cfg Config) *Broker {
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+	retryDelay := cfg.RetryDelay
+	if retryDelay < 0 {
+		retryDelay = 0
+	}
+	b := &Broker{
+		bindings:    cfg.Bindings,
+		source:      cfg.Source,
+		maxAttempts: maxAttempts,
+		retryDelay:  retryDelay,
+		queues:      make(map[string]*queue),
+	}
+	for _, binding := range cfg.Bindings {
+		b.queue(binding.Queue)
+	}
+	return b
+}
+
+// Publish delivers the event to every queue with a binding that matches its type, once per queue.
+// There is a single exchange, so exchange is ignored.
+func (b *Broker) Publish(ctx context.Context, exchange string, event *outbox.OutboxEvent) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	ev := messaging.NewEvent(event, b.source)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	routed := make(map[string]bool)
+	for _, binding := range b.bindings {
+		if routed[binding.Queue] || !messaging.MatchTopic(binding.Pattern, ev.RoutingKey) {
+			continue
+		}
+		routed[binding.Queue] = true
+		b.push(b.queue(binding.Queue), &message{event: ev})
+	}
+	return nil
+}
+
+// Consumer returns a consumer for queue running workers handlers concurrently.
+func (b *Broker) Consumer(queue string, workers int) *Consumer {
+	if workers <= 0 {
+		workers = 1
+	}
+	b.mu.Lock()
+	b.queue(queue)
+	b.mu.Unlock()
+	return &Consumer{broker: b, queue: queue, workers: workers}
+}
+
+// Pending returns the messages of queue that are waiting, being handled or waiting for a retry.
+func (b *Broker) Pending(queue string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	q := b.queue(queue)
+	return len(q.messages) + q.inFlight
+}
+
+// DeadLetters returns the messages of queue that failed every attempt.
+func (b *Broker) DeadLetters(queue string) []DeadLetter {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]DeadLetter(nil), b.queue(queue).dead...)
+}
+
+// queue returns the named queue, creating it if needed. The caller holds b.mu.
+func (b *Broker) queue(name string) *queue {
+	q, ok := b.queues[name]
+	if !ok {
+		q = &queue{ready: make(chan struct{}, 1)}
+		b.queues[name] = q
+	}
+	return q
+}
+
+// push appends msg and wakes a worker. The caller holds b.mu.
+func (b *Broker) push(q *queue, msg *message) {
+	q.messages = append(q.messages, msg)
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// next takes the oldest message of q, waiting until there is one or ctx is done.
+func (b *Broker) next(ctx context.Context, q *queue) *message {
+	for {
+		b.mu.Lock()
+		if len(q.messages) > 0 {
+			msg := q.messages[0]
+			q.messages = q.messages[1:]
+			q.inFlight++
+			if len(q.messages) > 0 {
+				select {
+				case q.ready <- struct{}{}:
+				default:
+				}
+			}
+			b.mu.Unlock()
+			return msg
+		}
+		b.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-q.ready:
+		}
+	}
+}
+
+// settle records the outcome of a handler run: the message is dropped on success, queued again
+// after the retry delay on failure, and dead-lettered after the last attempt. A retry still
+// counts as pending while it waits.
+func (b *Broker) settle(q *queue, msg *message, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err == nil {
+		q.inFlight--
+		return
+	}
+	msg.attempts++
+	if msg.attempts >= b.maxAttempts {
+		q.inFlight--
+		q.dead = append(q.dead, DeadLetter{Event: msg.event, Attempts: msg.attempts, LastError: err.Error()})
+		return
+	}
+	msg.event.Redelivered = true
+	time.AfterFunc(b.retryDelay, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		q.inFlight--
+		b.push(q, msg)
+	})
+}
+
+// Consumer consumes one queue of a Broker.
+type Consumer struct {
+	broker  *Broker
+	queue   string
+	workers int
+}
+
+// Start hands messages to handler until ctx is done. Handlers already running finish with a
+// context that is not cancelled; messages not yet taken stay in the queue.
+func (c *Consumer) Start(ctx context.Context, handler messaging.Handler) error {
+	c.broker.mu.Lock()
+	q := c.broker.queue(c.queue)
+	c.broker.mu.Unlock()
+
+	handlerCtx := context.WithoutCancel(ctx)
+	var wg sync.WaitGroup
+	for i := 0; i < c.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				msg := c.broker.next(ctx, q)
+				if msg == nil {
+					return
+				}
+				ev := msg.event
+				c.broker.settle(q, msg, handler(handlerCtx, &ev))
+			}
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+var (
+	_ outbox.MessagePublisher = (*Broker)(nil)
+	_ messaging.Consumer      = (*Consumer)(nil)
+)
@@ -0,0 +1,126 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"draftea-challenge/internal/adapters/messaging"
+	"draftea-challenge/internal/application/outbox"
+
+	"github.com/google/uuid"
+)
+
+func outboxEvent(eventType string) *outbox.OutboxEvent {
+	return &outbox.OutboxEvent{
+		ID:        uuid.New(),
+		EventType: eventType,
+		Payload:   `{"amount":1500}`,
+		CreatedAt: time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+// waitDrained waits until queue has nothing pending.
+func waitDrained(t *testing.T, b *Broker, queue string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for b.Pending(queue) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("queue %s not drained, %d pending", queue, b.Pending(queue))
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestPublishRoutesEventsToBoundQueues(t *testing.T) {
+	b := NewBroker(Config{Bindings: messaging.Bindings(messaging.Queues{Metrics: "metrics.queue", Audit: "audit.queue", Webhooks: "webhooks.queue"}), Source: "/test"})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	got := make(map[string][]string)
+	var wg sync.WaitGroup
+	for _, name := range []string{"metrics.queue", "audit.queue", "webhooks.queue"} {
+		name := name
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = b.Consumer(name, 2).Start(ctx, func(ctx context.Context, ev *messaging.Event) error {
+				mu.Lock()
+				defer mu.Unlock()
+				got[name] = append(got[name], ev.Type)
+				return nil
+			})
+		}()
+	}
+
+	published := outboxEvent("payment.completed")
+	for _, ev := range []*outbox.OutboxEvent{published, outboxEvent("refund.created"), outboxEvent("wallet.created")} {
+		if err := b.Publish(ctx, "payments.events", ev); err != nil {
+			t.Fatalf("publish: %v", err)
+		}
+	}
+	for _, name := range []string{"metrics.queue", "audit.queue", "webhooks.queue"} {
+		waitDrained(t, b, name)
+	}
+	cancel()
+	wg.Wait()
+
+	if len(got["metrics.queue"]) != 1 || len(got["audit.queue"]) != 2 || len(got["webhooks.queue"]) != 3 {
+		t.Fatalf("unexpected routing %+v", got)
+	}
+
+	// Messages published while no consumer runs wait in the queue.
+	if err := b.Publish(context.Background(), "payments.events", published); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	if b.Pending("metrics.queue") != 1 {
+		t.Fatalf("expected the message to wait for a consumer")
+	}
+}
+
+func TestFailedMessagesAreRetriedThenDeadLettered(t *testing.T) {
+	b := NewBroker(Config{Bindings: messaging.Bindings(messaging.Queues{Metrics: "metrics.queue"}), MaxAttempts: 3, RetryDelay: time.Millisecond})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	redelivered := 0
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = b.Consumer("metrics.queue", 1).Start(ctx, func(ctx context.Context, ev *messaging.Event) error {
+			mu.Lock()
+			defer mu.Unlock()
+			calls[ev.ID]++
+			if ev.Redelivered {
+				redelivered++
+			}
+			if ev.Type == "payment.failed" || calls[ev.ID] < 2 {
+				return errors.New("handler failed")
+			}
+			return nil
+		})
+	}()
+
+	flaky, poison := outboxEvent("payment.completed"), outboxEvent("payment.failed")
+	for _, ev := range []*outbox.OutboxEvent{flaky, poison} {
+		if err := b.Publish(ctx, "payments.events", ev); err != nil {
+			t.Fatalf("publish: %v", err)
+		}
+	}
+	waitDrained(t, b, "metrics.queue")
+	cancel()
+	<-done
+
+	if calls[flaky.ID.String()] != 2 || calls[poison.ID.String()] != 3 || redelivered != 3 {
+		t.Fatalf("unexpected attempts %+v, redelivered %d", calls, redelivered)
+	}
+	dead := b.DeadLetters("metrics.queue")
+	if len(dead) != 1 || dead[0].Event.ID != poison.ID.String() || dead[0].Attempts != 3 || dead[0].LastError != "handler failed" {
+		t.Fatalf("unexpected dead letters %+v", dead)
+	}
+}
@@ -0,0 +1,154 @@
+// Package messaging holds the broker-neutral side of event delivery: the decoded event handed to
+// consumer handlers, the Consumer port every broker adapter implements and the routing of event
+// types to consumer queues. Publishing goes through outbox.MessagePublisher.
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"draftea-challenge/internal/application/outbox"
+)
+
+// Broker kinds selectable with broker.kind. The in-memory broker (package memory) is not one of
+// them: publisher and consumers must share its process, so it is built directly by tests.
+const (
+	KindRabbit = "rabbit"
+	KindKafka  = "kafka"
+	KindNATS   = "nats"
+)
+
+// Event is a CloudEvents 1.0 event decoded from a broker message.
+type Event struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+	// RoutingKey and Redelivered come from the broker delivery, not from the event.
+	RoutingKey  string `json:"-"`
+	Redelivered bool   `json:"-"`
+}
+
+// NewEvent builds the CloudEvent for an outbox event. The routing key is the event type.
+func NewEvent(event *outbox.OutboxEvent, source string) Event {
+	return Event{
+		SpecVersion:     SpecVersion,
+		ID:              event.ID.String(),
+		Source:          source,
+		Type:            event.EventType,
+		Time:            event.CreatedAt.UTC(),
+		DataContentType: "application/json",
+		Data:            json.RawMessage(event.Payload),
+		RoutingKey:      event.EventType,
+	}
+}
+
+// Handler processes a decoded event. A returned error makes the broker retry the message and,
+// after the last attempt, dead-letter it.
+type Handler func(ctx context.Context, event *Event) error
+
+// Consumer reads one queue and hands every event to a handler.
+type Consumer interface {
+	// Start consumes until ctx is done and returns once in-flight handlers finished. It returns nil
+	// after a graceful shutdown.
+	Start(ctx context.Context, handler Handler) error
+}
+
+// DrainContext returns a context for handlers that outlives ctx by up to timeout, so handlers
+// running when shutdown starts can finish instead of failing half way.
+func DrainContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	handlerCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-handlerCtx.Done():
+			return
+		}
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			cancel()
+		case <-handlerCtx.Done():
+		}
+	}()
+	return handlerCtx, cancel
+}
+
+// Binding routes the events whose type matches Pattern to Queue. Patterns use the AMQP topic
+// syntax: words are separated by dots, * matches one word and # zero or more.
+type Binding struct {
+	Queue   string
+	Pattern string
+}
+
+// Queues names the consumer queues. An empty name leaves the queue out.
+type Queues struct {
+	Metrics       string
+	Audit         string
+	Webhooks      string
+	Notifications string
+}
+
+// Bindings returns the routing of event types to the consumer queues.
+func Bindings(q Queues) []Binding {
+	var out []Binding
+	if q.Metrics != "" {
+		out = append(out, Binding{Queue: q.Metrics, Pattern: "payment.*"})
+	}
+	if q.Audit != "" {
+		for _, pattern := range []string{"payment.*", "refund.*", "schedule.*", "topup.*"} {
+			out = append(out, Binding{Queue: q.Audit, Pattern: pattern})
+		}
+	}
+	if q.Webhooks != "" {
+		// Merchant subscriptions filter by event type, so every event reaches the webhook worker.
+		out = append(out, Binding{Queue: q.Webhooks, Pattern: "#"})
+	}
+	if q.Notifications != "" {
+		for _, pattern := range []string{"payment.completed", "payment.failed", "refund.created"} {
+			out = append(out, Binding{Queue: q.Notifications, Pattern: pattern})
+		}
+	}
+	return out
+}
+
+// QueuePatterns returns the patterns bound to queue, in binding order.
+func QueuePatterns(bindings []Binding, queue string) []string {
+	var out []string
+	for _, b := range bindings {
+		if b.Queue == queue {
+			out = append(out, b.Pattern)
+		}
+	}
+	return out
+}
+
+// MatchTopic reports whether key matches the topic pattern.
+func MatchTopic(pattern, key string) bool {
+	return matchWords(strings.Split(pattern, "."), strings.Split(key, "."))
+}
+
+func matchWords(pattern, key []string) bool {
+	if len(pattern) == 0 {
+		return len(key) == 0
+	}
+	switch pattern[0] {
+	case "#":
+		for i := 0; i <= len(key); i++ {
+			if matchWords(pattern[1:], key[i:]) {
+				return true
+			}
+		}
+		return false
+	case "*":
+		return len(key) > 0 && matchWords(pattern[1:], key[1:])
+	default:
+		return len(key) > 0 && pattern[0] == key[0] && matchWords(pattern[1:], key[1:])
+	}
+}
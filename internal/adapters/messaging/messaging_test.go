@@ -0,0 +1,70 @@
+package messaging
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMatchTopic(t *testing.T) {
+	cases := []struct {
+		pattern, key string
+		want         bool
+	}{
+		{"payment.*", "payment.completed", true},
+		{"payment.*", "payment", false},
+		{"payment.*", "payment.completed.v2", false},
+		{"payment.*", "refund.created", false},
+		{"#", "payment.completed", true},
+		{"#", "", true},
+		{"payment.#", "payment", true},
+		{"payment.#", "payment.completed.v2", true},
+		{"*.created", "refund.created", true},
+		{"*.created", "refund.completed", false},
+	}
+	for _, tc := range cases {
+		if got := MatchTopic(tc.pattern, tc.key); got != tc.want {
+			t.Fatalf("MatchTopic(%q, %q) = %v, want %v", tc.pattern, tc.key, got, tc.want)
+		}
+	}
+}
+
+func TestBindingsSkipEmptyQueues(t *testing.T) {
+	bindings := Bindings(Queues{Metrics: "metrics.queue", Webhooks: "webhooks.queue"})
+	if len(bindings) != 2 {
+		t.Fatalf("expected 2 bindings, got %+v", bindings)
+	}
+	if bindings[0] != (Binding{Queue: "metrics.queue", Pattern: "payment.*"}) || bindings[1] != (Binding{Queue: "webhooks.queue", Pattern: "#"}) {
+		t.Fatalf("unexpected bindings %+v", bindings)
+	}
+}
+
+func TestDrainContextOutlivesShutdownUpToTimeout(t *testing.T) {
+	ctx, stop := context.WithCancel(context.Background())
+	handlerCtx, cancel := DrainContext(ctx, 50*time.Millisecond)
+	defer cancel()
+
+	stop()
+	select {
+	case <-handlerCtx.Done():
+		t.Fatalf("handler context cancelled as soon as shutdown started")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	select {
+	case <-handlerCtx.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("handler context not cancelled after the drain timeout")
+	}
+}
+
+func TestQueuePatterns(t *testing.T) {
+	bindings := Bindings(Queues{Metrics: "metrics.queue", Audit: "audit.queue"})
+	patterns := QueuePatterns(bindings, "audit.queue")
+	if len(patterns) != 4 || patterns[0] != "payment.*" || patterns[3] != "topup.*" {
+		t.Fatalf("unexpected audit patterns %v", patterns)
+	}
+	if got := QueuePatterns(bindings, "unknown.queue"); len(got) != 0 {
+		t.Fatalf("expected no patterns, got %v", got)
+	}
+}
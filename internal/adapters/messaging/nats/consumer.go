@@ -0,0 +1,274 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"draftea-challenge/internal/adapters/messaging"
+
+	natsgo "github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"go.uber.org/zap"
+)
+
+// Headers added to dead letters.
+const (
+	headerRetryCount      = "x-retry-count"
+	headerLastError       = "x-last-error"
+	headerOriginalSubject = "x-original-subject"
+	headerDeadLetteredAt  = "x-dead-lettered-at"
+)
+
+// Consumer reads one queue through a durable pull consumer. A failed message is redelivered after
+// RetryDelay (NakWithDelay); after MaxAttempts deliveries it is copied to the dead-letter subject of
+// the queue and acked.
+type Consumer struct {
+	consumer     jetstream.Consumer
+	js           jetStreamPublisher
+	queue        string
+	deadLetters  string
+	prefetch     int
+	workers      int
+	drainTimeout time.Duration
+	maxAttempts  int
+	retryDelay   time.Duration
+	log          *zap.Logger
+}
+
+// ConsumerOptions configures how a Consumer reads its queue.
+type ConsumerOptions struct {
+	// Prefetch is the number of unacked messages the server hands to this consumer.
+	Prefetch int
+	// Workers is the number of handlers running concurrently.
+	Workers int
+	// DrainTimeout bounds how long in-flight handlers may run after shutdown starts;
+	// their context is cancelled afterwards.
+	DrainTimeout time.Duration
+}
+
+// errIteratorClosed reports that the pull subscription ended while the consumer was running.
+var errIteratorClosed = errors.New("nats: message iterator closed")
+
+// NewConsumer connects to NATS and creates or updates the durable consumer of queue, filtered on the
+// subjects of its bindings.
+func NewConsumer(cfg Config, queue string, opts ConsumerOptions, log *zap.Logger) (*Consumer, func() error, error) {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+	prefetch := opts.Prefetch
+	if prefetch < workers {
+		prefetch = workers
+	}
+	drainTimeout := opts.DrainTimeout
+	if drainTimeout <= 0 {
+		drainTimeout = 30 * time.Second
+	}
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+	retryDelay := cfg.RetryDelay
+	if retryDelay <= 0 {
+		retryDelay = 10 * time.Second
+	}
+
+	patterns := messaging.QueuePatterns(cfg.Bindings, queue)
+	if len(patterns) == 0 {
+		return nil, nil, fmt.Errorf("queue %q has no bindings", queue)
+	}
+	filters := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		subject, err := filterSubject(cfg.SubjectPrefix, pattern)
+		if err != nil {
+			return nil, nil, err
+		}
+		filters = append(filters, subject)
+	}
+
+	nc, js, err := connect(cfg, log)
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), setupTimeout)
+	defer cancel()
+	consumer, err := js.CreateOrUpdateConsumer(ctx, cfg.Stream, jetstream.ConsumerConfig{
+		Durable:        durableName(queue),
+		FilterSubjects: filters,
+		AckPolicy:      jetstream.AckExplicitPolicy,
+		MaxAckPending:  prefetch,
+		// Dead-lettering is done by the consumer, which needs to see the last delivery.
+		MaxDeliver: -1,
+	})
+	if err != nil {
+		nc.Close()
+		return nil, nil, fmt.Errorf("create nats consumer %s: %w", queue, err)
+	}
+
+	return &Consumer{
+		consumer:     consumer,
+		js:           js,
+		queue:        queue,
+		deadLetters:  DeadLetterSubject(cfg.SubjectPrefix, queue),
+		prefetch:     prefetch,
+		workers:      workers,
+		drainTimeout: drainTimeout,
+		maxAttempts:  maxAttempts,
+		retryDelay:   retryDelay,
+		log:          log,
+	}, closeFunc(nc, log), nil
+}
+
+// Start consumes messages until ctx is done, decoding each one as a CloudEvent before calling the
+// handler. Messages that cannot be decoded are dead-lettered right away. When the pull subscription
+// ends it subscribes again with backoff. Start returns nil after a graceful shutdown.
+func (c *Consumer) Start(ctx context.Context, handler messaging.Handler) error {
+	handlerCtx, cancel := messaging.DrainContext(ctx, c.drainTimeout)
+	defer cancel()
+
+	backoff := time.Second
+	for {
+		err := c.consume(ctx, handlerCtx, handler)
+		if ctx.Err() != nil {
+			return nil
+		}
+		c.log.Warn("consumer subscription lost, resubscribing", zap.String("queue", c.queue), zap.Error(err), zap.Duration("retry_in", backoff))
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > 30*time.Second {
+			backoff = 30 * time.Second
+		}
+	}
+}
+
+// consume pulls messages until ctx is done or the subscription ends.
+func (c *Consumer) consume(ctx, handlerCtx context.Context, handler messaging.Handler) error {
+	iter, err := c.consumer.Messages(jetstream.PullMaxMessages(c.prefetch))
+	if err != nil {
+		return err
+	}
+	defer iter.Stop()
+	c.log.Info("consumer subscribed", zap.String("queue", c.queue), zap.Int("prefetch", c.prefetch), zap.Int("workers", c.workers))
+
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			// Stop pulling; buffered messages are still returned by Next and handed back below.
+			iter.Drain()
+		case <-stop:
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < c.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				msg, err := iter.Next()
+				if errors.Is(err, jetstream.ErrMsgIteratorClosed) {
+					return
+				}
+				if err != nil {
+					// Missed heartbeats while the connection is down; Next keeps pulling once it is back.
+					c.log.Warn("consumer pull error", zap.String("queue", c.queue), zap.Error(err))
+					continue
+				}
+				if ctx.Err() != nil {
+					// Not started before shutdown: hand it back right away.
+					_ = msg.Nak()
+					continue
+				}
+				c.handle(handlerCtx, msg, handler)
+			}
+		}()
+	}
+	wg.Wait()
+	close(stop)
+
+	if ctx.Err() != nil {
+		return nil
+	}
+	return errIteratorClosed
+}
+
+func (c *Consumer) handle(ctx context.Context, msg jetstream.Msg, handler messaging.Handler) {
+	event, err := decodeEvent(msg)
+	if err != nil {
+		c.log.Warn("dead-lettering undecodable message", zap.Error(err), zap.String("subject", msg.Subject()))
+		c.deadLetter(ctx, msg, deliveries(msg)-1, err)
+		return
+	}
+	if err := handler(ctx, event); err != nil {
+		c.retryOrDeadLetter(ctx, msg, event.ID, err)
+		return
+	}
+	_ = msg.Ack()
+}
+
+// retryOrDeadLetter asks for a redelivery after retryDelay, or dead-letters the message once
+// maxAttempts deliveries failed.
+func (c *Consumer) retryOrDeadLetter(ctx context.Context, msg jetstream.Msg, id string, cause error) {
+	attempt := deliveries(msg)
+	if attempt >= c.maxAttempts {
+		c.log.Warn("dead-lettering message after max attempts", zap.Error(cause), zap.String("queue", c.queue), zap.String("message_id", id), zap.Int("attempts", attempt))
+		c.deadLetter(ctx, msg, attempt, cause)
+		return
+	}
+	c.log.Warn("consumer handler error, retrying", zap.Error(cause), zap.String("queue", c.queue), zap.String("message_id", id), zap.Int("attempt", attempt))
+	_ = msg.NakWithDelay(c.retryDelay)
+}
+
+// deadLetter copies the message to the dead-letter subject and acks the original once the stream
+// stored the copy. If the copy cannot be published the original is redelivered later, so nothing
+// is lost while the server is in trouble.
+func (c *Consumer) deadLetter(ctx context.Context, msg jetstream.Msg, attempts int, cause error) {
+	out := natsgo.NewMsg(c.deadLetters)
+	for k, v := range msg.Headers() {
+		out.Header[k] = append([]string(nil), v...)
+	}
+	out.Header.Set(headerRetryCount, strconv.Itoa(attempts))
+	out.Header.Set(headerLastError, truncate(cause.Error(), 1000))
+	out.Header.Set(headerOriginalSubject, msg.Subject())
+	out.Header.Set(headerDeadLetteredAt, time.Now().UTC().Format(time.RFC3339))
+	// The dead-letter stream is shared by every queue: dedupe per queue, so a copy published again
+	// after a lost ack is stored once while another queue's copy of the same event is kept.
+	if id := msg.Headers().Get(natsgo.MsgIdHdr); id != "" {
+		out.Header.Set(natsgo.MsgIdHdr, c.queue+":"+id)
+	}
+	out.Data = msg.Data()
+
+	if _, err := c.js.PublishMsg(ctx, out); err != nil {
+		c.log.Error("failed to dead-letter message, redelivering", zap.Error(err), zap.String("queue", c.queue), zap.String("target", c.deadLetters))
+		_ = msg.NakWithDelay(c.retryDelay)
+		return
+	}
+	_ = msg.Ack()
+}
+
+// deliveries returns how many times the message was delivered, this delivery included.
+func deliveries(msg jetstream.Msg) int {
+	md, err := msg.Metadata()
+	if err != nil {
+		return 1
+	}
+	return int(md.NumDelivered)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
+
+var _ messaging.Consumer = (*Consumer)(nil)
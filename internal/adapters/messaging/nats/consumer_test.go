@@ -0,0 +1,119 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"draftea-challenge/internal/adapters/messaging"
+
+	natsgo "github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"go.uber.org/zap"
+)
+
+// fakeMsg is a delivered JetStream message that records how it was settled.
+type fakeMsg struct {
+	jetstream.Msg
+	msg       *natsgo.Msg
+	delivered uint64
+	acked     bool
+	nakDelay  time.Duration
+	naked     bool
+}
+
+func (m *fakeMsg) Data() []byte           { return m.msg.Data }
+func (m *fakeMsg) Headers() natsgo.Header { return m.msg.Header }
+func (m *fakeMsg) Subject() string        { return m.msg.Subject }
+func (m *fakeMsg) Ack() error             { m.acked = true; return nil }
+func (m *fakeMsg) NakWithDelay(d time.Duration) error {
+	m.naked, m.nakDelay = true, d
+	return nil
+}
+func (m *fakeMsg) Metadata() (*jetstream.MsgMetadata, error) {
+	return &jetstream.MsgMetadata{NumDelivered: m.delivered}, nil
+}
+
+func testConsumer(js *fakeJetStream) *Consumer {
+	return &Consumer{
+		js:          js,
+		queue:       "audit.queue",
+		deadLetters: DeadLetterSubject("payments.events", "audit.queue"),
+		maxAttempts: 3,
+		retryDelay:  time.Second,
+		log:         zap.NewNop(),
+	}
+}
+
+func delivered(t *testing.T, attempt uint64) *fakeMsg {
+	t.Helper()
+	return &fakeMsg{msg: encodeEvent(testEvent(), "payments.events", "/draftea/payments"), delivered: attempt}
+}
+
+func TestHandleAcksHandledMessages(t *testing.T) {
+	c := testConsumer(&fakeJetStream{})
+	msg := delivered(t, 2)
+	var got *messaging.Event
+	c.handle(context.Background(), msg, func(ctx context.Context, ev *messaging.Event) error {
+		got = ev
+		return nil
+	})
+	if !msg.acked || msg.naked {
+		t.Fatalf("expected ack, got %+v", msg)
+	}
+	if got == nil || got.Type != "payment.completed" || !got.Redelivered {
+		t.Fatalf("unexpected event %+v", got)
+	}
+}
+
+func TestHandleRetriesThenDeadLetters(t *testing.T) {
+	js := &fakeJetStream{}
+	c := testConsumer(js)
+	failing := func(ctx context.Context, ev *messaging.Event) error { return errors.New("db down") }
+
+	first := delivered(t, 1)
+	c.handle(context.Background(), first, failing)
+	if !first.naked || first.nakDelay != time.Second || first.acked || len(js.published) != 0 {
+		t.Fatalf("expected a delayed redelivery, got %+v", first)
+	}
+
+	last := delivered(t, 3)
+	c.handle(context.Background(), last, failing)
+	if !last.acked || len(js.published) != 1 {
+		t.Fatalf("expected dead letter and ack, got %+v (%d published)", last, len(js.published))
+	}
+	dead := js.published[0]
+	if dead.Subject != "dlq.payments.events.audit.queue" || dead.Header.Get(headerLastError) != "db down" || dead.Header.Get(headerRetryCount) != "3" {
+		t.Fatalf("unexpected dead letter %s %v", dead.Subject, dead.Header)
+	}
+	if dead.Header.Get(headerOriginalSubject) != "payments.events.payment.completed" || dead.Header.Get("ce-type") != "payment.completed" {
+		t.Fatalf("dead letter lost the original event: %v", dead.Header)
+	}
+	if id := dead.Header.Get(natsgo.MsgIdHdr); id != "audit.queue:"+last.msg.Header.Get("ce-id") {
+		t.Fatalf("unexpected dead letter message ID %q", id)
+	}
+}
+
+func TestHandleRedeliversWhenDeadLetterFails(t *testing.T) {
+	c := testConsumer(&fakeJetStream{err: errors.New("stream unavailable")})
+	msg := delivered(t, 3)
+	c.handle(context.Background(), msg, func(ctx context.Context, ev *messaging.Event) error { return errors.New("db down") })
+	if msg.acked || !msg.naked {
+		t.Fatalf("expected redelivery when the dead letter cannot be stored, got %+v", msg)
+	}
+}
+
+func TestHandleDeadLettersUndecodableMessages(t *testing.T) {
+	js := &fakeJetStream{}
+	c := testConsumer(js)
+	msg := &fakeMsg{msg: &natsgo.Msg{Subject: "payments.events.payment.completed", Header: natsgo.Header{}, Data: []byte("{}")}, delivered: 1}
+	called := false
+	c.handle(context.Background(), msg, func(ctx context.Context, ev *messaging.Event) error {
+		called = true
+		return nil
+	})
+	if called || !msg.acked || len(js.published) != 1 {
+		t.Fatalf("expected the message to be dead-lettered without running the handler")
+	}
+}
@@ -0,0 +1,190 @@
+// Package nats publishes and consumes events on NATS JetStream. Events are stored in one stream
+// and published on <subject prefix>.<event type>; every consumer queue is a durable pull consumer
+// whose filter subjects come from messaging.Bindings.
+package nats
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"draftea-challenge/internal/adapters/messaging"
+	"draftea-challenge/internal/application/outbox"
+
+	natsgo "github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"go.uber.org/zap"
+)
+
+const (
+	contentTypeJSON = "application/json"
+
+	// headerPrefix prefixes the CloudEvents attribute headers (NATS binding, binary mode).
+	headerPrefix      = "ce-"
+	headerContentType = "Content-Type"
+
+	setupTimeout = 10 * time.Second
+)
+
+// Config configures the JetStream connection, stream and consumer retries.
+type Config struct {
+	URL string
+	// Stream is the JetStream stream holding the events; dead letters go to Stream_DLQ.
+	Stream string
+	// SubjectPrefix prefixes the event subjects: an event is published on <prefix>.<event type>.
+	SubjectPrefix string
+	Bindings      []messaging.Binding
+	Source        string // CloudEvents source of published events
+	// PublishTimeout bounds the wait for the stream acknowledgement of a publish.
+	PublishTimeout time.Duration
+	// MaxAttempts is how many times a consumer handles a message before dead-lettering it; failed
+	// attempts are redelivered after RetryDelay.
+	MaxAttempts int
+	RetryDelay  time.Duration
+}
+
+// jetStreamPublisher is the part of jetstream.JetStream used to publish.
+type jetStreamPublisher interface {
+	PublishMsg(ctx context.Context, msg *natsgo.Msg, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error)
+}
+
+// Publisher publishes events to the JetStream stream. The stream acknowledges every publish, and the
+// event ID is sent as Nats-Msg-Id so a publish retried by the relay is stored once.
+type Publisher struct {
+	js      jetStreamPublisher
+	prefix  string
+	source  string
+	timeout time.Duration
+}
+
+// NewPublisher connects to NATS and creates or updates the streams.
+func NewPublisher(cfg Config, log *zap.Logger) (*Publisher, func() error, error) {
+	nc, js, err := connect(cfg, log)
+	if err != nil {
+		return nil, nil, err
+	}
+	publisher := &Publisher{js: js, prefix: cfg.SubjectPrefix, source: cfg.Source, timeout: cfg.PublishTimeout}
+	return publisher, closeFunc(nc, log), nil
+}
+
+// Publish sends the event as a binary-mode CloudEvent on <prefix>.<event type>. There is a single
+// stream, so exchange is ignored.
+func (p *Publisher) Publish(ctx context.Context, exchange string, event *outbox.OutboxEvent) error {
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+	if _, err := p.js.PublishMsg(ctx, encodeEvent(event, p.prefix, p.source)); err != nil {
+		return fmt.Errorf("publish %s to nats: %w", event.ID, err)
+	}
+	return nil
+}
+
+// encodeEvent builds the NATS message of an outbox event.
+func encodeEvent(event *outbox.OutboxEvent, prefix, source string) *natsgo.Msg {
+	ce := messaging.NewEvent(event, source)
+	msg := natsgo.NewMsg(eventSubject(prefix, ce.Type))
+	for k, v := range messaging.BinaryHeaders(ce, headerPrefix) {
+		msg.Header.Set(k, v)
+	}
+	msg.Header.Set(headerContentType, contentTypeJSON)
+	msg.Header.Set(natsgo.MsgIdHdr, ce.ID)
+	msg.Data = []byte(event.Payload)
+	return msg
+}
+
+// decodeEvent reads a binary-mode CloudEvent. The routing key is the event type.
+func decodeEvent(msg jetstream.Msg) (*messaging.Event, error) {
+	header := msg.Headers()
+	ev, err := messaging.DecodeBinary(header.Get, headerPrefix, header.Get(headerContentType), msg.Data())
+	if err != nil {
+		return nil, err
+	}
+	ev.RoutingKey = ev.Type
+	if md, err := msg.Metadata(); err == nil {
+		ev.Redelivered = md.NumDelivered > 1
+	}
+	return ev, nil
+}
+
+func eventSubject(prefix, eventType string) string { return prefix + "." + eventType }
+
+// DeadLetterSubject returns the subject dead letters of queue are stored on.
+func DeadLetterSubject(prefix, queue string) string { return "dlq." + prefix + "." + queue }
+
+// deadLetterStream returns the name of the stream holding the dead letters.
+func deadLetterStream(stream string) string { return stream + "_DLQ" }
+
+// filterSubject maps a binding pattern to a filter subject under prefix. * matches one token in both
+// syntaxes; # becomes >, which NATS only allows as the last token.
+func filterSubject(prefix, pattern string) (string, error) {
+	words := strings.Split(pattern, ".")
+	for i, w := range words {
+		if w != "#" {
+			continue
+		}
+		if i != len(words)-1 {
+			return "", fmt.Errorf("pattern %q: # is only supported as the last word on nats", pattern)
+		}
+		words[i] = ">"
+	}
+	return prefix + "." + strings.Join(words, "."), nil
+}
+
+// durableName returns the durable consumer name of queue; names cannot contain dots.
+func durableName(queue string) string {
+	return strings.NewReplacer(".", "_", "*", "_", ">", "_", " ", "_").Replace(queue)
+}
+
+// connect dials NATS, reconnecting forever once connected, and creates or updates the event and
+// dead-letter streams.
+func connect(cfg Config, log *zap.Logger) (*natsgo.Conn, jetstream.JetStream, error) {
+	nc, err := natsgo.Connect(cfg.URL,
+		natsgo.Name("draftea-payments"),
+		natsgo.MaxReconnects(-1),
+		natsgo.DisconnectErrHandler(func(_ *natsgo.Conn, err error) {
+			log.Warn("nats disconnected", zap.Error(err))
+		}),
+		natsgo.ReconnectHandler(func(nc *natsgo.Conn) {
+			log.Info("nats reconnected", zap.String("url", nc.ConnectedUrl()))
+		}),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	js, err := jetstream.New(nc)
+	if err != nil {
+		nc.Close()
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), setupTimeout)
+	defer cancel()
+	streams := []jetstream.StreamConfig{
+		{Name: cfg.Stream, Subjects: []string{cfg.SubjectPrefix + ".>"}, Storage: jetstream.FileStorage},
+		{Name: deadLetterStream(cfg.Stream), Subjects: []string{"dlq." + cfg.SubjectPrefix + ".>"}, Storage: jetstream.FileStorage},
+	}
+	for _, stream := range streams {
+		if _, err := js.CreateOrUpdateStream(ctx, stream); err != nil {
+			nc.Close()
+			return nil, nil, fmt.Errorf("create nats stream %s: %w", stream.Name, err)
+		}
+	}
+	return nc, js, nil
+}
+
+// closeFunc drains the connection, so pending publishes and acks are flushed before it closes.
+func closeFunc(nc *natsgo.Conn, log *zap.Logger) func() error {
+	return func() error {
+		if err := nc.Drain(); err != nil {
+			log.Warn("failed to drain nats connection", zap.Error(err))
+			nc.Close()
+			return err
+		}
+		return nil
+	}
+}
+
+var _ outbox.MessagePublisher = (*Publisher)(nil)
@@ -0,0 +1,96 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"draftea-challenge/internal/application/outbox"
+
+	"github.com/google/uuid"
+	natsgo "github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+type fakeJetStream struct {
+	published []*natsgo.Msg
+	err       error
+}
+
+func (f *fakeJetStream) PublishMsg(ctx context.Context, msg *natsgo.Msg, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.published = append(f.published, msg)
+	return &jetstream.PubAck{Stream: "PAYMENTS_EVENTS", Sequence: uint64(len(f.published))}, nil
+}
+
+func testEvent() *outbox.OutboxEvent {
+	return &outbox.OutboxEvent{
+		ID:        uuid.New(),
+		EventType: "payment.completed",
+		Payload:   `{"amount":100}`,
+		CreatedAt: time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestPublishSendsBinaryCloudEvent(t *testing.T) {
+	js := &fakeJetStream{}
+	p := &Publisher{js: js, prefix: "payments.events", source: "/draftea/payments"}
+	event := testEvent()
+
+	if err := p.Publish(context.Background(), "", event); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	if len(js.published) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(js.published))
+	}
+	msg := js.published[0]
+	if msg.Subject != "payments.events.payment.completed" || string(msg.Data) != event.Payload {
+		t.Fatalf("unexpected message %s %s", msg.Subject, msg.Data)
+	}
+	if msg.Header.Get(natsgo.MsgIdHdr) != event.ID.String() || msg.Header.Get("ce-id") != event.ID.String() {
+		t.Fatalf("event ID not sent for deduplication: %v", msg.Header)
+	}
+	if msg.Header.Get("ce-type") != "payment.completed" || msg.Header.Get("ce-source") != "/draftea/payments" || msg.Header.Get("Content-Type") != contentTypeJSON {
+		t.Fatalf("unexpected headers %v", msg.Header)
+	}
+
+	decoded, err := decodeEvent(&fakeMsg{msg: msg, delivered: 1})
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if decoded.ID != event.ID.String() || decoded.RoutingKey != "payment.completed" || !decoded.Time.Equal(event.CreatedAt) || decoded.Redelivered {
+		t.Fatalf("unexpected event %+v", decoded)
+	}
+}
+
+func TestPublishReportsStreamErrors(t *testing.T) {
+	p := &Publisher{js: &fakeJetStream{err: errors.New("no responders")}, prefix: "payments.events"}
+	if err := p.Publish(context.Background(), "", testEvent()); err == nil {
+		t.Fatalf("expected publish error")
+	}
+}
+
+func TestFilterSubject(t *testing.T) {
+	cases := []struct {
+		pattern, want string
+		wantErr       bool
+	}{
+		{"payment.*", "payments.events.payment.*", false},
+		{"payment.completed", "payments.events.payment.completed", false},
+		{"#", "payments.events.>", false},
+		{"payment.#", "payments.events.payment.>", false},
+		{"#.created", "", true},
+	}
+	for _, c := range cases {
+		got, err := filterSubject("payments.events", c.pattern)
+		if (err != nil) != c.wantErr || got != c.want {
+			t.Fatalf("filterSubject(%q) = %q, %v; want %q", c.pattern, got, err, c.want)
+		}
+	}
+	if got := durableName("metrics.queue"); got != "metrics_queue" {
+		t.Fatalf("unexpected durable name %q", got)
+	}
+}
@@ -0,0 +1,115 @@
+package rabbitmq
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"draftea-challenge/internal/adapters/messaging"
+	"draftea-challenge/internal/application/outbox"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// CloudEvents content modes.
+const (
+	ModeBinary     = "binary"
+	ModeStructured = "structured"
+)
+
+const (
+	contentTypeJSON       = "application/json"
+	contentTypeCloudEvent = "application/cloudevents+json"
+
+	// headerPrefix prefixes the CloudEvents attribute headers in binary mode (AMQP binding).
+	headerPrefix = "ce-"
+)
+
+// Event is a CloudEvents 1.0 event decoded from a broker message.
+type Event = messaging.Event
+
+// validMode reports whether mode is a supported CloudEvents content mode.
+func validMode(mode string) bool {
+	return mode == ModeBinary || mode == ModeStructured
+}
+
+// encodeEvent builds the AMQP message for an outbox event in the given CloudEvents mode.
+// In binary mode the attributes travel as ce-* headers and the payload is the body as is;
+// datacontenttype maps to the AMQP content type.
+func encodeEvent(event *outbox.OutboxEvent, mode, source string) (amqp.Publishing, error) {
+	ce := messaging.NewEvent(event, source)
+	msg := amqp.Publishing{
+		MessageId: ce.ID,
+		Type:      ce.Type,
+		Timestamp: ce.Time,
+	}
+
+	switch mode {
+	case ModeStructured:
+		body, err := json.Marshal(ce)
+		if err != nil {
+			return amqp.Publishing{}, fmt.Errorf("encode cloudevent %s: %w", ce.ID, err)
+		}
+		msg.ContentType = contentTypeCloudEvent
+		msg.Body = body
+	case ModeBinary:
+		msg.ContentType = contentTypeJSON
+		msg.Headers = amqp.Table{}
+		for k, v := range messaging.BinaryHeaders(ce, headerPrefix) {
+			msg.Headers[k] = v
+		}
+		msg.Body = []byte(event.Payload)
+	default:
+		return amqp.Publishing{}, fmt.Errorf("unknown cloudevents mode %q", mode)
+	}
+	return msg, nil
+}
+
+// decodeEvent reads a delivery in either CloudEvents mode. Messages without CloudEvents
+// metadata (published before the switch) are mapped from the AMQP properties.
+func decodeEvent(msg amqp.Delivery) (*Event, error) {
+	header := func(name string) string { return headerString(msg.Headers, name) }
+	var ev *Event
+	switch {
+	case msg.ContentType == contentTypeCloudEvent:
+		ev = &Event{}
+		if err := json.Unmarshal(msg.Body, ev); err != nil {
+			return nil, fmt.Errorf("decode structured cloudevent: %w", err)
+		}
+		if err := ev.Validate(); err != nil {
+			return nil, err
+		}
+	case messaging.IsBinary(header, headerPrefix):
+		var err error
+		if ev, err = messaging.DecodeBinary(header, headerPrefix, msg.ContentType, msg.Body); err != nil {
+			return nil, err
+		}
+	default:
+		ev = &Event{
+			ID:              msg.MessageId,
+			Type:            routingKey(msg),
+			Time:            msg.Timestamp,
+			DataContentType: msg.ContentType,
+			Data:            json.RawMessage(msg.Body),
+		}
+	}
+
+	ev.RoutingKey = routingKey(msg)
+	ev.Redelivered = msg.Redelivered || retryCount(msg.Headers) > 0
+	return ev, nil
+}
+
+// routingKey returns the key the message was published with; retried and replayed messages
+// carry it in a header because they reach the queue through the default exchange.
+func routingKey(msg amqp.Delivery) string {
+	if key := headerString(msg.Headers, headerOriginalRoutingKey); key != "" {
+		return key
+	}
+	return msg.RoutingKey
+}
+
+func headerString(headers amqp.Table, key string) string {
+	if v, ok := headers[key].(string); ok {
+		return v
+	}
+	return ""
+}
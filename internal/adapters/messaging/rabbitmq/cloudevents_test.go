@@ -0,0 +1,86 @@
+package rabbitmq
+
+import (
+	"testing"
+	"time"
+
+	"draftea-challenge/internal/application/outbox"
+
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func deliveryFor(msg amqp.Publishing, routingKey string) amqp.Delivery {
+	return amqp.Delivery{
+		Headers:     msg.Headers,
+		ContentType: msg.ContentType,
+		MessageId:   msg.MessageId,
+		Timestamp:   msg.Timestamp,
+		Type:        msg.Type,
+		RoutingKey:  routingKey,
+		Body:        msg.Body,
+	}
+}
+
+func TestCloudEventsRoundTrip(t *testing.T) {
+	event := &outbox.OutboxEvent{
+		ID:        uuid.New(),
+		EventType: "payment.completed",
+		Payload:   `{"event_id":"e-1","type":"payment.completed","data":{"amount":100}}`,
+		CreatedAt: time.Date(2024, 3, 1, 12, 30, 0, 123000000, time.UTC),
+	}
+
+	for _, mode := range []string{ModeBinary, ModeStructured} {
+		t.Run(mode, func(t *testing.T) {
+			msg, err := encodeEvent(event, mode, "/draftea/payments")
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			if mode == ModeBinary && (msg.Headers["ce-id"] != event.ID.String() || string(msg.Body) != event.Payload) {
+				t.Fatalf("unexpected binary message %+v", msg)
+			}
+			if mode == ModeStructured && msg.ContentType != contentTypeCloudEvent {
+				t.Fatalf("unexpected structured content type %s", msg.ContentType)
+			}
+
+			got, err := decodeEvent(deliveryFor(msg, event.EventType))
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if got.SpecVersion != "1.0" || got.ID != event.ID.String() || got.Source != "/draftea/payments" ||
+				got.Type != event.EventType || !got.Time.Equal(event.CreatedAt) || got.RoutingKey != event.EventType {
+				t.Fatalf("unexpected event %+v", got)
+			}
+			if string(got.Data) != event.Payload {
+				t.Fatalf("unexpected data %s", got.Data)
+			}
+		})
+	}
+
+	if _, err := encodeEvent(event, "avro", "/draftea/payments"); err == nil {
+		t.Fatalf("expected error for unknown mode")
+	}
+}
+
+func TestDecodeEvent_LegacyAndInvalidMessages(t *testing.T) {
+	legacy := amqp.Delivery{MessageId: "m-1", RoutingKey: "refund.created", ContentType: contentTypeJSON, Body: []byte(`{}`)}
+	got, err := decodeEvent(legacy)
+	if err != nil {
+		t.Fatalf("decode legacy: %v", err)
+	}
+	if got.ID != "m-1" || got.Type != "refund.created" || string(got.Data) != `{}` {
+		t.Fatalf("unexpected legacy event %+v", got)
+	}
+
+	invalid := map[string]amqp.Delivery{
+		"malformed structured": {ContentType: contentTypeCloudEvent, Body: []byte(`{`)},
+		"missing source":       {Headers: amqp.Table{"ce-specversion": "1.0", "ce-id": "x", "ce-type": "payment.created"}},
+		"unsupported version":  {Headers: amqp.Table{"ce-specversion": "0.3", "ce-id": "x", "ce-source": "/s", "ce-type": "t"}},
+		"bad time":             {Headers: amqp.Table{"ce-specversion": "1.0", "ce-id": "x", "ce-source": "/s", "ce-type": "t", "ce-time": "yesterday"}},
+	}
+	for name, msg := range invalid {
+		if _, err := decodeEvent(msg); err == nil {
+			t.Fatalf("%s: expected decode error", name)
+		}
+	}
+}
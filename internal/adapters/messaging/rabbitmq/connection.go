@@ -0,0 +1,165 @@
+package rabbitmq
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+// ErrNotConnected is returned while the broker connection is down and being re-established.
+var ErrNotConnected = errors.New("rabbitmq: not connected")
+
+// connection keeps an AMQP connection open. When the broker closes it (restart, network failure)
+// it redials with exponential backoff and runs setup again on the new connection, e.g. to
+// re-declare topology. Each successful dial gets a new generation so users can drop channels
+// opened on a previous connection.
+type connection struct {
+	url            string
+	setup          func(*amqp.Connection) error
+	log            *zap.Logger
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+
+	mu   sync.RWMutex
+	conn *amqp.Connection
+	gen  uint64
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newConnection(cfg Config, setup func(*amqp.Connection) error, log *zap.Logger) (*connection, error) {
+	initialBackoff := cfg.ReconnectInitialBackoff
+	if initialBackoff <= 0 {
+		initialBackoff = 500 * time.Millisecond
+	}
+	maxBackoff := cfg.ReconnectMaxBackoff
+	if maxBackoff < initialBackoff {
+		maxBackoff = 30 * time.Second
+	}
+	c := &connection{
+		url:            cfg.URL,
+		setup:          setup,
+		log:            log,
+		initialBackoff: initialBackoff,
+		maxBackoff:     maxBackoff,
+		done:           make(chan struct{}),
+	}
+	conn, err := c.dial()
+	if err != nil {
+		return nil, err
+	}
+	c.set(conn)
+	return c, nil
+}
+
+// current returns the open connection and its generation, or ErrNotConnected while reconnecting.
+func (c *connection) current() (*amqp.Connection, uint64, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.conn == nil || c.conn.IsClosed() {
+		return nil, c.gen, ErrNotConnected
+	}
+	return c.conn, c.gen, nil
+}
+
+// generation returns the generation of the latest connection.
+func (c *connection) generation() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.gen
+}
+
+// Close closes the connection for good; it is not redialed afterwards.
+func (c *connection) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.mu.Lock()
+		conn := c.conn
+		c.conn = nil
+		c.mu.Unlock()
+		if conn != nil && !conn.IsClosed() {
+			err = conn.Close()
+		}
+	})
+	return err
+}
+
+func (c *connection) dial() (*amqp.Connection, error) {
+	conn, err := amqp.Dial(c.url)
+	if err != nil {
+		return nil, err
+	}
+	if c.setup != nil {
+		if err := c.setup(conn); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+func (c *connection) set(conn *amqp.Connection) {
+	c.mu.Lock()
+	c.conn = conn
+	c.gen++
+	c.mu.Unlock()
+	go c.watch(conn)
+}
+
+// watch waits for conn to close and reconnects unless Close was called.
+func (c *connection) watch(conn *amqp.Connection) {
+	if !c.closedUnexpectedly(conn.NotifyClose(make(chan *amqp.Error, 1))) {
+		return
+	}
+
+	c.mu.Lock()
+	c.conn = nil
+	c.mu.Unlock()
+
+	backoff := c.initialBackoff
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-time.After(backoff):
+		}
+		next, err := c.dial()
+		if err == nil {
+			c.set(next)
+			c.log.Info("rabbitmq connection re-established")
+			return
+		}
+		backoff *= 2
+		if backoff > c.maxBackoff {
+			backoff = c.maxBackoff
+		}
+		c.log.Warn("rabbitmq reconnect failed", zap.Error(err), zap.Duration("retry_in", backoff))
+	}
+}
+
+// closedUnexpectedly blocks until the connection closes and reports whether it should be redialed.
+// Only Close is a deliberate shutdown: it closes done before the connection, so a close without an
+// error (NotifyClose channel closed or a nil error, e.g. after a failed heartbeat) still reconnects.
+func (c *connection) closedUnexpectedly(closed <-chan *amqp.Error) bool {
+	select {
+	case <-c.done:
+		return false
+	case amqpErr := <-closed:
+		select {
+		case <-c.done:
+			return false
+		default:
+		}
+		if amqpErr != nil {
+			c.log.Warn("rabbitmq connection closed, reconnecting", zap.Error(amqpErr))
+		} else {
+			c.log.Warn("rabbitmq connection closed without an error, reconnecting")
+		}
+		return true
+	}
+}
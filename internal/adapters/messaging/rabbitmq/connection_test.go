@@ -0,0 +1,41 @@
+package rabbitmq
+
+import (
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+func TestClosedUnexpectedlyReconnectsUnlessClosedByUs(t *testing.T) {
+	newConn := func() *connection {
+		return &connection{log: zap.NewNop(), done: make(chan struct{})}
+	}
+
+	// Broker-initiated close with an error.
+	c := newConn()
+	closed := make(chan *amqp.Error, 1)
+	closed <- &amqp.Error{Code: amqp.ConnectionForced, Reason: "broker shutdown"}
+	if !c.closedUnexpectedly(closed) {
+		t.Fatalf("expected reconnect after a broker close")
+	}
+
+	// The library closes the notify channel without an error, e.g. after a missed heartbeat.
+	c = newConn()
+	closed = make(chan *amqp.Error)
+	close(closed)
+	if !c.closedUnexpectedly(closed) {
+		t.Fatalf("expected reconnect when the notify channel closes without Close")
+	}
+
+	// Close marks done before closing the connection, so the same signal means shutdown.
+	c = newConn()
+	if err := c.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	closed = make(chan *amqp.Error)
+	close(closed)
+	if c.closedUnexpectedly(closed) {
+		t.Fatalf("expected no reconnect after Close")
+	}
+}
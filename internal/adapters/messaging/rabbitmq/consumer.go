@@ -2,43 +2,106 @@ package rabbitmq
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"draftea-challenge/internal/adapters/messaging"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 	"go.uber.org/zap"
 )
 
-// Consumer consumes messages from a queue.
+// Consumer consumes messages from a queue. It resubscribes after the connection or channel is lost,
+// runs Workers handlers concurrently with a Qos prefetch of Prefetch, and on shutdown stops consuming
+// and lets in-flight handlers finish and ack before returning.
 type Consumer struct {
-	conn    *amqp.Connection
-	channel *amqp.Channel
-	queue   string
-	log     *zap.Logger
+	conn         *connection
+	queue        string
+	prefetch     int
+	workers      int
+	drainTimeout time.Duration
+	retry        backoffConfig
+	maxAttempts  int
+	retryDelay   time.Duration
+	exchange     string
+	confirmWait  time.Duration
+	log          *zap.Logger
+}
+
+// ConsumerOptions configures how a Consumer reads its queue.
+type ConsumerOptions struct {
+	// Prefetch is the number of unacked messages the broker hands to this consumer.
+	Prefetch int
+	// Workers is the number of handlers running concurrently.
+	Workers int
+	// DrainTimeout bounds how long in-flight handlers may run after shutdown starts;
+	// their context is cancelled afterwards.
+	DrainTimeout time.Duration
+}
+
+type backoffConfig struct {
+	initial time.Duration
+	max     time.Duration
 }
 
 // NewConsumer creates a consumer for the given queue.
-func NewConsumer(cfg Config, queue string, log *zap.Logger) (*Consumer, func() error, error) {
-	conn, err := amqp.Dial(cfg.URL)
-	if err != nil {
-		return nil, nil, err
+func NewConsumer(cfg Config, queue string, opts ConsumerOptions, log *zap.Logger) (*Consumer, func() error, error) {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+	prefetch := opts.Prefetch
+	if prefetch < workers {
+		prefetch = workers
+	}
+	drainTimeout := opts.DrainTimeout
+	if drainTimeout <= 0 {
+		drainTimeout = 30 * time.Second
 	}
 
-	ch, err := conn.Channel()
+	conn, err := newConnection(cfg, func(conn *amqp.Connection) error {
+		ch, err := conn.Channel()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = ch.Close() }()
+		return setupTopology(ch, cfg)
+	}, log)
 	if err != nil {
-		_ = conn.Close()
 		return nil, nil, err
 	}
 
-	if err := setupTopology(ch, cfg); err != nil {
-		_ = ch.Close()
-		_ = conn.Close()
-		return nil, nil, err
+	maxAttempts := cfg.ConsumerMaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+	retryDelay := cfg.ConsumerRetryDelay
+	if retryDelay <= 0 {
+		retryDelay = 10 * time.Second
+	}
+	confirmWait := cfg.PublishConfirmTimeout
+	if confirmWait <= 0 {
+		confirmWait = 5 * time.Second
 	}
 
-	consumer := &Consumer{conn: conn, channel: ch, queue: queue, log: log}
+	consumer := &Consumer{
+		conn:         conn,
+		queue:        queue,
+		prefetch:     prefetch,
+		workers:      workers,
+		drainTimeout: drainTimeout,
+		retry:        backoffConfig{initial: conn.initialBackoff, max: conn.maxBackoff},
+		maxAttempts:  maxAttempts,
+		retryDelay:   retryDelay,
+		exchange:     cfg.Exchange,
+		confirmWait:  confirmWait,
+		log:          log,
+	}
 	cleanup := func() error {
-		if err := ch.Close(); err != nil {
-			log.Warn("failed to close rabbitmq channel", zap.Error(err))
-		}
 		if err := conn.Close(); err != nil {
 			log.Warn("failed to close rabbitmq connection", zap.Error(err))
 			return err
@@ -49,27 +112,170 @@ func NewConsumer(cfg Config, queue string, log *zap.Logger) (*Consumer, func() e
 	return consumer, cleanup, nil
 }
 
-// Start begins consuming messages with the handler.
-func (c *Consumer) Start(ctx context.Context, handler func(context.Context, amqp.Delivery) error) error {
-	msgs, err := c.channel.Consume(c.queue, "", false, false, false, false, nil)
+// Handler processes a decoded event. A returned error sends the message to the retry queue, and
+// after the last attempt to the dead-letter queue.
+type Handler = messaging.Handler
+
+// errDeliveriesClosed reports that the broker closed the subscription (channel or connection lost).
+var errDeliveriesClosed = errors.New("rabbitmq: deliveries closed")
+
+// Start consumes messages until ctx is done, decoding each one as a CloudEvent before calling the
+// handler. Messages that cannot be decoded are dead-lettered right away. When the subscription is
+// lost it resubscribes with backoff once the connection is back. Start returns nil after a graceful
+// shutdown.
+func (c *Consumer) Start(ctx context.Context, handler Handler) error {
+	handlerCtx, cancel := messaging.DrainContext(ctx, c.drainTimeout)
+	defer cancel()
+
+	backoff := c.retry.initial
+	for {
+		subscribed, err := c.consume(ctx, handlerCtx, handler)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if subscribed {
+			backoff = c.retry.initial
+		}
+		c.log.Warn("consumer subscription lost, resubscribing", zap.String("queue", c.queue), zap.Error(err), zap.Duration("retry_in", backoff))
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > c.retry.max {
+			backoff = c.retry.max
+		}
+	}
+}
+
+// consume runs one subscription on a fresh channel until it is cancelled (ctx done) or lost.
+// subscribed reports whether the subscription was established.
+func (c *Consumer) consume(ctx, handlerCtx context.Context, handler Handler) (subscribed bool, err error) {
+	conn, _, err := c.conn.current()
+	if err != nil {
+		return false, err
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		return false, err
+	}
+	// Closing the channel requeues the messages prefetched but not handled yet.
+	defer func() { _ = ch.Close() }()
+
+	if err := ch.Qos(c.prefetch, 0, false); err != nil {
+		return false, err
+	}
+	// Retries and dead letters are republished on this channel before acking the original.
+	if err := ch.Confirm(false); err != nil {
+		return false, err
+	}
+	tag := consumerTag(c.queue)
+	msgs, err := ch.Consume(c.queue, tag, false, false, false, false, nil)
 	if err != nil {
-		return err
+		return false, err
 	}
+	c.log.Info("consumer subscribed", zap.String("queue", c.queue), zap.Int("prefetch", c.prefetch), zap.Int("workers", c.workers))
 
-	for {
+	stop := make(chan struct{})
+	go func() {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
-		case msg, ok := <-msgs:
-			if !ok {
-				return nil
-			}
-			if err := handler(ctx, msg); err != nil {
-				c.log.Warn("consumer handler error", zap.Error(err))
-				_ = msg.Nack(false, true)
-				continue
-			}
-			_ = msg.Ack(false)
+			// Stop new deliveries; the channel stays open so in-flight handlers can still ack.
+			_ = ch.Cancel(tag, false)
+		case <-stop:
 		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < c.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for msg := range msgs {
+				if ctx.Err() != nil {
+					// Not started before shutdown: hand it back right away.
+					_ = msg.Nack(false, true)
+					continue
+				}
+				c.handle(handlerCtx, ch, msg, handler)
+			}
+		}()
+	}
+	wg.Wait()
+	close(stop)
+
+	if ctx.Err() != nil {
+		return true, nil
+	}
+	return true, errDeliveriesClosed
+}
+
+func (c *Consumer) handle(ctx context.Context, ch *amqp.Channel, msg amqp.Delivery, handler Handler) {
+	event, err := decodeEvent(msg)
+	if err != nil {
+		c.log.Warn("dead-lettering undecodable message", zap.Error(err), zap.String("routing_key", msg.RoutingKey), zap.String("message_id", msg.MessageId))
+		c.deadLetter(ctx, ch, msg, err)
+		return
+	}
+	if err := handler(ctx, event); err != nil {
+		c.retryOrDeadLetter(ctx, ch, msg, err)
+		return
 	}
+	_ = msg.Ack(false)
 }
+
+// retryOrDeadLetter moves a failed message to the retry queue, where it waits retryDelay before
+// returning to the queue, or to the dead-letter queue once maxAttempts handler runs failed.
+func (c *Consumer) retryOrDeadLetter(ctx context.Context, ch *amqp.Channel, msg amqp.Delivery, cause error) {
+	attempt := retryCount(msg.Headers) + 1
+	if attempt >= c.maxAttempts {
+		c.log.Warn("dead-lettering message after max attempts", zap.Error(cause), zap.String("queue", c.queue), zap.String("message_id", msg.MessageId), zap.Int("attempts", attempt))
+		c.deadLetter(ctx, ch, msg, cause)
+		return
+	}
+	c.log.Warn("consumer handler error, retrying", zap.Error(cause), zap.String("queue", c.queue), zap.String("message_id", msg.MessageId), zap.Int("attempt", attempt))
+	out := copyForRepublish(msg, amqp.Table{
+		headerRetryCount: int32(attempt),
+		headerLastError:  truncate(cause.Error(), 1000),
+	})
+	out.Expiration = strconv.FormatInt(c.retryDelay.Milliseconds(), 10)
+	c.moveTo(ctx, ch, msg, "", RetryQueue(c.queue), out)
+}
+
+func (c *Consumer) deadLetter(ctx context.Context, ch *amqp.Channel, msg amqp.Delivery, cause error) {
+	out := copyForRepublish(msg, amqp.Table{
+		headerRetryCount:     int32(retryCount(msg.Headers)),
+		headerLastError:      truncate(cause.Error(), 1000),
+		headerDeadLetteredAt: time.Now().UTC().Format(time.RFC3339),
+	})
+	c.moveTo(ctx, ch, msg, deadLetterExchange(c.exchange), c.queue, out)
+}
+
+// moveTo republishes msg and acks the original once the broker confirmed the copy. If the copy
+// cannot be published the original is requeued, so nothing is lost while the broker is in trouble.
+func (c *Consumer) moveTo(ctx context.Context, ch *amqp.Channel, msg amqp.Delivery, exchange, key string, out amqp.Publishing) {
+	if err := republish(ctx, ch, exchange, key, out, c.confirmWait); err != nil {
+		c.log.Error("failed to move message, requeueing", zap.Error(err), zap.String("queue", c.queue), zap.String("target", key), zap.String("message_id", msg.MessageId))
+		_ = msg.Nack(false, true)
+		return
+	}
+	_ = msg.Ack(false)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
+
+func consumerTag(queue string) string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "consumer"
+	}
+	return fmt.Sprintf("%s-%s-%d-%d", queue, host, os.Getpid(), time.Now().UnixNano())
+}
+
+var _ messaging.Consumer = (*Consumer)(nil)
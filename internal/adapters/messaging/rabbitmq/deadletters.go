@@ -0,0 +1,180 @@
+package rabbitmq
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+// Every consumer queue Q gets a retry queue Q.retry, whose expired messages dead-letter back to Q,
+// and a dead-letter queue Q.dlq bound to the <exchange>.dlx exchange with routing key Q. Q itself is
+// declared without arguments, so existing deployments do not have to recreate it.
+const (
+	headerRetryCount         = "x-retry-count"
+	headerLastError          = "x-last-error"
+	headerOriginalRoutingKey = "x-original-routing-key"
+	headerDeadLetteredAt     = "x-dead-lettered-at"
+)
+
+// RetryQueue returns the name of the retry queue of a consumer queue.
+func RetryQueue(queue string) string { return queue + ".retry" }
+
+// DeadLetterQueue returns the name of the dead-letter queue of a consumer queue.
+func DeadLetterQueue(queue string) string { return queue + ".dlq" }
+
+func deadLetterExchange(exchange string) string { return exchange + ".dlx" }
+
+// declareRetryTopology declares the retry and dead-letter queues of a consumer queue.
+func declareRetryTopology(ch *amqp.Channel, exchange, queue string) error {
+	dlx := deadLetterExchange(exchange)
+	if err := ch.ExchangeDeclare(dlx, "direct", true, false, false, false, nil); err != nil {
+		return err
+	}
+	if _, err := ch.QueueDeclare(DeadLetterQueue(queue), true, false, false, false, nil); err != nil {
+		return err
+	}
+	if err := ch.QueueBind(DeadLetterQueue(queue), queue, dlx, false, nil); err != nil {
+		return err
+	}
+	// The delay is set per message (Expiration), so changing it does not require redeclaring the queue.
+	_, err := ch.QueueDeclare(RetryQueue(queue), true, false, false, false, amqp.Table{
+		"x-dead-letter-exchange":    "",
+		"x-dead-letter-routing-key": queue,
+	})
+	return err
+}
+
+// retryCount returns how many times the message has already been retried.
+func retryCount(headers amqp.Table) int {
+	switch v := headers[headerRetryCount].(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case int:
+		return v
+	case string:
+		n, _ := strconv.Atoi(v)
+		return n
+	}
+	return 0
+}
+
+// copyForRepublish builds a persistent copy of msg with extra headers, keeping the original
+// routing key so legacy messages still decode to the right type.
+func copyForRepublish(msg amqp.Delivery, headers amqp.Table) amqp.Publishing {
+	out := amqp.Table{}
+	for k, v := range msg.Headers {
+		out[k] = v
+	}
+	if _, ok := out[headerOriginalRoutingKey]; !ok && msg.RoutingKey != "" {
+		out[headerOriginalRoutingKey] = msg.RoutingKey
+	}
+	for k, v := range headers {
+		out[k] = v
+	}
+	return amqp.Publishing{
+		Headers:      out,
+		ContentType:  msg.ContentType,
+		DeliveryMode: amqp.Persistent,
+		MessageId:    msg.MessageId,
+		Timestamp:    msg.Timestamp,
+		Type:         msg.Type,
+		Body:         msg.Body,
+	}
+}
+
+// republish publishes on a confirm-mode channel and waits for the broker confirm.
+func republish(ctx context.Context, ch *amqp.Channel, exchange, key string, msg amqp.Publishing, timeout time.Duration) error {
+	confirm, err := ch.PublishWithDeferredConfirmWithContext(ctx, exchange, key, false, false, msg)
+	if err != nil {
+		return err
+	}
+	if confirm == nil {
+		return nil
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	acked, err := confirm.WaitContext(waitCtx)
+	if err != nil {
+		return fmt.Errorf("confirm timeout (delivery tag %d)", confirm.DeliveryTag)
+	}
+	if !acked {
+		return fmt.Errorf("not acknowledged (delivery tag %d)", confirm.DeliveryTag)
+	}
+	return nil
+}
+
+// ReplayDeadLetters moves up to limit messages (all of them when limit <= 0) from the dead-letter
+// queue of queue back to queue with their retry count reset, and returns how many were moved. It
+// never takes more than the messages in the DLQ when it started, so messages that fail again and
+// are dead-lettered during the replay are left for the next run. Each message is acked on the DLQ
+// only after the broker confirmed the republish.
+func ReplayDeadLetters(ctx context.Context, cfg Config, queue string, limit int, log *zap.Logger) (int, error) {
+	conn, err := amqp.Dial(cfg.URL)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = conn.Close() }()
+	ch, err := conn.Channel()
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = ch.Close() }()
+	if err := setupTopology(ch, cfg); err != nil {
+		return 0, err
+	}
+	if err := ch.Confirm(false); err != nil {
+		return 0, err
+	}
+	timeout := cfg.PublishConfirmTimeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	dlq, err := ch.QueueDeclarePassive(DeadLetterQueue(queue), true, false, false, false, nil)
+	if err != nil {
+		return 0, err
+	}
+	budget := replayBudget(limit, dlq.Messages)
+
+	replayed := 0
+	for replayed < budget {
+		if err := ctx.Err(); err != nil {
+			return replayed, err
+		}
+		msg, ok, err := ch.Get(DeadLetterQueue(queue), false)
+		if err != nil {
+			return replayed, err
+		}
+		if !ok {
+			break
+		}
+		out := copyForRepublish(msg, nil)
+		delete(out.Headers, headerRetryCount)
+		delete(out.Headers, headerDeadLetteredAt)
+		if err := republish(ctx, ch, "", queue, out, timeout); err != nil {
+			_ = msg.Nack(false, true)
+			return replayed, fmt.Errorf("replay message %s: %w", msg.MessageId, err)
+		}
+		if err := msg.Ack(false); err != nil {
+			return replayed, err
+		}
+		log.Info("replayed dead letter", zap.String("queue", queue), zap.String("message_id", msg.MessageId), zap.Any("last_error", msg.Headers[headerLastError]))
+		replayed++
+	}
+	return replayed, nil
+}
+
+// replayBudget returns how many messages a replay takes: limit, capped at the dead-letter queue
+// depth read at start; limit <= 0 means the whole depth.
+func replayBudget(limit, depth int) int {
+	if limit <= 0 || limit > depth {
+		return depth
+	}
+	return limit
+}
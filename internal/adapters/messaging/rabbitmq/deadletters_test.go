@@ -0,0 +1,74 @@
+package rabbitmq
+
+import (
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func TestCopyForRepublishKeepsOriginalRoutingKey(t *testing.T) {
+	msg := amqp.Delivery{
+		Headers:    amqp.Table{headerRetryCount: int32(1), "ce-id": "e-1"},
+		MessageId:  "e-1",
+		RoutingKey: "payment.completed",
+		Body:       []byte(`{"amount":100}`),
+	}
+
+	first := copyForRepublish(msg, amqp.Table{headerRetryCount: int32(2)})
+	if first.Headers[headerOriginalRoutingKey] != "payment.completed" || first.Headers["ce-id"] != "e-1" {
+		t.Fatalf("unexpected headers %+v", first.Headers)
+	}
+	if retryCount(first.Headers) != 2 || first.DeliveryMode != amqp.Persistent || string(first.Body) != string(msg.Body) {
+		t.Fatalf("unexpected copy %+v", first)
+	}
+	if retryCount(msg.Headers) != 1 {
+		t.Fatalf("original headers were modified: %+v", msg.Headers)
+	}
+
+	// Back from the retry queue the delivery is routed by queue name; the original key must survive.
+	retried := deliveryFor(first, "audit.queue")
+	second := copyForRepublish(retried, nil)
+	if second.Headers[headerOriginalRoutingKey] != "payment.completed" {
+		t.Fatalf("original routing key lost: %+v", second.Headers)
+	}
+	ev, err := decodeEvent(retried)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if ev.Type != "payment.completed" || ev.RoutingKey != "payment.completed" || !ev.Redelivered {
+		t.Fatalf("unexpected event %+v", ev)
+	}
+}
+
+func TestRetryCount(t *testing.T) {
+	cases := []struct {
+		headers amqp.Table
+		want    int
+	}{
+		{nil, 0},
+		{amqp.Table{headerRetryCount: int32(3)}, 3},
+		{amqp.Table{headerRetryCount: int64(4)}, 4},
+		{amqp.Table{headerRetryCount: "2"}, 2},
+		{amqp.Table{headerRetryCount: "x"}, 0},
+	}
+	for _, c := range cases {
+		if got := retryCount(c.headers); got != c.want {
+			t.Fatalf("retryCount(%v) = %d, want %d", c.headers, got, c.want)
+		}
+	}
+}
+
+func TestReplayBudgetIsCappedAtStartDepth(t *testing.T) {
+	cases := []struct{ limit, depth, want int }{
+		{0, 5, 5},
+		{-1, 5, 5},
+		{3, 5, 3},
+		{10, 5, 5},
+		{0, 0, 0},
+	}
+	for _, c := range cases {
+		if got := replayBudget(c.limit, c.depth); got != c.want {
+			t.Fatalf("replayBudget(%d, %d) = %d, want %d", c.limit, c.depth, got, c.want)
+		}
+	}
+}
@@ -2,72 +2,106 @@ package rabbitmq
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
+	"draftea-challenge/internal/adapters/messaging"
+	"draftea-challenge/internal/application/outbox"
+
 	amqp "github.com/rabbitmq/amqp091-go"
 	"go.uber.org/zap"
 )
 
-// Publisher publishes messages to RabbitMQ.
+// Publisher publishes messages to RabbitMQ. It survives broker restarts: the connection is
+// re-established in the background and topology re-declared, while Publish fails fast with
+// ErrNotConnected (the relay retries). Concurrent publishers borrow confirm-mode channels from a
+// small pool, and every publish waits for the confirmation of its own delivery tag.
 type Publisher struct {
-	conn           *amqp.Connection
-	channel        *amqp.Channel
-	confirmations  <-chan amqp.Confirmation
+	conn           *connection
+	slots          chan struct{}
+	mu             sync.Mutex
+	idle           []pooledChannel
 	exchange       string
 	confirmTimeout time.Duration
+	mode           string
+	source         string
 	log            *zap.Logger
 }
 
+// pooledChannel is a confirm-mode channel and the connection generation it was opened on.
+type pooledChannel struct {
+	ch  *amqp.Channel
+	gen uint64
+}
+
 // Config configures RabbitMQ connections and topology.
 type Config struct {
 	URL                   string
 	Exchange              string
 	MetricsQueue          string
 	AuditQueue            string
+	WebhooksQueue         string
+	NotificationsQueue    string
 	PublishConfirmTimeout time.Duration
+	CloudEventsMode       string // binary (default) or structured
+	CloudEventsSource     string
+	// ConsumerMaxAttempts is how many times a consumer handles a message before dead-lettering it;
+	// failed attempts wait ConsumerRetryDelay in the retry queue.
+	ConsumerMaxAttempts int
+	ConsumerRetryDelay  time.Duration
+	// ChannelPoolSize bounds concurrent publishes; each one holds a channel until confirmed.
+	ChannelPoolSize         int
+	ReconnectInitialBackoff time.Duration
+	ReconnectMaxBackoff     time.Duration
 }
 
 // NewPublisher creates a publisher and declares exchange/queues.
 func NewPublisher(cfg Config, log *zap.Logger) (*Publisher, func() error, error) {
-	conn, err := amqp.Dial(cfg.URL)
-	if err != nil {
-		return nil, nil, err
+	mode := cfg.CloudEventsMode
+	if mode == "" {
+		mode = ModeBinary
 	}
-
-	ch, err := conn.Channel()
-	if err != nil {
-		_ = conn.Close()
-		return nil, nil, err
+	if !validMode(mode) {
+		return nil, nil, fmt.Errorf("unknown cloudevents mode %q", mode)
 	}
-
-	if err := setupTopology(ch, cfg); err != nil {
-		_ = ch.Close()
-		_ = conn.Close()
-		return nil, nil, err
+	poolSize := cfg.ChannelPoolSize
+	if poolSize <= 0 {
+		poolSize = 8
 	}
 
-	if err := ch.Confirm(false); err != nil {
-		_ = ch.Close()
-		_ = conn.Close()
+	conn, err := newConnection(cfg, func(conn *amqp.Connection) error {
+		ch, err := conn.Channel()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = ch.Close() }()
+		return setupTopology(ch, cfg)
+	}, log)
+	if err != nil {
 		return nil, nil, err
 	}
 
-	confirmations := ch.NotifyPublish(make(chan amqp.Confirmation, 1))
-
 	publisher := &Publisher{
 		conn:           conn,
-		channel:        ch,
-		confirmations:  confirmations,
+		slots:          make(chan struct{}, poolSize),
 		exchange:       cfg.Exchange,
 		confirmTimeout: cfg.PublishConfirmTimeout,
+		mode:           mode,
+		source:         cfg.CloudEventsSource,
 		log:            log,
 	}
 
 	cleanup := func() error {
-		if err := ch.Close(); err != nil {
-			log.Warn("failed to close rabbitmq channel", zap.Error(err))
+		publisher.mu.Lock()
+		for _, pc := range publisher.idle {
+			if err := pc.ch.Close(); err != nil && !errors.Is(err, amqp.ErrClosed) {
+				log.Warn("failed to close rabbitmq channel", zap.Error(err))
+			}
 		}
+		publisher.idle = nil
+		publisher.mu.Unlock()
 		if err := conn.Close(); err != nil {
 			log.Warn("failed to close rabbitmq connection", zap.Error(err))
 			return err
@@ -78,34 +112,102 @@ func NewPublisher(cfg Config, log *zap.Logger) (*Publisher, func() error, error)
 	return publisher, cleanup, nil
 }
 
-// Publish sends a message to the exchange with the given routing key.
-func (p *Publisher) Publish(ctx context.Context, exchange, routingKey string, body []byte) error {
+// Publish sends the event as a CloudEvent to the exchange, routed by its event type.
+// The CloudEvents id (the outbox event ID) lets consumers deduplicate redeliveries.
+func (p *Publisher) Publish(ctx context.Context, exchange string, event *outbox.OutboxEvent) error {
 	if exchange == "" {
 		exchange = p.exchange
 	}
 
-	if err := p.channel.PublishWithContext(ctx, exchange, routingKey, false, false, amqp.Publishing{
-		ContentType: "application/json",
-		Body:        body,
-	}); err != nil {
+	msg, err := encodeEvent(event, p.mode, p.source)
+	if err != nil {
 		return err
 	}
 
-	if p.confirmTimeout <= 0 {
+	pc, err := p.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	confirm, err := pc.ch.PublishWithDeferredConfirmWithContext(ctx, exchange, event.EventType, false, false, msg)
+	if err != nil {
+		// The channel is most likely closed; do not hand it out again.
+		_ = pc.ch.Close()
+		p.release(pooledChannel{})
+		return err
+	}
+	defer p.release(pc)
+
+	if p.confirmTimeout <= 0 || confirm == nil {
 		return nil
 	}
 
+	waitCtx, cancel := context.WithTimeout(ctx, p.confirmTimeout)
+	defer cancel()
+	acked, err := confirm.WaitContext(waitCtx)
+	switch {
+	case err != nil && ctx.Err() != nil:
+		return ctx.Err()
+	case err != nil:
+		return fmt.Errorf("publish confirm timeout (delivery tag %d)", confirm.DeliveryTag)
+	case !acked:
+		return fmt.Errorf("publish not acknowledged (delivery tag %d)", confirm.DeliveryTag)
+	}
+	return nil
+}
+
+// acquire takes a pool slot and returns an open channel of the current connection, reusing an idle
+// one when possible. Channels of a previous connection are discarded.
+func (p *Publisher) acquire(ctx context.Context) (pooledChannel, error) {
 	select {
-	case confirm := <-p.confirmations:
-		if !confirm.Ack {
-			return fmt.Errorf("publish not acknowledged")
-		}
-		return nil
-	case <-time.After(p.confirmTimeout):
-		return fmt.Errorf("publish confirm timeout")
+	case p.slots <- struct{}{}:
 	case <-ctx.Done():
-		return ctx.Err()
+		return pooledChannel{}, ctx.Err()
+	}
+
+	conn, gen, err := p.conn.current()
+	if err != nil {
+		<-p.slots
+		return pooledChannel{}, err
+	}
+
+	p.mu.Lock()
+	for len(p.idle) > 0 {
+		pc := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		if pc.gen == gen && !pc.ch.IsClosed() {
+			p.mu.Unlock()
+			return pc, nil
+		}
+		_ = pc.ch.Close()
+	}
+	p.mu.Unlock()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		<-p.slots
+		return pooledChannel{}, err
+	}
+	if err := ch.Confirm(false); err != nil {
+		_ = ch.Close()
+		<-p.slots
+		return pooledChannel{}, err
+	}
+	return pooledChannel{ch: ch, gen: gen}, nil
+}
+
+// release frees the pool slot and keeps the channel for reuse if it is still usable.
+func (p *Publisher) release(pc pooledChannel) {
+	defer func() { <-p.slots }()
+	if pc.ch == nil {
+		return
+	}
+	if pc.ch.IsClosed() || pc.gen != p.conn.generation() {
+		_ = pc.ch.Close()
+		return
 	}
+	p.mu.Lock()
+	p.idle = append(p.idle, pc)
+	p.mu.Unlock()
 }
 
 func setupTopology(ch *amqp.Channel, cfg Config) error {
@@ -113,23 +215,23 @@ func setupTopology(ch *amqp.Channel, cfg Config) error {
 		return err
 	}
 
-	if cfg.MetricsQueue != "" {
-		if _, err := ch.QueueDeclare(cfg.MetricsQueue, true, false, false, false, nil); err != nil {
-			return err
-		}
-		if err := ch.QueueBind(cfg.MetricsQueue, "payment.*", cfg.Exchange, false, nil); err != nil {
-			return err
-		}
-	}
-
-	if cfg.AuditQueue != "" {
-		if _, err := ch.QueueDeclare(cfg.AuditQueue, true, false, false, false, nil); err != nil {
-			return err
-		}
-		if err := ch.QueueBind(cfg.AuditQueue, "payment.*", cfg.Exchange, false, nil); err != nil {
-			return err
+	declared := make(map[string]bool)
+	for _, b := range messaging.Bindings(messaging.Queues{
+		Metrics:       cfg.MetricsQueue,
+		Audit:         cfg.AuditQueue,
+		Webhooks:      cfg.WebhooksQueue,
+		Notifications: cfg.NotificationsQueue,
+	}) {
+		if !declared[b.Queue] {
+			if _, err := ch.QueueDeclare(b.Queue, true, false, false, false, nil); err != nil {
+				return err
+			}
+			if err := declareRetryTopology(ch, cfg.Exchange, b.Queue); err != nil {
+				return err
+			}
+			declared[b.Queue] = true
 		}
-		if err := ch.QueueBind(cfg.AuditQueue, "refund.*", cfg.Exchange, false, nil); err != nil {
+		if err := ch.QueueBind(b.Queue, b.Pattern, cfg.Exchange, false, nil); err != nil {
 			return err
 		}
 	}
@@ -0,0 +1,39 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"draftea-challenge/internal/application/outbox"
+
+	"github.com/google/uuid"
+)
+
+func TestPublishFailsFastWhileDisconnected(t *testing.T) {
+	p := &Publisher{
+		conn:  &connection{done: make(chan struct{})},
+		slots: make(chan struct{}, 1),
+		mode:  ModeBinary,
+	}
+	event := &outbox.OutboxEvent{ID: uuid.New(), EventType: "payment.created", Payload: `{}`, CreatedAt: time.Now()}
+
+	// The pool slot must be returned on failure, otherwise the second call would block.
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		err := p.Publish(ctx, "payments.events", event)
+		cancel()
+		if !errors.Is(err, ErrNotConnected) {
+			t.Fatalf("publish %d: expected ErrNotConnected, got %v", i, err)
+		}
+	}
+
+	// With every channel in use, Publish waits for a slot until the context ends.
+	p.slots <- struct{}{}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.Publish(ctx, "payments.events", event); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded with an exhausted pool, got %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package filesink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"draftea-challenge/internal/application/notifications"
+)
+
+// Record is one line of the sink file.
+type Record struct {
+	SentAt time.Time `json:"sent_at"`
+	notifications.Message
+}
+
+// Sink appends notifications as JSON lines to a local file instead of sending them. It is meant
+// for local runs and tests, where the file doubles as an outbox to inspect.
+type Sink struct {
+	mu   sync.Mutex
+	path string
+}
+
+// New creates a sink writing to path, creating its directory if needed.
+func New(path string) (*Sink, error) {
+	if path == "" {
+		return nil, fmt.Errorf("notifications file sink: empty path")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("notifications file sink: %w", err)
+	}
+	return &Sink{path: path}, nil
+}
+
+// Notify appends msg to the file. The file is reopened on every call so it can be truncated or
+// rotated while the worker runs.
+func (s *Sink) Notify(ctx context.Context, msg notifications.Message) error {
+	line, err := json.Marshal(Record{SentAt: time.Now().UTC(), Message: msg})
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+var _ notifications.Notifier = (*Sink)(nil)
@@ -0,0 +1,49 @@
+package filesink
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"draftea-challenge/internal/application/notifications"
+
+	"github.com/google/uuid"
+)
+
+func TestNotifyAppendsJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out", "notifications.jsonl")
+	sink, err := New(path)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	msgs := []notifications.Message{
+		{EventID: uuid.New(), EventType: "payment.completed", UserID: uuid.New(), Locale: "es", To: "ana@example.com", Subject: "Tu pago", Body: "Hola,\n"},
+		{EventID: uuid.New(), EventType: "refund.created", UserID: uuid.New(), Locale: "en", To: "bob@example.com", Subject: "Refund", Body: "Hi,\n"},
+	}
+	for _, m := range msgs {
+		if err := sink.Notify(context.Background(), m); err != nil {
+			t.Fatalf("notify: %v", err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer f.Close()
+	var got []Record
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatalf("decode %q: %v", scanner.Text(), err)
+		}
+		got = append(got, r)
+	}
+	if len(got) != 2 || got[0].Message != msgs[0] || got[1].Message != msgs[1] || got[0].SentAt.IsZero() {
+		t.Fatalf("unexpected records %+v", got)
+	}
+}
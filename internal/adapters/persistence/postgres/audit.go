@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"draftea-challenge/internal/application/audit"
+	domainerrors "draftea-challenge/internal/domain/errors"
+)
+
+func (p *PostgresPersistence) GetAuditEntryByEventID(ctx context.Context, eventID uuid.UUID) (*audit.Entry, error) {
+	var m AuditLogModel
+	if err := p.conn(ctx).Where("event_id = ?", eventID.String()).First(&m).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domainerrors.NewNotFoundError("audit entry not found")
+		}
+		return nil, err
+	}
+	return toAuditEntry(m), nil
+}
+
+func (p *PostgresPersistence) LastAuditEntry(ctx context.Context) (*audit.Entry, error) {
+	var rows []AuditLogModel
+	if err := p.conn(ctx).Order("seq DESC").Limit(1).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return toAuditEntry(rows[0]), nil
+}
+
+// InsertAuditEntry appends the entry and sets its Seq. A writer that lost the race for the chain head
+// fails on the unique prev_hash index.
+func (p *PostgresPersistence) InsertAuditEntry(ctx context.Context, entry *audit.Entry) error {
+	m := AuditLogModel{
+		EventID:       entry.EventID.String(),
+		EventType:     entry.EventType,
+		AggregateType: entry.AggregateType,
+		AggregateID:   entry.AggregateID.String(),
+		OccurredAt:    entry.OccurredAt,
+		RecordedAt:    entry.RecordedAt,
+		Payload:       entry.Payload,
+		PrevHash:      entry.PrevHash,
+		Hash:          entry.Hash,
+	}
+	if err := p.conn(ctx).Create(&m).Error; err != nil {
+		return err
+	}
+	entry.Seq = m.Seq
+	return nil
+}
+
+func (p *PostgresPersistence) ListAuditEntries(ctx context.Context, afterSeq int64, limit int) ([]*audit.Entry, error) {
+	var rows []AuditLogModel
+	if err := p.conn(ctx).Where("seq > ?", afterSeq).Order("seq ASC").Limit(limit).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make([]*audit.Entry, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, toAuditEntry(r))
+	}
+	return out, nil
+}
+
+func toAuditEntry(m AuditLogModel) *audit.Entry {
+	eventID, _ := uuid.Parse(m.EventID)
+	aggregateID, _ := uuid.Parse(m.AggregateID)
+	return &audit.Entry{
+		Seq:           m.Seq,
+		EventID:       eventID,
+		EventType:     m.EventType,
+		AggregateType: m.AggregateType,
+		AggregateID:   aggregateID,
+		OccurredAt:    m.OccurredAt.UTC(),
+		RecordedAt:    m.RecordedAt.UTC(),
+		Payload:       m.Payload,
+		PrevHash:      m.PrevHash,
+		Hash:          m.Hash,
+	}
+}
+
+var (
+	_ audit.Repository = (*PostgresPersistence)(nil)
+)
@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"draftea-challenge/internal/application/batches"
+	"draftea-challenge/internal/application/payments"
+	domainpayment "draftea-challenge/internal/domain/payment"
+	domainwallet "draftea-challenge/internal/domain/wallet"
+	"draftea-challenge/internal/platform/idgen"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openPaymentsTestDB uses the Postgres pointed to by OUTBOX_TEST_POSTGRES_DSN, where LockWallet takes
+// a real row lock; otherwise a file-backed SQLite database, where transactions are serialized and only
+// the bookkeeping of the payment flow is covered. Every test works on its own wallet, so the
+// Postgres database is not emptied.
+func openPaymentsTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	cfg := &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)}
+	var db *gorm.DB
+	if dsn := os.Getenv("OUTBOX_TEST_POSTGRES_DSN"); dsn != "" {
+		var err error
+		if db, err = gorm.Open(postgres.Open(dsn), cfg); err != nil {
+			t.Fatalf("open postgres: %v", err)
+		}
+	} else {
+		var err error
+		if db, err = gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "payments.db")+"?_busy_timeout=5000"), cfg); err != nil {
+			t.Fatalf("open sqlite: %v", err)
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			t.Fatalf("sql db: %v", err)
+		}
+		sqlDB.SetMaxOpenConns(1)
+	}
+	if err := AutoMigrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+type approvingGateway struct{}
+
+func (approvingGateway) ProcessPayment(ctx context.Context, p *domainpayment.Payment) (string, error) {
+	return "approved", nil
+}
+
+func TestBatchItemsDebitTheWalletConcurrently(t *testing.T) {
+	const items, amount, initial = 8, 100, 1000
+	persistence := NewPostgresPersistence(openPaymentsTestDB(t))
+	ctx := context.Background()
+	userID := uuid.New()
+	if err := persistence.CreateWallet(ctx, &domainwallet.Wallet{ID: uuid.New(), UserID: userID, Name: "batch", Balances: map[string]int64{"USD": initial}}); err != nil {
+		t.Fatalf("create wallet: %v", err)
+	}
+
+	paymentService := payments.NewPaymentService(
+		persistence, persistence, approvingGateway{}, persistence, persistence,
+		nil, nil, nil, nil, persistence, idgen.UUIDGenerator{}, systemClock{},
+	)
+	service := batches.NewBatchService(persistence, persistence, paymentService, systemClock{}, batches.Config{MaxItems: 10, Concurrency: 4})
+
+	req := &batches.SubmitBatchRequest{UserID: userID}
+	for i := 0; i < items; i++ {
+		req.Items = append(req.Items, batches.BatchItemRequest{ProviderID: uuid.New(), ExternalReference: fmt.Sprintf("bill-%d", i), Amount: amount, Currency: "USD"})
+	}
+	resp, err := service.SubmitBatch(ctx, req)
+	if err != nil {
+		t.Fatalf("submit batch: %v", err)
+	}
+	if resp.Summary["APPROVED"] != items {
+		t.Fatalf("expected %d approved items, got %v", items, resp.Summary)
+	}
+	w, err := persistence.GetWallet(ctx, userID)
+	if err != nil {
+		t.Fatalf("get wallet: %v", err)
+	}
+	if want := int64(initial - items*amount); w.GetBalance("USD") != want {
+		t.Fatalf("expected final balance %d, got %d", want, w.GetBalance("USD"))
+	}
+}
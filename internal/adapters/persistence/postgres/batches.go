@@ -0,0 +1,148 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"draftea-challenge/internal/application/batches"
+	domainbatch "draftea-challenge/internal/domain/batch"
+	domainerrors "draftea-challenge/internal/domain/errors"
+)
+
+// BatchRepository
+func (p *PostgresPersistence) CreateBatch(ctx context.Context, b *domainbatch.Batch) error {
+	var walletRow WalletModel
+	if err := p.conn(ctx).Where("user_id = ?", b.UserID.String()).First(&walletRow).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domainerrors.NewNotFoundError("wallet not found")
+		}
+		return err
+	}
+
+	m := BatchModel{
+		ID:          b.ID.String(),
+		WalletID:    walletRow.ID,
+		UserID:      b.UserID.String(),
+		Status:      string(b.Status),
+		TotalItems:  len(b.Items),
+		CreatedAt:   b.CreatedAt,
+		UpdatedAt:   b.UpdatedAt,
+		CompletedAt: b.CompletedAt,
+	}
+	if b.IdempotencyKey != "" {
+		m.IdempotencyKey = &b.IdempotencyKey
+	}
+	items := make([]BatchItemModel, 0, len(b.Items))
+	for _, item := range b.Items {
+		items = append(items, toBatchItemModel(b.ID, item))
+	}
+
+	return p.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&m).Error; err != nil {
+			return err
+		}
+		return tx.CreateInBatches(&items, 100).Error
+	})
+}
+
+func (p *PostgresPersistence) GetBatch(ctx context.Context, userID, batchID uuid.UUID) (*domainbatch.Batch, error) {
+	return p.findBatch(ctx, p.conn(ctx).Where("id = ? AND user_id = ?", batchID.String(), userID.String()))
+}
+
+func (p *PostgresPersistence) GetBatchByKey(ctx context.Context, userID uuid.UUID, key string) (*domainbatch.Batch, error) {
+	return p.findBatch(ctx, p.conn(ctx).Where("user_id = ? AND idempotency_key = ?", userID.String(), key))
+}
+
+func (p *PostgresPersistence) SaveBatchItem(ctx context.Context, batchID uuid.UUID, item *domainbatch.Item) error {
+	m := toBatchItemModel(batchID, item)
+	return p.conn(ctx).Model(&BatchItemModel{}).
+		Where("batch_id = ? AND item_index = ?", m.BatchID, m.ItemIndex).
+		Updates(map[string]interface{}{
+			"status":         m.Status,
+			"transaction_id": m.TransactionID,
+			"error_code":     m.ErrorCode,
+			"error_message":  m.ErrorMessage,
+			"updated_at":     time.Now(),
+		}).Error
+}
+
+func (p *PostgresPersistence) UpdateBatchStatus(ctx context.Context, b *domainbatch.Batch) error {
+	return p.conn(ctx).Model(&BatchModel{}).Where("id = ?", b.ID.String()).Updates(map[string]interface{}{
+		"status":       string(b.Status),
+		"completed_at": b.CompletedAt,
+		"updated_at":   b.UpdatedAt,
+	}).Error
+}
+
+func (p *PostgresPersistence) findBatch(ctx context.Context, query *gorm.DB) (*domainbatch.Batch, error) {
+	var m BatchModel
+	if err := query.First(&m).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domainerrors.NewNotFoundError("batch not found")
+		}
+		return nil, err
+	}
+	var rows []BatchItemModel
+	if err := p.conn(ctx).Where("batch_id = ?", m.ID).Order("item_index").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	b := &domainbatch.Batch{
+		ID:          uuid.MustParse(m.ID),
+		UserID:      uuid.MustParse(m.UserID),
+		Status:      domainbatch.Status(m.Status),
+		Items:       make([]*domainbatch.Item, 0, len(rows)),
+		CreatedAt:   m.CreatedAt,
+		UpdatedAt:   m.UpdatedAt,
+		CompletedAt: m.CompletedAt,
+	}
+	if m.IdempotencyKey != nil {
+		b.IdempotencyKey = *m.IdempotencyKey
+	}
+	for _, r := range rows {
+		item := &domainbatch.Item{
+			Index:             r.ItemIndex,
+			ProviderID:        uuid.MustParse(r.ProviderID),
+			ExternalReference: r.ExternalReference,
+			Amount:            r.Amount,
+			Currency:          r.Currency,
+			IdempotencyKey:    r.IdempotencyKey,
+			Status:            domainbatch.ItemStatus(r.Status),
+			ErrorCode:         r.ErrorCode,
+			ErrorMessage:      r.ErrorMessage,
+		}
+		if r.TransactionID != nil {
+			txID := uuid.MustParse(*r.TransactionID)
+			item.TransactionID = &txID
+		}
+		b.Items = append(b.Items, item)
+	}
+	return b, nil
+}
+
+func toBatchItemModel(batchID uuid.UUID, item *domainbatch.Item) BatchItemModel {
+	m := BatchItemModel{
+		BatchID:           batchID.String(),
+		ItemIndex:         item.Index,
+		ProviderID:        item.ProviderID.String(),
+		ExternalReference: item.ExternalReference,
+		Amount:            item.Amount,
+		Currency:          item.Currency,
+		IdempotencyKey:    item.IdempotencyKey,
+		Status:            string(item.Status),
+		ErrorCode:         item.ErrorCode,
+		ErrorMessage:      item.ErrorMessage,
+		UpdatedAt:         time.Now(),
+	}
+	if item.TransactionID != nil {
+		txID := item.TransactionID.String()
+		m.TransactionID = &txID
+	}
+	return m
+}
+
+var _ batches.BatchRepository = (*PostgresPersistence)(nil)
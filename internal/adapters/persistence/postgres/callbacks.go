@@ -0,0 +1,35 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+
+	"draftea-challenge/internal/application/callbacks"
+)
+
+// EventRepository
+func (p *PostgresPersistence) RecordGatewayEvent(ctx context.Context, eventID string, transactionID uuid.UUID, status string, receivedAt time.Time) (bool, error) {
+	m := GatewayEventModel{
+		EventID:       eventID,
+		TransactionID: transactionID.String(),
+		Status:        status,
+		ReceivedAt:    receivedAt,
+	}
+	res := p.conn(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&m)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (p *PostgresPersistence) DeleteGatewayEvent(ctx context.Context, eventID string) error {
+	return p.conn(ctx).Where("event_id = ?", eventID).Delete(&GatewayEventModel{}).Error
+}
+
+var (
+	_ callbacks.EventRepository       = (*PostgresPersistence)(nil)
+	_ callbacks.TransactionRepository = (*PostgresPersistence)(nil)
+)
@@ -0,0 +1,55 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"draftea-challenge/internal/application/inbox"
+)
+
+// ProcessMessage records msg and runs fn in one transaction. fn gets a PostgresPersistence bound to
+// that transaction, so every repository method it calls commits or rolls back with the inbox row
+// (methods that open their own transaction use a savepoint). The row is inserted first: a concurrent
+// delivery of the same message blocks on it until this transaction ends and then skips.
+func (p *PostgresPersistence) ProcessMessage(ctx context.Context, msg inbox.ProcessedMessage, fn func(ctx context.Context, repos *PostgresPersistence) error) (bool, error) {
+	processed := false
+	err := p.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&ProcessedMessageModel{
+			Consumer:    msg.Consumer,
+			MessageID:   msg.MessageID,
+			EventType:   msg.EventType,
+			ProcessedAt: msg.ProcessedAt.UTC(),
+		})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+		processed = true
+		return fn(ctx, NewPostgresPersistence(tx))
+	})
+	if err != nil {
+		return false, err
+	}
+	return processed, nil
+}
+
+func (p *PostgresPersistence) PurgeProcessedMessages(ctx context.Context, consumer string, before time.Time, limit int) (int, error) {
+	res := p.conn(ctx).Exec(`
+DELETE FROM processed_messages
+WHERE consumer = ? AND message_id IN (
+  SELECT message_id FROM processed_messages
+  WHERE consumer = ? AND processed_at < ?
+  ORDER BY processed_at
+  LIMIT ?
+)`, consumer, consumer, before.UTC(), limit)
+	return int(res.RowsAffected), res.Error
+}
+
+var (
+	_ inbox.Store[*PostgresPersistence] = (*PostgresPersistence)(nil)
+)
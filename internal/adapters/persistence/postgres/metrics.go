@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"draftea-challenge/internal/application/metrics"
+)
+
+// AddHourly upserts the rollup row, adding the delta to the stored totals.
+func (p *PostgresPersistence) AddHourly(ctx context.Context, delta metrics.HourlyRollup) error {
+	m := PaymentMetricsHourlyModel{
+		BucketStart: delta.BucketStart.UTC(),
+		Status:      delta.Status,
+		Currency:    delta.Currency,
+		ProviderID:  delta.ProviderID.String(),
+		Count:       delta.Count,
+		Amount:      delta.Amount,
+		Fee:         delta.Fee,
+		UpdatedAt:   time.Now().UTC(),
+	}
+	return p.conn(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "bucket_start"}, {Name: "status"}, {Name: "currency"}, {Name: "provider_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"count":      gorm.Expr("payment_metrics_hourly.count + excluded.count"),
+			"amount":     gorm.Expr("payment_metrics_hourly.amount + excluded.amount"),
+			"fee":        gorm.Expr("payment_metrics_hourly.fee + excluded.fee"),
+			"updated_at": gorm.Expr("excluded.updated_at"),
+		}),
+	}).Create(&m).Error
+}
+
+func (p *PostgresPersistence) ListHourly(ctx context.Context, from, to time.Time) ([]metrics.HourlyRollup, error) {
+	var rows []PaymentMetricsHourlyModel
+	if err := p.conn(ctx).
+		Where("bucket_start >= ? AND bucket_start < ?", from, to).
+		Order("bucket_start ASC, status ASC, currency ASC, provider_id ASC").
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make([]metrics.HourlyRollup, 0, len(rows))
+	for _, r := range rows {
+		providerID, _ := uuid.Parse(r.ProviderID)
+		out = append(out, metrics.HourlyRollup{
+			BucketStart: r.BucketStart.UTC(),
+			Key:         metrics.Key{Status: r.Status, Currency: r.Currency, ProviderID: providerID},
+			Totals:      metrics.Totals{Count: r.Count, Amount: r.Amount, Fee: r.Fee},
+		})
+	}
+	return out, nil
+}
+
+var (
+	_ metrics.Repository = (*PostgresPersistence)(nil)
+)
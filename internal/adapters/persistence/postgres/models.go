@@ -33,8 +33,10 @@ type TransactionModel struct {
 	Status            string `gorm:"type:varchar(32);index"`
 	ProviderID        string `gorm:"type:varchar(36);index"`
 	ExternalReference string `gorm:"type:text"`
-	CreatedAt         time.Time
-	UpdatedAt         time.Time
+	// RelatedTransactionID links FEE and REFUND rows to the originating payment.
+	RelatedTransactionID *string `gorm:"type:varchar(36);index"`
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
 }
 
 type IdempotencyModel struct {
@@ -47,21 +49,250 @@ type IdempotencyModel struct {
 }
 
 type OutboxModel struct {
-	ID        string `gorm:"primaryKey;type:varchar(36)"`
-	EventType string `gorm:"type:varchar(128)"`
-	Payload   string `gorm:"type:jsonb"`
-	CreatedAt time.Time
-	SentAt    *time.Time
+	ID             string  `gorm:"primaryKey;type:varchar(36)"`
+	EventType      string  `gorm:"type:varchar(128)"`
+	Payload        string  `gorm:"type:jsonb"`
+	AggregateID    *string `gorm:"type:varchar(36);uniqueIndex:idx_outbox_aggregate_sequence"`
+	Sequence       int64   `gorm:"not null;default:0;uniqueIndex:idx_outbox_aggregate_sequence"`
+	CreatedAt      time.Time
+	SentAt         *time.Time
+	LockedBy       *string `gorm:"type:varchar(128)"`
+	LockedUntil    *time.Time
+	Attempts       int    `gorm:"not null;default:0"`
+	LastError      string `gorm:"type:text"`
+	NextAttemptAt  *time.Time
+	DeadLetteredAt *time.Time
+}
+
+// OutboxArchiveModel keeps sent outbox rows moved out of the hot table by the retention job.
+type OutboxArchiveModel struct {
+	ID          string  `gorm:"primaryKey;type:varchar(36)"`
+	EventType   string  `gorm:"type:varchar(128)"`
+	Payload     string  `gorm:"type:jsonb"`
+	AggregateID *string `gorm:"type:varchar(36);index:idx_outbox_archive_aggregate_sequence"`
+	Sequence    int64   `gorm:"not null;default:0;index:idx_outbox_archive_aggregate_sequence"`
+	Attempts    int     `gorm:"not null;default:0"`
+	CreatedAt   time.Time
+	SentAt      time.Time `gorm:"index"`
+	ArchivedAt  time.Time
+}
+
+type SpendingLimitModel struct {
+	ID                 string  `gorm:"primaryKey;type:varchar(36)"`
+	UserID             *string `gorm:"type:varchar(36);index"`
+	Currency           string  `gorm:"type:varchar(8)"`
+	MaxSinglePayment   int64
+	DailySpend         int64
+	MonthlySpend       int64
+	MaxPaymentsPerHour int
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+}
+
+type ScheduleModel struct {
+	ID                string `gorm:"primaryKey;type:varchar(36)"`
+	WalletID          string `gorm:"type:varchar(36);index"`
+	UserID            string `gorm:"type:varchar(36);index"`
+	ProviderID        string `gorm:"type:varchar(36)"`
+	ExternalReference string `gorm:"type:text"`
+	Amount            int64
+	Currency          string `gorm:"type:varchar(8)"`
+	CronExpr          string `gorm:"type:varchar(128)"`
+	IntervalSeconds   int64
+	EndDate           *time.Time
+	NextRunAt         time.Time `gorm:"index"`
+	LastRunAt         *time.Time
+	LastStatus        string `gorm:"type:varchar(32)"`
+	Status            string `gorm:"type:varchar(16);index"`
+	LockedUntil       *time.Time
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+type BatchModel struct {
+	ID             string  `gorm:"primaryKey;type:varchar(36)"`
+	WalletID       string  `gorm:"type:varchar(36);index"`
+	UserID         string  `gorm:"type:varchar(36);index"`
+	IdempotencyKey *string `gorm:"type:text"`
+	Status         string  `gorm:"type:varchar(16)"`
+	TotalItems     int
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	CompletedAt    *time.Time
+}
+
+type BatchItemModel struct {
+	BatchID           string `gorm:"primaryKey;type:varchar(36)"`
+	ItemIndex         int    `gorm:"primaryKey"`
+	ProviderID        string `gorm:"type:varchar(36)"`
+	ExternalReference string `gorm:"type:text"`
+	Amount            int64
+	Currency          string  `gorm:"type:varchar(8)"`
+	IdempotencyKey    string  `gorm:"type:text"`
+	Status            string  `gorm:"type:varchar(32)"`
+	TransactionID     *string `gorm:"type:varchar(36)"`
+	ErrorCode         string  `gorm:"type:varchar(64)"`
+	ErrorMessage      string  `gorm:"type:text"`
+	UpdatedAt         time.Time
+}
+
+type ProviderModel struct {
+	ID               string `gorm:"primaryKey;type:varchar(36)"`
+	Name             string `gorm:"type:varchar(128)"`
+	Currencies       string `gorm:"type:jsonb"`
+	MinAmount        int64
+	MaxAmount        int64
+	ReferencePattern string `gorm:"type:text"`
+	Enabled          bool
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+type FeePolicyModel struct {
+	ID            string  `gorm:"primaryKey;type:varchar(36)"`
+	ProviderID    *string `gorm:"type:varchar(36);index"`
+	Currency      string  `gorm:"type:varchar(8)"`
+	FixedAmount   int64
+	PercentageBps int64
+	MinFee        int64
+	MaxFee        int64
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+type GatewayEventModel struct {
+	EventID       string `gorm:"primaryKey;type:varchar(128)"`
+	TransactionID string `gorm:"type:varchar(36);index"`
+	Status        string `gorm:"type:varchar(32)"`
+	ReceivedAt    time.Time
+}
+
+type WebhookSubscriptionModel struct {
+	ID                  string `gorm:"primaryKey;type:varchar(36)"`
+	URL                 string `gorm:"type:text"`
+	EventTypes          string `gorm:"type:jsonb"`
+	Secret              string `gorm:"type:varchar(128)"`
+	Enabled             bool   `gorm:"index"`
+	ConsecutiveFailures int
+	DisabledAt          *time.Time
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
+
+type WebhookDeliveryModel struct {
+	ID             string `gorm:"primaryKey;type:varchar(36)"`
+	SubscriptionID string `gorm:"type:varchar(36);index:idx_webhook_deliveries_subscription_event"`
+	EventID        string `gorm:"type:varchar(36);index:idx_webhook_deliveries_subscription_event"`
+	EventType      string `gorm:"type:varchar(128)"`
+	Attempt        int
+	Status         string `gorm:"type:varchar(16)"`
+	ResponseStatus int
+	Error          string `gorm:"type:text"`
+	DurationMs     int64
+	Replay         bool
+	NextAttemptAt  *time.Time `gorm:"index"`
+	CreatedAt      time.Time
+}
+
+// PaymentMetricsHourlyModel holds the hourly payment KPI rollups written by the metrics consumer.
+type PaymentMetricsHourlyModel struct {
+	BucketStart time.Time `gorm:"primaryKey"`
+	Status      string    `gorm:"primaryKey;type:varchar(32)"`
+	Currency    string    `gorm:"primaryKey;type:varchar(8)"`
+	ProviderID  string    `gorm:"primaryKey;type:varchar(36)"`
+	Count       int64
+	Amount      int64
+	Fee         int64
+	UpdatedAt   time.Time
+}
+
+// AuditLogModel is an append-only, hash-chained record of payment and refund events.
+type AuditLogModel struct {
+	Seq           int64  `gorm:"primaryKey;autoIncrement"`
+	EventID       string `gorm:"type:varchar(36);uniqueIndex"`
+	EventType     string `gorm:"type:varchar(128)"`
+	AggregateType string `gorm:"type:varchar(32)"`
+	AggregateID   string `gorm:"type:varchar(36);index"`
+	OccurredAt    time.Time
+	RecordedAt    time.Time
+	Payload       string `gorm:"type:text"`
+	PrevHash      string `gorm:"type:char(64);uniqueIndex"`
+	Hash          string `gorm:"type:char(64);uniqueIndex"`
+}
+
+// ProjectionCheckpointModel is the outbox history position each projection has applied up to.
+type ProjectionCheckpointModel struct {
+	Name            string    `gorm:"primaryKey;type:varchar(64)"`
+	PositionAt      time.Time `gorm:"not null"`
+	PositionEventID string    `gorm:"type:varchar(36);not null;default:''"`
+	UpdatedAt       time.Time
+}
+
+// OutboxPurgeHorizonModel is the single row holding the newest history position deleted by the
+// outbox archive purge; projections cannot be rebuilt from before it.
+type OutboxPurgeHorizonModel struct {
+	ID              int       `gorm:"primaryKey"`
+	PositionAt      time.Time `gorm:"not null"`
+	PositionEventID string    `gorm:"type:varchar(36);not null"`
+	UpdatedAt       time.Time
+}
+
+type UserDailySpendModel struct {
+	UserID         string    `gorm:"primaryKey;type:varchar(36)"`
+	Day            time.Time `gorm:"primaryKey;type:date;index"`
+	Currency       string    `gorm:"primaryKey;type:varchar(8)"`
+	Payments       int64
+	Amount         int64
+	Fee            int64
+	FailedPayments int64
+	UpdatedAt      time.Time
+}
+
+// ProcessedMessageModel is the consumer inbox: one row per message a consumer applied.
+type ProcessedMessageModel struct {
+	Consumer    string    `gorm:"primaryKey;type:varchar(128)"`
+	MessageID   string    `gorm:"primaryKey;type:varchar(128)"`
+	EventType   string    `gorm:"type:varchar(128);not null"`
+	ProcessedAt time.Time `gorm:"not null;index"`
+}
+
+// NotificationPreferenceModel holds one user's notification channel and opt-ins.
+type NotificationPreferenceModel struct {
+	UserID           string `gorm:"primaryKey;type:varchar(36)"`
+	Email            string `gorm:"type:varchar(320);not null"`
+	Locale           string `gorm:"type:varchar(8);not null"`
+	PaymentCompleted bool   `gorm:"not null"`
+	PaymentFailed    bool   `gorm:"not null"`
+	RefundCreated    bool   `gorm:"not null"`
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
 }
 
 // Ensure GORM recognizes table names (optional)
-func (WalletModel) TableName() string        { return "wallets" }
-func (WalletBalanceModel) TableName() string { return "wallet_balances" }
-func (TransactionModel) TableName() string   { return "transactions" }
-func (IdempotencyModel) TableName() string   { return "idempotency_records" }
-func (OutboxModel) TableName() string        { return "outbox" }
+func (WalletModel) TableName() string                 { return "wallets" }
+func (WalletBalanceModel) TableName() string          { return "wallet_balances" }
+func (TransactionModel) TableName() string            { return "transactions" }
+func (IdempotencyModel) TableName() string            { return "idempotency_records" }
+func (OutboxModel) TableName() string                 { return "outbox" }
+func (OutboxArchiveModel) TableName() string          { return "outbox_archive" }
+func (SpendingLimitModel) TableName() string          { return "spending_limits" }
+func (ScheduleModel) TableName() string               { return "payment_schedules" }
+func (BatchModel) TableName() string                  { return "payment_batches" }
+func (BatchItemModel) TableName() string              { return "payment_batch_items" }
+func (ProviderModel) TableName() string               { return "providers" }
+func (FeePolicyModel) TableName() string              { return "fee_policies" }
+func (GatewayEventModel) TableName() string           { return "gateway_webhook_events" }
+func (WebhookSubscriptionModel) TableName() string    { return "webhook_subscriptions" }
+func (WebhookDeliveryModel) TableName() string        { return "webhook_deliveries" }
+func (PaymentMetricsHourlyModel) TableName() string   { return "payment_metrics_hourly" }
+func (AuditLogModel) TableName() string               { return "audit_log" }
+func (ProjectionCheckpointModel) TableName() string   { return "projection_checkpoints" }
+func (OutboxPurgeHorizonModel) TableName() string     { return "outbox_purge_horizon" }
+func (UserDailySpendModel) TableName() string         { return "user_daily_spend" }
+func (ProcessedMessageModel) TableName() string       { return "processed_messages" }
+func (NotificationPreferenceModel) TableName() string { return "notification_preferences" }
 
 // AutoMigrate helper
 func AutoMigrate(db *gorm.DB) error {
-	return db.AutoMigrate(&WalletModel{}, &WalletBalanceModel{}, &TransactionModel{}, &IdempotencyModel{}, &OutboxModel{})
+	return db.AutoMigrate(&WalletModel{}, &WalletBalanceModel{}, &TransactionModel{}, &IdempotencyModel{}, &OutboxModel{}, &OutboxArchiveModel{}, &SpendingLimitModel{}, &ScheduleModel{}, &BatchModel{}, &BatchItemModel{}, &ProviderModel{}, &FeePolicyModel{}, &GatewayEventModel{}, &WebhookSubscriptionModel{}, &WebhookDeliveryModel{}, &PaymentMetricsHourlyModel{}, &AuditLogModel{}, &ProjectionCheckpointModel{}, &OutboxPurgeHorizonModel{}, &UserDailySpendModel{}, &ProcessedMessageModel{}, &NotificationPreferenceModel{})
 }
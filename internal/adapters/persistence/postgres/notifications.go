@@ -0,0 +1,54 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"draftea-challenge/internal/application/notifications"
+	domainerrors "draftea-challenge/internal/domain/errors"
+)
+
+// PreferencesRepository
+func (p *PostgresPersistence) GetNotificationPreferences(ctx context.Context, userID uuid.UUID) (*notifications.Preferences, error) {
+	var m NotificationPreferenceModel
+	if err := p.conn(ctx).Where("user_id = ?", userID.String()).First(&m).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domainerrors.NewNotFoundError("notification preferences not found")
+		}
+		return nil, err
+	}
+	return &notifications.Preferences{
+		UserID:           uuid.MustParse(m.UserID),
+		Email:            m.Email,
+		Locale:           m.Locale,
+		PaymentCompleted: m.PaymentCompleted,
+		PaymentFailed:    m.PaymentFailed,
+		RefundCreated:    m.RefundCreated,
+		CreatedAt:        m.CreatedAt,
+		UpdatedAt:        m.UpdatedAt,
+	}, nil
+}
+
+func (p *PostgresPersistence) SaveNotificationPreferences(ctx context.Context, prefs *notifications.Preferences) error {
+	return p.conn(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"email", "locale", "payment_completed", "payment_failed", "refund_created", "updated_at"}),
+	}).Create(&NotificationPreferenceModel{
+		UserID:           prefs.UserID.String(),
+		Email:            prefs.Email,
+		Locale:           prefs.Locale,
+		PaymentCompleted: prefs.PaymentCompleted,
+		PaymentFailed:    prefs.PaymentFailed,
+		RefundCreated:    prefs.RefundCreated,
+		CreatedAt:        prefs.CreatedAt,
+		UpdatedAt:        prefs.UpdatedAt,
+	}).Error
+}
+
+var (
+	_ notifications.PreferencesRepository = (*PostgresPersistence)(nil)
+)
@@ -0,0 +1,455 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	appoutbox "draftea-challenge/internal/application/outbox"
+	"draftea-challenge/internal/application/projections"
+	"draftea-challenge/internal/application/webhooks"
+	domainerrors "draftea-challenge/internal/domain/errors"
+)
+
+// createEventAttempts bounds how many times CreateEvent re-reads the aggregate sequence after losing
+// the unique (aggregate_id, sequence) index to a concurrent writer.
+const createEventAttempts = 5
+
+// CreateEvent assigns the next sequence of the event's aggregate, continuing after archived events.
+// A concurrent writer that read the same MAX is rejected by the unique (aggregate_id, sequence) index
+// instead of publishing out of order; each try runs in a savepoint, so the loser rolls back only its
+// insert and retries with the sequence the winner committed.
+func (p *PostgresPersistence) CreateEvent(ctx context.Context, event *appoutbox.OutboxEvent) error {
+	m := OutboxModel{ID: event.ID.String(), EventType: event.EventType, Payload: event.Payload, CreatedAt: event.CreatedAt}
+	if event.SentAt != nil {
+		m.SentAt = event.SentAt
+	}
+	if event.AggregateID == uuid.Nil {
+		return p.conn(ctx).Create(&m).Error
+	}
+	aggregateID := event.AggregateID.String()
+	m.AggregateID = &aggregateID
+	var err error
+	for attempt := 0; attempt < createEventAttempts; attempt++ {
+		db := p.conn(ctx)
+		err = db.Transaction(func(tx *gorm.DB) error {
+			var last, archived int64
+			if err := tx.Model(&OutboxModel{}).Where("aggregate_id = ?", aggregateID).Select("COALESCE(MAX(sequence), 0)").Scan(&last).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&OutboxArchiveModel{}).Where("aggregate_id = ?", aggregateID).Select("COALESCE(MAX(sequence), 0)").Scan(&archived).Error; err != nil {
+				return err
+			}
+			if archived > last {
+				last = archived
+			}
+			m.Sequence = last + 1
+			return tx.Create(&m).Error
+		})
+		if err == nil {
+			event.Sequence = m.Sequence
+			return nil
+		}
+		if !isUniqueViolation(db, err) {
+			return err
+		}
+	}
+	return err
+}
+
+// isUniqueViolation reports whether err is a unique index violation, using the dialect's error
+// translation (pgconn code 23505 on Postgres, SQLITE_CONSTRAINT_UNIQUE in tests).
+func isUniqueViolation(db *gorm.DB, err error) bool {
+	if translator, ok := db.Dialector.(gorm.ErrorTranslator); ok {
+		err = translator.Translate(err)
+	}
+	return errors.Is(err, gorm.ErrDuplicatedKey)
+}
+
+// GetPendingEvents returns unsent, not dead-lettered events oldest first; events of one aggregate come in sequence order.
+func (p *PostgresPersistence) GetPendingEvents(ctx context.Context, limit int) ([]*appoutbox.OutboxEvent, error) {
+	var rows []OutboxModel
+	if err := p.conn(ctx).Where("sent_at IS NULL AND dead_lettered_at IS NULL").Order("created_at").Order("sequence").Limit(limit).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make([]*appoutbox.OutboxEvent, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, toOutboxEvent(r))
+	}
+	return out, nil
+}
+
+func toOutboxEvent(m OutboxModel) *appoutbox.OutboxEvent {
+	event := &appoutbox.OutboxEvent{
+		ID:             uuid.MustParse(m.ID),
+		EventType:      m.EventType,
+		Payload:        m.Payload,
+		Sequence:       m.Sequence,
+		Attempts:       m.Attempts,
+		LastError:      m.LastError,
+		NextAttemptAt:  m.NextAttemptAt,
+		DeadLetteredAt: m.DeadLetteredAt,
+		CreatedAt:      m.CreatedAt,
+		SentAt:         m.SentAt,
+	}
+	if m.AggregateID != nil {
+		event.AggregateID = uuid.MustParse(*m.AggregateID)
+	}
+	return event
+}
+
+// ClaimPendingEvents leases a batch of unsent events to relayID. Rows locked by a concurrent claim are
+// skipped, and an aggregate is only claimed as an in-order prefix of its unsent events, so two relays
+// never publish events of the same aggregate at the same time.
+func (p *PostgresPersistence) ClaimPendingEvents(ctx context.Context, relayID string, now time.Time, limit int, lease time.Duration) ([]*appoutbox.OutboxEvent, error) {
+	var claimed []OutboxModel
+	err := p.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		var rows []OutboxModel
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("sent_at IS NULL AND dead_lettered_at IS NULL").
+			Where("next_attempt_at IS NULL OR next_attempt_at <= ?", now).
+			Where("locked_until IS NULL OR locked_until < ?", now).
+			Order("created_at").
+			Order("sequence").
+			Limit(limit).
+			Find(&rows).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		var err error
+		if claimed, err = inOrderPrefixes(tx, rows); err != nil {
+			return err
+		}
+		if len(claimed) == 0 {
+			return nil
+		}
+		ids := make([]string, 0, len(claimed))
+		for _, r := range claimed {
+			ids = append(ids, r.ID)
+		}
+		return tx.Model(&OutboxModel{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"locked_by":    relayID,
+			"locked_until": now.Add(lease),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*appoutbox.OutboxEvent, 0, len(claimed))
+	for _, r := range claimed {
+		out = append(out, toOutboxEvent(r))
+	}
+	return out, nil
+}
+
+// inOrderPrefixes drops candidates that are not the next unsent events of their aggregate, i.e. when an
+// earlier event is locked or leased by another relay, waits for its next attempt or fell outside the batch.
+// Dead-lettered events no longer hold their aggregate back.
+func inOrderPrefixes(tx *gorm.DB, rows []OutboxModel) ([]OutboxModel, error) {
+	var aggregateIDs []string
+	seen := make(map[string]bool)
+	for _, r := range rows {
+		if r.AggregateID != nil && !seen[*r.AggregateID] {
+			seen[*r.AggregateID] = true
+			aggregateIDs = append(aggregateIDs, *r.AggregateID)
+		}
+	}
+	if len(aggregateIDs) == 0 {
+		return rows, nil
+	}
+
+	var unsent []OutboxModel
+	if err := tx.Model(&OutboxModel{}).Select("aggregate_id", "sequence").
+		Where("sent_at IS NULL AND dead_lettered_at IS NULL AND aggregate_id IN ?", aggregateIDs).
+		Order("sequence").
+		Find(&unsent).Error; err != nil {
+		return nil, err
+	}
+	pending := make(map[string][]int64, len(aggregateIDs))
+	for _, u := range unsent {
+		pending[*u.AggregateID] = append(pending[*u.AggregateID], u.Sequence)
+	}
+
+	candidates := make(map[string][]OutboxModel, len(aggregateIDs))
+	for _, r := range rows {
+		if r.AggregateID != nil {
+			candidates[*r.AggregateID] = append(candidates[*r.AggregateID], r)
+		}
+	}
+	allowed := make(map[string]bool, len(rows))
+	for aggregateID, chain := range candidates {
+		sort.Slice(chain, func(a, b int) bool { return chain[a].Sequence < chain[b].Sequence })
+		for i, r := range chain {
+			if i >= len(pending[aggregateID]) || pending[aggregateID][i] != r.Sequence {
+				break
+			}
+			allowed[r.ID] = true
+		}
+	}
+
+	out := make([]OutboxModel, 0, len(rows))
+	for _, r := range rows {
+		if r.AggregateID == nil || allowed[r.ID] {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func (p *PostgresPersistence) ReleaseEvents(ctx context.Context, relayID string, eventIDs []uuid.UUID) error {
+	if len(eventIDs) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(eventIDs))
+	for _, id := range eventIDs {
+		ids = append(ids, id.String())
+	}
+	return p.conn(ctx).Model(&OutboxModel{}).
+		Where("id IN ? AND locked_by = ? AND sent_at IS NULL", ids, relayID).
+		Updates(map[string]interface{}{"locked_by": nil, "locked_until": nil}).Error
+}
+
+func (p *PostgresPersistence) RecordEventFailure(ctx context.Context, event *appoutbox.OutboxEvent) error {
+	return p.conn(ctx).Model(&OutboxModel{}).Where("id = ? AND sent_at IS NULL", event.ID.String()).Updates(map[string]interface{}{
+		"attempts":         event.Attempts,
+		"last_error":       event.LastError,
+		"next_attempt_at":  event.NextAttemptAt,
+		"dead_lettered_at": event.DeadLetteredAt,
+		"locked_by":        nil,
+		"locked_until":     nil,
+	}).Error
+}
+
+func (p *PostgresPersistence) MarkEventAsSent(ctx context.Context, eventID uuid.UUID) error {
+	return p.conn(ctx).Model(&OutboxModel{}).Where("id = ?", eventID.String()).Update("sent_at", time.Now()).Error
+}
+
+// EventRepository, DeadLetterRepository
+// GetEventByID also looks in the archive, so old events can still be replayed to webhooks.
+func (p *PostgresPersistence) GetEventByID(ctx context.Context, eventID uuid.UUID) (*appoutbox.OutboxEvent, error) {
+	var m OutboxModel
+	err := p.conn(ctx).Where("id = ?", eventID.String()).First(&m).Error
+	if err == nil {
+		return toOutboxEvent(m), nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	var archived OutboxArchiveModel
+	if err := p.conn(ctx).Where("id = ?", eventID.String()).First(&archived).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domainerrors.NewNotFoundError("event not found")
+		}
+		return nil, err
+	}
+	sentAt := archived.SentAt
+	return toOutboxEvent(OutboxModel{
+		ID:          archived.ID,
+		EventType:   archived.EventType,
+		Payload:     archived.Payload,
+		AggregateID: archived.AggregateID,
+		Sequence:    archived.Sequence,
+		Attempts:    archived.Attempts,
+		CreatedAt:   archived.CreatedAt,
+		SentAt:      &sentAt,
+	}), nil
+}
+
+// DeadLetterRepository (admin)
+func (p *PostgresPersistence) ListDeadLetterEvents(ctx context.Context, limit, offset int) ([]*appoutbox.OutboxEvent, int, error) {
+	q := p.conn(ctx).Model(&OutboxModel{}).Where("sent_at IS NULL AND dead_lettered_at IS NOT NULL")
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var rows []OutboxModel
+	if err := q.Order("dead_lettered_at DESC").Limit(limit).Offset(offset).Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	out := make([]*appoutbox.OutboxEvent, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, toOutboxEvent(r))
+	}
+	return out, int(total), nil
+}
+
+func (p *PostgresPersistence) RequeueDeadLetterEvent(ctx context.Context, eventID uuid.UUID) error {
+	res := p.conn(ctx).Model(&OutboxModel{}).
+		Where("id = ? AND sent_at IS NULL AND dead_lettered_at IS NOT NULL", eventID.String()).
+		Updates(map[string]interface{}{
+			"attempts":         0,
+			"next_attempt_at":  nil,
+			"dead_lettered_at": nil,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return domainerrors.NewNotFoundError("dead-lettered event not found")
+	}
+	return nil
+}
+
+// RetentionRepository
+// ArchiveSentEvents moves up to limit events sent before sentBefore into outbox_archive. Rows are
+// locked with SKIP LOCKED, so relay replicas running the job at the same time move disjoint batches.
+func (p *PostgresPersistence) ArchiveSentEvents(ctx context.Context, sentBefore time.Time, limit int) (int, error) {
+	moved := 0
+	err := p.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		var rows []OutboxModel
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("sent_at IS NOT NULL AND sent_at < ?", sentBefore).
+			Order("sent_at").
+			Limit(limit).
+			Find(&rows).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		now := time.Now()
+		archive := make([]OutboxArchiveModel, 0, len(rows))
+		ids := make([]string, 0, len(rows))
+		for _, r := range rows {
+			archive = append(archive, OutboxArchiveModel{
+				ID:          r.ID,
+				EventType:   r.EventType,
+				Payload:     r.Payload,
+				AggregateID: r.AggregateID,
+				Sequence:    r.Sequence,
+				Attempts:    r.Attempts,
+				CreatedAt:   r.CreatedAt,
+				SentAt:      *r.SentAt,
+				ArchivedAt:  now,
+			})
+			ids = append(ids, r.ID)
+		}
+		if err := tx.Create(&archive).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id IN ?", ids).Delete(&OutboxModel{}).Error; err != nil {
+			return err
+		}
+		moved = len(rows)
+		return nil
+	})
+	return moved, err
+}
+
+// purgeHorizonID is the key of the single outbox_purge_horizon row.
+const purgeHorizonID = 1
+
+// PurgeArchivedEvents deletes up to limit archived events sent before sentBefore. In the same
+// transaction it moves the purge horizon to the newest deleted history position, so projections
+// refuse to rebuild from a history that is no longer complete.
+func (p *PostgresPersistence) PurgeArchivedEvents(ctx context.Context, sentBefore time.Time, limit int) (int, error) {
+	purged := 0
+	err := p.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		var rows []OutboxArchiveModel
+		if err := tx.Select("id, created_at").Where("sent_at < ?", sentBefore).Order("sent_at").Limit(limit).Find(&rows).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		ids := make([]string, 0, len(rows))
+		newest := projections.Position{}
+		for _, r := range rows {
+			ids = append(ids, r.ID)
+			if pos := (projections.Position{CreatedAt: r.CreatedAt, EventID: r.ID}); newest.Before(pos) {
+				newest = pos
+			}
+		}
+		res := tx.Where("id IN ?", ids).Delete(&OutboxArchiveModel{})
+		if res.Error != nil {
+			return res.Error
+		}
+		purged = int(res.RowsAffected)
+
+		var horizon []OutboxPurgeHorizonModel
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", purgeHorizonID).Limit(1).Find(&horizon).Error; err != nil {
+			return err
+		}
+		if len(horizon) == 0 {
+			return tx.Create(&OutboxPurgeHorizonModel{ID: purgeHorizonID, PositionAt: newest.CreatedAt, PositionEventID: newest.EventID, UpdatedAt: time.Now().UTC()}).Error
+		}
+		if !(projections.Position{CreatedAt: horizon[0].PositionAt, EventID: horizon[0].PositionEventID}).Before(newest) {
+			return nil
+		}
+		return tx.Model(&OutboxPurgeHorizonModel{}).Where("id = ?", purgeHorizonID).Updates(map[string]interface{}{
+			"position_at":       newest.CreatedAt,
+			"position_event_id": newest.EventID,
+			"updated_at":        time.Now().UTC(),
+		}).Error
+	})
+	return purged, err
+}
+
+// HistoryHorizon returns the newest history position deleted by PurgeArchivedEvents, or the zero
+// position if the archive was never purged.
+func (p *PostgresPersistence) HistoryHorizon(ctx context.Context) (projections.Position, error) {
+	var rows []OutboxPurgeHorizonModel
+	if err := p.conn(ctx).Where("id = ?", purgeHorizonID).Limit(1).Find(&rows).Error; err != nil {
+		return projections.Position{}, err
+	}
+	if len(rows) == 0 {
+		return projections.Position{}, nil
+	}
+	return projections.Position{CreatedAt: rows[0].PositionAt, EventID: rows[0].PositionEventID}, nil
+}
+
+// historyRow is one event of the outbox history, read from either outbox or outbox_archive.
+type historyRow struct {
+	ID          string
+	EventType   string
+	Payload     string
+	AggregateID *string
+	Sequence    int64
+	CreatedAt   time.Time
+}
+
+// ListEventsAfter reads the outbox history in (created_at, id) order, whether the events were sent,
+// are pending or dead-lettered: they were all committed with the state change they describe.
+func (p *PostgresPersistence) ListEventsAfter(ctx context.Context, after projections.Position, until time.Time, limit int) ([]*appoutbox.OutboxEvent, error) {
+	const cols = "id, event_type, payload, aggregate_id, sequence, created_at"
+	const cond = "created_at < ? AND (created_at > ? OR (created_at = ? AND id > ?))"
+	args := []interface{}{until, after.CreatedAt, after.CreatedAt, after.EventID}
+
+	var rows []historyRow
+	err := p.conn(ctx).Raw(
+		"SELECT "+cols+" FROM outbox WHERE "+cond+
+			" UNION ALL SELECT "+cols+" FROM outbox_archive WHERE "+cond+
+			" ORDER BY created_at, id LIMIT ?",
+		append(append(args, args...), limit)...,
+	).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*appoutbox.OutboxEvent, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, toOutboxEvent(OutboxModel{
+			ID:          r.ID,
+			EventType:   r.EventType,
+			Payload:     r.Payload,
+			AggregateID: r.AggregateID,
+			Sequence:    r.Sequence,
+			CreatedAt:   r.CreatedAt,
+		}))
+	}
+	return out, nil
+}
+
+var (
+	_ appoutbox.OutboxRepository     = (*PostgresPersistence)(nil)
+	_ appoutbox.DeadLetterRepository = (*PostgresPersistence)(nil)
+	_ appoutbox.RetentionRepository  = (*PostgresPersistence)(nil)
+	_ webhooks.EventRepository       = (*PostgresPersistence)(nil)
+	_ projections.EventHistory       = (*PostgresPersistence)(nil)
+)
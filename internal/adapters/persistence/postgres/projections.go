@@ -0,0 +1,143 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"draftea-challenge/internal/application/projections"
+)
+
+func (p *PostgresPersistence) GetProjectionCheckpoint(ctx context.Context, name string) (projections.Position, error) {
+	var m ProjectionCheckpointModel
+	if err := p.conn(ctx).Where("name = ?", name).First(&m).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return projections.Position{}, nil
+		}
+		return projections.Position{}, err
+	}
+	return projections.Position{CreatedAt: m.PositionAt.UTC(), EventID: m.PositionEventID}, nil
+}
+
+// advanceCheckpoint moves the checkpoint from -> to inside tx. The conditional update makes a
+// concurrent runner that read the same checkpoint fail instead of applying the batch twice.
+func advanceCheckpoint(tx *gorm.DB, name string, from, to projections.Position) (bool, error) {
+	now := time.Now().UTC()
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&ProjectionCheckpointModel{Name: name, PositionAt: time.Time{}, UpdatedAt: now}).Error; err != nil {
+		return false, err
+	}
+	res := tx.Model(&ProjectionCheckpointModel{}).
+		Where("name = ? AND position_at = ? AND position_event_id = ?", name, from.CreatedAt, from.EventID).
+		Updates(map[string]interface{}{
+			"position_at":       to.CreatedAt,
+			"position_event_id": to.EventID,
+			"updated_at":        now,
+		})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func setCheckpoint(tx *gorm.DB, name string, to projections.Position) error {
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"position_at", "position_event_id", "updated_at"}),
+	}).Create(&ProjectionCheckpointModel{
+		Name:            name,
+		PositionAt:      to.CreatedAt,
+		PositionEventID: to.EventID,
+		UpdatedAt:       time.Now().UTC(),
+	}).Error
+}
+
+// errCheckpointMoved rolls back a batch whose checkpoint was moved by someone else.
+var errCheckpointMoved = errors.New("projection checkpoint moved")
+
+func (p *PostgresPersistence) ApplyDailySpend(ctx context.Context, name string, deltas []projections.DailySpend, from, to projections.Position) (bool, error) {
+	err := p.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		ok, err := advanceCheckpoint(tx, name, from, to)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errCheckpointMoved
+		}
+		now := time.Now().UTC()
+		for _, d := range deltas {
+			m := UserDailySpendModel{
+				UserID:         d.UserID.String(),
+				Day:            d.Day.UTC(),
+				Currency:       d.Currency,
+				Payments:       d.Payments,
+				Amount:         d.Amount,
+				Fee:            d.Fee,
+				FailedPayments: d.FailedPayments,
+				UpdatedAt:      now,
+			}
+			if err := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "user_id"}, {Name: "day"}, {Name: "currency"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{
+					"payments":        gorm.Expr("user_daily_spend.payments + excluded.payments"),
+					"amount":          gorm.Expr("user_daily_spend.amount + excluded.amount"),
+					"fee":             gorm.Expr("user_daily_spend.fee + excluded.fee"),
+					"failed_payments": gorm.Expr("user_daily_spend.failed_payments + excluded.failed_payments"),
+					"updated_at":      gorm.Expr("excluded.updated_at"),
+				}),
+			}).Create(&m).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errCheckpointMoved) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (p *PostgresPersistence) RewindDailySpend(ctx context.Context, name string, since time.Time, to projections.Position) error {
+	return p.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		q := tx.Where("1 = 1")
+		if !since.IsZero() {
+			q = q.Where("day >= ?", since.UTC())
+		}
+		if err := q.Delete(&UserDailySpendModel{}).Error; err != nil {
+			return err
+		}
+		return setCheckpoint(tx, name, to)
+	})
+}
+
+func (p *PostgresPersistence) ListDailySpend(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]projections.DailySpend, error) {
+	var rows []UserDailySpendModel
+	if err := p.conn(ctx).
+		Where("user_id = ? AND day >= ? AND day <= ?", userID.String(), from.UTC(), to.UTC()).
+		Order("day ASC, currency ASC").
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make([]projections.DailySpend, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, projections.DailySpend{
+			UserID:         userID,
+			Day:            r.Day.UTC(),
+			Currency:       r.Currency,
+			Payments:       r.Payments,
+			Amount:         r.Amount,
+			Fee:            r.Fee,
+			FailedPayments: r.FailedPayments,
+		})
+	}
+	return out, nil
+}
+
+var (
+	_ projections.CheckpointRepository = (*PostgresPersistence)(nil)
+	_ projections.DailySpendRepository = (*PostgresPersistence)(nil)
+)
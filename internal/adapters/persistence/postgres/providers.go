@@ -0,0 +1,132 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"draftea-challenge/internal/application/payments"
+	"draftea-challenge/internal/application/providers"
+	domainerrors "draftea-challenge/internal/domain/errors"
+	domainprovider "draftea-challenge/internal/domain/provider"
+)
+
+// ProviderRepository
+func (p *PostgresPersistence) CreateProvider(ctx context.Context, prov *domainprovider.Provider) error {
+	m, err := toProviderModel(prov)
+	if err != nil {
+		return err
+	}
+	return p.conn(ctx).Create(&m).Error
+}
+
+func (p *PostgresPersistence) GetProvider(ctx context.Context, providerID uuid.UUID) (*domainprovider.Provider, error) {
+	var m ProviderModel
+	if err := p.conn(ctx).Where("id = ?", providerID.String()).First(&m).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domainerrors.NewNotFoundError("provider not found")
+		}
+		return nil, err
+	}
+	return fromProviderModel(m)
+}
+
+func (p *PostgresPersistence) ListProviders(ctx context.Context, limit, offset int) ([]*domainprovider.Provider, int, error) {
+	var total int64
+	if err := p.conn(ctx).Model(&ProviderModel{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var rows []ProviderModel
+	if err := p.conn(ctx).Order("name").Limit(limit).Offset(offset).Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	out := make([]*domainprovider.Provider, 0, len(rows))
+	for _, r := range rows {
+		prov, err := fromProviderModel(r)
+		if err != nil {
+			return nil, 0, err
+		}
+		out = append(out, prov)
+	}
+	return out, int(total), nil
+}
+
+func (p *PostgresPersistence) UpdateProvider(ctx context.Context, prov *domainprovider.Provider) error {
+	m, err := toProviderModel(prov)
+	if err != nil {
+		return err
+	}
+	res := p.conn(ctx).Model(&ProviderModel{}).Where("id = ?", m.ID).Updates(map[string]interface{}{
+		"name":              m.Name,
+		"currencies":        m.Currencies,
+		"min_amount":        m.MinAmount,
+		"max_amount":        m.MaxAmount,
+		"reference_pattern": m.ReferencePattern,
+		"enabled":           m.Enabled,
+		"updated_at":        m.UpdatedAt,
+	})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return domainerrors.NewNotFoundError("provider not found")
+	}
+	return nil
+}
+
+func (p *PostgresPersistence) DeleteProvider(ctx context.Context, providerID uuid.UUID) error {
+	res := p.conn(ctx).Where("id = ?", providerID.String()).Delete(&ProviderModel{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return domainerrors.NewNotFoundError("provider not found")
+	}
+	return nil
+}
+
+func toProviderModel(prov *domainprovider.Provider) (ProviderModel, error) {
+	currencies, err := json.Marshal(prov.Currencies)
+	if err != nil {
+		return ProviderModel{}, err
+	}
+	return ProviderModel{
+		ID:               prov.ID.String(),
+		Name:             prov.Name,
+		Currencies:       string(currencies),
+		MinAmount:        prov.MinAmount,
+		MaxAmount:        prov.MaxAmount,
+		ReferencePattern: prov.ReferencePattern,
+		Enabled:          prov.Enabled,
+		CreatedAt:        prov.CreatedAt,
+		UpdatedAt:        prov.UpdatedAt,
+	}, nil
+}
+
+func fromProviderModel(m ProviderModel) (*domainprovider.Provider, error) {
+	var currencies []string
+	if m.Currencies != "" {
+		if err := json.Unmarshal([]byte(m.Currencies), &currencies); err != nil {
+			return nil, err
+		}
+	}
+	return &domainprovider.Provider{
+		ID:               uuid.MustParse(m.ID),
+		Name:             m.Name,
+		Currencies:       currencies,
+		MinAmount:        m.MinAmount,
+		MaxAmount:        m.MaxAmount,
+		ReferencePattern: m.ReferencePattern,
+		Enabled:          m.Enabled,
+		CreatedAt:        m.CreatedAt,
+		UpdatedAt:        m.UpdatedAt,
+	}, nil
+}
+
+var (
+	_ providers.ProviderRepository = (*PostgresPersistence)(nil)
+	_ payments.ProviderRepository  = (*PostgresPersistence)(nil)
+)
@@ -0,0 +1,162 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	appoutbox "draftea-challenge/internal/application/outbox"
+	"draftea-challenge/internal/application/outbox/relay"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openRelayTestDB uses the Postgres pointed to by OUTBOX_TEST_POSTGRES_DSN (a throwaway database:
+// the outbox tables are emptied) so SKIP LOCKED is exercised; otherwise a file-backed SQLite database,
+// where claims are serialized and only the lease and in-order prefix rules are covered.
+func openRelayTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	cfg := &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)}
+	if dsn := os.Getenv("OUTBOX_TEST_POSTGRES_DSN"); dsn != "" {
+		db, err := gorm.Open(postgres.Open(dsn), cfg)
+		if err != nil {
+			t.Fatalf("open postgres: %v", err)
+		}
+		if err := db.AutoMigrate(&OutboxModel{}, &OutboxArchiveModel{}); err != nil {
+			t.Fatalf("migrate: %v", err)
+		}
+		for _, table := range []string{"outbox", "outbox_archive"} {
+			if err := db.Exec("DELETE FROM " + table).Error; err != nil {
+				t.Fatalf("reset %s: %v", table, err)
+			}
+		}
+		return db
+	}
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "outbox.db")+"?_busy_timeout=5000"), cfg)
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("sql db: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&OutboxModel{}, &OutboxArchiveModel{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+type publishedEvent struct {
+	relay string
+	event *appoutbox.OutboxEvent
+}
+
+type recordingPublisher struct {
+	relay string
+	mu    *sync.Mutex
+	log   *[]publishedEvent
+}
+
+func (p recordingPublisher) Publish(ctx context.Context, exchange string, event *appoutbox.OutboxEvent) error {
+	time.Sleep(time.Millisecond)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	*p.log = append(*p.log, publishedEvent{relay: p.relay, event: event})
+	return nil
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func TestTwoRelaysPublishEachEventOnceInAggregateOrder(t *testing.T) {
+	db := openRelayTestDB(t)
+	repo := NewPostgresPersistence(db)
+	ctx := context.Background()
+
+	aggregates := make([]uuid.UUID, 4)
+	for i := range aggregates {
+		aggregates[i] = uuid.New()
+	}
+	total := 0
+	base := time.Now().Add(-time.Minute)
+	for seq := 0; seq < 5; seq++ {
+		for _, agg := range aggregates {
+			ev := &appoutbox.OutboxEvent{ID: uuid.New(), EventType: fmt.Sprintf("payment.step%d", seq), Payload: `{}`, AggregateID: agg, CreatedAt: base.Add(time.Duration(total) * time.Millisecond)}
+			if err := repo.CreateEvent(ctx, ev); err != nil {
+				t.Fatalf("create event: %v", err)
+			}
+			total++
+		}
+		standalone := &appoutbox.OutboxEvent{ID: uuid.New(), EventType: "schedule.failed", Payload: `{}`, CreatedAt: base.Add(time.Duration(total) * time.Millisecond)}
+		if err := repo.CreateEvent(ctx, standalone); err != nil {
+			t.Fatalf("create event: %v", err)
+		}
+		total++
+	}
+
+	var mu sync.Mutex
+	var log []publishedEvent
+	var wg sync.WaitGroup
+	for _, name := range []string{"relay-a", "relay-b"} {
+		r := relay.NewRelay(repo, recordingPublisher{relay: name, mu: &mu, log: &log}, systemClock{}, relay.Config{
+			ID:          name,
+			Lease:       time.Minute,
+			BatchSize:   3,
+			MaxInFlight: 2,
+		})
+		wg.Add(1)
+		go func(name string, r *relay.Relay) {
+			defer wg.Done()
+			deadline := time.Now().Add(10 * time.Second)
+			for time.Now().Before(deadline) {
+				if _, err := r.ProcessOnce(ctx); err != nil {
+					t.Errorf("%s: %v", name, err)
+					return
+				}
+				pending, err := repo.GetPendingEvents(ctx, 1)
+				if err != nil {
+					t.Errorf("%s: pending: %v", name, err)
+					return
+				}
+				if len(pending) == 0 {
+					return
+				}
+			}
+			t.Errorf("%s: outbox not drained before deadline", name)
+		}(name, r)
+	}
+	wg.Wait()
+	if t.Failed() {
+		return
+	}
+
+	if len(log) != total {
+		t.Fatalf("expected %d publishes, got %d", total, len(log))
+	}
+	seen := make(map[uuid.UUID]string)
+	last := make(map[uuid.UUID]int64)
+	for _, p := range log {
+		if other, dup := seen[p.event.ID]; dup {
+			t.Fatalf("event %s published by %s and %s", p.event.ID, other, p.relay)
+		}
+		seen[p.event.ID] = p.relay
+		if p.event.AggregateID == uuid.Nil {
+			continue
+		}
+		if p.event.Sequence != last[p.event.AggregateID]+1 {
+			t.Fatalf("aggregate %s: sequence %d published after %d", p.event.AggregateID, p.event.Sequence, last[p.event.AggregateID])
+		}
+		last[p.event.AggregateID] = p.event.Sequence
+	}
+}
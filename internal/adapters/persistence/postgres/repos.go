@@ -9,10 +9,13 @@ import (
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 
-	appoutbox "draftea-challenge/internal/application/outbox"
 	"draftea-challenge/internal/application/payments"
+	"draftea-challenge/internal/application/ports"
+	"draftea-challenge/internal/application/screening"
 	"draftea-challenge/internal/application/wallets"
 	domainerrors "draftea-challenge/internal/domain/errors"
+	domainfee "draftea-challenge/internal/domain/fee"
+	domainlimit "draftea-challenge/internal/domain/limit"
 	domaintx "draftea-challenge/internal/domain/transaction"
 	domainwallet "draftea-challenge/internal/domain/wallet"
 )
@@ -25,10 +28,28 @@ func NewPostgresPersistence(db *gorm.DB) *PostgresPersistence {
 	return &PostgresPersistence{db: db}
 }
 
+type txKey struct{}
+
+// WithinTransaction runs fn in a transaction carried by its ctx; every method called with that ctx
+// uses it. Inside another transaction it opens a savepoint.
+func (p *PostgresPersistence) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return p.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// conn returns the transaction carried by ctx, or the persistence's own connection.
+func (p *PostgresPersistence) conn(ctx context.Context) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return p.db.WithContext(ctx)
+}
+
 // WalletRepository
 func (p *PostgresPersistence) GetWallet(ctx context.Context, userID uuid.UUID) (*domainwallet.Wallet, error) {
 	var w WalletModel
-	if err := p.db.WithContext(ctx).Where("user_id = ?", userID.String()).First(&w).Error; err != nil {
+	if err := p.conn(ctx).Where("user_id = ?", userID.String()).First(&w).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, domainerrors.NewNotFoundError("wallet not found")
 		}
@@ -36,7 +57,7 @@ func (p *PostgresPersistence) GetWallet(ctx context.Context, userID uuid.UUID) (
 	}
 	// Load balances
 	var balances []WalletBalanceModel
-	if err := p.db.WithContext(ctx).Where("user_id = ?", userID.String()).Find(&balances).Error; err != nil {
+	if err := p.conn(ctx).Where("user_id = ?", userID.String()).Find(&balances).Error; err != nil {
 		return nil, err
 	}
 	m := make(map[string]int64)
@@ -51,15 +72,27 @@ func (p *PostgresPersistence) GetWallet(ctx context.Context, userID uuid.UUID) (
 	}, nil
 }
 
+// LockWallet locks the user's wallet row (SELECT ... FOR UPDATE) until the transaction in ctx ends.
+func (p *PostgresPersistence) LockWallet(ctx context.Context, userID uuid.UUID) error {
+	var w WalletModel
+	if err := p.conn(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID.String()).First(&w).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domainerrors.NewNotFoundError("wallet not found")
+		}
+		return err
+	}
+	return nil
+}
+
 func (p *PostgresPersistence) CreateWallet(ctx context.Context, w *domainwallet.Wallet) error {
 	wm := WalletModel{ID: w.ID.String(), UserID: w.UserID.String(), Name: w.Name, CreatedAt: time.Now()}
-	if err := p.db.WithContext(ctx).Create(&wm).Error; err != nil {
+	if err := p.conn(ctx).Create(&wm).Error; err != nil {
 		return err
 	}
 	// create balances rows
 	for cur, bal := range w.Balances {
 		bm := WalletBalanceModel{ID: uuid.NewString(), WalletID: wm.ID, UserID: wm.UserID, Currency: cur, CurrentBalance: bal, CreatedAt: time.Now(), UpdatedAt: time.Now()}
-		if err := p.db.WithContext(ctx).Create(&bm).Error; err != nil {
+		if err := p.conn(ctx).Create(&bm).Error; err != nil {
 			return err
 		}
 	}
@@ -67,7 +100,7 @@ func (p *PostgresPersistence) CreateWallet(ctx context.Context, w *domainwallet.
 }
 
 func (p *PostgresPersistence) UpdateBalance(ctx context.Context, userID uuid.UUID, currency string, newBalance int64) error {
-	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	return p.conn(ctx).Transaction(func(tx *gorm.DB) error {
 		var bal WalletBalanceModel
 		// Lock the specific row for update
 		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ? AND currency = ?", userID.String(), currency).First(&bal).Error; err != nil {
@@ -106,16 +139,19 @@ func (p *PostgresPersistence) UpdateBalance(ctx context.Context, userID uuid.UUI
 }
 
 // Ensure PostgresPersistence implements WalletRepository interface
-var _ wallets.WalletRepository = (*PostgresPersistence)(nil)
+var (
+	_ wallets.WalletRepository = (*PostgresPersistence)(nil)
+	_ ports.Transactor         = (*PostgresPersistence)(nil)
+)
 
 func (p *PostgresPersistence) ListWallets(ctx context.Context, limit, offset int) ([]*domainwallet.Wallet, int, error) {
 	var total int64
-	if err := p.db.WithContext(ctx).Model(&WalletModel{}).Count(&total).Error; err != nil {
+	if err := p.conn(ctx).Model(&WalletModel{}).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	var rows []WalletModel
-	if err := p.db.WithContext(ctx).Order("created_at").Limit(limit).Offset(offset).Find(&rows).Error; err != nil {
+	if err := p.conn(ctx).Order("created_at").Limit(limit).Offset(offset).Find(&rows).Error; err != nil {
 		return nil, 0, err
 	}
 	if len(rows) == 0 {
@@ -128,7 +164,7 @@ func (p *PostgresPersistence) ListWallets(ctx context.Context, limit, offset int
 	}
 
 	var balances []WalletBalanceModel
-	if err := p.db.WithContext(ctx).Where("user_id IN ?", userIDs).Find(&balances).Error; err != nil {
+	if err := p.conn(ctx).Where("user_id IN ?", userIDs).Find(&balances).Error; err != nil {
 		return nil, 0, err
 	}
 
@@ -160,7 +196,7 @@ func (p *PostgresPersistence) ListWallets(ctx context.Context, limit, offset int
 // PaymentRepository & IdempotencyRepo & Outbox
 func (p *PostgresPersistence) CreateTransaction(ctx context.Context, txDomain *domaintx.Transaction) error {
 	var walletRow WalletModel
-	if err := p.db.WithContext(ctx).Where("user_id = ?", txDomain.UserID.String()).First(&walletRow).Error; err != nil {
+	if err := p.conn(ctx).Where("user_id = ?", txDomain.UserID.String()).First(&walletRow).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return domainerrors.NewNotFoundError("wallet not found")
 		}
@@ -179,68 +215,106 @@ func (p *PostgresPersistence) CreateTransaction(ctx context.Context, txDomain *d
 		CreatedAt:         txDomain.CreatedAt,
 		UpdatedAt:         txDomain.UpdatedAt,
 	}
-	return p.db.WithContext(ctx).Create(&m).Error
+	if txDomain.RelatedTransactionID != nil {
+		relatedID := txDomain.RelatedTransactionID.String()
+		m.RelatedTransactionID = &relatedID
+	}
+	return p.conn(ctx).Create(&m).Error
 }
 
 func (p *PostgresPersistence) UpdateTransactionStatus(ctx context.Context, txID uuid.UUID, status domaintx.Status) error {
-	return p.db.WithContext(ctx).Model(&TransactionModel{}).Where("id = ?", txID.String()).Updates(map[string]interface{}{"status": string(status), "updated_at": time.Now()}).Error
+	return p.conn(ctx).Model(&TransactionModel{}).Where("id = ?", txID.String()).Updates(map[string]interface{}{"status": string(status), "updated_at": time.Now()}).Error
+}
+
+// TransitionTransactionStatus sets status to "to" only while the row is still in "from" and reports
+// whether this call changed it, so concurrent finalizers (poll and callback) apply side effects once.
+func (p *PostgresPersistence) TransitionTransactionStatus(ctx context.Context, txID uuid.UUID, from, to domaintx.Status) (bool, error) {
+	res := p.conn(ctx).Model(&TransactionModel{}).
+		Where("id = ? AND status = ?", txID.String(), string(from)).
+		Updates(map[string]interface{}{"status": string(to), "updated_at": time.Now()})
+	return res.RowsAffected == 1, res.Error
 }
 
 func (p *PostgresPersistence) GetTransactionByID(ctx context.Context, txID uuid.UUID) (*domaintx.Transaction, error) {
 	var m TransactionModel
-	if err := p.db.WithContext(ctx).Where("id = ?", txID.String()).First(&m).Error; err != nil {
+	if err := p.conn(ctx).Where("id = ?", txID.String()).First(&m).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, domainerrors.NewNotFoundError("transaction not found")
 		}
 		return nil, err
 	}
-	return &domaintx.Transaction{
-		ID:                uuid.MustParse(m.ID),
-		UserID:            uuid.MustParse(m.UserID),
-		Type:              domaintx.Type(m.Type),
-		Amount:            m.Amount,
-		Currency:          m.Currency,
-		Status:            domaintx.Status(m.Status),
-		ProviderID:        uuid.MustParse(m.ProviderID),
-		ExternalReference: m.ExternalReference,
-		CreatedAt:         m.CreatedAt,
-		UpdatedAt:         m.UpdatedAt,
-	}, nil
+	return toTransaction(m), nil
+}
+
+func (p *PostgresPersistence) GetFeeTransaction(ctx context.Context, paymentTxID uuid.UUID) (*domaintx.Transaction, error) {
+	var m TransactionModel
+	if err := p.conn(ctx).
+		Where("related_transaction_id = ? AND type = ?", paymentTxID.String(), string(domaintx.TypeFee)).
+		First(&m).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domainerrors.NewNotFoundError("fee transaction not found")
+		}
+		return nil, err
+	}
+	return toTransaction(m), nil
 }
 
 func (p *PostgresPersistence) ListTransactions(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*domaintx.Transaction, error) {
-	var rows []TransactionModel
-	if err := p.db.WithContext(ctx).Where("user_id = ?", userID.String()).Order("created_at desc").Limit(limit).Offset(offset).Find(&rows).Error; err != nil {
+	var rows []struct {
+		TransactionModel
+		ProviderName *string
+	}
+	if err := p.conn(ctx).
+		Table("transactions").
+		Select("transactions.*, providers.name AS provider_name").
+		Joins("LEFT JOIN providers ON providers.id = transactions.provider_id").
+		Where("transactions.user_id = ?", userID.String()).
+		Order("transactions.created_at desc").
+		Limit(limit).
+		Offset(offset).
+		Scan(&rows).Error; err != nil {
 		return nil, err
 	}
 	out := make([]*domaintx.Transaction, 0, len(rows))
 	for _, r := range rows {
-		t := &domaintx.Transaction{
-			ID:       uuid.MustParse(r.ID),
-			UserID:   uuid.MustParse(r.UserID),
-			Type:     domaintx.Type(r.Type),
-			Amount:   r.Amount,
-			Currency: r.Currency,
-			Status:   domaintx.Status(r.Status),
-			ProviderID: func() uuid.UUID {
-				if r.ProviderID == "" {
-					return uuid.Nil
-				}
-				return uuid.MustParse(r.ProviderID)
-			}(),
-			ExternalReference: r.ExternalReference,
-			CreatedAt:         r.CreatedAt,
-			UpdatedAt:         r.UpdatedAt,
+		t := toTransaction(r.TransactionModel)
+		if r.ProviderName != nil {
+			t.ProviderName = *r.ProviderName
 		}
 		out = append(out, t)
 	}
 	return out, nil
 }
 
+func toTransaction(m TransactionModel) *domaintx.Transaction {
+	t := &domaintx.Transaction{
+		ID:       uuid.MustParse(m.ID),
+		UserID:   uuid.MustParse(m.UserID),
+		Type:     domaintx.Type(m.Type),
+		Amount:   m.Amount,
+		Currency: m.Currency,
+		Status:   domaintx.Status(m.Status),
+		ProviderID: func() uuid.UUID {
+			if m.ProviderID == "" {
+				return uuid.Nil
+			}
+			return uuid.MustParse(m.ProviderID)
+		}(),
+		ExternalReference: m.ExternalReference,
+		CreatedAt:         m.CreatedAt,
+		UpdatedAt:         m.UpdatedAt,
+	}
+	if m.RelatedTransactionID != nil {
+		relatedID := uuid.MustParse(*m.RelatedTransactionID)
+		t.RelatedTransactionID = &relatedID
+	}
+	return t
+}
+
 // Idempotency
 func (p *PostgresPersistence) GetIdempotencyRecord(ctx context.Context, userID uuid.UUID, key string) (*payments.IdempotencyRecord, error) {
 	var m IdempotencyModel
-	if err := p.db.WithContext(ctx).Where("user_id = ? AND key = ?", userID.String(), key).First(&m).Error; err != nil {
+	if err := p.conn(ctx).Where("user_id = ? AND key = ?", userID.String(), key).First(&m).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
@@ -263,35 +337,102 @@ func (p *PostgresPersistence) CreateIdempotencyRecord(ctx context.Context, recor
 		Response:  record.Response,
 		CreatedAt: createdAt,
 	}
-	return p.db.WithContext(ctx).Create(&m).Error
+	return p.conn(ctx).Create(&m).Error
 }
 
-// Outbox
-func (p *PostgresPersistence) CreateEvent(ctx context.Context, event *appoutbox.OutboxEvent) error {
-	m := OutboxModel{ID: event.ID.String(), EventType: event.EventType, Payload: event.Payload, CreatedAt: event.CreatedAt}
-	if event.SentAt != nil {
-		m.SentAt = event.SentAt
+// Spending limits
+func (p *PostgresPersistence) GetSpendingLimit(ctx context.Context, userID uuid.UUID, currency string) (*domainlimit.SpendingLimit, error) {
+	var m SpendingLimitModel
+	// Wallet-specific rows take precedence over the currency default (user_id IS NULL).
+	if err := p.conn(ctx).
+		Where("currency = ? AND (user_id = ? OR user_id IS NULL)", currency, userID.String()).
+		Order("user_id IS NULL").
+		First(&m).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domainerrors.NewNotFoundError("spending limit not found")
+		}
+		return nil, err
+	}
+	out := &domainlimit.SpendingLimit{
+		ID:                 uuid.MustParse(m.ID),
+		Currency:           m.Currency,
+		MaxSinglePayment:   m.MaxSinglePayment,
+		DailySpend:         m.DailySpend,
+		MonthlySpend:       m.MonthlySpend,
+		MaxPaymentsPerHour: m.MaxPaymentsPerHour,
 	}
-	return p.db.WithContext(ctx).Create(&m).Error
+	if m.UserID != nil {
+		out.UserID = uuid.MustParse(*m.UserID)
+	}
+	return out, nil
 }
 
-func (p *PostgresPersistence) GetPendingEvents(ctx context.Context, limit int) ([]*appoutbox.OutboxEvent, error) {
-	var rows []OutboxModel
-	if err := p.db.WithContext(ctx).Where("sent_at IS NULL").Order("created_at").Limit(limit).Find(&rows).Error; err != nil {
+// FeeRepository
+func (p *PostgresPersistence) GetFeePolicy(ctx context.Context, providerID uuid.UUID, currency string) (*domainfee.Policy, error) {
+	var m FeePolicyModel
+	// Provider-specific rows take precedence over the currency default (provider_id IS NULL).
+	if err := p.conn(ctx).
+		Where("UPPER(currency) = UPPER(?) AND (provider_id = ? OR provider_id IS NULL)", currency, providerID.String()).
+		Order("provider_id IS NULL").
+		First(&m).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domainerrors.NewNotFoundError("fee policy not found")
+		}
 		return nil, err
 	}
-	out := make([]*appoutbox.OutboxEvent, 0, len(rows))
-	for _, r := range rows {
-		out = append(out, &appoutbox.OutboxEvent{ID: uuid.MustParse(r.ID), EventType: r.EventType, Payload: r.Payload, CreatedAt: r.CreatedAt, SentAt: r.SentAt})
+	out := &domainfee.Policy{
+		ID:            uuid.MustParse(m.ID),
+		Currency:      m.Currency,
+		FixedAmount:   m.FixedAmount,
+		PercentageBps: m.PercentageBps,
+		MinFee:        m.MinFee,
+		MaxFee:        m.MaxFee,
+	}
+	if m.ProviderID != nil {
+		out.ProviderID = uuid.MustParse(*m.ProviderID)
 	}
 	return out, nil
 }
 
-func (p *PostgresPersistence) MarkEventAsSent(ctx context.Context, eventID uuid.UUID) error {
-	return p.db.WithContext(ctx).Model(&OutboxModel{}).Where("id = ?", eventID.String()).Update("sent_at", time.Now()).Error
+func (p *PostgresPersistence) GetPaymentUsage(ctx context.Context, userID uuid.UUID, currency string, since time.Time) (int64, int, error) {
+	var row struct {
+		Total int64
+		Count int
+	}
+	err := p.conn(ctx).Model(&TransactionModel{}).
+		Select("COALESCE(SUM(amount), 0) AS total, COUNT(*) AS count").
+		Where("user_id = ? AND currency = ? AND type = ? AND status IN ? AND created_at >= ?",
+			userID.String(), currency, string(domaintx.TypePayment),
+			[]string{string(domaintx.StatusPending), string(domaintx.StatusHeld), string(domaintx.StatusApproved)}, since).
+		Scan(&row).Error
+	if err != nil {
+		return 0, 0, err
+	}
+	return row.Total, row.Count, nil
+}
+
+// Risk history
+func (p *PostgresPersistence) CountPaymentsSince(ctx context.Context, userID uuid.UUID, since time.Time) (int, error) {
+	var count int64
+	err := p.conn(ctx).Model(&TransactionModel{}).
+		Where("user_id = ? AND type = ? AND created_at >= ?", userID.String(), string(domaintx.TypePayment), since).
+		Count(&count).Error
+	return int(count), err
+}
+
+func (p *PostgresPersistence) HasPaidProvider(ctx context.Context, userID, providerID uuid.UUID) (bool, error) {
+	var count int64
+	err := p.conn(ctx).Model(&TransactionModel{}).
+		Where("user_id = ? AND provider_id = ? AND type = ? AND status = ?",
+			userID.String(), providerID.String(), string(domaintx.TypePayment), string(domaintx.StatusApproved)).
+		Limit(1).
+		Count(&count).Error
+	return count > 0, err
 }
 
 // Compile-time interface checks
 var _ payments.PaymentRepository = (*PostgresPersistence)(nil)
 var _ payments.IdempotencyRepository = (*PostgresPersistence)(nil)
-var _ appoutbox.OutboxRepository = (*PostgresPersistence)(nil)
+var _ payments.LimitRepository = (*PostgresPersistence)(nil)
+var _ payments.FeeRepository = (*PostgresPersistence)(nil)
+var _ screening.HistoryRepository = (*PostgresPersistence)(nil)
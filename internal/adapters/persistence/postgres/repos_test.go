@@ -2,10 +2,19 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
+	"draftea-challenge/internal/application/audit"
+	"draftea-challenge/internal/application/events"
+	"draftea-challenge/internal/application/inbox"
+	"draftea-challenge/internal/application/metrics"
+	"draftea-challenge/internal/application/notifications"
+	appoutbox "draftea-challenge/internal/application/outbox"
+	"draftea-challenge/internal/application/projections"
 	domainerrors "draftea-challenge/internal/domain/errors"
+	domainwebhook "draftea-challenge/internal/domain/webhook"
 
 	"github.com/google/uuid"
 	"gorm.io/driver/sqlite"
@@ -61,3 +70,1106 @@ func TestUpdateBalanceMissingWallet(t *testing.T) {
 		t.Fatalf("expected not found error, got %v", err)
 	}
 }
+
+func TestWithinTransactionRollsBackRepositoryWrites(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&WalletModel{}, &WalletBalanceModel{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	userID := uuid.New()
+	if err := db.Create(&WalletModel{ID: uuid.New().String(), UserID: userID.String(), CreatedAt: time.Now()}).Error; err != nil {
+		t.Fatalf("create wallet: %v", err)
+	}
+	repo := NewPostgresPersistence(db)
+	ctx := context.Background()
+	if err := repo.UpdateBalance(ctx, userID, "USD", 100); err != nil {
+		t.Fatalf("update balance: %v", err)
+	}
+
+	boom := errors.New("boom")
+	err = repo.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := repo.LockWallet(ctx, userID); err != nil {
+			return err
+		}
+		if err := repo.UpdateBalance(ctx, userID, "USD", 40); err != nil {
+			return err
+		}
+		return boom
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("expected the callback error, got %v", err)
+	}
+	w, err := repo.GetWallet(ctx, userID)
+	if err != nil {
+		t.Fatalf("get wallet: %v", err)
+	}
+	if got := w.GetBalance("USD"); got != 100 {
+		t.Fatalf("expected the rolled back balance 100, got %d", got)
+	}
+
+	err = repo.WithinTransaction(ctx, func(ctx context.Context) error {
+		return repo.LockWallet(ctx, uuid.New())
+	})
+	if domErr, ok := err.(domainerrors.Error); !ok || domErr.Code != domainerrors.CodeNotFound {
+		t.Fatalf("expected not found for a missing wallet, got %v", err)
+	}
+}
+
+func TestGetSpendingLimitPrefersWalletOverDefault(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&SpendingLimitModel{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	userID := uuid.New()
+	userIDStr := userID.String()
+	rows := []SpendingLimitModel{
+		{ID: uuid.NewString(), Currency: "USD", DailySpend: 1000},
+		{ID: uuid.NewString(), UserID: &userIDStr, Currency: "USD", DailySpend: 5000},
+	}
+	if err := db.Create(&rows).Error; err != nil {
+		t.Fatalf("create limits: %v", err)
+	}
+
+	repo := NewPostgresPersistence(db)
+	l, err := repo.GetSpendingLimit(context.Background(), userID, "USD")
+	if err != nil {
+		t.Fatalf("get limit: %v", err)
+	}
+	if l.DailySpend != 5000 {
+		t.Fatalf("expected wallet limit 5000, got %d", l.DailySpend)
+	}
+
+	l, err = repo.GetSpendingLimit(context.Background(), uuid.New(), "USD")
+	if err != nil {
+		t.Fatalf("get default limit: %v", err)
+	}
+	if l.DailySpend != 1000 || l.UserID != uuid.Nil {
+		t.Fatalf("expected default limit, got %+v", l)
+	}
+
+	_, err = repo.GetSpendingLimit(context.Background(), userID, "EUR")
+	if domErr, ok := err.(domainerrors.Error); !ok || domErr.Code != domainerrors.CodeNotFound {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
+
+func TestGetFeePolicyPrefersProviderOverDefault(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&FeePolicyModel{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	providerID := uuid.New()
+	providerIDStr := providerID.String()
+	rows := []FeePolicyModel{
+		{ID: uuid.NewString(), Currency: "USD", FixedAmount: 10},
+		{ID: uuid.NewString(), ProviderID: &providerIDStr, Currency: "USD", PercentageBps: 150},
+	}
+	if err := db.Create(&rows).Error; err != nil {
+		t.Fatalf("create policies: %v", err)
+	}
+
+	repo := NewPostgresPersistence(db)
+	policy, err := repo.GetFeePolicy(context.Background(), providerID, "USD")
+	if err != nil {
+		t.Fatalf("get policy: %v", err)
+	}
+	if policy.PercentageBps != 150 || policy.ProviderID != providerID {
+		t.Fatalf("expected provider policy, got %+v", policy)
+	}
+
+	policy, err = repo.GetFeePolicy(context.Background(), uuid.New(), "USD")
+	if err != nil {
+		t.Fatalf("get default policy: %v", err)
+	}
+	if policy.FixedAmount != 10 || policy.ProviderID != uuid.Nil {
+		t.Fatalf("expected default policy, got %+v", policy)
+	}
+
+	_, err = repo.GetFeePolicy(context.Background(), providerID, "EUR")
+	if domErr, ok := err.(domainerrors.Error); !ok || domErr.Code != domainerrors.CodeNotFound {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
+
+func TestClaimDueSchedulesLeasesRows(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&ScheduleModel{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	base := ScheduleModel{
+		WalletID:          uuid.NewString(),
+		UserID:            uuid.NewString(),
+		ProviderID:        uuid.NewString(),
+		ExternalReference: "bill-1",
+		Amount:            100,
+		Currency:          "USD",
+		IntervalSeconds:   3600,
+		Status:            "ACTIVE",
+	}
+	due, future, paused := base, base, base
+	due.ID, due.NextRunAt = uuid.NewString(), now.Add(-time.Minute)
+	future.ID, future.NextRunAt = uuid.NewString(), now.Add(time.Hour)
+	paused.ID, paused.NextRunAt, paused.Status = uuid.NewString(), now.Add(-time.Minute), "PAUSED"
+	if err := db.Create(&[]ScheduleModel{due, future, paused}).Error; err != nil {
+		t.Fatalf("create schedules: %v", err)
+	}
+
+	repo := NewPostgresPersistence(db)
+	claimed, err := repo.ClaimDueSchedules(context.Background(), now, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if len(claimed) != 1 || claimed[0].ID.String() != due.ID {
+		t.Fatalf("expected only the due schedule, got %+v", claimed)
+	}
+
+	claimed, err = repo.ClaimDueSchedules(context.Background(), now, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("second claim: %v", err)
+	}
+	if len(claimed) != 0 {
+		t.Fatalf("expected leased schedule to be skipped, got %d", len(claimed))
+	}
+}
+
+func TestAdvanceScheduleKeepsConcurrentCancel(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&ScheduleModel{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	row := ScheduleModel{
+		ID:                uuid.NewString(),
+		WalletID:          uuid.NewString(),
+		UserID:            uuid.NewString(),
+		ProviderID:        uuid.NewString(),
+		ExternalReference: "bill-1",
+		Amount:            100,
+		Currency:          "USD",
+		IntervalSeconds:   3600,
+		NextRunAt:         now.Add(-time.Minute),
+		Status:            "ACTIVE",
+	}
+	if err := db.Create(&row).Error; err != nil {
+		t.Fatalf("create schedule: %v", err)
+	}
+	repo := NewPostgresPersistence(db)
+	ctx := context.Background()
+	claimed, err := repo.ClaimDueSchedules(ctx, now, 10, time.Minute)
+	if err != nil || len(claimed) != 1 {
+		t.Fatalf("claim: %v (%d claimed)", err, len(claimed))
+	}
+	sched := claimed[0]
+
+	// The user cancels and edits the amount while the occurrence runs.
+	cancelled := *sched
+	cancelled.Amount, cancelled.Status = 250, "CANCELLED"
+	if err := repo.UpdateSchedule(ctx, &cancelled); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	var stored ScheduleModel
+	if err := db.First(&stored, "id = ?", row.ID).Error; err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if stored.LockedUntil == nil {
+		t.Fatalf("expected the API update to keep the running lease")
+	}
+
+	if err := repo.AdvanceSchedule(ctx, sched.ID, sched.NextRunAt, now, "APPROVED"); err != nil {
+		t.Fatalf("advance: %v", err)
+	}
+	stored = ScheduleModel{}
+	if err := db.First(&stored, "id = ?", row.ID).Error; err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if stored.Status != "CANCELLED" || stored.Amount != 250 || stored.LastRunAt != nil || stored.LockedUntil != nil {
+		t.Fatalf("expected the cancel to stand and the lease to be released, got %+v", stored)
+	}
+}
+
+func TestAdvanceScheduleUsesEndDateEditedDuringRun(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&ScheduleModel{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	end := now.Add(30 * time.Minute)
+	row := ScheduleModel{
+		ID:                uuid.NewString(),
+		WalletID:          uuid.NewString(),
+		UserID:            uuid.NewString(),
+		ProviderID:        uuid.NewString(),
+		ExternalReference: "bill-1",
+		Amount:            100,
+		Currency:          "USD",
+		IntervalSeconds:   3600,
+		EndDate:           &end,
+		NextRunAt:         now.Add(-time.Minute),
+		Status:            "ACTIVE",
+	}
+	if err := db.Create(&row).Error; err != nil {
+		t.Fatalf("create schedule: %v", err)
+	}
+	repo := NewPostgresPersistence(db)
+	ctx := context.Background()
+	claimed, err := repo.ClaimDueSchedules(ctx, now, 10, time.Minute)
+	if err != nil || len(claimed) != 1 {
+		t.Fatalf("claim: %v (%d claimed)", err, len(claimed))
+	}
+	sched := claimed[0]
+
+	// The user extends the end date while the last occurrence runs.
+	edited := *sched
+	extended := now.Add(48 * time.Hour)
+	edited.EndDate = &extended
+	if err := repo.UpdateSchedule(ctx, &edited); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	if err := repo.AdvanceSchedule(ctx, sched.ID, sched.NextRunAt, now, "APPROVED"); err != nil {
+		t.Fatalf("advance: %v", err)
+	}
+	var stored ScheduleModel
+	if err := db.First(&stored, "id = ?", row.ID).Error; err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if stored.Status != "ACTIVE" || stored.EndDate == nil || !stored.EndDate.Equal(extended) {
+		t.Fatalf("expected the extended schedule to stay active, got %+v", stored)
+	}
+	if want := row.NextRunAt.Add(time.Hour); !stored.NextRunAt.Equal(want) || stored.LastStatus != "APPROVED" || stored.LockedUntil != nil {
+		t.Fatalf("expected the run recorded and next run %s, got %+v", want, stored)
+	}
+}
+
+func TestTransitionTransactionStatusAppliesOnce(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&TransactionModel{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	txID := uuid.New()
+	row := TransactionModel{ID: txID.String(), UserID: uuid.NewString(), Type: "TOP_UP", Amount: 100, Currency: "USD", Status: "PENDING", CreatedAt: time.Now()}
+	if err := db.Create(&row).Error; err != nil {
+		t.Fatalf("create transaction: %v", err)
+	}
+
+	repo := NewPostgresPersistence(db)
+	changed, err := repo.TransitionTransactionStatus(context.Background(), txID, "PENDING", "APPROVED")
+	if err != nil || !changed {
+		t.Fatalf("expected the first transition to apply, got %v %v", changed, err)
+	}
+	changed, err = repo.TransitionTransactionStatus(context.Background(), txID, "PENDING", "FAILED")
+	if err != nil || changed {
+		t.Fatalf("expected the second transition to be a no-op, got %v %v", changed, err)
+	}
+	tx, err := repo.GetTransactionByID(context.Background(), txID)
+	if err != nil || tx.Status != "APPROVED" {
+		t.Fatalf("expected APPROVED, got %+v %v", tx, err)
+	}
+}
+
+func TestListTransactionsIncludesProviderName(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&TransactionModel{}, &ProviderModel{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	userID := uuid.New()
+	providerID := uuid.NewString()
+	if err := db.Create(&ProviderModel{ID: providerID, Name: "Water Co", Currencies: `["USD"]`, Enabled: true}).Error; err != nil {
+		t.Fatalf("create provider: %v", err)
+	}
+	rows := []TransactionModel{
+		{ID: uuid.NewString(), UserID: userID.String(), Type: "PAYMENT", Amount: 100, Currency: "USD", Status: "APPROVED", ProviderID: providerID, CreatedAt: time.Now()},
+		{ID: uuid.NewString(), UserID: userID.String(), Type: "TOP_UP", Amount: 500, Currency: "USD", Status: "APPROVED", ProviderID: uuid.Nil.String(), CreatedAt: time.Now().Add(-time.Minute)},
+	}
+	if err := db.Create(&rows).Error; err != nil {
+		t.Fatalf("create transactions: %v", err)
+	}
+
+	repo := NewPostgresPersistence(db)
+	txs, err := repo.ListTransactions(context.Background(), userID, 10, 0)
+	if err != nil {
+		t.Fatalf("list transactions: %v", err)
+	}
+	if len(txs) != 2 {
+		t.Fatalf("expected 2 transactions, got %d", len(txs))
+	}
+	if txs[0].ProviderName != "Water Co" || txs[1].ProviderName != "" {
+		t.Fatalf("unexpected provider names: %q, %q", txs[0].ProviderName, txs[1].ProviderName)
+	}
+}
+
+func TestRecordGatewayEventDeduplicates(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&GatewayEventModel{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	repo := NewPostgresPersistence(db)
+	ctx := context.Background()
+	txID := uuid.New()
+	recorded, err := repo.RecordGatewayEvent(ctx, "evt-1", txID, "approved", time.Now())
+	if err != nil || !recorded {
+		t.Fatalf("expected event recorded, got %v %v", recorded, err)
+	}
+	recorded, err = repo.RecordGatewayEvent(ctx, "evt-1", txID, "approved", time.Now())
+	if err != nil || recorded {
+		t.Fatalf("expected duplicate event ignored, got %v %v", recorded, err)
+	}
+
+	if err := repo.DeleteGatewayEvent(ctx, "evt-1"); err != nil {
+		t.Fatalf("delete event: %v", err)
+	}
+	recorded, err = repo.RecordGatewayEvent(ctx, "evt-1", txID, "approved", time.Now())
+	if err != nil || !recorded {
+		t.Fatalf("expected released event recorded again, got %v %v", recorded, err)
+	}
+}
+
+func TestWebhookSubscriptionHealthDoesNotReenable(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&WebhookSubscriptionModel{}, &WebhookDeliveryModel{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	repo := NewPostgresPersistence(db)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	sub, err := domainwebhook.NewSubscription(domainwebhook.Attributes{URL: "https://merchant.example/hook", EventTypes: []string{"payment.*"}, Enabled: true}, "secret", now)
+	if err != nil {
+		t.Fatalf("new subscription: %v", err)
+	}
+	if err := repo.CreateWebhookSubscription(ctx, sub); err != nil {
+		t.Fatalf("create subscription: %v", err)
+	}
+
+	// The worker records a failure after an admin disabled the subscription.
+	sub.Enabled = false
+	if err := repo.UpdateWebhookSubscription(ctx, sub); err != nil {
+		t.Fatalf("update subscription: %v", err)
+	}
+	if err := repo.RecordWebhookFailure(ctx, sub.ID, 0, now); err != nil {
+		t.Fatalf("record failure: %v", err)
+	}
+
+	stored, err := repo.GetWebhookSubscription(ctx, sub.ID)
+	if err != nil {
+		t.Fatalf("get subscription: %v", err)
+	}
+	if stored.Enabled || stored.ConsecutiveFailures != 1 || stored.EventTypes[0] != "payment.*" || stored.Secret != "secret" {
+		t.Fatalf("unexpected subscription %+v", stored)
+	}
+	enabled, err := repo.ListEnabledWebhookSubscriptions(ctx)
+	if err != nil || len(enabled) != 0 {
+		t.Fatalf("expected no enabled subscriptions, got %d %v", len(enabled), err)
+	}
+
+	eventID := uuid.New()
+	replay := &domainwebhook.Delivery{ID: uuid.New(), SubscriptionID: sub.ID, EventID: eventID, EventType: "payment.completed", Attempt: 1, Status: domainwebhook.DeliverySucceeded, Replay: true, CreatedAt: now}
+	if err := repo.CreateWebhookDelivery(ctx, replay); err != nil {
+		t.Fatalf("create delivery: %v", err)
+	}
+	delivered, err := repo.HasSuccessfulWebhookDelivery(ctx, sub.ID, eventID)
+	if err != nil || delivered {
+		t.Fatalf("expected replays to be ignored, got %v %v", delivered, err)
+	}
+}
+
+func TestRecordWebhookFailureCountsInSQLAndDisables(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&WebhookSubscriptionModel{}, &WebhookDeliveryModel{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	repo := NewPostgresPersistence(db)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	sub, err := domainwebhook.NewSubscription(domainwebhook.Attributes{URL: "https://merchant.example/hook", Enabled: true}, "secret", now)
+	if err != nil {
+		t.Fatalf("new subscription: %v", err)
+	}
+	if err := repo.CreateWebhookSubscription(ctx, sub); err != nil {
+		t.Fatalf("create subscription: %v", err)
+	}
+
+	// Two workers recording from the same stale copy must both count.
+	for i := 0; i < 2; i++ {
+		if err := repo.RecordWebhookFailure(ctx, sub.ID, 3, now); err != nil {
+			t.Fatalf("record failure: %v", err)
+		}
+	}
+	stored, err := repo.GetWebhookSubscription(ctx, sub.ID)
+	if err != nil || !stored.Enabled || stored.ConsecutiveFailures != 2 || stored.DisabledAt != nil {
+		t.Fatalf("expected 2 failures and still enabled, got %+v %v", stored, err)
+	}
+
+	if err := repo.RecordWebhookFailure(ctx, sub.ID, 3, now); err != nil {
+		t.Fatalf("record failure: %v", err)
+	}
+	stored, err = repo.GetWebhookSubscription(ctx, sub.ID)
+	if err != nil || stored.Enabled || stored.ConsecutiveFailures != 3 || stored.DisabledAt == nil {
+		t.Fatalf("expected disabled after 3 failures, got %+v %v", stored, err)
+	}
+
+	if err := repo.ResetWebhookFailures(ctx, sub.ID, now); err != nil {
+		t.Fatalf("reset failures: %v", err)
+	}
+	stored, err = repo.GetWebhookSubscription(ctx, sub.ID)
+	if err != nil || stored.Enabled || stored.ConsecutiveFailures != 0 {
+		t.Fatalf("expected reset to keep the subscription disabled, got %+v %v", stored, err)
+	}
+}
+
+func TestClaimDueWebhookRetries(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&WebhookSubscriptionModel{}, &WebhookDeliveryModel{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	repo := NewPostgresPersistence(db)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	due := now.Add(-time.Second)
+	later := now.Add(time.Minute)
+	subID := uuid.New()
+	deliveries := []*domainwebhook.Delivery{
+		{ID: uuid.New(), SubscriptionID: subID, EventID: uuid.New(), Attempt: 1, Status: domainwebhook.DeliveryFailed, NextAttemptAt: &due, CreatedAt: now},
+		{ID: uuid.New(), SubscriptionID: subID, EventID: uuid.New(), Attempt: 1, Status: domainwebhook.DeliveryFailed, NextAttemptAt: &later, CreatedAt: now},
+		{ID: uuid.New(), SubscriptionID: subID, EventID: uuid.New(), Attempt: 3, Status: domainwebhook.DeliveryFailed, CreatedAt: now},
+	}
+	for _, d := range deliveries {
+		if err := repo.CreateWebhookDelivery(ctx, d); err != nil {
+			t.Fatalf("create delivery: %v", err)
+		}
+	}
+
+	claimed, err := repo.ClaimDueWebhookRetries(ctx, now, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if len(claimed) != 1 || claimed[0].ID != deliveries[0].ID {
+		t.Fatalf("expected only the due retry, got %+v", claimed)
+	}
+	// The lease hides the claimed retry from other workers until it expires.
+	if again, err := repo.ClaimDueWebhookRetries(ctx, now, 10, time.Minute); err != nil || len(again) != 0 {
+		t.Fatalf("expected leased retry to stay claimed, got %d %v", len(again), err)
+	}
+
+	if err := repo.CompleteWebhookRetry(ctx, deliveries[0].ID); err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+	if again, err := repo.ClaimDueWebhookRetries(ctx, now.Add(2*time.Minute), 10, time.Minute); err != nil || len(again) != 1 || again[0].ID != deliveries[1].ID {
+		t.Fatalf("expected only the later retry once due, got %+v %v", again, err)
+	}
+}
+
+func TestCreateEventAssignsSequencePerAggregate(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&OutboxModel{}, &OutboxArchiveModel{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	repo := NewPostgresPersistence(db)
+	ctx := context.Background()
+	paymentA, paymentB := uuid.New(), uuid.New()
+	base := time.Now()
+	created := []*appoutbox.OutboxEvent{
+		{ID: uuid.New(), EventType: "payment.created", Payload: `{}`, AggregateID: paymentA, CreatedAt: base},
+		{ID: uuid.New(), EventType: "payment.created", Payload: `{}`, AggregateID: paymentB, CreatedAt: base},
+		{ID: uuid.New(), EventType: "payment.completed", Payload: `{}`, AggregateID: paymentA, CreatedAt: base},
+		{ID: uuid.New(), EventType: "legacy", Payload: `{}`, CreatedAt: base},
+	}
+	for _, ev := range created {
+		if err := repo.CreateEvent(ctx, ev); err != nil {
+			t.Fatalf("create event: %v", err)
+		}
+	}
+	if created[0].Sequence != 1 || created[1].Sequence != 1 || created[2].Sequence != 2 || created[3].Sequence != 0 {
+		t.Fatalf("unexpected sequences %d %d %d %d", created[0].Sequence, created[1].Sequence, created[2].Sequence, created[3].Sequence)
+	}
+
+	// A writer that raced on the same MAX is rejected by the unique index.
+	aggregateID := paymentA.String()
+	dup := OutboxModel{ID: uuid.NewString(), EventType: "payment.failed", Payload: `{}`, AggregateID: &aggregateID, Sequence: 2, CreatedAt: base}
+	if err := db.Create(&dup).Error; err == nil {
+		t.Fatalf("expected duplicate aggregate sequence to be rejected")
+	}
+
+	pending, err := repo.GetPendingEvents(ctx, 10)
+	if err != nil {
+		t.Fatalf("pending events: %v", err)
+	}
+	if len(pending) != 4 {
+		t.Fatalf("expected 4 pending events, got %d", len(pending))
+	}
+	var seqA []int64
+	for _, ev := range pending {
+		if ev.AggregateID == paymentA {
+			seqA = append(seqA, ev.Sequence)
+		}
+	}
+	if len(seqA) != 2 || seqA[0] != 1 || seqA[1] != 2 {
+		t.Fatalf("expected aggregate events in sequence order, got %v", seqA)
+	}
+}
+
+func TestCreateEventRetriesSequenceTakenConcurrently(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&OutboxModel{}, &OutboxArchiveModel{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	repo := NewPostgresPersistence(db)
+	ctx := context.Background()
+	paymentID := uuid.New()
+	aggregateID := paymentID.String()
+	// A concurrent writer takes the sequence this one just read: the first insert hits the unique index.
+	// The conflicting row lives in the same savepoint (SQLite has a single writer), so it rolls back too.
+	inserts := 0
+	if err := db.Callback().Create().Before("gorm:create").Register("test:race", func(tx *gorm.DB) {
+		inserts++
+		if inserts > 1 {
+			return
+		}
+		tx.Session(&gorm.Session{NewDB: true}).Exec("INSERT INTO outbox (id, event_type, payload, aggregate_id, sequence, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+			uuid.NewString(), "payment.created", `{}`, aggregateID, tx.Statement.Dest.(*OutboxModel).Sequence, time.Now())
+	}); err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+
+	ev := &appoutbox.OutboxEvent{ID: uuid.New(), EventType: "payment.completed", Payload: `{}`, AggregateID: paymentID, CreatedAt: time.Now()}
+	if err := repo.CreateEvent(ctx, ev); err != nil {
+		t.Fatalf("create event: %v", err)
+	}
+	if inserts != 2 || ev.Sequence != 1 {
+		t.Fatalf("expected one retry after the unique violation, got %d inserts and sequence %d", inserts, ev.Sequence)
+	}
+	var count int64
+	if err := db.Model(&OutboxModel{}).Where("aggregate_id = ?", aggregateID).Count(&count).Error; err != nil || count != 1 {
+		t.Fatalf("expected only the retried event stored, got %d %v", count, err)
+	}
+}
+
+func TestClaimPendingEventsLeaseExpiryAndRelease(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&OutboxModel{}, &OutboxArchiveModel{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	repo := NewPostgresPersistence(db)
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	payment := uuid.New()
+	first := &appoutbox.OutboxEvent{ID: uuid.New(), EventType: "payment.created", Payload: `{}`, AggregateID: payment, CreatedAt: now}
+	second := &appoutbox.OutboxEvent{ID: uuid.New(), EventType: "payment.completed", Payload: `{}`, AggregateID: payment, CreatedAt: now.Add(time.Second)}
+	for _, ev := range []*appoutbox.OutboxEvent{first, second} {
+		if err := repo.CreateEvent(ctx, ev); err != nil {
+			t.Fatalf("create event: %v", err)
+		}
+	}
+
+	// The crashed relay only got the head of the aggregate (batch of one).
+	claimed, err := repo.ClaimPendingEvents(ctx, "crashed", now, 1, time.Minute)
+	if err != nil || len(claimed) != 1 || claimed[0].ID != first.ID {
+		t.Fatalf("expected the first event to be claimed, got %v %v", claimed, err)
+	}
+
+	// While the lease holds, the later event must not be claimed by another relay.
+	claimed, err = repo.ClaimPendingEvents(ctx, "relay-b", now.Add(30*time.Second), 10, time.Minute)
+	if err != nil || len(claimed) != 0 {
+		t.Fatalf("expected nothing claimable during the lease, got %d %v", len(claimed), err)
+	}
+
+	// After expiry the whole aggregate is claimable again, in order.
+	claimed, err = repo.ClaimPendingEvents(ctx, "relay-b", now.Add(2*time.Minute), 10, time.Minute)
+	if err != nil || len(claimed) != 2 || claimed[0].ID != first.ID || claimed[1].ID != second.ID {
+		t.Fatalf("expected both events after lease expiry, got %v %v", claimed, err)
+	}
+
+	// Releasing only affects the caller's own claims.
+	if err := repo.ReleaseEvents(ctx, "crashed", []uuid.UUID{first.ID, second.ID}); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if claimed, _ := repo.ClaimPendingEvents(ctx, "relay-c", now.Add(2*time.Minute), 10, time.Minute); len(claimed) != 0 {
+		t.Fatalf("expected claims of relay-b to survive a foreign release, got %d", len(claimed))
+	}
+	if err := repo.ReleaseEvents(ctx, "relay-b", []uuid.UUID{first.ID, second.ID}); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if claimed, _ := repo.ClaimPendingEvents(ctx, "relay-c", now.Add(2*time.Minute), 10, time.Minute); len(claimed) != 2 {
+		t.Fatalf("expected released events to be claimable, got %d", len(claimed))
+	}
+}
+
+func TestRecordEventFailureBackoffAndDeadLetter(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&OutboxModel{}, &OutboxArchiveModel{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	repo := NewPostgresPersistence(db)
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	payment := uuid.New()
+	first := &appoutbox.OutboxEvent{ID: uuid.New(), EventType: "payment.created", Payload: `{}`, AggregateID: payment, CreatedAt: now}
+	second := &appoutbox.OutboxEvent{ID: uuid.New(), EventType: "payment.completed", Payload: `{}`, AggregateID: payment, CreatedAt: now.Add(time.Second)}
+	for _, ev := range []*appoutbox.OutboxEvent{first, second} {
+		if err := repo.CreateEvent(ctx, ev); err != nil {
+			t.Fatalf("create event: %v", err)
+		}
+	}
+
+	claimed, err := repo.ClaimPendingEvents(ctx, "relay-a", now, 1, time.Minute)
+	if err != nil || len(claimed) != 1 {
+		t.Fatalf("claim: %v %v", claimed, err)
+	}
+	first.RecordFailure(errors.New("broker unavailable"), now, 2, time.Minute)
+	if err := repo.RecordEventFailure(ctx, first); err != nil {
+		t.Fatalf("record failure: %v", err)
+	}
+
+	// The failed event releases its lease but still blocks its aggregate until the next attempt.
+	if claimed, _ := repo.ClaimPendingEvents(ctx, "relay-b", now.Add(30*time.Second), 10, time.Minute); len(claimed) != 0 {
+		t.Fatalf("expected nothing claimable before the next attempt, got %d", len(claimed))
+	}
+	claimed, err = repo.ClaimPendingEvents(ctx, "relay-b", now.Add(time.Minute), 1, time.Minute)
+	if err != nil || len(claimed) != 1 || claimed[0].ID != first.ID || claimed[0].Attempts != 1 || claimed[0].LastError != "broker unavailable" {
+		t.Fatalf("expected the failed event to be retried, got %+v %v", claimed, err)
+	}
+
+	first.RecordFailure(errors.New("broker unavailable"), now.Add(time.Minute), 2, time.Minute)
+	if err := repo.RecordEventFailure(ctx, first); err != nil {
+		t.Fatalf("record failure: %v", err)
+	}
+	dead, total, err := repo.ListDeadLetterEvents(ctx, 10, 0)
+	if err != nil || total != 1 || len(dead) != 1 || dead[0].ID != first.ID || dead[0].Status() != appoutbox.StatusDeadLetter {
+		t.Fatalf("expected the event to be dead-lettered, got %+v %d %v", dead, total, err)
+	}
+
+	// Dead letters no longer hold back the aggregate.
+	claimed, err = repo.ClaimPendingEvents(ctx, "relay-b", now.Add(time.Hour), 10, time.Minute)
+	if err != nil || len(claimed) != 1 || claimed[0].ID != second.ID {
+		t.Fatalf("expected the next event of the aggregate to be claimable, got %+v %v", claimed, err)
+	}
+
+	if err := repo.RequeueDeadLetterEvent(ctx, first.ID); err != nil {
+		t.Fatalf("requeue: %v", err)
+	}
+	requeued, err := repo.GetEventByID(ctx, first.ID)
+	if err != nil || requeued.Status() != appoutbox.StatusPending || requeued.Attempts != 0 || requeued.LastError == "" {
+		t.Fatalf("expected a pending event that keeps its last error, got %+v %v", requeued, err)
+	}
+	if err := repo.RequeueDeadLetterEvent(ctx, first.ID); err == nil {
+		t.Fatalf("expected requeue of a pending event to fail")
+	}
+}
+
+func TestArchiveSentEventsKeepsSequenceAndLookup(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&OutboxModel{}, &OutboxArchiveModel{}, &OutboxPurgeHorizonModel{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	repo := NewPostgresPersistence(db)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	payment := uuid.New()
+	old := &appoutbox.OutboxEvent{ID: uuid.New(), EventType: "payment.created", Payload: `{}`, AggregateID: payment, CreatedAt: now.Add(-10 * 24 * time.Hour)}
+	pending := &appoutbox.OutboxEvent{ID: uuid.New(), EventType: "schedule.failed", Payload: `{}`, CreatedAt: now.Add(-10 * 24 * time.Hour)}
+	for _, ev := range []*appoutbox.OutboxEvent{old, pending} {
+		if err := repo.CreateEvent(ctx, ev); err != nil {
+			t.Fatalf("create event: %v", err)
+		}
+	}
+	if err := db.Model(&OutboxModel{}).Where("id = ?", old.ID.String()).Update("sent_at", now.Add(-9*24*time.Hour)).Error; err != nil {
+		t.Fatalf("mark sent: %v", err)
+	}
+
+	moved, err := repo.ArchiveSentEvents(ctx, now.Add(-7*24*time.Hour), 10)
+	if err != nil || moved != 1 {
+		t.Fatalf("expected one archived event, got %d %v", moved, err)
+	}
+	var remaining int64
+	db.Model(&OutboxModel{}).Count(&remaining)
+	if remaining != 1 {
+		t.Fatalf("expected only the pending event to stay in outbox, got %d", remaining)
+	}
+
+	// Archived events are still found by ID and the aggregate keeps counting after them.
+	got, err := repo.GetEventByID(ctx, old.ID)
+	if err != nil || got.Status() != appoutbox.StatusSent || got.AggregateID != payment || got.Sequence != 1 {
+		t.Fatalf("expected archived event, got %+v %v", got, err)
+	}
+	next := &appoutbox.OutboxEvent{ID: uuid.New(), EventType: "payment.completed", Payload: `{}`, AggregateID: payment, CreatedAt: now}
+	if err := repo.CreateEvent(ctx, next); err != nil || next.Sequence != 2 {
+		t.Fatalf("expected sequence 2 after the archived event, got %d %v", next.Sequence, err)
+	}
+
+	purged, err := repo.PurgeArchivedEvents(ctx, now.Add(-30*24*time.Hour), 10)
+	if err != nil || purged != 0 {
+		t.Fatalf("expected nothing to purge within the archive window, got %d %v", purged, err)
+	}
+	if horizon, err := repo.HistoryHorizon(ctx); err != nil || !horizon.IsZero() {
+		t.Fatalf("expected no purge horizon before a purge, got %+v %v", horizon, err)
+	}
+	purged, err = repo.PurgeArchivedEvents(ctx, now, 10)
+	if err != nil || purged != 1 {
+		t.Fatalf("expected the archived event to be purged, got %d %v", purged, err)
+	}
+	if _, err := repo.GetEventByID(ctx, old.ID); err == nil {
+		t.Fatalf("expected purged event to be gone")
+	}
+	// Projections can no longer be rebuilt from before the purged event.
+	horizon, err := repo.HistoryHorizon(ctx)
+	if err != nil || horizon.EventID != old.ID.String() || !horizon.CreatedAt.Equal(old.CreatedAt) {
+		t.Fatalf("expected the purged event as horizon, got %+v %v", horizon, err)
+	}
+}
+
+func TestAddHourlyAccumulatesPerSeries(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&PaymentMetricsHourlyModel{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	repo := NewPostgresPersistence(db)
+	ctx := context.Background()
+
+	hour := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	key := metrics.Key{Status: "APPROVED", Currency: "USD", ProviderID: uuid.New()}
+	for _, delta := range []metrics.HourlyRollup{
+		{BucketStart: hour, Key: key, Totals: metrics.Totals{Count: 1, Amount: 1500, Fee: 45}},
+		{BucketStart: hour, Key: key, Totals: metrics.Totals{Count: 1, Amount: 500, Fee: 15}},
+		{BucketStart: hour.Add(time.Hour), Key: key, Totals: metrics.Totals{Count: 1, Amount: 100}},
+	} {
+		if err := repo.AddHourly(ctx, delta); err != nil {
+			t.Fatalf("add hourly: %v", err)
+		}
+	}
+
+	rollups, err := repo.ListHourly(ctx, hour, hour.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("list hourly: %v", err)
+	}
+	if len(rollups) != 1 {
+		t.Fatalf("expected 1 rollup in range, got %d", len(rollups))
+	}
+	got := rollups[0]
+	if !got.BucketStart.Equal(hour) || got.Key != key || got.Totals != (metrics.Totals{Count: 2, Amount: 2000, Fee: 60}) {
+		t.Fatalf("unexpected rollup %+v", got)
+	}
+}
+
+func TestAuditEntriesRoundTripAndRejectForks(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&AuditLogModel{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	repo := NewPostgresPersistence(db)
+	ctx := context.Background()
+
+	entry := &audit.Entry{
+		EventID:       uuid.New(),
+		EventType:     "payment.completed",
+		AggregateType: "payment",
+		AggregateID:   uuid.New(),
+		OccurredAt:    time.Date(2024, 3, 1, 12, 30, 0, 123456000, time.UTC),
+		RecordedAt:    time.Date(2024, 3, 1, 12, 30, 1, 0, time.UTC),
+		Payload:       `{"b":1, "a":2}`,
+		PrevHash:      audit.GenesisHash,
+	}
+	entry.Hash = audit.ComputeHash(entry)
+	if err := repo.InsertAuditEntry(ctx, entry); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if entry.Seq == 0 {
+		t.Fatalf("expected seq to be assigned")
+	}
+
+	// A second writer that read the same head cannot extend it again.
+	fork := *entry
+	fork.EventID = uuid.New()
+	fork.Hash = audit.ComputeHash(&fork)
+	if err := repo.InsertAuditEntry(ctx, &fork); err == nil {
+		t.Fatalf("expected fork on the same prev_hash to be rejected")
+	}
+
+	last, err := repo.LastAuditEntry(ctx)
+	if err != nil || last == nil {
+		t.Fatalf("last entry: %v %v", last, err)
+	}
+	if audit.ComputeHash(last) != entry.Hash {
+		t.Fatalf("hash changed after round trip: %+v", last)
+	}
+	if _, err := repo.GetAuditEntryByEventID(ctx, uuid.New()); err == nil {
+		t.Fatalf("expected not found")
+	} else if domErr, ok := err.(domainerrors.Error); !ok || domErr.Code != domainerrors.CodeNotFound {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
+
+type fixedClock struct{ now time.Time }
+
+func (c fixedClock) Now() time.Time { return c.now }
+
+func TestDailySpendProjectionRebuildsFromOutboxHistory(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&OutboxModel{}, &OutboxArchiveModel{}, &OutboxPurgeHorizonModel{}, &ProjectionCheckpointModel{}, &UserDailySpendModel{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	repo := NewPostgresPersistence(db)
+	ctx := context.Background()
+
+	userID := uuid.New()
+	day1 := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+	create := func(at time.Time, ev events.Event) *appoutbox.OutboxEvent {
+		t.Helper()
+		event, err := events.NewOutboxEvent(ctx, uuid.New(), at, ev)
+		if err != nil {
+			t.Fatalf("outbox event: %v", err)
+		}
+		if err := repo.CreateEvent(ctx, event); err != nil {
+			t.Fatalf("create event: %v", err)
+		}
+		return event
+	}
+	payment := func(amount, fee int64) events.Payment {
+		return events.Payment{TransactionID: uuid.New(), UserID: userID, Amount: amount, Fee: fee, Currency: "USD"}
+	}
+	create(day1.Add(time.Hour), events.PaymentCreated{Payment: payment(1000, 30)})
+	archived := create(day1.Add(2*time.Hour), events.PaymentCompleted{Payment: payment(1000, 30)})
+	create(day1.Add(3*time.Hour), events.PaymentFailed{Payment: payment(500, 0), Reason: "declined"})
+	create(day2.Add(time.Hour), events.PaymentCompleted{Payment: payment(200, 5)})
+	create(day2.Add(2*time.Hour), events.PaymentCompleted{Payment: payment(300, 5)})
+
+	// One event already moved to the archive must still be part of the history.
+	if err := db.Model(&OutboxModel{}).Where("id = ?", archived.ID.String()).Update("sent_at", day1).Error; err != nil {
+		t.Fatalf("mark sent: %v", err)
+	}
+	if n, err := repo.ArchiveSentEvents(ctx, time.Now(), 10); err != nil || n != 1 {
+		t.Fatalf("archive: %d %v", n, err)
+	}
+
+	clock := fixedClock{now: day2.Add(12 * time.Hour)}
+	projection := projections.NewDailySpendProjection(repo, clock)
+	runner := projections.NewRunner(repo, repo, clock, projections.Config{BatchSize: 2}, projection)
+
+	want := []projections.DailySpend{
+		{UserID: userID, Day: day1, Currency: "USD", Payments: 1, Amount: 1000, Fee: 30, FailedPayments: 1},
+		{UserID: userID, Day: day2, Currency: "USD", Payments: 2, Amount: 500, Fee: 10},
+	}
+	check := func(step string) {
+		t.Helper()
+		got, err := repo.ListDailySpend(ctx, userID, day1, day2)
+		if err != nil {
+			t.Fatalf("%s: list: %v", step, err)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("%s: expected %d days, got %+v", step, len(want), got)
+		}
+		for i := range want {
+			if !got[i].Day.Equal(want[i].Day) || got[i].Payments != want[i].Payments || got[i].Amount != want[i].Amount ||
+				got[i].Fee != want[i].Fee || got[i].FailedPayments != want[i].FailedPayments {
+				t.Fatalf("%s: day %d: expected %+v, got %+v", step, i, want[i], got[i])
+			}
+		}
+	}
+
+	results, err := runner.RunOnce(ctx)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if results[0].Applied != 5 {
+		t.Fatalf("expected 5 events applied, got %+v", results[0])
+	}
+	check("catch up")
+
+	// Nothing new: a second pass is a no-op.
+	if results, err = runner.RunOnce(ctx); err != nil || results[0].Applied != 0 {
+		t.Fatalf("second run: %+v %v", results, err)
+	}
+	check("idle")
+
+	// Replaying from day 2 rebuilds that day without double counting day 1.
+	res, err := runner.Replay(ctx, projections.DailySpendName, day2.Add(5*time.Hour))
+	if err != nil || res.Applied != 2 {
+		t.Fatalf("replay from day 2: %+v %v", res, err)
+	}
+	check("replay from day 2")
+
+	if res, err = runner.Replay(ctx, projections.DailySpendName, time.Time{}); err != nil || res.Applied != 5 {
+		t.Fatalf("full replay: %+v %v", res, err)
+	}
+	check("full replay")
+
+	// A runner holding a stale checkpoint does not apply its batch.
+	ok, err := repo.ApplyDailySpend(ctx, projections.DailySpendName, []projections.DailySpend{{UserID: userID, Day: day1, Currency: "USD", Payments: 1}}, projections.Position{}, projections.Position{CreatedAt: day1})
+	if err != nil || ok {
+		t.Fatalf("expected stale apply to be rejected, got %v %v", ok, err)
+	}
+	check("stale apply")
+}
+
+func TestProcessMessageCommitsEffectsWithTheInboxRow(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&ProcessedMessageModel{}, &PaymentMetricsHourlyModel{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	repo := NewPostgresPersistence(db)
+	ctx := context.Background()
+
+	hour := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	delta := metrics.HourlyRollup{BucketStart: hour, Key: metrics.Key{Status: "APPROVED", Currency: "USD"}, Totals: metrics.Totals{Count: 1, Amount: 1500}}
+	msg := inbox.ProcessedMessage{Consumer: "metrics.queue", MessageID: uuid.NewString(), EventType: "payment.completed", ProcessedAt: hour}
+	apply := func(fail bool) (bool, error) {
+		return repo.ProcessMessage(ctx, msg, func(ctx context.Context, tx *PostgresPersistence) error {
+			if err := tx.AddHourly(ctx, delta); err != nil {
+				return err
+			}
+			if fail {
+				return errors.New("handler failed")
+			}
+			return nil
+		})
+	}
+	count := func() int64 {
+		rollups, err := repo.ListHourly(ctx, hour, hour.Add(time.Hour))
+		if err != nil {
+			t.Fatalf("list hourly: %v", err)
+		}
+		if len(rollups) == 0 {
+			return 0
+		}
+		return rollups[0].Count
+	}
+
+	if processed, err := apply(true); err == nil || processed {
+		t.Fatalf("expected the failing handler to roll back, got %v %v", processed, err)
+	}
+	if count() != 0 {
+		t.Fatalf("expected no rollup after rollback")
+	}
+	for i, want := range []bool{true, false} {
+		processed, err := apply(false)
+		if err != nil || processed != want {
+			t.Fatalf("delivery %d: expected processed=%v, got %v %v", i, want, processed, err)
+		}
+	}
+	if count() != 1 {
+		t.Fatalf("expected the duplicate to be skipped, count %d", count())
+	}
+
+	purged, err := repo.PurgeProcessedMessages(ctx, "metrics.queue", hour.Add(time.Second), 10)
+	if err != nil || purged != 1 {
+		t.Fatalf("expected 1 purged message, got %d %v", purged, err)
+	}
+}
+
+func TestNotificationPreferencesUpsertKeepsCreatedAt(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&NotificationPreferenceModel{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	repo := NewPostgresPersistence(db)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	_, err = repo.GetNotificationPreferences(ctx, userID)
+	if domErr, ok := err.(domainerrors.Error); !ok || domErr.Code != domainerrors.CodeNotFound {
+		t.Fatalf("expected not found, got %v", err)
+	}
+
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	prefs := &notifications.Preferences{UserID: userID, Email: "ana@example.com", Locale: "es", PaymentCompleted: true, PaymentFailed: true, RefundCreated: true, CreatedAt: created, UpdatedAt: created}
+	if err := repo.SaveNotificationPreferences(ctx, prefs); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	// created_at is kept by the upsert even if the caller sends another value.
+	updated := *prefs
+	updated.Locale, updated.RefundCreated = "en", false
+	updated.CreatedAt, updated.UpdatedAt = created.Add(time.Hour), created.Add(time.Hour)
+	if err := repo.SaveNotificationPreferences(ctx, &updated); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	got, err := repo.GetNotificationPreferences(ctx, userID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.Email != "ana@example.com" || got.Locale != "en" || got.RefundCreated || !got.PaymentCompleted || !got.CreatedAt.Equal(created) || !got.UpdatedAt.Equal(created.Add(time.Hour)) {
+		t.Fatalf("unexpected preferences %+v", got)
+	}
+}
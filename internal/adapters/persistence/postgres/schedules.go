@@ -0,0 +1,173 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"draftea-challenge/internal/application/schedules"
+	domainerrors "draftea-challenge/internal/domain/errors"
+	domainschedule "draftea-challenge/internal/domain/schedule"
+)
+
+// ScheduleRepository
+func (p *PostgresPersistence) CreateSchedule(ctx context.Context, s *domainschedule.Schedule) error {
+	var walletRow WalletModel
+	if err := p.conn(ctx).Where("user_id = ?", s.UserID.String()).First(&walletRow).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domainerrors.NewNotFoundError("wallet not found")
+		}
+		return err
+	}
+	m := toScheduleModel(s)
+	m.WalletID = walletRow.ID
+	return p.conn(ctx).Create(&m).Error
+}
+
+func (p *PostgresPersistence) GetSchedule(ctx context.Context, userID, scheduleID uuid.UUID) (*domainschedule.Schedule, error) {
+	var m ScheduleModel
+	if err := p.conn(ctx).Where("id = ? AND user_id = ?", scheduleID.String(), userID.String()).First(&m).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domainerrors.NewNotFoundError("schedule not found")
+		}
+		return nil, err
+	}
+	return fromScheduleModel(m), nil
+}
+
+func (p *PostgresPersistence) ListSchedules(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*domainschedule.Schedule, int, error) {
+	var total int64
+	query := p.conn(ctx).Model(&ScheduleModel{}).Where("user_id = ?", userID.String())
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var rows []ScheduleModel
+	if err := query.Order("created_at desc").Limit(limit).Offset(offset).Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	out := make([]*domainschedule.Schedule, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, fromScheduleModel(r))
+	}
+	return out, int(total), nil
+}
+
+func (p *PostgresPersistence) UpdateSchedule(ctx context.Context, s *domainschedule.Schedule) error {
+	m := toScheduleModel(s)
+	return p.conn(ctx).Model(&ScheduleModel{}).Where("id = ?", m.ID).Updates(map[string]interface{}{
+		"external_reference": m.ExternalReference,
+		"amount":             m.Amount,
+		"cron_expr":          m.CronExpr,
+		"interval_seconds":   m.IntervalSeconds,
+		"end_date":           m.EndDate,
+		"next_run_at":        m.NextRunAt,
+		"last_run_at":        m.LastRunAt,
+		"last_status":        m.LastStatus,
+		"status":             m.Status,
+		"updated_at":         time.Now(),
+	}).Error
+}
+
+// AdvanceSchedule records a run of occurrence and releases its lease. The row is re-read under a
+// row lock and advanced from what is stored, so a rule or end_date edited during the run is the one
+// the next occurrence and the completion check use. A schedule cancelled or paused meanwhile keeps
+// its state and only loses the lease.
+func (p *PostgresPersistence) AdvanceSchedule(ctx context.Context, scheduleID uuid.UUID, occurrence, now time.Time, lastStatus string) error {
+	return p.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		var m ScheduleModel
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", scheduleID.String()).First(&m).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return domainerrors.NewNotFoundError("schedule not found")
+			}
+			return err
+		}
+		updates := map[string]interface{}{"locked_until": nil}
+		if m.Status == string(domainschedule.StatusActive) {
+			s := fromScheduleModel(m)
+			s.Advance(occurrence, now, lastStatus)
+			updates["next_run_at"] = s.NextRunAt
+			updates["last_run_at"] = s.LastRunAt
+			updates["last_status"] = s.LastStatus
+			updates["status"] = string(s.Status)
+			updates["updated_at"] = time.Now()
+		}
+		return tx.Model(&ScheduleModel{}).Where("id = ?", m.ID).Updates(updates).Error
+	})
+}
+
+func (p *PostgresPersistence) ClaimDueSchedules(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*domainschedule.Schedule, error) {
+	var rows []ScheduleModel
+	err := p.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		// SKIP LOCKED lets several scheduler replicas claim disjoint batches.
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_run_at <= ? AND (locked_until IS NULL OR locked_until < ?)",
+				string(domainschedule.StatusActive), now, now).
+			Order("next_run_at").
+			Limit(limit).
+			Find(&rows).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		ids := make([]string, 0, len(rows))
+		for _, r := range rows {
+			ids = append(ids, r.ID)
+		}
+		return tx.Model(&ScheduleModel{}).Where("id IN ?", ids).Update("locked_until", now.Add(lease)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*domainschedule.Schedule, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, fromScheduleModel(r))
+	}
+	return out, nil
+}
+
+func toScheduleModel(s *domainschedule.Schedule) ScheduleModel {
+	return ScheduleModel{
+		ID:                s.ID.String(),
+		UserID:            s.UserID.String(),
+		ProviderID:        s.ProviderID.String(),
+		ExternalReference: s.ExternalReference,
+		Amount:            s.Amount,
+		Currency:          s.Currency,
+		CronExpr:          s.CronExpr,
+		IntervalSeconds:   int64(s.Interval / time.Second),
+		EndDate:           s.EndDate,
+		NextRunAt:         s.NextRunAt,
+		LastRunAt:         s.LastRunAt,
+		LastStatus:        s.LastStatus,
+		Status:            string(s.Status),
+		CreatedAt:         s.CreatedAt,
+		UpdatedAt:         s.UpdatedAt,
+	}
+}
+
+func fromScheduleModel(m ScheduleModel) *domainschedule.Schedule {
+	return &domainschedule.Schedule{
+		ID:                uuid.MustParse(m.ID),
+		UserID:            uuid.MustParse(m.UserID),
+		ProviderID:        uuid.MustParse(m.ProviderID),
+		ExternalReference: m.ExternalReference,
+		Amount:            m.Amount,
+		Currency:          m.Currency,
+		CronExpr:          m.CronExpr,
+		Interval:          time.Duration(m.IntervalSeconds) * time.Second,
+		EndDate:           m.EndDate,
+		NextRunAt:         m.NextRunAt.UTC(),
+		LastRunAt:         m.LastRunAt,
+		LastStatus:        m.LastStatus,
+		Status:            domainschedule.Status(m.Status),
+		CreatedAt:         m.CreatedAt,
+		UpdatedAt:         m.UpdatedAt,
+	}
+}
+
+var _ schedules.ScheduleRepository = (*PostgresPersistence)(nil)
@@ -0,0 +1,266 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"draftea-challenge/internal/application/webhooks"
+	domainerrors "draftea-challenge/internal/domain/errors"
+	domainwebhook "draftea-challenge/internal/domain/webhook"
+)
+
+// SubscriptionRepository
+func (p *PostgresPersistence) CreateWebhookSubscription(ctx context.Context, s *domainwebhook.Subscription) error {
+	m, err := toWebhookSubscriptionModel(s)
+	if err != nil {
+		return err
+	}
+	return p.conn(ctx).Create(&m).Error
+}
+
+func (p *PostgresPersistence) GetWebhookSubscription(ctx context.Context, subscriptionID uuid.UUID) (*domainwebhook.Subscription, error) {
+	var m WebhookSubscriptionModel
+	if err := p.conn(ctx).Where("id = ?", subscriptionID.String()).First(&m).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domainerrors.NewNotFoundError("webhook subscription not found")
+		}
+		return nil, err
+	}
+	return fromWebhookSubscriptionModel(m)
+}
+
+func (p *PostgresPersistence) ListWebhookSubscriptions(ctx context.Context, limit, offset int) ([]*domainwebhook.Subscription, int, error) {
+	var total int64
+	if err := p.conn(ctx).Model(&WebhookSubscriptionModel{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var rows []WebhookSubscriptionModel
+	if err := p.conn(ctx).Order("created_at").Limit(limit).Offset(offset).Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	out, err := fromWebhookSubscriptionModels(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return out, int(total), nil
+}
+
+func (p *PostgresPersistence) ListEnabledWebhookSubscriptions(ctx context.Context) ([]*domainwebhook.Subscription, error) {
+	var rows []WebhookSubscriptionModel
+	if err := p.conn(ctx).Where("enabled = ?", true).Order("created_at").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return fromWebhookSubscriptionModels(rows)
+}
+
+func (p *PostgresPersistence) UpdateWebhookSubscription(ctx context.Context, s *domainwebhook.Subscription) error {
+	m, err := toWebhookSubscriptionModel(s)
+	if err != nil {
+		return err
+	}
+	res := p.conn(ctx).Model(&WebhookSubscriptionModel{}).Where("id = ?", m.ID).Updates(map[string]interface{}{
+		"url":                  m.URL,
+		"event_types":          m.EventTypes,
+		"enabled":              m.Enabled,
+		"consecutive_failures": m.ConsecutiveFailures,
+		"disabled_at":          m.DisabledAt,
+		"updated_at":           m.UpdatedAt,
+	})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return domainerrors.NewNotFoundError("webhook subscription not found")
+	}
+	return nil
+}
+
+// RecordWebhookFailure increments the failure counter in SQL so concurrent workers never lose a
+// count, and disables the subscription once it reaches disableAfter. It never re-enables one.
+func (p *PostgresPersistence) RecordWebhookFailure(ctx context.Context, subscriptionID uuid.UUID, disableAfter int, now time.Time) error {
+	updates := map[string]interface{}{
+		"consecutive_failures": gorm.Expr("consecutive_failures + 1"),
+		"updated_at":           now,
+	}
+	if disableAfter > 0 {
+		updates["enabled"] = gorm.Expr("enabled AND consecutive_failures + 1 < ?", disableAfter)
+		updates["disabled_at"] = gorm.Expr("CASE WHEN enabled AND consecutive_failures + 1 >= ? THEN ? ELSE disabled_at END", disableAfter, now)
+	}
+	return p.conn(ctx).Model(&WebhookSubscriptionModel{}).Where("id = ?", subscriptionID.String()).Updates(updates).Error
+}
+
+func (p *PostgresPersistence) ResetWebhookFailures(ctx context.Context, subscriptionID uuid.UUID, now time.Time) error {
+	return p.conn(ctx).Model(&WebhookSubscriptionModel{}).Where("id = ?", subscriptionID.String()).Updates(map[string]interface{}{
+		"consecutive_failures": 0,
+		"updated_at":           now,
+	}).Error
+}
+
+func (p *PostgresPersistence) DeleteWebhookSubscription(ctx context.Context, subscriptionID uuid.UUID) error {
+	res := p.conn(ctx).Where("id = ?", subscriptionID.String()).Delete(&WebhookSubscriptionModel{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return domainerrors.NewNotFoundError("webhook subscription not found")
+	}
+	return nil
+}
+
+// DeliveryRepository
+func (p *PostgresPersistence) CreateWebhookDelivery(ctx context.Context, d *domainwebhook.Delivery) error {
+	m := WebhookDeliveryModel{
+		ID:             d.ID.String(),
+		SubscriptionID: d.SubscriptionID.String(),
+		EventID:        d.EventID.String(),
+		EventType:      d.EventType,
+		Attempt:        d.Attempt,
+		Status:         string(d.Status),
+		ResponseStatus: d.ResponseStatus,
+		Error:          d.Error,
+		DurationMs:     d.DurationMs,
+		Replay:         d.Replay,
+		NextAttemptAt:  d.NextAttemptAt,
+		CreatedAt:      d.CreatedAt,
+	}
+	return p.conn(ctx).Create(&m).Error
+}
+
+func (p *PostgresPersistence) ListWebhookDeliveries(ctx context.Context, subscriptionID uuid.UUID, limit, offset int) ([]*domainwebhook.Delivery, int, error) {
+	q := p.conn(ctx).Model(&WebhookDeliveryModel{}).Where("subscription_id = ?", subscriptionID.String())
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var rows []WebhookDeliveryModel
+	if err := q.Order("created_at DESC").Limit(limit).Offset(offset).Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	return fromWebhookDeliveryModels(rows), int(total), nil
+}
+
+func (p *PostgresPersistence) HasSuccessfulWebhookDelivery(ctx context.Context, subscriptionID, eventID uuid.UUID) (bool, error) {
+	var count int64
+	err := p.conn(ctx).Model(&WebhookDeliveryModel{}).
+		Where("subscription_id = ? AND event_id = ? AND status = ? AND replay = ?", subscriptionID.String(), eventID.String(), string(domainwebhook.DeliverySucceeded), false).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// ClaimDueWebhookRetries returns failed deliveries whose retry is due and pushes their
+// next_attempt_at forward by lease, so a worker that dies mid-batch leaves them to be claimed again.
+func (p *PostgresPersistence) ClaimDueWebhookRetries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*domainwebhook.Delivery, error) {
+	var rows []WebhookDeliveryModel
+	err := p.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		// SKIP LOCKED lets several webhook workers claim disjoint batches.
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND replay = ? AND next_attempt_at <= ?", string(domainwebhook.DeliveryFailed), false, now).
+			Order("next_attempt_at").
+			Limit(limit).
+			Find(&rows).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		ids := make([]string, 0, len(rows))
+		for _, r := range rows {
+			ids = append(ids, r.ID)
+		}
+		return tx.Model(&WebhookDeliveryModel{}).Where("id IN ?", ids).Update("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fromWebhookDeliveryModels(rows), nil
+}
+
+// CompleteWebhookRetry clears the scheduled retry of a delivery once its next attempt was recorded.
+func (p *PostgresPersistence) CompleteWebhookRetry(ctx context.Context, deliveryID uuid.UUID) error {
+	return p.conn(ctx).Model(&WebhookDeliveryModel{}).Where("id = ?", deliveryID.String()).Update("next_attempt_at", nil).Error
+}
+
+func fromWebhookDeliveryModels(rows []WebhookDeliveryModel) []*domainwebhook.Delivery {
+	out := make([]*domainwebhook.Delivery, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, &domainwebhook.Delivery{
+			ID:             uuid.MustParse(r.ID),
+			SubscriptionID: uuid.MustParse(r.SubscriptionID),
+			EventID:        uuid.MustParse(r.EventID),
+			EventType:      r.EventType,
+			Attempt:        r.Attempt,
+			Status:         domainwebhook.DeliveryStatus(r.Status),
+			ResponseStatus: r.ResponseStatus,
+			Error:          r.Error,
+			DurationMs:     r.DurationMs,
+			Replay:         r.Replay,
+			NextAttemptAt:  r.NextAttemptAt,
+			CreatedAt:      r.CreatedAt,
+		})
+	}
+	return out
+}
+
+func toWebhookSubscriptionModel(s *domainwebhook.Subscription) (WebhookSubscriptionModel, error) {
+	eventTypes, err := json.Marshal(s.EventTypes)
+	if err != nil {
+		return WebhookSubscriptionModel{}, err
+	}
+	return WebhookSubscriptionModel{
+		ID:                  s.ID.String(),
+		URL:                 s.URL,
+		EventTypes:          string(eventTypes),
+		Secret:              s.Secret,
+		Enabled:             s.Enabled,
+		ConsecutiveFailures: s.ConsecutiveFailures,
+		DisabledAt:          s.DisabledAt,
+		CreatedAt:           s.CreatedAt,
+		UpdatedAt:           s.UpdatedAt,
+	}, nil
+}
+
+func fromWebhookSubscriptionModel(m WebhookSubscriptionModel) (*domainwebhook.Subscription, error) {
+	var eventTypes []string
+	if m.EventTypes != "" {
+		if err := json.Unmarshal([]byte(m.EventTypes), &eventTypes); err != nil {
+			return nil, err
+		}
+	}
+	return &domainwebhook.Subscription{
+		ID:                  uuid.MustParse(m.ID),
+		URL:                 m.URL,
+		EventTypes:          eventTypes,
+		Secret:              m.Secret,
+		Enabled:             m.Enabled,
+		ConsecutiveFailures: m.ConsecutiveFailures,
+		DisabledAt:          m.DisabledAt,
+		CreatedAt:           m.CreatedAt,
+		UpdatedAt:           m.UpdatedAt,
+	}, nil
+}
+
+func fromWebhookSubscriptionModels(rows []WebhookSubscriptionModel) ([]*domainwebhook.Subscription, error) {
+	out := make([]*domainwebhook.Subscription, 0, len(rows))
+	for _, r := range rows {
+		s, err := fromWebhookSubscriptionModel(r)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+var (
+	_ webhooks.SubscriptionRepository = (*PostgresPersistence)(nil)
+	_ webhooks.DeliveryRepository     = (*PostgresPersistence)(nil)
+)
@@ -0,0 +1,53 @@
+package httpsender
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"draftea-challenge/internal/application/webhooks"
+)
+
+// maxResponseBytes bounds how much of a merchant response is read before closing it.
+const maxResponseBytes = 64 << 10
+
+// Sender posts signed webhook deliveries to merchant endpoints.
+type Sender struct {
+	client *http.Client
+}
+
+// New creates a webhook sender with the given per-request timeout.
+func New(timeout time.Duration) *Sender {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &Sender{client: &http.Client{
+		Timeout: timeout,
+		// Redirects are not followed so a signed body is only ever sent to the registered URL.
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}}
+}
+
+// Send posts the body and returns the response status; err is only set on transport failures.
+func (s *Sender) Send(ctx context.Context, url string, headers map[string]string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("User-Agent", "draftea-webhooks/1.0")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBytes))
+	return resp.StatusCode, nil
+}
+
+var _ webhooks.Sender = (*Sender)(nil)
@@ -0,0 +1,37 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GenesisHash es el prev_hash del primer registro de la cadena.
+const GenesisHash = "0000000000000000000000000000000000000000000000000000000000000000"
+
+// Entry es un registro del audit log. Hash encadena el registro con el anterior (PrevHash), así
+// modificar, borrar o intercalar un registro rompe la cadena a partir de ese punto.
+type Entry struct {
+	Seq           int64     `json:"seq"`
+	EventID       uuid.UUID `json:"event_id"`
+	EventType     string    `json:"event_type"`
+	AggregateType string    `json:"aggregate_type"`
+	AggregateID   uuid.UUID `json:"aggregate_id"`
+	OccurredAt    time.Time `json:"occurred_at"`
+	RecordedAt    time.Time `json:"recorded_at"`
+	Payload       string    `json:"payload"` // envelope tal como llegó, sin normalizar
+	PrevHash      string    `json:"prev_hash"`
+	Hash          string    `json:"hash"`
+}
+
+// Repository persiste el audit log. La tabla es append-only: event_id y prev_hash son únicos, por lo
+// que dos escritores concurrentes no pueden bifurcar la cadena; el perdedor falla y reintenta.
+type Repository interface {
+	GetAuditEntryByEventID(ctx context.Context, eventID uuid.UUID) (*Entry, error)
+	// LastAuditEntry retorna el último registro de la cadena, o nil si está vacía.
+	LastAuditEntry(ctx context.Context) (*Entry, error)
+	InsertAuditEntry(ctx context.Context, entry *Entry) error
+	// ListAuditEntries lista hasta limit registros con seq > afterSeq, en orden de seq.
+	ListAuditEntries(ctx context.Context, afterSeq int64, limit int) ([]*Entry, error)
+}
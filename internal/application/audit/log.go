@@ -0,0 +1,103 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"draftea-challenge/internal/application/events"
+	"draftea-challenge/internal/application/ports"
+	"draftea-challenge/internal/domain/errors"
+)
+
+// maxAppendAttempts acota los reintentos cuando otro escritor extendió la cadena primero.
+const maxAppendAttempts = 5
+
+// Log registra los eventos de pagos y reembolsos en el audit log encadenado.
+type Log struct {
+	repo  Repository
+	clock ports.Clock
+}
+
+// NewLog crea una nueva instancia de Log.
+func NewLog(repo Repository, clock ports.Clock) *Log {
+	return &Log{repo: repo, clock: clock}
+}
+
+// Record agrega el evento al final de la cadena y retorna si agregó un registro. Es idempotente por
+// event ID: un evento ya registrado se ignora, igual que los que no son payment.* ni refund.*.
+func (l *Log) Record(ctx context.Context, payload []byte) (bool, error) {
+	env, err := events.Decode(payload)
+	if err != nil {
+		return false, err
+	}
+	if !strings.HasPrefix(env.Type, "payment.") && !strings.HasPrefix(env.Type, "refund.") {
+		return false, nil
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < maxAppendAttempts; attempt++ {
+		if _, err := l.repo.GetAuditEntryByEventID(ctx, env.EventID); err == nil {
+			return false, nil
+		} else if !isNotFoundError(err) {
+			return false, err
+		}
+
+		last, err := l.repo.LastAuditEntry(ctx)
+		if err != nil {
+			return false, err
+		}
+		entry := &Entry{
+			EventID:       env.EventID,
+			EventType:     env.Type,
+			AggregateType: env.AggregateType,
+			AggregateID:   env.AggregateID,
+			OccurredAt:    env.OccurredAt.UTC().Truncate(time.Microsecond),
+			RecordedAt:    l.clock.Now().UTC().Truncate(time.Microsecond),
+			Payload:       string(payload),
+			PrevHash:      GenesisHash,
+		}
+		if last != nil {
+			entry.PrevHash = last.Hash
+		}
+		entry.Hash = ComputeHash(entry)
+
+		// Un conflicto en event_id o prev_hash significa que otro escritor ganó; se relee la cadena.
+		if lastErr = l.repo.InsertAuditEntry(ctx, entry); lastErr == nil {
+			return true, nil
+		}
+	}
+	return false, fmt.Errorf("append audit entry %s: %w", env.EventID, lastErr)
+}
+
+// ComputeHash calcula el SHA-256 del registro encadenado a PrevHash. Cada campo va precedido de su
+// longitud para que no haya dos registros distintos con la misma entrada.
+func ComputeHash(e *Entry) string {
+	h := sha256.New()
+	for _, field := range []string{
+		e.PrevHash,
+		e.EventID.String(),
+		e.EventType,
+		e.AggregateType,
+		e.AggregateID.String(),
+		e.OccurredAt.UTC().Format(time.RFC3339Nano),
+		e.RecordedAt.UTC().Format(time.RFC3339Nano),
+		e.Payload,
+	} {
+		h.Write([]byte(strconv.Itoa(len(field))))
+		h.Write([]byte{':'})
+		h.Write([]byte(field))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func isNotFoundError(err error) bool {
+	if domErr, ok := err.(errors.Error); ok {
+		return domErr.Code == errors.CodeNotFound
+	}
+	return false
+}
@@ -0,0 +1,165 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"draftea-challenge/internal/application/events"
+	domainerrors "draftea-challenge/internal/domain/errors"
+
+	"github.com/google/uuid"
+)
+
+// fakeRepo mimics the unique event_id and prev_hash indexes of audit_log.
+type fakeRepo struct {
+	entries []*Entry
+	// beforeInsert runs once before the next insert, to simulate a concurrent writer.
+	beforeInsert func()
+}
+
+func (f *fakeRepo) GetAuditEntryByEventID(ctx context.Context, eventID uuid.UUID) (*Entry, error) {
+	for _, e := range f.entries {
+		if e.EventID == eventID {
+			return e, nil
+		}
+	}
+	return nil, domainerrors.NewNotFoundError("audit entry not found")
+}
+
+func (f *fakeRepo) LastAuditEntry(ctx context.Context) (*Entry, error) {
+	if len(f.entries) == 0 {
+		return nil, nil
+	}
+	return f.entries[len(f.entries)-1], nil
+}
+
+func (f *fakeRepo) InsertAuditEntry(ctx context.Context, entry *Entry) error {
+	if hook := f.beforeInsert; hook != nil {
+		f.beforeInsert = nil
+		hook()
+	}
+	for _, e := range f.entries {
+		if e.EventID == entry.EventID || e.PrevHash == entry.PrevHash {
+			return errors.New("duplicate key")
+		}
+	}
+	copied := *entry
+	copied.Seq = int64(len(f.entries) + 1)
+	entry.Seq = copied.Seq
+	f.entries = append(f.entries, &copied)
+	return nil
+}
+
+func (f *fakeRepo) ListAuditEntries(ctx context.Context, afterSeq int64, limit int) ([]*Entry, error) {
+	var out []*Entry
+	for _, e := range f.entries {
+		if e.Seq > afterSeq && len(out) < limit {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+type fixedClock struct{ now time.Time }
+
+func (c fixedClock) Now() time.Time { return c.now }
+
+func eventPayload(t *testing.T, ev events.Event) []byte {
+	t.Helper()
+	env, err := events.NewEnvelope(context.Background(), uuid.New(), time.Date(2026, 5, 1, 12, 0, 0, 123456789, time.UTC), ev)
+	if err != nil {
+		t.Fatalf("envelope: %v", err)
+	}
+	payload, err := json.Marshal(env)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return payload
+}
+
+func TestRecordChainsEntriesAndIsIdempotent(t *testing.T) {
+	repo := &fakeRepo{}
+	log := NewLog(repo, fixedClock{now: time.Date(2026, 5, 1, 12, 0, 1, 0, time.UTC)})
+	ctx := context.Background()
+
+	payment := eventPayload(t, events.PaymentCompleted{Payment: events.Payment{TransactionID: uuid.New(), Amount: 1500, Currency: "USD"}})
+	refund := eventPayload(t, events.RefundCreated{RefundID: uuid.New(), PaymentID: uuid.New(), Amount: 1500, Currency: "USD"})
+	topUp := eventPayload(t, events.TopUpCompleted{TopUp: events.TopUp{TransactionID: uuid.New(), Amount: 10}})
+
+	for i, payload := range [][]byte{payment, refund, payment, topUp} {
+		recorded, err := log.Record(ctx, payload)
+		if err != nil {
+			t.Fatalf("record %d: %v", i, err)
+		}
+		if want := i < 2; recorded != want {
+			t.Fatalf("record %d: expected recorded=%v", i, want)
+		}
+	}
+
+	if len(repo.entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(repo.entries))
+	}
+	first, second := repo.entries[0], repo.entries[1]
+	if first.PrevHash != GenesisHash || second.PrevHash != first.Hash || first.Hash == second.Hash {
+		t.Fatalf("entries are not chained: %+v %+v", first, second)
+	}
+	if first.OccurredAt.Nanosecond() != 123456000 {
+		t.Fatalf("expected occurred_at truncated to microseconds, got %v", first.OccurredAt)
+	}
+
+	report, err := log.Verify(ctx, 1)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if !report.OK() || report.Checked != 2 || report.LastHash != second.Hash {
+		t.Fatalf("unexpected report %+v", report)
+	}
+}
+
+func TestRecordRetriesWhenAnotherWriterExtendsTheChain(t *testing.T) {
+	repo := &fakeRepo{}
+	log := NewLog(repo, fixedClock{now: time.Date(2026, 5, 1, 12, 0, 1, 0, time.UTC)})
+	ctx := context.Background()
+
+	other := eventPayload(t, events.PaymentCreated{Payment: events.Payment{TransactionID: uuid.New()}})
+	repo.beforeInsert = func() {
+		if _, err := log.Record(ctx, other); err != nil {
+			t.Fatalf("concurrent record: %v", err)
+		}
+	}
+	recorded, err := log.Record(ctx, eventPayload(t, events.PaymentCompleted{Payment: events.Payment{TransactionID: uuid.New()}}))
+	if err != nil || !recorded {
+		t.Fatalf("record: recorded=%v err=%v", recorded, err)
+	}
+	if len(repo.entries) != 2 || repo.entries[1].PrevHash != repo.entries[0].Hash {
+		t.Fatalf("expected a linear chain, got %+v", repo.entries)
+	}
+}
+
+func TestVerifyReportsTamperedAndMissingEntries(t *testing.T) {
+	repo := &fakeRepo{}
+	log := NewLog(repo, fixedClock{now: time.Date(2026, 5, 1, 12, 0, 1, 0, time.UTC)})
+	ctx := context.Background()
+	for i := 0; i < 4; i++ {
+		if _, err := log.Record(ctx, eventPayload(t, events.PaymentCompleted{Payment: events.Payment{TransactionID: uuid.New(), Amount: int64(i)}})); err != nil {
+			t.Fatalf("record: %v", err)
+		}
+	}
+
+	repo.entries[1].Payload = `{"tampered":true}`
+	repo.entries = append(repo.entries[:2], repo.entries[3:]...) // drop seq 3
+
+	report, err := log.Verify(ctx, 2)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if report.OK() || report.BreakCount != 2 || report.Checked != 3 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if report.Breaks[0].Seq != 2 || report.Breaks[1].Seq != 4 {
+		t.Fatalf("unexpected breaks %+v", report.Breaks)
+	}
+}
@@ -0,0 +1,27 @@
+package batches
+
+import (
+	"context"
+	"draftea-challenge/internal/application/payments"
+	"draftea-challenge/internal/domain/batch"
+
+	"github.com/google/uuid"
+)
+
+// BatchRepository define la interfaz para persistir lotes de pagos y sus resultados.
+type BatchRepository interface {
+	// CreateBatch persiste el lote junto con sus ítems.
+	CreateBatch(ctx context.Context, b *batch.Batch) error
+	GetBatch(ctx context.Context, userID, batchID uuid.UUID) (*batch.Batch, error)
+	// GetBatchByKey busca un lote por la Idempotency-Key con la que fue enviado.
+	GetBatchByKey(ctx context.Context, userID uuid.UUID, key string) (*batch.Batch, error)
+	// SaveBatchItem persiste el resultado de un ítem.
+	SaveBatchItem(ctx context.Context, batchID uuid.UUID, item *batch.Item) error
+	// UpdateBatchStatus persiste el estado agregado del lote.
+	UpdateBatchStatus(ctx context.Context, b *batch.Batch) error
+}
+
+// PaymentProcessor define el flujo de pago usado para cada ítem.
+type PaymentProcessor interface {
+	ProcessPayment(ctx context.Context, req *payments.ProcessPaymentRequest) (*payments.ProcessPaymentResponse, error)
+}
@@ -0,0 +1,217 @@
+package batches
+
+import (
+	"context"
+	"draftea-challenge/internal/application/payments"
+	"draftea-challenge/internal/application/ports"
+	"draftea-challenge/internal/application/wallets"
+	"draftea-challenge/internal/domain/batch"
+	"draftea-challenge/internal/domain/errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Config define los límites del procesamiento de lotes.
+type Config struct {
+	MaxItems    int
+	Concurrency int
+}
+
+// BatchService procesa lotes de pagos a través del PaymentService.
+type BatchService struct {
+	repo        BatchRepository
+	walletRepo  wallets.WalletRepository
+	payments    PaymentProcessor
+	clock       ports.Clock
+	maxItems    int
+	concurrency int
+}
+
+// NewBatchService crea una nueva instancia de BatchService.
+func NewBatchService(repo BatchRepository, walletRepo wallets.WalletRepository, processor PaymentProcessor, clock ports.Clock, cfg Config) *BatchService {
+	maxItems := cfg.MaxItems
+	if maxItems <= 0 {
+		maxItems = 100
+	}
+	concurrency := cfg.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	return &BatchService{
+		repo:        repo,
+		walletRepo:  walletRepo,
+		payments:    processor,
+		clock:       clock,
+		maxItems:    maxItems,
+		concurrency: concurrency,
+	}
+}
+
+// SubmitBatchRequest representa la solicitud de un lote de pagos.
+type SubmitBatchRequest struct {
+	UserID         uuid.UUID          `json:"user_id"`
+	IdempotencyKey string             `json:"idempotency_key"`
+	Items          []BatchItemRequest `json:"items"`
+}
+
+// BatchItemRequest representa un pago dentro del lote.
+type BatchItemRequest struct {
+	ProviderID        uuid.UUID `json:"provider_id"`
+	ExternalReference string    `json:"external_reference"`
+	Amount            int64     `json:"amount"`
+	Currency          string    `json:"currency"`
+	IdempotencyKey    string    `json:"idempotency_key"`
+}
+
+// BatchResponse representa el reporte del lote con el resultado de cada ítem.
+type BatchResponse struct {
+	BatchID     uuid.UUID      `json:"batch_id"`
+	Status      batch.Status   `json:"status"`
+	Total       int            `json:"total"`
+	Summary     map[string]int `json:"summary"`
+	Items       []*batch.Item  `json:"items"`
+	CreatedAt   time.Time      `json:"created_at"`
+	CompletedAt *time.Time     `json:"completed_at,omitempty"`
+}
+
+// SubmitBatch procesa cada ítem del lote con concurrencia acotada.
+// Reenviar un lote con la misma Idempotency-Key retoma los ítems pendientes y
+// devuelve el reporte existente; los ítems ya procesados no se vuelven a pagar.
+func (s *BatchService) SubmitBatch(ctx context.Context, req *SubmitBatchRequest) (*BatchResponse, error) {
+	b, err := s.loadOrCreate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	s.processPending(ctx, b)
+
+	// El reporte se persiste aunque el request haya expirado, para poder consultarlo luego.
+	persistCtx := context.WithoutCancel(ctx)
+	b.Refresh(s.clock.Now().UTC())
+	if err := s.repo.UpdateBatchStatus(persistCtx, b); err != nil {
+		return nil, err
+	}
+	return toResponse(b), nil
+}
+
+// GetBatch obtiene el reporte de un lote de la wallet.
+func (s *BatchService) GetBatch(ctx context.Context, userID, batchID uuid.UUID) (*BatchResponse, error) {
+	b, err := s.repo.GetBatch(ctx, userID, batchID)
+	if err != nil {
+		return nil, err
+	}
+	return toResponse(b), nil
+}
+
+func (s *BatchService) loadOrCreate(ctx context.Context, req *SubmitBatchRequest) (*batch.Batch, error) {
+	if req.IdempotencyKey != "" {
+		existing, err := s.repo.GetBatchByKey(ctx, req.UserID, req.IdempotencyKey)
+		if err != nil && !isNotFoundError(err) {
+			return nil, err
+		}
+		if existing != nil {
+			return existing, nil
+		}
+	}
+
+	if _, err := s.walletRepo.GetWallet(ctx, req.UserID); err != nil {
+		return nil, err
+	}
+
+	items := make([]*batch.Item, 0, len(req.Items))
+	for _, it := range req.Items {
+		items = append(items, &batch.Item{
+			ProviderID:        it.ProviderID,
+			ExternalReference: it.ExternalReference,
+			Amount:            it.Amount,
+			Currency:          it.Currency,
+			IdempotencyKey:    it.IdempotencyKey,
+		})
+	}
+	b, err := batch.NewBatch(req.UserID, req.IdempotencyKey, items, s.maxItems)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateBatch(ctx, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// processPending ejecuta los ítems pendientes con hasta s.concurrency pagos en vuelo.
+// Si el contexto expira, los ítems no iniciados quedan PENDING para un reenvío posterior.
+func (s *BatchService) processPending(ctx context.Context, b *batch.Batch) {
+	sem := make(chan struct{}, s.concurrency)
+	var wg sync.WaitGroup
+	for _, item := range b.Pending() {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case sem <- struct{}{}:
+		}
+		wg.Add(1)
+		go func(item *batch.Item) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			s.processItem(ctx, b, item)
+		}(item)
+	}
+	wg.Wait()
+}
+
+func (s *BatchService) processItem(ctx context.Context, b *batch.Batch, item *batch.Item) {
+	resp, err := s.payments.ProcessPayment(ctx, &payments.ProcessPaymentRequest{
+		UserID:            b.UserID,
+		ProviderID:        item.ProviderID,
+		ExternalReference: item.ExternalReference,
+		Amount:            item.Amount,
+		Currency:          item.Currency,
+		IdempotencyKey:    item.IdempotencyKey,
+	})
+	switch {
+	case err != nil && ctx.Err() != nil:
+		// Cortado por el timeout del request: se deja PENDING para reintentar.
+		return
+	case err != nil:
+		code := errors.CodeInternal
+		message := err.Error()
+		if domErr, ok := err.(errors.Error); ok {
+			code = domErr.Code
+			message = domErr.Message
+		}
+		item.Fail(code, message)
+	default:
+		item.Succeed(resp.TransactionID, resp.Status)
+	}
+
+	if err := s.repo.SaveBatchItem(context.WithoutCancel(ctx), b.ID, item); err != nil {
+		// Sin persistir el resultado, el ítem vuelve a PENDING; la idempotencia del pago evita duplicarlo.
+		item.Status = batch.ItemPending
+	}
+}
+
+func toResponse(b *batch.Batch) *BatchResponse {
+	summary := make(map[string]int)
+	for status, count := range b.Summary() {
+		summary[string(status)] = count
+	}
+	return &BatchResponse{
+		BatchID:     b.ID,
+		Status:      b.Status,
+		Total:       len(b.Items),
+		Summary:     summary,
+		Items:       b.Items,
+		CreatedAt:   b.CreatedAt,
+		CompletedAt: b.CompletedAt,
+	}
+}
+
+func isNotFoundError(err error) bool {
+	if domErr, ok := err.(errors.Error); ok {
+		return domErr.Code == errors.CodeNotFound
+	}
+	return false
+}
@@ -0,0 +1,181 @@
+package batches
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"draftea-challenge/internal/application/payments"
+	"draftea-challenge/internal/domain/batch"
+	"draftea-challenge/internal/domain/errors"
+	"draftea-challenge/internal/domain/wallet"
+
+	"github.com/google/uuid"
+)
+
+type mockBatchRepo struct {
+	mu      sync.Mutex
+	batches map[uuid.UUID]*batch.Batch
+	saved   int
+}
+
+func newMockBatchRepo() *mockBatchRepo {
+	return &mockBatchRepo{batches: make(map[uuid.UUID]*batch.Batch)}
+}
+
+func (m *mockBatchRepo) CreateBatch(ctx context.Context, b *batch.Batch) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.batches[b.ID] = b
+	return nil
+}
+
+func (m *mockBatchRepo) GetBatch(ctx context.Context, userID, batchID uuid.UUID) (*batch.Batch, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if b, ok := m.batches[batchID]; ok && b.UserID == userID {
+		return b, nil
+	}
+	return nil, errors.NewNotFoundError("batch not found")
+}
+
+func (m *mockBatchRepo) GetBatchByKey(ctx context.Context, userID uuid.UUID, key string) (*batch.Batch, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, b := range m.batches {
+		if b.UserID == userID && b.IdempotencyKey == key {
+			return b, nil
+		}
+	}
+	return nil, errors.NewNotFoundError("batch not found")
+}
+
+func (m *mockBatchRepo) SaveBatchItem(ctx context.Context, batchID uuid.UUID, item *batch.Item) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.saved++
+	return nil
+}
+
+func (m *mockBatchRepo) UpdateBatchStatus(ctx context.Context, b *batch.Batch) error {
+	return nil
+}
+
+type mockWalletRepo struct{}
+
+func (mockWalletRepo) GetWallet(ctx context.Context, userID uuid.UUID) (*wallet.Wallet, error) {
+	return &wallet.Wallet{UserID: userID}, nil
+}
+
+func (mockWalletRepo) CreateWallet(ctx context.Context, w *wallet.Wallet) error { return nil }
+
+func (mockWalletRepo) UpdateBalance(ctx context.Context, userID uuid.UUID, currency string, newBalance int64) error {
+	return nil
+}
+
+func (mockWalletRepo) LockWallet(ctx context.Context, userID uuid.UUID) error { return nil }
+
+func (mockWalletRepo) ListWallets(ctx context.Context, limit, offset int) ([]*wallet.Wallet, int, error) {
+	return nil, 0, nil
+}
+
+type mockProcessor struct {
+	inFlight    int32
+	maxInFlight int32
+	calls       int32
+	failRef     string
+	delay       time.Duration
+}
+
+func (m *mockProcessor) ProcessPayment(ctx context.Context, req *payments.ProcessPaymentRequest) (*payments.ProcessPaymentResponse, error) {
+	atomic.AddInt32(&m.calls, 1)
+	cur := atomic.AddInt32(&m.inFlight, 1)
+	defer atomic.AddInt32(&m.inFlight, -1)
+	for {
+		max := atomic.LoadInt32(&m.maxInFlight)
+		if cur <= max || atomic.CompareAndSwapInt32(&m.maxInFlight, max, cur) {
+			break
+		}
+	}
+	time.Sleep(m.delay)
+	if req.ExternalReference == m.failRef {
+		return nil, errors.NewInsufficientFundsError("insufficient funds", nil)
+	}
+	return &payments.ProcessPaymentResponse{TransactionID: uuid.New(), Status: "APPROVED"}, nil
+}
+
+type fixedClock struct{}
+
+func (fixedClock) Now() time.Time { return time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC) }
+
+func batchItems(n int) []BatchItemRequest {
+	items := make([]BatchItemRequest, 0, n)
+	for i := 0; i < n; i++ {
+		items = append(items, BatchItemRequest{
+			ProviderID:        uuid.New(),
+			ExternalReference: "bill-" + string(rune('a'+i)),
+			Amount:            100,
+			Currency:          "USD",
+		})
+	}
+	return items
+}
+
+func TestSubmitBatch_ReportsEachItemWithBoundedConcurrency(t *testing.T) {
+	repo := newMockBatchRepo()
+	processor := &mockProcessor{failRef: "bill-c", delay: 5 * time.Millisecond}
+	service := NewBatchService(repo, mockWalletRepo{}, processor, fixedClock{}, Config{MaxItems: 10, Concurrency: 2})
+
+	resp, err := service.SubmitBatch(context.Background(), &SubmitBatchRequest{UserID: uuid.New(), Items: batchItems(6)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Status != batch.StatusCompleted || resp.Total != 6 {
+		t.Fatalf("unexpected batch: %+v", resp)
+	}
+	if resp.Summary["APPROVED"] != 5 || resp.Summary["ERROR"] != 1 {
+		t.Fatalf("unexpected summary: %v", resp.Summary)
+	}
+	if failed := resp.Items[2]; failed.ErrorCode != errors.CodeInsufficientFunds {
+		t.Fatalf("expected item 2 to report insufficient funds, got %+v", failed)
+	}
+	if processor.maxInFlight > 2 {
+		t.Fatalf("expected at most 2 payments in flight, got %d", processor.maxInFlight)
+	}
+	if resp.Items[0].IdempotencyKey != "batch:"+resp.BatchID.String()+":0" {
+		t.Fatalf("unexpected derived idempotency key %s", resp.Items[0].IdempotencyKey)
+	}
+}
+
+func TestSubmitBatch_ResubmitWithKeyReturnsExistingBatch(t *testing.T) {
+	repo := newMockBatchRepo()
+	processor := &mockProcessor{}
+	service := NewBatchService(repo, mockWalletRepo{}, processor, fixedClock{}, Config{})
+	req := &SubmitBatchRequest{UserID: uuid.New(), IdempotencyKey: "upload-1", Items: batchItems(3)}
+
+	first, err := service.SubmitBatch(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := service.SubmitBatch(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first.BatchID != second.BatchID {
+		t.Fatalf("expected the same batch, got %s and %s", first.BatchID, second.BatchID)
+	}
+	if processor.calls != 3 {
+		t.Fatalf("expected items to be processed once, got %d calls", processor.calls)
+	}
+}
+
+func TestSubmitBatch_RejectsOversizedBatch(t *testing.T) {
+	service := NewBatchService(newMockBatchRepo(), mockWalletRepo{}, &mockProcessor{}, fixedClock{}, Config{MaxItems: 2})
+
+	_, err := service.SubmitBatch(context.Background(), &SubmitBatchRequest{UserID: uuid.New(), Items: batchItems(3)})
+	if domErr, ok := err.(errors.Error); !ok || domErr.Code != errors.CodeValidationError {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+package callbacks
+
+import (
+	"context"
+	"draftea-challenge/internal/application/payments"
+	"draftea-challenge/internal/application/wallets"
+	"draftea-challenge/internal/domain/transaction"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventRepository registra los IDs de eventos recibidos de la pasarela para descartar reenvíos.
+type EventRepository interface {
+	// RecordGatewayEvent retorna false si el evento ya había sido registrado.
+	RecordGatewayEvent(ctx context.Context, eventID string, transactionID uuid.UUID, status string, receivedAt time.Time) (bool, error)
+	DeleteGatewayEvent(ctx context.Context, eventID string) error
+}
+
+// TransactionRepository define la búsqueda de la transacción referida por el webhook.
+type TransactionRepository interface {
+	GetTransactionByID(ctx context.Context, txID uuid.UUID) (*transaction.Transaction, error)
+}
+
+// PaymentCompleter finaliza pagos PENDING confirmados asíncronamente.
+type PaymentCompleter interface {
+	CompletePendingPayment(ctx context.Context, txID uuid.UUID, status string) (*payments.ProcessPaymentResponse, error)
+}
+
+// TopUpCompleter finaliza top-ups PENDING confirmados asíncronamente.
+type TopUpCompleter interface {
+	CompleteTopUp(ctx context.Context, txID uuid.UUID, status wallets.FundingStatus) (*wallets.TopUpResponse, error)
+}
@@ -0,0 +1,167 @@
+package callbacks
+
+import (
+	"context"
+	"draftea-challenge/internal/application/ports"
+	"draftea-challenge/internal/application/wallets"
+	"draftea-challenge/internal/domain/errors"
+	"draftea-challenge/internal/domain/transaction"
+	"draftea-challenge/internal/platform/signature"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Config define la verificación de los webhooks de la pasarela.
+type Config struct {
+	Secret    string        // secreto compartido para la firma HMAC-SHA256
+	Tolerance time.Duration // desfasaje máximo aceptado del timestamp firmado
+}
+
+// GatewayCallbackService procesa los webhooks firmados con los que la pasarela confirma
+// pagos y top-ups de forma asíncrona.
+type GatewayCallbackService struct {
+	events    EventRepository
+	txRepo    TransactionRepository
+	payments  PaymentCompleter
+	topUps    TopUpCompleter
+	clock     ports.Clock
+	secret    []byte
+	tolerance time.Duration
+}
+
+// NewGatewayCallbackService crea una nueva instancia de GatewayCallbackService.
+func NewGatewayCallbackService(events EventRepository, txRepo TransactionRepository, payments PaymentCompleter, topUps TopUpCompleter, clock ports.Clock, cfg Config) *GatewayCallbackService {
+	tolerance := cfg.Tolerance
+	if tolerance <= 0 {
+		tolerance = 5 * time.Minute
+	}
+	return &GatewayCallbackService{
+		events:    events,
+		txRepo:    txRepo,
+		payments:  payments,
+		topUps:    topUps,
+		clock:     clock,
+		secret:    []byte(cfg.Secret),
+		tolerance: tolerance,
+	}
+}
+
+// CallbackRequest representa un webhook tal como llegó: cuerpo crudo y cabeceras de firma.
+type CallbackRequest struct {
+	Payload   []byte
+	Timestamp string // segundos unix incluidos en la firma
+	Signature string // hex(HMAC-SHA256(secret, timestamp + "." + payload))
+}
+
+// CallbackEvent representa el cuerpo del webhook.
+type CallbackEvent struct {
+	EventID       string `json:"event_id"`
+	TransactionID string `json:"transaction_id"`
+	Status        string `json:"status"` // approved, declined o failed
+}
+
+// CallbackResponse representa el resultado del procesamiento.
+type CallbackResponse struct {
+	EventID           string    `json:"event_id"`
+	TransactionID     uuid.UUID `json:"transaction_id"`
+	TransactionStatus string    `json:"transaction_status,omitempty"`
+	Duplicate         bool      `json:"duplicate"`
+}
+
+// HandleGatewayCallback verifica la firma del webhook, descarta eventos repetidos y
+// finaliza la transacción PENDING referida.
+func (s *GatewayCallbackService) HandleGatewayCallback(ctx context.Context, req *CallbackRequest) (*CallbackResponse, error) {
+	if err := s.verify(req); err != nil {
+		return nil, err
+	}
+
+	var event CallbackEvent
+	if err := json.Unmarshal(req.Payload, &event); err != nil {
+		return nil, errors.NewValidationError("invalid webhook payload", map[string]interface{}{"error": err.Error()})
+	}
+	details := make(map[string]interface{})
+	if strings.TrimSpace(event.EventID) == "" {
+		details["event_id"] = "required"
+	}
+	txID, err := uuid.Parse(event.TransactionID)
+	if err != nil {
+		details["transaction_id"] = event.TransactionID
+	}
+	switch event.Status {
+	case "approved", "declined", "failed":
+	default:
+		details["status"] = event.Status
+	}
+	if len(details) > 0 {
+		return nil, errors.NewValidationError("invalid webhook event", details)
+	}
+
+	recorded, err := s.events.RecordGatewayEvent(ctx, event.EventID, txID, event.Status, s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !recorded {
+		return &CallbackResponse{EventID: event.EventID, TransactionID: txID, Duplicate: true}, nil
+	}
+
+	status, err := s.apply(ctx, txID, event.Status)
+	if err != nil {
+		// Se libera el evento para que el reintento de la pasarela vuelva a procesarlo
+		_ = s.events.DeleteGatewayEvent(context.WithoutCancel(ctx), event.EventID)
+		return nil, err
+	}
+	return &CallbackResponse{EventID: event.EventID, TransactionID: txID, TransactionStatus: status}, nil
+}
+
+// apply despacha el resultado al caso de uso que corresponde al tipo de transacción.
+func (s *GatewayCallbackService) apply(ctx context.Context, txID uuid.UUID, status string) (string, error) {
+	tx, err := s.txRepo.GetTransactionByID(ctx, txID)
+	if err != nil {
+		return "", err
+	}
+	switch tx.Type {
+	case transaction.TypePayment:
+		resp, err := s.payments.CompletePendingPayment(ctx, txID, status)
+		if err != nil {
+			return "", err
+		}
+		return resp.Status, nil
+	case transaction.TypeTopUp:
+		resp, err := s.topUps.CompleteTopUp(ctx, txID, wallets.FundingStatus(status))
+		if err != nil {
+			return "", err
+		}
+		return resp.Status, nil
+	default:
+		return "", errors.NewValidationError("transaction is not confirmed by the gateway", map[string]interface{}{
+			"transaction_id": txID,
+			"type":           tx.Type,
+		})
+	}
+}
+
+// verify valida la firma HMAC y que el timestamp esté dentro de la tolerancia (evita replays).
+func (s *GatewayCallbackService) verify(req *CallbackRequest) error {
+	if len(s.secret) == 0 {
+		return errors.NewUnauthorizedError("gateway webhooks are not configured")
+	}
+	ts, err := strconv.ParseInt(req.Timestamp, 10, 64)
+	if err != nil {
+		return errors.NewUnauthorizedError("invalid webhook timestamp")
+	}
+	skew := s.clock.Now().Sub(time.Unix(ts, 0))
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > s.tolerance {
+		return errors.NewUnauthorizedError("webhook timestamp outside tolerance")
+	}
+	if !signature.Verify(s.secret, req.Timestamp, req.Payload, req.Signature) {
+		return errors.NewUnauthorizedError("invalid webhook signature")
+	}
+	return nil
+}
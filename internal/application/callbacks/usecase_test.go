@@ -0,0 +1,173 @@
+package callbacks
+
+import (
+	"context"
+	"encoding/hex"
+	"strconv"
+	"testing"
+	"time"
+
+	"draftea-challenge/internal/application/payments"
+	"draftea-challenge/internal/application/wallets"
+	"draftea-challenge/internal/domain/errors"
+	"draftea-challenge/internal/domain/transaction"
+	"draftea-challenge/internal/platform/signature"
+
+	"github.com/google/uuid"
+)
+
+type mockEventRepo struct {
+	events  map[string]bool
+	deleted []string
+}
+
+func (m *mockEventRepo) RecordGatewayEvent(ctx context.Context, eventID string, transactionID uuid.UUID, status string, receivedAt time.Time) (bool, error) {
+	if m.events == nil {
+		m.events = make(map[string]bool)
+	}
+	if m.events[eventID] {
+		return false, nil
+	}
+	m.events[eventID] = true
+	return true, nil
+}
+
+func (m *mockEventRepo) DeleteGatewayEvent(ctx context.Context, eventID string) error {
+	delete(m.events, eventID)
+	m.deleted = append(m.deleted, eventID)
+	return nil
+}
+
+type mockTxRepo struct {
+	tx *transaction.Transaction
+}
+
+func (m *mockTxRepo) GetTransactionByID(ctx context.Context, txID uuid.UUID) (*transaction.Transaction, error) {
+	if m.tx == nil || m.tx.ID != txID {
+		return nil, errors.NewNotFoundError("transaction not found")
+	}
+	return m.tx, nil
+}
+
+type mockPaymentCompleter struct {
+	calls  int
+	status string
+	err    error
+}
+
+func (m *mockPaymentCompleter) CompletePendingPayment(ctx context.Context, txID uuid.UUID, status string) (*payments.ProcessPaymentResponse, error) {
+	m.calls++
+	m.status = status
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &payments.ProcessPaymentResponse{TransactionID: txID, Status: string(transaction.StatusDeclined)}, nil
+}
+
+type mockTopUpCompleter struct {
+	calls int
+}
+
+func (m *mockTopUpCompleter) CompleteTopUp(ctx context.Context, txID uuid.UUID, status wallets.FundingStatus) (*wallets.TopUpResponse, error) {
+	m.calls++
+	return &wallets.TopUpResponse{TransactionID: txID, Status: string(transaction.StatusApproved)}, nil
+}
+
+type fixedClock struct {
+	t time.Time
+}
+
+func (f fixedClock) Now() time.Time {
+	return f.t
+}
+
+const testSecret = "test-secret"
+
+func signedRequest(payload string, at time.Time) *CallbackRequest {
+	ts := strconv.FormatInt(at.Unix(), 10)
+	return &CallbackRequest{
+		Payload:   []byte(payload),
+		Timestamp: ts,
+		Signature: hex.EncodeToString(signature.Sign([]byte(testSecret), ts, []byte(payload))),
+	}
+}
+
+func TestHandleGatewayCallback_RejectsInvalidSignatures(t *testing.T) {
+	now := time.Now()
+	svc := NewGatewayCallbackService(&mockEventRepo{}, &mockTxRepo{}, &mockPaymentCompleter{}, &mockTopUpCompleter{}, fixedClock{t: now}, Config{Secret: testSecret, Tolerance: time.Minute})
+	payload := `{"event_id":"evt-1","transaction_id":"` + uuid.NewString() + `","status":"approved"}`
+
+	tampered := signedRequest(payload, now)
+	tampered.Payload = []byte(`{"event_id":"evt-1","transaction_id":"x","status":"approved"}`)
+	cases := map[string]*CallbackRequest{
+		"tampered payload":  tampered,
+		"expired timestamp": signedRequest(payload, now.Add(-2*time.Minute)),
+		"missing signature": {Payload: []byte(payload), Timestamp: strconv.FormatInt(now.Unix(), 10)},
+	}
+	for name, req := range cases {
+		_, err := svc.HandleGatewayCallback(context.Background(), req)
+		if domErr, ok := err.(errors.Error); !ok || domErr.Code != errors.CodeUnauthorized {
+			t.Fatalf("%s: expected unauthorized error, got %v", name, err)
+		}
+	}
+}
+
+func TestHandleGatewayCallback_CompletesPaymentOnce(t *testing.T) {
+	now := time.Now()
+	tx, _ := transaction.NewTransaction(uuid.New(), transaction.TypePayment, 500, "USD", uuid.New(), "ref-1")
+	events := &mockEventRepo{}
+	completer := &mockPaymentCompleter{}
+	svc := NewGatewayCallbackService(events, &mockTxRepo{tx: tx}, completer, &mockTopUpCompleter{}, fixedClock{t: now}, Config{Secret: testSecret})
+
+	payload := `{"event_id":"evt-1","transaction_id":"` + tx.ID.String() + `","status":"declined"}`
+	resp, err := svc.HandleGatewayCallback(context.Background(), signedRequest(payload, now))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Duplicate || resp.TransactionStatus != string(transaction.StatusDeclined) {
+		t.Fatalf("expected processed callback, got %+v", resp)
+	}
+	if completer.calls != 1 || completer.status != "declined" {
+		t.Fatalf("expected payment completed with declined, got %d %s", completer.calls, completer.status)
+	}
+
+	resp, err = svc.HandleGatewayCallback(context.Background(), signedRequest(payload, now))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Duplicate || completer.calls != 1 {
+		t.Fatalf("expected duplicate event to be ignored, got %+v after %d calls", resp, completer.calls)
+	}
+}
+
+func TestHandleGatewayCallback_ReleasesEventOnFailure(t *testing.T) {
+	now := time.Now()
+	tx, _ := transaction.NewTransaction(uuid.New(), transaction.TypePayment, 500, "USD", uuid.New(), "ref-1")
+	events := &mockEventRepo{}
+	completer := &mockPaymentCompleter{err: errors.NewInternalError("refund failed")}
+	svc := NewGatewayCallbackService(events, &mockTxRepo{tx: tx}, completer, &mockTopUpCompleter{}, fixedClock{t: now}, Config{Secret: testSecret})
+
+	payload := `{"event_id":"evt-1","transaction_id":"` + tx.ID.String() + `","status":"failed"}`
+	if _, err := svc.HandleGatewayCallback(context.Background(), signedRequest(payload, now)); err == nil {
+		t.Fatalf("expected error")
+	}
+	if len(events.deleted) != 1 || events.events["evt-1"] {
+		t.Fatalf("expected event released for retry")
+	}
+}
+
+func TestHandleGatewayCallback_CompletesTopUp(t *testing.T) {
+	now := time.Now()
+	tx, _ := transaction.NewTransaction(uuid.New(), transaction.TypeTopUp, 500, "USD", uuid.Nil, "card_tok")
+	topUps := &mockTopUpCompleter{}
+	svc := NewGatewayCallbackService(&mockEventRepo{}, &mockTxRepo{tx: tx}, &mockPaymentCompleter{}, topUps, fixedClock{t: now}, Config{Secret: testSecret})
+
+	payload := `{"event_id":"evt-2","transaction_id":"` + tx.ID.String() + `","status":"approved"}`
+	resp, err := svc.HandleGatewayCallback(context.Background(), signedRequest(payload, now))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if topUps.calls != 1 || resp.TransactionStatus != string(transaction.StatusApproved) {
+		t.Fatalf("expected top-up completed, got %+v", resp)
+	}
+}
@@ -0,0 +1,84 @@
+package events
+
+import (
+	"context"
+	"draftea-challenge/internal/application/outbox"
+	"draftea-challenge/internal/application/ports"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event es el contrato que cumplen los eventos tipados publicados vía outbox.
+type Event interface {
+	// EventType retorna el tipo (routing key), e.g. "payment.completed".
+	EventType() string
+	// EventVersion retorna la versión del esquema de Data; se incrementa ante cambios incompatibles.
+	EventVersion() int
+	// AggregateType y AggregateID identifican la entidad cuyo ciclo de vida describe el evento.
+	AggregateType() string
+	AggregateID() uuid.UUID
+}
+
+// Envelope es el sobre común con el que se serializan todos los eventos.
+type Envelope struct {
+	EventID       uuid.UUID       `json:"event_id"`
+	Type          string          `json:"type"`
+	Version       int             `json:"version"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	CorrelationID string          `json:"correlation_id,omitempty"` // request ID que originó el evento
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   uuid.UUID       `json:"aggregate_id"`
+	Data          json.RawMessage `json:"data"`
+}
+
+// NewEnvelope envuelve el evento con sus metadatos; el correlation ID se toma del contexto.
+func NewEnvelope(ctx context.Context, id uuid.UUID, occurredAt time.Time, ev Event) (*Envelope, error) {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return nil, fmt.Errorf("encode %s event: %w", ev.EventType(), err)
+	}
+	return &Envelope{
+		EventID:       id,
+		Type:          ev.EventType(),
+		Version:       ev.EventVersion(),
+		OccurredAt:    occurredAt.UTC(),
+		CorrelationID: ports.CorrelationID(ctx),
+		AggregateType: ev.AggregateType(),
+		AggregateID:   ev.AggregateID(),
+		Data:          data,
+	}, nil
+}
+
+// NewOutboxEvent serializa el evento dentro de su Envelope como fila de outbox.
+func NewOutboxEvent(ctx context.Context, id uuid.UUID, occurredAt time.Time, ev Event) (*outbox.OutboxEvent, error) {
+	env, err := NewEnvelope(ctx, id, occurredAt, ev)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(env)
+	if err != nil {
+		return nil, fmt.Errorf("encode %s envelope: %w", ev.EventType(), err)
+	}
+	return &outbox.OutboxEvent{
+		ID:          id,
+		EventType:   env.Type,
+		Payload:     string(payload),
+		AggregateID: env.AggregateID,
+		CreatedAt:   occurredAt,
+	}, nil
+}
+
+// Decode parsea un Envelope; Data queda sin decodificar para que el consumidor elija el tipo según Type y Version.
+func Decode(payload []byte) (*Envelope, error) {
+	var env Envelope
+	if err := json.Unmarshal(payload, &env); err != nil {
+		return nil, fmt.Errorf("decode event envelope: %w", err)
+	}
+	if env.Type == "" || env.EventID == uuid.Nil {
+		return nil, fmt.Errorf("decode event envelope: missing event_id or type")
+	}
+	return &env, nil
+}
@@ -0,0 +1,127 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"draftea-challenge/internal/application/ports"
+
+	"github.com/google/uuid"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+var (
+	eventID     = uuid.MustParse("0b6f1c3e-5d2a-4c1e-9a47-3f0d2b8e7c11")
+	paymentID   = uuid.MustParse("6a1e2f3b-4c5d-4e6f-8a9b-0c1d2e3f4a5b")
+	feeID       = uuid.MustParse("7b2f3a4c-5d6e-4f70-9b8c-1d2e3f4a5b6c")
+	refundID    = uuid.MustParse("8c3a4b5d-6e7f-4081-8c9d-2e3f4a5b6c7d")
+	userID      = uuid.MustParse("1f2e3d4c-5b6a-4978-8695-a4b3c2d1e0f9")
+	providerID  = uuid.MustParse("2a3b4c5d-6e7f-4a8b-9c0d-1e2f3a4b5c6d")
+	scheduleID  = uuid.MustParse("3b4c5d6e-7f80-4b9c-8d1e-2f3a4b5c6d7e")
+	occurredAt  = time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	testPayment = Payment{
+		TransactionID:     paymentID,
+		UserID:            userID,
+		ProviderID:        providerID,
+		ExternalReference: "invoice-42",
+		Amount:            1500,
+		Fee:               45,
+		Currency:          "USD",
+		Status:            "APPROVED",
+	}
+)
+
+func TestEnvelopeGolden(t *testing.T) {
+	declined := testPayment
+	declined.Status = "DECLINED"
+
+	cases := map[string]Event{
+		"payment_created":   PaymentCreated{Payment: Payment{TransactionID: paymentID, UserID: userID, ProviderID: providerID, ExternalReference: "invoice-42", Amount: 1500, Fee: 45, Currency: "USD", Status: "PENDING"}},
+		"payment_completed": PaymentCompleted{Payment: testPayment},
+		"payment_failed":    PaymentFailed{Payment: declined, Reason: "declined"},
+		"refund_created": RefundCreated{
+			RefundID:      refundID,
+			TransactionID: feeID,
+			PaymentID:     paymentID,
+			UserID:        userID,
+			Amount:        45,
+			Currency:      "USD",
+			Status:        "APPROVED",
+		},
+		"topup_completed": TopUpCompleted{TopUp: TopUp{TransactionID: paymentID, UserID: userID, Amount: 5000, Currency: "USD", Status: "APPROVED"}},
+		"schedule_failed": ScheduleFailed{ScheduleID: scheduleID, UserID: userID, Occurrence: occurredAt, Code: "INSUFFICIENT_FUNDS"},
+	}
+
+	ctx := ports.WithCorrelationID(context.Background(), "req-123")
+	for name, ev := range cases {
+		t.Run(name, func(t *testing.T) {
+			row, err := NewOutboxEvent(ctx, eventID, occurredAt, ev)
+			if err != nil {
+				t.Fatalf("new outbox event: %v", err)
+			}
+			if row.ID != eventID || row.EventType != ev.EventType() || !row.CreatedAt.Equal(occurredAt) {
+				t.Fatalf("unexpected outbox row %+v", row)
+			}
+
+			var got bytes.Buffer
+			if err := json.Indent(&got, []byte(row.Payload), "", "  "); err != nil {
+				t.Fatalf("indent payload: %v", err)
+			}
+			got.WriteByte('\n')
+
+			path := filepath.Join("testdata", name+".golden.json")
+			if *update {
+				if err := os.WriteFile(path, got.Bytes(), 0o644); err != nil {
+					t.Fatalf("write golden: %v", err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("read golden (run with -update to create it): %v", err)
+			}
+			if !bytes.Equal(got.Bytes(), want) {
+				t.Fatalf("payload does not match %s:\n%s", path, got.String())
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	row, err := NewOutboxEvent(context.Background(), eventID, occurredAt, PaymentCompleted{Payment: testPayment})
+	if err != nil {
+		t.Fatalf("new outbox event: %v", err)
+	}
+
+	env, err := Decode([]byte(row.Payload))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if env.EventID != eventID || env.Type != TypePaymentCompleted || env.Version != 1 || env.CorrelationID != "" ||
+		env.AggregateType != AggregatePayment || env.AggregateID != paymentID || !env.OccurredAt.Equal(occurredAt) {
+		t.Fatalf("unexpected envelope %+v", env)
+	}
+	var data PaymentCompleted
+	if err := json.Unmarshal(env.Data, &data); err != nil {
+		t.Fatalf("decode data: %v", err)
+	}
+	if data.Payment != testPayment {
+		t.Fatalf("unexpected data %+v", data)
+	}
+
+	for name, payload := range map[string]string{
+		"invalid json":     `{`,
+		"missing type":     `{"event_id":"0b6f1c3e-5d2a-4c1e-9a47-3f0d2b8e7c11","data":{}}`,
+		"missing event_id": `{"type":"payment.completed","data":{}}`,
+	} {
+		if _, err := Decode([]byte(payload)); err == nil {
+			t.Fatalf("%s: expected decode error", name)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package events
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tipos de evento (routing keys en el exchange payments.events).
+const (
+	TypePaymentCreated   = "payment.created"
+	TypePaymentHeld      = "payment.held"
+	TypePaymentCompleted = "payment.completed"
+	TypePaymentFailed    = "payment.failed"
+	TypeRefundCreated    = "refund.created"
+	TypeTopUpCreated     = "topup.created"
+	TypeTopUpCompleted   = "topup.completed"
+	TypeTopUpFailed      = "topup.failed"
+	TypeScheduleFailed   = "schedule.failed"
+)
+
+// Tipos de agregado.
+const (
+	AggregatePayment  = "payment"
+	AggregateTopUp    = "topup"
+	AggregateSchedule = "schedule"
+)
+
+// Payment son los datos comunes de los eventos de pago.
+type Payment struct {
+	TransactionID     uuid.UUID `json:"transaction_id"`
+	UserID            uuid.UUID `json:"user_id"`
+	ProviderID        uuid.UUID `json:"provider_id"`
+	ExternalReference string    `json:"external_reference"`
+	Amount            int64     `json:"amount"` // principal en minor units
+	Fee               int64     `json:"fee"`    // comisión en minor units, 0 si no aplica
+	Currency          string    `json:"currency"`
+	Status            string    `json:"status"`
+}
+
+func (Payment) EventVersion() int        { return 1 }
+func (Payment) AggregateType() string    { return AggregatePayment }
+func (p Payment) AggregateID() uuid.UUID { return p.TransactionID }
+
+// PaymentCreated se emite al debitar el pago, antes de enviarlo a la pasarela.
+type PaymentCreated struct{ Payment }
+
+func (PaymentCreated) EventType() string { return TypePaymentCreated }
+
+// PaymentHeld se emite cuando el screening de riesgo retiene el pago para revisión manual.
+type PaymentHeld struct{ Payment }
+
+func (PaymentHeld) EventType() string { return TypePaymentHeld }
+
+// PaymentCompleted se emite cuando la pasarela aprueba el pago.
+type PaymentCompleted struct{ Payment }
+
+func (PaymentCompleted) EventType() string { return TypePaymentCompleted }
+
+// PaymentFailed se emite cuando el pago es declinado, falla o se rechaza en revisión; los fondos se reembolsan.
+type PaymentFailed struct {
+	Payment
+	Reason string `json:"reason"` // declined, failed, rejected o el código de error de la pasarela
+}
+
+func (PaymentFailed) EventType() string { return TypePaymentFailed }
+
+// RefundCreated se emite por cada reembolso interno (principal o comisión) de un pago.
+type RefundCreated struct {
+	RefundID      uuid.UUID `json:"refund_id"`
+	TransactionID uuid.UUID `json:"transaction_id"` // transacción reembolsada (pago o comisión)
+	PaymentID     uuid.UUID `json:"payment_id"`     // pago al que pertenece el reembolso
+	UserID        uuid.UUID `json:"user_id"`
+	Amount        int64     `json:"amount"`
+	Currency      string    `json:"currency"`
+	Status        string    `json:"status"`
+}
+
+func (RefundCreated) EventType() string        { return TypeRefundCreated }
+func (RefundCreated) EventVersion() int        { return 1 }
+func (RefundCreated) AggregateType() string    { return AggregatePayment }
+func (r RefundCreated) AggregateID() uuid.UUID { return r.PaymentID }
+
+// TopUp son los datos comunes de los eventos de recarga.
+type TopUp struct {
+	TransactionID uuid.UUID `json:"transaction_id"`
+	UserID        uuid.UUID `json:"user_id"`
+	Amount        int64     `json:"amount"`
+	Currency      string    `json:"currency"`
+	Status        string    `json:"status"`
+}
+
+func (TopUp) EventVersion() int        { return 1 }
+func (TopUp) AggregateType() string    { return AggregateTopUp }
+func (t TopUp) AggregateID() uuid.UUID { return t.TransactionID }
+
+// TopUpCreated se emite al registrar la recarga, antes de cobrar la fuente de fondos.
+type TopUpCreated struct{ TopUp }
+
+func (TopUpCreated) EventType() string { return TypeTopUpCreated }
+
+// TopUpCompleted se emite al acreditar la recarga.
+type TopUpCompleted struct{ TopUp }
+
+func (TopUpCompleted) EventType() string { return TypeTopUpCompleted }
+
+// TopUpFailed se emite cuando el cobro de la recarga es declinado o falla.
+type TopUpFailed struct{ TopUp }
+
+func (TopUpFailed) EventType() string { return TypeTopUpFailed }
+
+// ScheduleFailed se emite cuando una ocurrencia de un pago programado no se completa.
+type ScheduleFailed struct {
+	ScheduleID uuid.UUID `json:"schedule_id"`
+	UserID     uuid.UUID `json:"user_id"`
+	Occurrence time.Time `json:"occurrence"`
+	Code       string    `json:"code"`
+}
+
+func (ScheduleFailed) EventType() string        { return TypeScheduleFailed }
+func (ScheduleFailed) EventVersion() int        { return 1 }
+func (ScheduleFailed) AggregateType() string    { return AggregateSchedule }
+func (s ScheduleFailed) AggregateID() uuid.UUID { return s.ScheduleID }
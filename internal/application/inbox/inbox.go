@@ -0,0 +1,92 @@
+package inbox
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"draftea-challenge/internal/application/ports"
+	"draftea-challenge/internal/domain/errors"
+)
+
+// purgeBatchSize acota las filas borradas por consulta en Purge.
+const purgeBatchSize = 1000
+
+// Inbox hace idempotente a un consumidor: los efectos del handler y el registro del mensaje
+// confirman juntos, así una entrega repetida no vuelve a aplicarse.
+type Inbox[R any] struct {
+	store    Store[R]
+	clock    ports.Clock
+	consumer string
+}
+
+// NewInbox crea una nueva instancia de Inbox para el consumidor indicado (p. ej. su cola).
+func NewInbox[R any](store Store[R], clock ports.Clock, consumer string) *Inbox[R] {
+	return &Inbox[R]{store: store, clock: clock, consumer: consumer}
+}
+
+// Process ejecuta fn una sola vez por messageID. Retorna false, sin ejecutar fn, si el mensaje ya
+// había sido procesado. Las funciones registradas con AfterCommit corren después de confirmar.
+func (i *Inbox[R]) Process(ctx context.Context, messageID, eventType string, fn func(ctx context.Context, repos R) error) (bool, error) {
+	if messageID == "" {
+		return false, errors.NewValidationError("message id is required", map[string]interface{}{"consumer": i.consumer})
+	}
+	hooks := &afterCommitHooks{}
+	msg := ProcessedMessage{
+		Consumer:    i.consumer,
+		MessageID:   messageID,
+		EventType:   eventType,
+		ProcessedAt: i.clock.Now().UTC(),
+	}
+	processed, err := i.store.ProcessMessage(context.WithValue(ctx, afterCommitKey{}, hooks), msg, fn)
+	if err != nil || !processed {
+		return false, err
+	}
+	hooks.run()
+	return true, nil
+}
+
+// Purge borra los mensajes procesados hace más de retention. Un duplicado que llegue después vuelve
+// a procesarse, así que retention debe cubrir la reentrega más tardía esperable (p. ej. un replay de
+// la DLQ).
+func (i *Inbox[R]) Purge(ctx context.Context, retention time.Duration) (int, error) {
+	before := i.clock.Now().Add(-retention)
+	total := 0
+	for {
+		n, err := i.store.PurgeProcessedMessages(ctx, i.consumer, before, purgeBatchSize)
+		total += n
+		if err != nil || n < purgeBatchSize {
+			return total, err
+		}
+	}
+}
+
+type afterCommitKey struct{}
+
+type afterCommitHooks struct {
+	mu    sync.Mutex
+	funcs []func()
+}
+
+func (h *afterCommitHooks) run() {
+	h.mu.Lock()
+	funcs := h.funcs
+	h.funcs = nil
+	h.mu.Unlock()
+	for _, f := range funcs {
+		f()
+	}
+}
+
+// AfterCommit registra f para cuando confirme la transacción de Process que recibió ctx, p. ej. para
+// actualizar estado en memoria. Si la transacción falla f no se ejecuta; fuera de Process corre ya.
+func AfterCommit(ctx context.Context, f func()) {
+	hooks, ok := ctx.Value(afterCommitKey{}).(*afterCommitHooks)
+	if !ok {
+		f()
+		return
+	}
+	hooks.mu.Lock()
+	hooks.funcs = append(hooks.funcs, f)
+	hooks.mu.Unlock()
+}
@@ -0,0 +1,101 @@
+package inbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	domainerrors "draftea-challenge/internal/domain/errors"
+)
+
+// fakeStore keeps processed messages in memory; repos is a counter the handler writes to.
+type fakeStore struct {
+	processed map[string]bool
+	writes    int
+	purged    []time.Time
+}
+
+func (f *fakeStore) ProcessMessage(ctx context.Context, msg ProcessedMessage, fn func(ctx context.Context, repos *int) error) (bool, error) {
+	key := msg.Consumer + "/" + msg.MessageID
+	if f.processed[key] {
+		return false, nil
+	}
+	writes := f.writes
+	if err := fn(ctx, &writes); err != nil {
+		return false, err
+	}
+	f.writes = writes
+	f.processed[key] = true
+	return true, nil
+}
+
+func (f *fakeStore) PurgeProcessedMessages(ctx context.Context, consumer string, before time.Time, limit int) (int, error) {
+	f.purged = append(f.purged, before)
+	return 0, nil
+}
+
+type fixedClock struct{ now time.Time }
+
+func (c fixedClock) Now() time.Time { return c.now }
+
+func TestProcessAppliesOnceAndRunsHooksAfterCommit(t *testing.T) {
+	store := &fakeStore{processed: make(map[string]bool)}
+	in := NewInbox[*int](store, fixedClock{now: time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)}, "metrics.queue")
+	ctx := context.Background()
+
+	hooks := 0
+	handler := func(fail bool) func(ctx context.Context, repos *int) error {
+		return func(ctx context.Context, repos *int) error {
+			*repos++
+			AfterCommit(ctx, func() { hooks++ })
+			if fail {
+				return errors.New("handler failed")
+			}
+			return nil
+		}
+	}
+
+	if processed, err := in.Process(ctx, "evt-1", "payment.completed", handler(true)); err == nil || processed {
+		t.Fatalf("expected failure, got %v %v", processed, err)
+	}
+	if store.writes != 0 || hooks != 0 {
+		t.Fatalf("failed handler leaked effects: writes=%d hooks=%d", store.writes, hooks)
+	}
+
+	for i, want := range []bool{true, false} {
+		processed, err := in.Process(ctx, "evt-1", "payment.completed", handler(false))
+		if err != nil || processed != want {
+			t.Fatalf("delivery %d: expected processed=%v, got %v %v", i, want, processed, err)
+		}
+	}
+	if store.writes != 1 || hooks != 1 {
+		t.Fatalf("expected one write and one hook, got writes=%d hooks=%d", store.writes, hooks)
+	}
+
+	_, err := in.Process(ctx, "", "payment.completed", handler(false))
+	var domErr domainerrors.Error
+	if !errors.As(err, &domErr) || domErr.Code != domainerrors.CodeValidationError {
+		t.Fatalf("expected validation error for empty message id, got %v", err)
+	}
+}
+
+func TestAfterCommitOutsideProcessRunsImmediately(t *testing.T) {
+	ran := false
+	AfterCommit(context.Background(), func() { ran = true })
+	if !ran {
+		t.Fatalf("expected hook to run immediately")
+	}
+}
+
+func TestPurgeUsesRetention(t *testing.T) {
+	store := &fakeStore{processed: make(map[string]bool)}
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	in := NewInbox[*int](store, fixedClock{now: now}, "metrics.queue")
+	if _, err := in.Purge(context.Background(), 24*time.Hour); err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	if len(store.purged) != 1 || !store.purged[0].Equal(now.Add(-24*time.Hour)) {
+		t.Fatalf("unexpected purge cutoff %v", store.purged)
+	}
+}
@@ -0,0 +1,23 @@
+package inbox
+
+import (
+	"context"
+	"time"
+)
+
+// ProcessedMessage registra que un consumidor procesó un mensaje.
+type ProcessedMessage struct {
+	Consumer    string    `json:"consumer"`
+	MessageID   string    `json:"message_id"`
+	EventType   string    `json:"event_type"`
+	ProcessedAt time.Time `json:"processed_at"`
+}
+
+// Store persiste los mensajes procesados. R es el acceso a los repositorios ligado a la transacción.
+type Store[R any] interface {
+	// ProcessMessage inserta msg y ejecuta fn en la misma transacción. Si (consumer, message_id) ya
+	// estaba registrado no ejecuta fn y retorna false; si fn falla no queda nada escrito.
+	ProcessMessage(ctx context.Context, msg ProcessedMessage, fn func(ctx context.Context, repos R) error) (bool, error)
+	// PurgeProcessedMessages borra hasta limit mensajes del consumidor procesados antes de before.
+	PurgeProcessedMessages(ctx context.Context, consumer string, before time.Time, limit int) (int, error)
+}
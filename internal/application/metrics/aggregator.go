@@ -0,0 +1,256 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"draftea-challenge/internal/application/events"
+	"draftea-challenge/internal/application/ports"
+	"draftea-challenge/internal/domain/errors"
+	"draftea-challenge/internal/domain/transaction"
+)
+
+// maxHourlyRange limita el rango consultable de rollups horarios.
+const maxHourlyRange = 31 * 24 * time.Hour
+
+// Aggregator calcula KPIs de pagos a partir de los eventos payment.*: mantiene ventanas móviles
+// en memoria (buckets por minuto) y persiste rollups horarios por estado, moneda y proveedor.
+type Aggregator struct {
+	repo    Repository
+	clock   ports.Clock
+	windows []time.Duration
+
+	mu      sync.Mutex
+	buckets map[int64]map[Key]*Totals // clave: minuto Unix del evento
+	pruned  int64
+}
+
+// NewAggregator crea una nueva instancia de Aggregator. Sin ventanas configuradas usa 5m, 1h y 24h.
+func NewAggregator(repo Repository, clock ports.Clock, windows []time.Duration) *Aggregator {
+	valid := make([]time.Duration, 0, len(windows))
+	for _, w := range windows {
+		if w >= time.Minute {
+			valid = append(valid, w.Truncate(time.Minute))
+		}
+	}
+	if len(valid) == 0 {
+		valid = []time.Duration{5 * time.Minute, time.Hour, 24 * time.Hour}
+	}
+	sort.Slice(valid, func(i, j int) bool { return valid[i] < valid[j] })
+	return &Aggregator{
+		repo:    repo,
+		clock:   clock,
+		windows: valid,
+		buckets: make(map[int64]map[Key]*Totals),
+	}
+}
+
+// Handle procesa el payload de un evento. Los eventos que no son de pago se ignoran; el rollup
+// horario se persiste antes de actualizar las ventanas, así un error deja el evento para reintentar.
+func (a *Aggregator) Handle(ctx context.Context, payload []byte) error {
+	record, err := a.Stage(ctx, a.repo, payload)
+	if err != nil {
+		return err
+	}
+	record()
+	return nil
+}
+
+// Stage persiste el rollup horario del evento con repo (p. ej. ligado a la transacción del inbox) y
+// retorna la función que lo suma a las ventanas en memoria, a llamar una vez confirmada la escritura.
+func (a *Aggregator) Stage(ctx context.Context, repo Repository, payload []byte) (func(), error) {
+	env, err := events.Decode(payload)
+	if err != nil {
+		return nil, err
+	}
+	switch env.Type {
+	case events.TypePaymentCreated, events.TypePaymentHeld, events.TypePaymentCompleted, events.TypePaymentFailed:
+	default:
+		return func() {}, nil
+	}
+	if env.Version != 1 {
+		return nil, fmt.Errorf("unsupported %s version %d", env.Type, env.Version)
+	}
+
+	var p events.Payment
+	if err := json.Unmarshal(env.Data, &p); err != nil {
+		return nil, fmt.Errorf("decode %s data: %w", env.Type, err)
+	}
+	occurredAt := env.OccurredAt.UTC()
+	if occurredAt.IsZero() {
+		occurredAt = a.clock.Now().UTC()
+	}
+
+	key := Key{Status: p.Status, Currency: p.Currency, ProviderID: p.ProviderID}
+	totals := Totals{Count: 1, Amount: p.Amount, Fee: p.Fee}
+	if err := repo.AddHourly(ctx, HourlyRollup{BucketStart: occurredAt.Truncate(time.Hour), Key: key, Totals: totals}); err != nil {
+		return nil, err
+	}
+	return func() { a.record(occurredAt, key, totals) }, nil
+}
+
+func (a *Aggregator) record(occurredAt time.Time, key Key, totals Totals) {
+	minute := occurredAt.Unix() / 60
+	oldest := a.clock.Now().Add(-a.windows[len(a.windows)-1]).Unix() / 60
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if minute < oldest {
+		return
+	}
+	series, ok := a.buckets[minute]
+	if !ok {
+		series = make(map[Key]*Totals)
+		a.buckets[minute] = series
+	}
+	t, ok := series[key]
+	if !ok {
+		t = &Totals{}
+		series[key] = t
+	}
+	t.add(totals)
+
+	// Los buckets fuera de la ventana más larga se descartan una vez por minuto.
+	if oldest > a.pruned {
+		for m := range a.buckets {
+			if m < oldest {
+				delete(a.buckets, m)
+			}
+		}
+		a.pruned = oldest
+	}
+}
+
+// WindowSeries son los totales de una serie dentro de una ventana.
+type WindowSeries struct {
+	Key
+	Totals
+}
+
+// WindowSnapshot representa los KPIs de una ventana móvil terminada en To. Series tiene una fila
+// por estado, así un pago aparece como PENDING y luego con su estado final; Total suma solo los
+// estados finales para contar cada pago una vez.
+type WindowSnapshot struct {
+	Window string         `json:"window"`
+	From   time.Time      `json:"from"`
+	To     time.Time      `json:"to"`
+	Total  Totals         `json:"total"`
+	Series []WindowSeries `json:"series"`
+}
+
+// SnapshotResponse representa la respuesta de las ventanas móviles.
+type SnapshotResponse struct {
+	Windows []*WindowSnapshot `json:"windows"`
+}
+
+// Snapshot devuelve los KPIs de la ventana pedida, o de todas las configuradas si window es 0.
+func (a *Aggregator) Snapshot(window time.Duration) (*SnapshotResponse, error) {
+	windows := a.windows
+	if window != 0 {
+		windows = nil
+		for _, w := range a.windows {
+			if w == window {
+				windows = []time.Duration{w}
+			}
+		}
+		if windows == nil {
+			allowed := make([]string, 0, len(a.windows))
+			for _, w := range a.windows {
+				allowed = append(allowed, w.String())
+			}
+			return nil, errors.NewValidationError("unsupported window", map[string]interface{}{"window": window.String(), "allowed": allowed})
+		}
+	}
+
+	now := a.clock.Now().UTC()
+	resp := &SnapshotResponse{Windows: make([]*WindowSnapshot, 0, len(windows))}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, w := range windows {
+		resp.Windows = append(resp.Windows, a.snapshot(now, w))
+	}
+	return resp, nil
+}
+
+// snapshot suma los buckets de los minutos que caen en la ventana; el minuto actual cuenta completo.
+func (a *Aggregator) snapshot(now time.Time, window time.Duration) *WindowSnapshot {
+	from := now.Add(-window)
+	first := from.Unix() / 60
+	sums := make(map[Key]*Totals)
+	snap := &WindowSnapshot{Window: window.String(), From: from, To: now}
+	for minute, series := range a.buckets {
+		if minute < first {
+			continue
+		}
+		for key, t := range series {
+			sum, ok := sums[key]
+			if !ok {
+				sum = &Totals{}
+				sums[key] = sum
+			}
+			sum.add(*t)
+			if isFinal(key.Status) {
+				snap.Total.add(*t)
+			}
+		}
+	}
+
+	snap.Series = make([]WindowSeries, 0, len(sums))
+	for key, t := range sums {
+		snap.Series = append(snap.Series, WindowSeries{Key: key, Totals: *t})
+	}
+	sort.Slice(snap.Series, func(i, j int) bool {
+		x, y := snap.Series[i].Key, snap.Series[j].Key
+		if x.Status != y.Status {
+			return x.Status < y.Status
+		}
+		if x.Currency != y.Currency {
+			return x.Currency < y.Currency
+		}
+		return x.ProviderID.String() < y.ProviderID.String()
+	})
+	return snap
+}
+
+// isFinal indica si el estado es el resultado de un pago (payment.completed o payment.failed).
+func isFinal(status string) bool {
+	switch transaction.Status(status) {
+	case transaction.StatusApproved, transaction.StatusDeclined, transaction.StatusFailed:
+		return true
+	}
+	return false
+}
+
+// ListHourlyResponse representa la respuesta de rollups horarios.
+type ListHourlyResponse struct {
+	From    time.Time      `json:"from"`
+	To      time.Time      `json:"to"`
+	Rollups []HourlyRollup `json:"rollups"`
+}
+
+// ListHourly lista los rollups horarios persistidos en [from, to). Sin to usa el fin de la hora
+// actual y sin from las 24 horas previas a to.
+func (a *Aggregator) ListHourly(ctx context.Context, from, to time.Time) (*ListHourlyResponse, error) {
+	if to.IsZero() {
+		to = a.clock.Now().UTC().Truncate(time.Hour).Add(time.Hour)
+	}
+	if from.IsZero() {
+		from = to.Add(-24 * time.Hour)
+	}
+	if !from.Before(to) {
+		return nil, errors.NewValidationError("from must be before to", map[string]interface{}{"from": from, "to": to})
+	}
+	if to.Sub(from) > maxHourlyRange {
+		return nil, errors.NewValidationError("range too large", map[string]interface{}{"max": maxHourlyRange.String()})
+	}
+
+	rollups, err := a.repo.ListHourly(ctx, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, err
+	}
+	return &ListHourlyResponse{From: from.UTC(), To: to.UTC(), Rollups: rollups}, nil
+}
@@ -0,0 +1,182 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"draftea-challenge/internal/application/events"
+
+	"github.com/google/uuid"
+)
+
+type fakeRepo struct {
+	rollups []HourlyRollup
+	err     error
+}
+
+func (f *fakeRepo) AddHourly(ctx context.Context, delta HourlyRollup) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.rollups = append(f.rollups, delta)
+	return nil
+}
+
+func (f *fakeRepo) ListHourly(ctx context.Context, from, to time.Time) ([]HourlyRollup, error) {
+	return f.rollups, nil
+}
+
+type manualClock struct{ now time.Time }
+
+func (c *manualClock) Now() time.Time { return c.now }
+
+func paymentEvent(t *testing.T, ev events.Event, occurredAt time.Time) []byte {
+	t.Helper()
+	env, err := events.NewEnvelope(context.Background(), uuid.New(), occurredAt, ev)
+	if err != nil {
+		t.Fatalf("envelope: %v", err)
+	}
+	payload, err := json.Marshal(env)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return payload
+}
+
+func TestAggregatorRollsUpPaymentEvents(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 30, 0, 0, time.UTC)
+	clock := &manualClock{now: now}
+	repo := &fakeRepo{}
+	agg := NewAggregator(repo, clock, []time.Duration{5 * time.Minute, time.Hour})
+	provider := uuid.New()
+	ctx := context.Background()
+
+	completed := events.PaymentCompleted{Payment: events.Payment{ProviderID: provider, Amount: 1500, Fee: 45, Currency: "USD", Status: "APPROVED"}}
+	failed := events.PaymentFailed{Payment: events.Payment{ProviderID: provider, Amount: 700, Currency: "USD", Status: "DECLINED"}, Reason: "declined"}
+	for _, payload := range [][]byte{
+		paymentEvent(t, completed, now.Add(-time.Minute)),
+		paymentEvent(t, completed, now.Add(-20*time.Minute)),
+		paymentEvent(t, failed, now.Add(-2*time.Minute)),
+		paymentEvent(t, events.TopUpCompleted{TopUp: events.TopUp{Amount: 99, Currency: "USD"}}, now), // ignored
+	} {
+		if err := agg.Handle(ctx, payload); err != nil {
+			t.Fatalf("handle: %v", err)
+		}
+	}
+
+	if len(repo.rollups) != 3 {
+		t.Fatalf("expected 3 hourly deltas, got %d", len(repo.rollups))
+	}
+	if !repo.rollups[1].BucketStart.Equal(time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected bucket %v", repo.rollups[1].BucketStart)
+	}
+
+	resp, err := agg.Snapshot(0)
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	if len(resp.Windows) != 2 {
+		t.Fatalf("expected 2 windows, got %d", len(resp.Windows))
+	}
+	short, long := resp.Windows[0], resp.Windows[1]
+	if short.Window != "5m0s" || short.Total != (Totals{Count: 2, Amount: 2200, Fee: 45}) || len(short.Series) != 2 {
+		t.Fatalf("unexpected 5m window %+v", short)
+	}
+	if short.Series[0].Status != "APPROVED" || short.Series[0].Totals != (Totals{Count: 1, Amount: 1500, Fee: 45}) {
+		t.Fatalf("unexpected series %+v", short.Series[0])
+	}
+	if long.Total != (Totals{Count: 3, Amount: 3700, Fee: 90}) {
+		t.Fatalf("unexpected 1h window %+v", long)
+	}
+
+	// Once the events age out of every window they no longer count.
+	clock.now = now.Add(2 * time.Hour)
+	resp, err = agg.Snapshot(time.Hour)
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	if resp.Windows[0].Total.Count != 0 {
+		t.Fatalf("expected empty window, got %+v", resp.Windows[0])
+	}
+
+	if _, err := agg.Snapshot(10 * time.Minute); err == nil {
+		t.Fatalf("expected error for unconfigured window")
+	}
+}
+
+func TestAggregatorTotalCountsEachPaymentOnce(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 30, 0, 0, time.UTC)
+	agg := NewAggregator(&fakeRepo{}, &manualClock{now: now}, []time.Duration{time.Hour})
+	ctx := context.Background()
+
+	// One payment held and then approved, another created and declined.
+	held := events.Payment{Amount: 1000, Fee: 30, Currency: "USD"}
+	declined := events.Payment{Amount: 400, Currency: "USD"}
+	for _, ev := range []events.Event{
+		events.PaymentCreated{Payment: withStatus(held, "PENDING")},
+		events.PaymentHeld{Payment: withStatus(held, "HELD")},
+		events.PaymentCompleted{Payment: withStatus(held, "APPROVED")},
+		events.PaymentCreated{Payment: withStatus(declined, "PENDING")},
+		events.PaymentFailed{Payment: withStatus(declined, "DECLINED"), Reason: "declined"},
+	} {
+		if err := agg.Handle(ctx, paymentEvent(t, ev, now.Add(-time.Minute))); err != nil {
+			t.Fatalf("handle: %v", err)
+		}
+	}
+
+	resp, err := agg.Snapshot(time.Hour)
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	w := resp.Windows[0]
+	if w.Total != (Totals{Count: 2, Amount: 1400, Fee: 30}) {
+		t.Fatalf("expected each payment counted once, got %+v", w.Total)
+	}
+	if len(w.Series) != 4 {
+		t.Fatalf("expected a series per status, got %+v", w.Series)
+	}
+}
+
+func withStatus(p events.Payment, status string) events.Payment {
+	p.Status = status
+	return p
+}
+
+func TestAggregatorKeepsWindowsWhenPersistFails(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 30, 0, 0, time.UTC)
+	repo := &fakeRepo{err: errors.New("db down")}
+	agg := NewAggregator(repo, &manualClock{now: now}, nil)
+
+	payload := paymentEvent(t, events.PaymentCreated{Payment: events.Payment{Amount: 100, Currency: "USD", Status: "PENDING"}}, now)
+	if err := agg.Handle(context.Background(), payload); err == nil {
+		t.Fatalf("expected persist error")
+	}
+	resp, _ := agg.Snapshot(0)
+	for _, w := range resp.Windows {
+		if w.Total.Count != 0 {
+			t.Fatalf("failed event counted in window %s", w.Window)
+		}
+	}
+}
+
+func TestListHourlyValidatesRange(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 30, 0, 0, time.UTC)
+	agg := NewAggregator(&fakeRepo{}, &manualClock{now: now}, nil)
+
+	resp, err := agg.ListHourly(context.Background(), time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if !resp.To.Equal(time.Date(2026, 5, 1, 13, 0, 0, 0, time.UTC)) || resp.To.Sub(resp.From) != 24*time.Hour {
+		t.Fatalf("unexpected default range %v - %v", resp.From, resp.To)
+	}
+	if _, err := agg.ListHourly(context.Background(), now, now.Add(-time.Hour)); err == nil {
+		t.Fatalf("expected error for inverted range")
+	}
+	if _, err := agg.ListHourly(context.Background(), now.Add(-60*24*time.Hour), now); err == nil {
+		t.Fatalf("expected error for range too large")
+	}
+}
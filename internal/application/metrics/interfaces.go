@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Key identifica una serie de KPIs: estado, moneda y proveedor del pago.
+type Key struct {
+	Status     string    `json:"status"`
+	Currency   string    `json:"currency"`
+	ProviderID uuid.UUID `json:"provider_id"`
+}
+
+// Totals acumula la cantidad de eventos y sus montos (minor units) de una serie.
+type Totals struct {
+	Count  int64 `json:"count"`
+	Amount int64 `json:"amount"`
+	Fee    int64 `json:"fee"`
+}
+
+func (t *Totals) add(o Totals) {
+	t.Count += o.Count
+	t.Amount += o.Amount
+	t.Fee += o.Fee
+}
+
+// HourlyRollup es el acumulado de una serie en una hora UTC.
+type HourlyRollup struct {
+	BucketStart time.Time `json:"bucket_start"`
+	Key
+	Totals
+}
+
+// Repository persiste los rollups horarios.
+type Repository interface {
+	// AddHourly suma los totales de delta a la fila de su hora y serie, creándola si no existe.
+	AddHourly(ctx context.Context, delta HourlyRollup) error
+	// ListHourly lista los rollups con bucket_start en [from, to), ordenados por hora.
+	ListHourly(ctx context.Context, from, to time.Time) ([]HourlyRollup, error)
+}
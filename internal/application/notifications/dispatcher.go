@@ -0,0 +1,101 @@
+package notifications
+
+import (
+	"context"
+	"draftea-challenge/internal/application/events"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// Dispatcher renderiza y envía la notificación de un evento de pago o reembolso según las
+// preferencias del usuario. La deduplicación por evento la hace el inbox del consumidor.
+type Dispatcher struct {
+	prefs     PreferencesRepository
+	notifier  Notifier
+	templates *Templates
+}
+
+// NewDispatcher crea una nueva instancia de Dispatcher.
+func NewDispatcher(prefs PreferencesRepository, notifier Notifier, templates *Templates) *Dispatcher {
+	return &Dispatcher{prefs: prefs, notifier: notifier, templates: templates}
+}
+
+// Notify envía la notificación del evento y reporta si se envió. Los eventos sin plantilla,
+// los reembolsos de comisión, y los de usuarios sin preferencias o con el tipo desactivado se
+// ignoran sin error; un error del Notifier se retorna para que el mensaje se reintente.
+func (d *Dispatcher) Notify(ctx context.Context, payload []byte) (bool, error) {
+	env, err := events.Decode(payload)
+	if err != nil {
+		return false, err
+	}
+	if env.Version != 1 || !d.templates.Has(env.Type) {
+		return false, nil
+	}
+	data, userID, err := templateData(env)
+	if err != nil {
+		return false, err
+	}
+	if userID == uuid.Nil {
+		return false, nil
+	}
+
+	prefs, err := d.prefs.GetNotificationPreferences(ctx, userID)
+	if err != nil {
+		if isNotFoundError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if prefs.Email == "" || !prefs.Wants(env.Type) {
+		return false, nil
+	}
+
+	subject, body, locale, err := d.templates.Render(prefs.Locale, env.Type, data)
+	if err != nil {
+		return false, err
+	}
+	msg := Message{
+		EventID:   env.EventID,
+		EventType: env.Type,
+		UserID:    userID,
+		Locale:    locale,
+		To:        prefs.Email,
+		Subject:   subject,
+		Body:      body,
+	}
+	if err := d.notifier.Notify(ctx, msg); err != nil {
+		return false, fmt.Errorf("notify %s for event %s: %w", env.Type, env.EventID, err)
+	}
+	return true, nil
+}
+
+func templateData(env *events.Envelope) (TemplateData, uuid.UUID, error) {
+	data := TemplateData{EventType: env.Type, OccurredAt: env.OccurredAt}
+	switch env.Type {
+	case events.TypePaymentCompleted, events.TypePaymentFailed:
+		var ev events.PaymentFailed // PaymentCompleted sin Reason
+		if err := json.Unmarshal(env.Data, &ev); err != nil {
+			return data, uuid.Nil, fmt.Errorf("decode %s data: %w", env.Type, err)
+		}
+		data.TransactionID, data.PaymentID = ev.TransactionID, ev.TransactionID
+		data.Amount, data.Fee, data.Currency, data.Reason = ev.Amount, ev.Fee, ev.Currency, ev.Reason
+		return data, ev.UserID, nil
+	case events.TypeRefundCreated:
+		var ev events.RefundCreated
+		if err := json.Unmarshal(env.Data, &ev); err != nil {
+			return data, uuid.Nil, fmt.Errorf("decode %s data: %w", env.Type, err)
+		}
+		// Un pago fallido con comisión emite dos reembolsos; solo se notifica el del principal
+		// para que el usuario reciba un único aviso por pago.
+		if ev.TransactionID != ev.PaymentID {
+			return data, uuid.Nil, nil
+		}
+		data.TransactionID, data.PaymentID = ev.RefundID, ev.PaymentID
+		data.Amount, data.Currency = ev.Amount, ev.Currency
+		return data, ev.UserID, nil
+	default:
+		return data, uuid.Nil, fmt.Errorf("unsupported event type %s", env.Type)
+	}
+}
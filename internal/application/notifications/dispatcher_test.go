@@ -0,0 +1,199 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"draftea-challenge/internal/application/events"
+	"draftea-challenge/internal/domain/errors"
+
+	"github.com/google/uuid"
+)
+
+type fakePrefsRepo struct {
+	prefs map[uuid.UUID]*Preferences
+}
+
+func (r *fakePrefsRepo) GetNotificationPreferences(ctx context.Context, userID uuid.UUID) (*Preferences, error) {
+	p, ok := r.prefs[userID]
+	if !ok {
+		return nil, errors.NewNotFoundError("notification preferences not found")
+	}
+	cp := *p
+	return &cp, nil
+}
+
+func (r *fakePrefsRepo) SaveNotificationPreferences(ctx context.Context, p *Preferences) error {
+	cp := *p
+	r.prefs[p.UserID] = &cp
+	return nil
+}
+
+type fakeNotifier struct {
+	sent []Message
+	err  error
+}
+
+func (n *fakeNotifier) Notify(ctx context.Context, msg Message) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.sent = append(n.sent, msg)
+	return nil
+}
+
+type fixedClock struct{ now time.Time }
+
+func (c fixedClock) Now() time.Time { return c.now }
+
+var occurredAt = time.Date(2026, 5, 1, 12, 30, 0, 0, time.UTC)
+
+func eventPayload(t *testing.T, ev events.Event) []byte {
+	t.Helper()
+	env, err := events.NewEnvelope(context.Background(), uuid.New(), occurredAt, ev)
+	if err != nil {
+		t.Fatalf("envelope: %v", err)
+	}
+	payload, err := json.Marshal(env)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return payload
+}
+
+func newTestDispatcher(t *testing.T, prefs ...*Preferences) (*Dispatcher, *fakeNotifier) {
+	t.Helper()
+	templates, err := NewTemplates(LocaleES)
+	if err != nil {
+		t.Fatalf("templates: %v", err)
+	}
+	repo := &fakePrefsRepo{prefs: make(map[uuid.UUID]*Preferences)}
+	for _, p := range prefs {
+		repo.prefs[p.UserID] = p
+	}
+	notifier := &fakeNotifier{}
+	return NewDispatcher(repo, notifier, templates), notifier
+}
+
+func TestNotifyRendersTheUserLocale(t *testing.T) {
+	es := &Preferences{UserID: uuid.New(), Email: "ana@example.com", Locale: LocaleES, PaymentCompleted: true, PaymentFailed: true, RefundCreated: true}
+	en := &Preferences{UserID: uuid.New(), Email: "bob@example.com", Locale: LocaleEN, PaymentCompleted: true, PaymentFailed: true, RefundCreated: true}
+	d, notifier := newTestDispatcher(t, es, en)
+	ctx := context.Background()
+
+	payment := events.Payment{TransactionID: uuid.New(), UserID: es.UserID, Amount: 150000, Fee: 45, Currency: "USD"}
+	if sent, err := d.Notify(ctx, eventPayload(t, events.PaymentCompleted{Payment: payment})); err != nil || !sent {
+		t.Fatalf("notify es: sent=%v err=%v", sent, err)
+	}
+	payment.UserID = en.UserID
+	if sent, err := d.Notify(ctx, eventPayload(t, events.PaymentFailed{Payment: payment, Reason: "declined"})); err != nil || !sent {
+		t.Fatalf("notify en: sent=%v err=%v", sent, err)
+	}
+	refund := events.RefundCreated{RefundID: uuid.New(), TransactionID: payment.TransactionID, PaymentID: payment.TransactionID, UserID: en.UserID, Amount: 45, Currency: "USD"}
+	if sent, err := d.Notify(ctx, eventPayload(t, refund)); err != nil || !sent {
+		t.Fatalf("notify refund: sent=%v err=%v", sent, err)
+	}
+
+	if len(notifier.sent) != 3 {
+		t.Fatalf("expected 3 notifications, got %d", len(notifier.sent))
+	}
+	completed, failed, refunded := notifier.sent[0], notifier.sent[1], notifier.sent[2]
+	if completed.To != es.Email || completed.Locale != LocaleES || completed.Subject != "Tu pago de 1.500,00 USD se completó" {
+		t.Fatalf("unexpected es message %+v", completed)
+	}
+	if !strings.Contains(completed.Body, "01/05/2026 12:30 UTC") || !strings.Contains(completed.Body, "comisión de 0,45 USD") {
+		t.Fatalf("unexpected es body %q", completed.Body)
+	}
+	if failed.To != en.Email || failed.Subject != "Your payment of 1,500.00 USD did not go through" || !strings.Contains(failed.Body, "the provider declined it") {
+		t.Fatalf("unexpected en message %+v", failed)
+	}
+	if refunded.Subject != "We refunded 0.45 USD to your wallet" || !strings.Contains(refunded.Body, refund.RefundID.String()) {
+		t.Fatalf("unexpected refund message %+v", refunded)
+	}
+}
+
+func TestNotifySendsOnlyThePrincipalRefundOfAPayment(t *testing.T) {
+	prefs := &Preferences{UserID: uuid.New(), Email: "bob@example.com", Locale: LocaleEN, RefundCreated: true}
+	d, notifier := newTestDispatcher(t, prefs)
+	ctx := context.Background()
+
+	paymentID, feeID := uuid.New(), uuid.New()
+	principal := events.RefundCreated{RefundID: uuid.New(), TransactionID: paymentID, PaymentID: paymentID, UserID: prefs.UserID, Amount: 1500, Currency: "USD"}
+	fee := events.RefundCreated{RefundID: uuid.New(), TransactionID: feeID, PaymentID: paymentID, UserID: prefs.UserID, Amount: 45, Currency: "USD"}
+	if sent, err := d.Notify(ctx, eventPayload(t, principal)); err != nil || !sent {
+		t.Fatalf("notify principal refund: sent=%v err=%v", sent, err)
+	}
+	if sent, err := d.Notify(ctx, eventPayload(t, fee)); err != nil || sent {
+		t.Fatalf("expected the fee refund skipped: sent=%v err=%v", sent, err)
+	}
+	if len(notifier.sent) != 1 || !strings.Contains(notifier.sent[0].Body, principal.RefundID.String()) {
+		t.Fatalf("expected one principal refund notification, got %+v", notifier.sent)
+	}
+}
+
+func TestNotifySkipsUsersWithoutPreferencesOrOptedOut(t *testing.T) {
+	optedOut := &Preferences{UserID: uuid.New(), Email: "ana@example.com", Locale: LocaleEN, PaymentCompleted: false, PaymentFailed: true}
+	d, notifier := newTestDispatcher(t, optedOut)
+	ctx := context.Background()
+
+	cases := []events.Event{
+		events.PaymentCompleted{Payment: events.Payment{TransactionID: uuid.New(), UserID: optedOut.UserID, Amount: 100, Currency: "USD"}},
+		events.PaymentCompleted{Payment: events.Payment{TransactionID: uuid.New(), UserID: uuid.New(), Amount: 100, Currency: "USD"}},
+		events.PaymentHeld{Payment: events.Payment{TransactionID: uuid.New(), UserID: optedOut.UserID, Amount: 100, Currency: "USD"}},
+	}
+	for _, ev := range cases {
+		if sent, err := d.Notify(ctx, eventPayload(t, ev)); err != nil || sent {
+			t.Fatalf("%s: expected skip, got sent=%v err=%v", ev.EventType(), sent, err)
+		}
+	}
+	if len(notifier.sent) != 0 {
+		t.Fatalf("expected no notifications, got %+v", notifier.sent)
+	}
+
+	// Un error del canal se propaga para que el broker reintente.
+	notifier.err = errors.NewInternalError("smtp down")
+	failed := events.PaymentFailed{Payment: events.Payment{TransactionID: uuid.New(), UserID: optedOut.UserID, Amount: 100, Currency: "USD"}, Reason: "failed"}
+	if _, err := d.Notify(ctx, eventPayload(t, failed)); err == nil {
+		t.Fatalf("expected the notifier error")
+	}
+}
+
+func TestUpdatePreferencesValidatesAndKeepsCreatedAt(t *testing.T) {
+	repo := &fakePrefsRepo{prefs: make(map[uuid.UUID]*Preferences)}
+	clock := &fixedClock{now: occurredAt}
+	svc := NewPreferencesService(repo, clock)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	for _, req := range []*PreferencesRequest{
+		{Email: "not-an-email"},
+		{Email: "Ana <ana@example.com>"},
+		{Email: "ana@example.com", Locale: "pt"},
+	} {
+		_, err := svc.UpdatePreferences(ctx, userID, req)
+		if domErr, ok := err.(errors.Error); !ok || domErr.Code != errors.CodeValidationError {
+			t.Fatalf("%+v: expected a validation error, got %v", req, err)
+		}
+	}
+
+	created, err := svc.UpdatePreferences(ctx, userID, &PreferencesRequest{Email: "ana@example.com"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if created.Locale != LocaleES || !created.PaymentCompleted || !created.PaymentFailed || !created.RefundCreated {
+		t.Fatalf("unexpected defaults %+v", created)
+	}
+
+	off := false
+	clock.now = occurredAt.Add(time.Hour)
+	updated, err := svc.UpdatePreferences(ctx, userID, &PreferencesRequest{Email: "ana@example.com", Locale: "EN", RefundCreated: &off})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if updated.Locale != LocaleEN || updated.RefundCreated || !updated.CreatedAt.Equal(occurredAt) || !updated.UpdatedAt.Equal(clock.now) {
+		t.Fatalf("unexpected update %+v", updated)
+	}
+}
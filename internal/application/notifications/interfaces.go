@@ -0,0 +1,65 @@
+package notifications
+
+import (
+	"context"
+	"draftea-challenge/internal/application/events"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Idiomas con plantillas.
+const (
+	LocaleES = "es"
+	LocaleEN = "en"
+)
+
+// Preferences son las preferencias de notificación de un usuario. Sin email no se le notifica.
+type Preferences struct {
+	UserID           uuid.UUID `json:"user_id"`
+	Email            string    `json:"email"`
+	Locale           string    `json:"locale"`
+	PaymentCompleted bool      `json:"payment_completed"`
+	PaymentFailed    bool      `json:"payment_failed"`
+	RefundCreated    bool      `json:"refund_created"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// Wants indica si el usuario quiere ser notificado del tipo de evento.
+func (p *Preferences) Wants(eventType string) bool {
+	switch eventType {
+	case events.TypePaymentCompleted:
+		return p.PaymentCompleted
+	case events.TypePaymentFailed:
+		return p.PaymentFailed
+	case events.TypeRefundCreated:
+		return p.RefundCreated
+	default:
+		return false
+	}
+}
+
+// PreferencesRepository persiste las preferencias de notificación.
+type PreferencesRepository interface {
+	// GetNotificationPreferences retorna NotFound si el usuario no configuró preferencias.
+	GetNotificationPreferences(ctx context.Context, userID uuid.UUID) (*Preferences, error)
+	// SaveNotificationPreferences crea o reemplaza las preferencias del usuario.
+	SaveNotificationPreferences(ctx context.Context, p *Preferences) error
+}
+
+// Message es una notificación renderizada, lista para enviar.
+type Message struct {
+	EventID   uuid.UUID `json:"event_id"`
+	EventType string    `json:"event_type"`
+	UserID    uuid.UUID `json:"user_id"`
+	Locale    string    `json:"locale"`
+	To        string    `json:"to"`
+	Subject   string    `json:"subject"`
+	Body      string    `json:"body"`
+}
+
+// Notifier envía una notificación por un canal (email, archivo local).
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
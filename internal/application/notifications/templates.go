@@ -0,0 +1,121 @@
+package notifications
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+//go:embed templates/*/*.tmpl
+var templateFS embed.FS
+
+// TemplateData son los datos disponibles en las plantillas. Los montos están en unidades menores.
+type TemplateData struct {
+	EventType     string
+	OccurredAt    time.Time
+	TransactionID uuid.UUID // pago o reembolso según el evento
+	PaymentID     uuid.UUID // pago reembolsado (solo refund.created)
+	Amount        int64
+	Fee           int64
+	Currency      string
+	Reason        string // solo payment.failed
+}
+
+// Templates renderiza asunto y cuerpo por idioma y tipo de evento. Cada archivo
+// templates/<idioma>/<tipo>.tmpl define los bloques "subject" y "body".
+type Templates struct {
+	byLocale      map[string]map[string]*template.Template
+	defaultLocale string
+}
+
+// NewTemplates carga las plantillas embebidas; defaultLocale se usa cuando el idioma del usuario no tiene plantilla.
+func NewTemplates(defaultLocale string) (*Templates, error) {
+	if !SupportedLocale(defaultLocale) {
+		return nil, fmt.Errorf("unsupported default locale %q", defaultLocale)
+	}
+	t := &Templates{byLocale: make(map[string]map[string]*template.Template), defaultLocale: defaultLocale}
+	for _, locale := range []string{LocaleES, LocaleEN} {
+		files, err := templateFS.ReadDir("templates/" + locale)
+		if err != nil {
+			return nil, fmt.Errorf("read %s templates: %w", locale, err)
+		}
+		t.byLocale[locale] = make(map[string]*template.Template)
+		for _, f := range files {
+			eventType := strings.TrimSuffix(f.Name(), ".tmpl")
+			tmpl, err := template.New(f.Name()).Funcs(templateFuncs(locale)).ParseFS(templateFS, "templates/"+locale+"/"+f.Name())
+			if err != nil {
+				return nil, fmt.Errorf("parse %s/%s template: %w", locale, eventType, err)
+			}
+			if tmpl.Lookup("subject") == nil || tmpl.Lookup("body") == nil {
+				return nil, fmt.Errorf("template %s/%s must define subject and body", locale, eventType)
+			}
+			t.byLocale[locale][eventType] = tmpl
+		}
+	}
+	return t, nil
+}
+
+// SupportedLocale indica si hay plantillas para el idioma.
+func SupportedLocale(locale string) bool {
+	return locale == LocaleES || locale == LocaleEN
+}
+
+// Has indica si hay plantilla para el tipo de evento.
+func (t *Templates) Has(eventType string) bool {
+	_, ok := t.byLocale[t.defaultLocale][eventType]
+	return ok
+}
+
+// Render retorna el asunto, el cuerpo y el idioma efectivamente usado.
+func (t *Templates) Render(locale, eventType string, data TemplateData) (subject, body, used string, err error) {
+	used = locale
+	tmpl, ok := t.byLocale[locale][eventType]
+	if !ok {
+		used = t.defaultLocale
+		if tmpl, ok = t.byLocale[used][eventType]; !ok {
+			return "", "", "", fmt.Errorf("no template for %s", eventType)
+		}
+	}
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, "subject", data); err != nil {
+		return "", "", "", fmt.Errorf("render %s/%s subject: %w", used, eventType, err)
+	}
+	subject = strings.TrimSpace(buf.String())
+	buf.Reset()
+	if err := tmpl.ExecuteTemplate(&buf, "body", data); err != nil {
+		return "", "", "", fmt.Errorf("render %s/%s body: %w", used, eventType, err)
+	}
+	return subject, strings.TrimLeft(buf.String(), "\n"), used, nil
+}
+
+// templateFuncs formatea montos y fechas según el idioma: "1,500.00 USD" en inglés y "1.500,00 USD" en español.
+func templateFuncs(locale string) template.FuncMap {
+	thousands, decimal := ",", "."
+	dateLayout := "Jan 2, 2006 15:04 MST"
+	if locale == LocaleES {
+		thousands, decimal = ".", ","
+		dateLayout = "02/01/2006 15:04 MST"
+	}
+	return template.FuncMap{
+		"money": func(minor int64, currency string) string {
+			sign := ""
+			if minor < 0 {
+				sign, minor = "-", -minor
+			}
+			units := strconv.FormatInt(minor/100, 10)
+			for i := len(units) - 3; i > 0; i -= 3 {
+				units = units[:i] + thousands + units[i:]
+			}
+			return fmt.Sprintf("%s%s%s%02d %s", sign, units, decimal, minor%100, currency)
+		},
+		"date": func(t time.Time) string {
+			return t.UTC().Format(dateLayout)
+		},
+	}
+}
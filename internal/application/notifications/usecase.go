@@ -0,0 +1,90 @@
+package notifications
+
+import (
+	"context"
+	"draftea-challenge/internal/application/ports"
+	"draftea-challenge/internal/domain/errors"
+	"net/mail"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// PreferencesService gestiona las preferencias de notificación de los usuarios.
+type PreferencesService struct {
+	repo  PreferencesRepository
+	clock ports.Clock
+}
+
+// NewPreferencesService crea una nueva instancia de PreferencesService.
+func NewPreferencesService(repo PreferencesRepository, clock ports.Clock) *PreferencesService {
+	return &PreferencesService{repo: repo, clock: clock}
+}
+
+// PreferencesRequest representa el reemplazo de las preferencias de un usuario.
+type PreferencesRequest struct {
+	Email            string `json:"email"`
+	Locale           string `json:"locale"`            // es o en; por defecto es
+	PaymentCompleted *bool  `json:"payment_completed"` // por defecto true
+	PaymentFailed    *bool  `json:"payment_failed"`    // por defecto true
+	RefundCreated    *bool  `json:"refund_created"`    // por defecto true
+}
+
+// GetPreferences obtiene las preferencias del usuario; NotFound si nunca las configuró.
+func (s *PreferencesService) GetPreferences(ctx context.Context, userID uuid.UUID) (*Preferences, error) {
+	return s.repo.GetNotificationPreferences(ctx, userID)
+}
+
+// UpdatePreferences crea o reemplaza las preferencias del usuario.
+func (s *PreferencesService) UpdatePreferences(ctx context.Context, userID uuid.UUID, req *PreferencesRequest) (*Preferences, error) {
+	email := strings.TrimSpace(req.Email)
+	if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+		return nil, errors.NewValidationError("invalid email", map[string]interface{}{"email": req.Email})
+	}
+	locale := strings.ToLower(strings.TrimSpace(req.Locale))
+	if locale == "" {
+		locale = LocaleES
+	}
+	if !SupportedLocale(locale) {
+		return nil, errors.NewValidationError("unsupported locale", map[string]interface{}{
+			"locale":    req.Locale,
+			"supported": []string{LocaleES, LocaleEN},
+		})
+	}
+
+	now := s.clock.Now().UTC()
+	createdAt := now
+	current, err := s.repo.GetNotificationPreferences(ctx, userID)
+	switch {
+	case err == nil:
+		createdAt = current.CreatedAt
+	case !isNotFoundError(err):
+		return nil, err
+	}
+
+	prefs := &Preferences{
+		UserID:           userID,
+		Email:            email,
+		Locale:           locale,
+		PaymentCompleted: boolOrTrue(req.PaymentCompleted),
+		PaymentFailed:    boolOrTrue(req.PaymentFailed),
+		RefundCreated:    boolOrTrue(req.RefundCreated),
+		CreatedAt:        createdAt,
+		UpdatedAt:        now,
+	}
+	if err := s.repo.SaveNotificationPreferences(ctx, prefs); err != nil {
+		return nil, err
+	}
+	return prefs, nil
+}
+
+func boolOrTrue(v *bool) bool {
+	return v == nil || *v
+}
+
+func isNotFoundError(err error) bool {
+	if domErr, ok := err.(errors.Error); ok && domErr.Code == errors.CodeNotFound {
+		return true
+	}
+	return false
+}
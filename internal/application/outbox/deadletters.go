@@ -0,0 +1,52 @@
+package outbox
+
+import (
+	"context"
+
+	"draftea-challenge/internal/domain/errors"
+
+	"github.com/google/uuid"
+)
+
+// DeadLetterService permite inspeccionar y reencolar los eventos que el relay dejó de intentar.
+type DeadLetterService struct {
+	repo DeadLetterRepository
+}
+
+// NewDeadLetterService crea una nueva instancia de DeadLetterService.
+func NewDeadLetterService(repo DeadLetterRepository) *DeadLetterService {
+	return &DeadLetterService{repo: repo}
+}
+
+// ListDeadLettersResponse representa la respuesta paginada de eventos en dead letter.
+type ListDeadLettersResponse struct {
+	Events []*OutboxEvent `json:"events"`
+	Total  int            `json:"total"`
+}
+
+// ListDeadLetters lista los eventos en dead letter, más recientes primero.
+func (s *DeadLetterService) ListDeadLetters(ctx context.Context, limit, offset int) (*ListDeadLettersResponse, error) {
+	events, total, err := s.repo.ListDeadLetterEvents(ctx, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return &ListDeadLettersResponse{Events: events, Total: total}, nil
+}
+
+// Requeue devuelve un evento en dead letter a pendiente; el relay lo publica en el próximo ciclo.
+func (s *DeadLetterService) Requeue(ctx context.Context, eventID uuid.UUID) (*OutboxEvent, error) {
+	event, err := s.repo.GetEventByID(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if event.Status() != StatusDeadLetter {
+		return nil, errors.NewValidationError("event is not dead-lettered", map[string]interface{}{
+			"event_id": eventID.String(),
+			"status":   event.Status(),
+		})
+	}
+	if err := s.repo.RequeueDeadLetterEvent(ctx, eventID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetEventByID(ctx, eventID)
+}
@@ -11,19 +11,96 @@ import (
 type OutboxRepository interface {
 	CreateEvent(ctx context.Context, event *OutboxEvent) error
 	GetPendingEvents(ctx context.Context, limit int) ([]*OutboxEvent, error)
+	// ClaimPendingEvents reserva hasta limit eventos pendientes para relayID durante lease, de modo que
+	// varias réplicas del relay no publiquen el mismo evento. Solo se reclaman prefijos en orden de cada
+	// agregado: si un evento anterior del agregado está reservado por otro relay, el agregado se omite.
+	// Los leases vencidos (relay caído) vuelven a estar disponibles. Se omiten los eventos en dead letter
+	// y los que tienen next_attempt_at en el futuro (también bloquean al resto de su agregado).
+	ClaimPendingEvents(ctx context.Context, relayID string, now time.Time, limit int, lease time.Duration) ([]*OutboxEvent, error)
+	// ReleaseEvents libera los eventos reservados por relayID que no llegaron a publicarse.
+	ReleaseEvents(ctx context.Context, relayID string, eventIDs []uuid.UUID) error
+	// RecordEventFailure persiste attempts, last_error, next_attempt_at y dead_lettered_at, liberando el lease.
+	RecordEventFailure(ctx context.Context, event *OutboxEvent) error
 	MarkEventAsSent(ctx context.Context, eventID uuid.UUID) error
 }
 
-// MessagePublisher define la interfaz para publicar mensajes a RabbitMQ.
+// DeadLetterRepository define el acceso a los eventos en dead letter (administración).
+type DeadLetterRepository interface {
+	GetEventByID(ctx context.Context, eventID uuid.UUID) (*OutboxEvent, error)
+	ListDeadLetterEvents(ctx context.Context, limit, offset int) ([]*OutboxEvent, int, error)
+	// RequeueDeadLetterEvent vuelve el evento a pendiente con los intentos en cero.
+	RequeueDeadLetterEvent(ctx context.Context, eventID uuid.UUID) error
+}
+
+// RetentionRepository define el archivado y purga de eventos ya publicados.
+type RetentionRepository interface {
+	// ArchiveSentEvents mueve a outbox_archive hasta limit eventos enviados antes de sentBefore.
+	ArchiveSentEvents(ctx context.Context, sentBefore time.Time, limit int) (int, error)
+	// PurgeArchivedEvents borra hasta limit eventos archivados enviados antes de sentBefore.
+	PurgeArchivedEvents(ctx context.Context, sentBefore time.Time, limit int) (int, error)
+}
+
+// MessagePublisher define la interfaz para publicar eventos de outbox al broker.
+// El routing key es el tipo de evento y el ID del evento viaja como ID del mensaje.
 type MessagePublisher interface {
-	Publish(ctx context.Context, exchange, routingKey string, body []byte) error
+	Publish(ctx context.Context, exchange string, event *OutboxEvent) error
 }
 
+// Estados derivados de un evento de outbox.
+const (
+	StatusPending    = "pending"
+	StatusSent       = "sent"
+	StatusDeadLetter = "dead_letter"
+)
+
 // OutboxEvent representa un evento en la tabla de outbox.
 type OutboxEvent struct {
-	ID        uuid.UUID  `json:"id"`
-	EventType string     `json:"event_type"` // e.g., "payment.created"
-	Payload   string     `json:"payload"`    // JSON del evento
-	CreatedAt time.Time  `json:"created_at"`
-	SentAt    *time.Time `json:"sent_at,omitempty"`
+	ID             uuid.UUID  `json:"id"`
+	EventType      string     `json:"event_type"`             // e.g., "payment.created"
+	Payload        string     `json:"payload"`                // JSON del evento
+	AggregateID    uuid.UUID  `json:"aggregate_id,omitempty"` // uuid.Nil si el evento no pertenece a un agregado
+	Sequence       int64      `json:"sequence,omitempty"`     // posición dentro del agregado, asignada al persistir
+	Attempts       int        `json:"attempts"`               // publicaciones fallidas (cada una tras agotar los reintentos en proceso)
+	LastError      string     `json:"last_error,omitempty"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`  // no se reclama antes de este instante
+	DeadLetteredAt *time.Time `json:"dead_lettered_at,omitempty"` // el relay dejó de intentar; requiere requeue manual
+	CreatedAt      time.Time  `json:"created_at"`
+	SentAt         *time.Time `json:"sent_at,omitempty"`
+}
+
+// Status retorna el estado del evento según sus marcas de tiempo.
+func (e *OutboxEvent) Status() string {
+	switch {
+	case e.SentAt != nil:
+		return StatusSent
+	case e.DeadLetteredAt != nil:
+		return StatusDeadLetter
+	default:
+		return StatusPending
+	}
+}
+
+// RecordFailure registra una publicación fallida: programa el próximo intento tras delay o, al
+// alcanzar maxAttempts, mueve el evento a dead letter. Retorna true si quedó en dead letter.
+func (e *OutboxEvent) RecordFailure(cause error, now time.Time, maxAttempts int, delay time.Duration) bool {
+	e.Attempts++
+	e.LastError = truncateError(cause.Error())
+	if maxAttempts > 0 && e.Attempts >= maxAttempts {
+		e.NextAttemptAt = nil
+		e.DeadLetteredAt = &now
+		return true
+	}
+	next := now.Add(delay)
+	e.NextAttemptAt = &next
+	return false
+}
+
+// maxErrorLength acota last_error para no guardar respuestas completas del broker.
+const maxErrorLength = 1000
+
+func truncateError(msg string) string {
+	if len(msg) <= maxErrorLength {
+		return msg
+	}
+	return msg[:maxErrorLength]
 }
@@ -3,11 +3,14 @@ package relay
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
 	"draftea-challenge/internal/application/outbox"
 	"draftea-challenge/internal/application/ports"
+
+	"github.com/google/uuid"
 )
 
 // Relay publishes pending outbox events to the message broker.
@@ -15,10 +18,15 @@ type Relay struct {
 	repo        outbox.OutboxRepository
 	publisher   outbox.MessagePublisher
 	clock       ports.Clock
+	id          string
+	lease       time.Duration
 	batchSize   int
 	maxInFlight int
 	retries     int
 	backoff     backoffConfig
+	maxAttempts int
+	retryDelay  backoffConfig
+	poll        backoffConfig
 }
 
 type backoffConfig struct {
@@ -28,11 +36,25 @@ type backoffConfig struct {
 
 // Config configures relay behavior.
 type Config struct {
+	// ID identifies this relay instance in outbox claims; a random ID is used when empty.
+	ID string
+	// Lease is how long claimed events stay reserved; after it expires (e.g. the relay crashed)
+	// another instance may claim them. It must exceed the time needed to publish a batch.
+	Lease          time.Duration
 	BatchSize      int
 	MaxInFlight    int
 	MaxRetries     int
 	InitialBackoff time.Duration
 	MaxBackoff     time.Duration
+	// MaxAttempts is how many failed publishes (each after exhausting MaxRetries) an event gets
+	// before it is dead-lettered; RetryBackoff doubles per attempt up to RetryMaxBackoff.
+	MaxAttempts     int
+	RetryBackoff    time.Duration
+	RetryMaxBackoff time.Duration
+	// PollInterval is how long Run waits after a batch that drained the outbox; it doubles while the
+	// outbox stays empty, up to MaxPollInterval. Notifications cut the wait short.
+	PollInterval    time.Duration
+	MaxPollInterval time.Duration
 }
 
 // NewRelay creates a new outbox relay.
@@ -57,10 +79,40 @@ func NewRelay(repo outbox.OutboxRepository, publisher outbox.MessagePublisher, c
 	if maxBackoff <= 0 {
 		maxBackoff = 2 * time.Second
 	}
+	id := cfg.ID
+	if id == "" {
+		id = uuid.NewString()
+	}
+	lease := cfg.Lease
+	if lease <= 0 {
+		lease = 30 * time.Second
+	}
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 10
+	}
+	retryBackoff := cfg.RetryBackoff
+	if retryBackoff <= 0 {
+		retryBackoff = 5 * time.Second
+	}
+	retryMaxBackoff := cfg.RetryMaxBackoff
+	if retryMaxBackoff <= 0 {
+		retryMaxBackoff = 5 * time.Minute
+	}
+	pollInterval := cfg.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = time.Second
+	}
+	maxPollInterval := cfg.MaxPollInterval
+	if maxPollInterval < pollInterval {
+		maxPollInterval = pollInterval
+	}
 	return &Relay{
 		repo:        repo,
 		publisher:   publisher,
 		clock:       clock,
+		id:          id,
+		lease:       lease,
 		batchSize:   batchSize,
 		maxInFlight: maxInFlight,
 		retries:     retries,
@@ -68,40 +120,58 @@ func NewRelay(repo outbox.OutboxRepository, publisher outbox.MessagePublisher, c
 			initial: initialBackoff,
 			max:     maxBackoff,
 		},
+		maxAttempts: maxAttempts,
+		retryDelay: backoffConfig{
+			initial: retryBackoff,
+			max:     retryMaxBackoff,
+		},
+		poll: backoffConfig{
+			initial: pollInterval,
+			max:     maxPollInterval,
+		},
 	}
 }
 
-// ProcessOnce publishes a single batch of pending events.
-func (r *Relay) ProcessOnce(ctx context.Context) error {
-	events, err := r.repo.GetPendingEvents(ctx, r.batchSize)
+// ProcessResult summarizes a single ProcessOnce pass.
+type ProcessResult struct {
+	Claimed   int
+	Published int
+}
+
+// ProcessOnce claims and publishes a single batch of pending events. Events are grouped by aggregate:
+// up to maxInFlight aggregates are published concurrently, each one strictly in sequence order.
+// When an event fails, the rest of its aggregate waits until the event's next attempt.
+func (r *Relay) ProcessOnce(ctx context.Context) (ProcessResult, error) {
+	events, err := r.repo.ClaimPendingEvents(ctx, r.id, r.clock.Now(), r.batchSize, r.lease)
 	if err != nil {
-		return err
+		return ProcessResult{}, err
 	}
+	result := ProcessResult{Claimed: len(events)}
 	if len(events) == 0 {
-		return nil
+		return result, nil
 	}
 
+	chains := groupByAggregate(events)
 	sem := make(chan struct{}, r.maxInFlight)
 	var wg sync.WaitGroup
-	errCh := make(chan error, len(events))
+	var mu sync.Mutex
+	errCh := make(chan error, len(chains))
 
-	for _, event := range events {
+	for _, chain := range chains {
 		wg.Add(1)
 		sem <- struct{}{}
-		go func(ev *outbox.OutboxEvent) {
+		go func(chain []*outbox.OutboxEvent) {
 			defer wg.Done()
 			defer func() { <-sem }()
 
-			if err := r.publishWithRetry(ctx, ev); err != nil {
+			published, err := r.publishChain(ctx, chain)
+			mu.Lock()
+			result.Published += published
+			mu.Unlock()
+			if err != nil {
 				errCh <- err
-				return
-			}
-			now := r.clock.Now()
-			ev.SentAt = &now
-			if err := r.repo.MarkEventAsSent(ctx, ev.ID); err != nil {
-				errCh <- fmt.Errorf("mark outbox event %s as sent: %w", ev.ID, err)
 			}
-		}(event)
+		}(chain)
 	}
 
 	wg.Wait()
@@ -109,10 +179,97 @@ func (r *Relay) ProcessOnce(ctx context.Context) error {
 
 	for err := range errCh {
 		if err != nil {
-			return err
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// publishChain publishes the events of one aggregate in order, stopping at the first failure, and
+// returns how many were published. The failed event is scheduled for a later attempt (or dead-lettered)
+// and the events after it are released; they stay behind it until it is published or dead-lettered.
+func (r *Relay) publishChain(ctx context.Context, chain []*outbox.OutboxEvent) (int, error) {
+	for i, ev := range chain {
+		if err := r.publishWithRetry(ctx, ev); err != nil {
+			if ctx.Err() != nil {
+				// Shutting down: not the event's fault, so it does not count as an attempt.
+				r.release(chain[i:])
+				return i, err
+			}
+			err = r.recordFailure(ev, err)
+			r.release(chain[i+1:])
+			if skipped := len(chain) - i - 1; skipped > 0 {
+				return i, fmt.Errorf("%w (%d later events of aggregate %s held back)", err, skipped, ev.AggregateID)
+			}
+			return i, err
 		}
+		now := r.clock.Now()
+		ev.SentAt = &now
+		if err := r.repo.MarkEventAsSent(ctx, ev.ID); err != nil {
+			// The event may be published again, but never after a later event of the same aggregate.
+			return i + 1, fmt.Errorf("mark outbox event %s as sent: %w", ev.ID, err)
+		}
+	}
+	return len(chain), nil
+}
+
+// groupByAggregate splits a batch into per-aggregate chains sorted by sequence, keeping the
+// order in which aggregates first appear. Events without aggregate form a chain of their own.
+func groupByAggregate(events []*outbox.OutboxEvent) [][]*outbox.OutboxEvent {
+	var chains [][]*outbox.OutboxEvent
+	index := make(map[uuid.UUID]int)
+	for _, ev := range events {
+		if ev.AggregateID == uuid.Nil {
+			chains = append(chains, []*outbox.OutboxEvent{ev})
+			continue
+		}
+		i, ok := index[ev.AggregateID]
+		if !ok {
+			i = len(chains)
+			index[ev.AggregateID] = i
+			chains = append(chains, nil)
+		}
+		chains[i] = append(chains[i], ev)
+	}
+	for _, chain := range chains {
+		sort.SliceStable(chain, func(a, b int) bool { return chain[a].Sequence < chain[b].Sequence })
+	}
+	return chains
+}
+
+// recordFailure stores the failed attempt and returns err annotated with the outcome. It uses a
+// background context for the same reason as release.
+func (r *Relay) recordFailure(ev *outbox.OutboxEvent, err error) error {
+	deadLettered := ev.RecordFailure(err, r.clock.Now(), r.maxAttempts, r.retryDelayFor(ev.Attempts+1))
+	if recErr := r.repo.RecordEventFailure(context.Background(), ev); recErr != nil {
+		return fmt.Errorf("%w (record failure: %v)", err, recErr)
+	}
+	if deadLettered {
+		return fmt.Errorf("%w (dead-lettered after %d attempts)", err, ev.Attempts)
+	}
+	return fmt.Errorf("%w (attempt %d/%d, next at %s)", err, ev.Attempts, r.maxAttempts, ev.NextAttemptAt.Format(time.RFC3339))
+}
+
+// retryDelayFor returns the delay before the next attempt after the given number of failures.
+func (r *Relay) retryDelayFor(attempts int) time.Duration {
+	delay := r.retryDelay.initial
+	for i := 1; i < attempts && delay < r.retryDelay.max; i++ {
+		delay = nextBackoff(delay, r.retryDelay.max)
+	}
+	if delay > r.retryDelay.max {
+		return r.retryDelay.max
+	}
+	return delay
+}
+
+// release returns events to the pool (also during shutdown, hence the background context);
+// if it fails they become claimable again once the lease expires.
+func (r *Relay) release(events []*outbox.OutboxEvent) {
+	ids := make([]uuid.UUID, 0, len(events))
+	for _, ev := range events {
+		ids = append(ids, ev.ID)
 	}
-	return nil
+	_ = r.repo.ReleaseEvents(context.Background(), r.id, ids)
 }
 
 func (r *Relay) publishWithRetry(ctx context.Context, event *outbox.OutboxEvent) error {
@@ -120,7 +277,7 @@ func (r *Relay) publishWithRetry(ctx context.Context, event *outbox.OutboxEvent)
 	backoff := r.backoff.initial
 
 	for attempt := 0; attempt <= r.retries; attempt++ {
-		if err := r.publisher.Publish(ctx, "payments.events", event.EventType, []byte(event.Payload)); err != nil {
+		if err := r.publisher.Publish(ctx, "payments.events", event); err != nil {
 			lastErr = err
 		} else {
 			return nil
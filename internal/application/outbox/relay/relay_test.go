@@ -0,0 +1,240 @@
+package relay
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"draftea-challenge/internal/application/outbox"
+
+	"github.com/google/uuid"
+)
+
+type mockOutboxRepo struct {
+	mu       sync.Mutex
+	pending  []*outbox.OutboxEvent
+	sent     map[uuid.UUID]bool
+	released []uuid.UUID
+	failures []outbox.OutboxEvent
+}
+
+func (m *mockOutboxRepo) CreateEvent(ctx context.Context, event *outbox.OutboxEvent) error {
+	return nil
+}
+
+func (m *mockOutboxRepo) GetPendingEvents(ctx context.Context, limit int) ([]*outbox.OutboxEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []*outbox.OutboxEvent
+	for _, ev := range m.pending {
+		if !m.sent[ev.ID] && ev.DeadLetteredAt == nil && len(out) < limit {
+			out = append(out, ev)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockOutboxRepo) ClaimPendingEvents(ctx context.Context, relayID string, now time.Time, limit int, lease time.Duration) ([]*outbox.OutboxEvent, error) {
+	pending, err := m.GetPendingEvents(ctx, len(m.pending))
+	if err != nil {
+		return nil, err
+	}
+	// An event waiting for its next attempt holds back the rest of its aggregate.
+	blocked := make(map[uuid.UUID]bool)
+	var out []*outbox.OutboxEvent
+	for _, ev := range pending {
+		if ev.NextAttemptAt != nil && ev.NextAttemptAt.After(now) {
+			blocked[ev.AggregateID] = true
+			continue
+		}
+		if !blocked[ev.AggregateID] && len(out) < limit {
+			out = append(out, ev)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockOutboxRepo) ReleaseEvents(ctx context.Context, relayID string, eventIDs []uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.released = append(m.released, eventIDs...)
+	return nil
+}
+
+func (m *mockOutboxRepo) RecordEventFailure(ctx context.Context, event *outbox.OutboxEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failures = append(m.failures, *event)
+	return nil
+}
+
+func (m *mockOutboxRepo) MarkEventAsSent(ctx context.Context, eventID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent[eventID] = true
+	return nil
+}
+
+type mockPublisher struct {
+	mu        sync.Mutex
+	published []*outbox.OutboxEvent
+	fail      map[uuid.UUID]bool
+}
+
+func (m *mockPublisher) Publish(ctx context.Context, exchange string, event *outbox.OutboxEvent) error {
+	// Yield so goroutines of different aggregates interleave.
+	time.Sleep(time.Millisecond)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.fail[event.ID] {
+		return fmt.Errorf("broker unavailable")
+	}
+	m.published = append(m.published, event)
+	return nil
+}
+
+type fixedClock struct{}
+
+func (fixedClock) Now() time.Time { return time.Now() }
+
+type manualClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *manualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newEvent(aggregateID uuid.UUID, seq int64, eventType string) *outbox.OutboxEvent {
+	return &outbox.OutboxEvent{ID: uuid.New(), EventType: eventType, Payload: `{}`, AggregateID: aggregateID, Sequence: seq, CreatedAt: time.Now()}
+}
+
+func TestProcessOnce_PublishesEachAggregateInSequenceOrder(t *testing.T) {
+	var pending []*outbox.OutboxEvent
+	aggregates := make([]uuid.UUID, 5)
+	for i := range aggregates {
+		aggregates[i] = uuid.New()
+	}
+	// Interleave aggregates and list the later sequence first to check the relay reorders.
+	for seq := int64(3); seq >= 1; seq-- {
+		for _, agg := range aggregates {
+			pending = append(pending, newEvent(agg, seq, fmt.Sprintf("payment.%d", seq)))
+		}
+	}
+	repo := &mockOutboxRepo{pending: pending, sent: make(map[uuid.UUID]bool)}
+	pub := &mockPublisher{}
+	r := NewRelay(repo, pub, fixedClock{}, Config{BatchSize: 100, MaxInFlight: 5})
+
+	if _, err := r.ProcessOnce(context.Background()); err != nil {
+		t.Fatalf("process: %v", err)
+	}
+	if len(pub.published) != len(pending) {
+		t.Fatalf("expected %d published events, got %d", len(pending), len(pub.published))
+	}
+	last := make(map[uuid.UUID]int64)
+	for _, ev := range pub.published {
+		if ev.Sequence != last[ev.AggregateID]+1 {
+			t.Fatalf("aggregate %s published sequence %d after %d", ev.AggregateID, ev.Sequence, last[ev.AggregateID])
+		}
+		last[ev.AggregateID] = ev.Sequence
+	}
+}
+
+func TestProcessOnce_StopsAggregateChainOnFirstFailure(t *testing.T) {
+	broken, healthy := uuid.New(), uuid.New()
+	b1, b2, b3 := newEvent(broken, 1, "payment.created"), newEvent(broken, 2, "payment.completed"), newEvent(broken, 3, "refund.created")
+	h1, h2 := newEvent(healthy, 1, "payment.created"), newEvent(healthy, 2, "payment.completed")
+	repo := &mockOutboxRepo{pending: []*outbox.OutboxEvent{b1, h1, b2, h2, b3}, sent: make(map[uuid.UUID]bool)}
+	pub := &mockPublisher{fail: map[uuid.UUID]bool{b2.ID: true}}
+	clock := &manualClock{now: time.Now()}
+	r := NewRelay(repo, pub, clock, Config{MaxInFlight: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, RetryBackoff: time.Minute})
+
+	if _, err := r.ProcessOnce(context.Background()); err == nil {
+		t.Fatalf("expected publish error")
+	}
+	if !repo.sent[b1.ID] || repo.sent[b2.ID] || repo.sent[b3.ID] {
+		t.Fatalf("expected only the first event of the failing aggregate to be sent, got %v", repo.sent)
+	}
+	if !repo.sent[h1.ID] || !repo.sent[h2.ID] {
+		t.Fatalf("expected the healthy aggregate to be fully sent")
+	}
+	if len(repo.released) != 1 || repo.released[0] != b3.ID {
+		t.Fatalf("expected the events after the failure to be released, got %v", repo.released)
+	}
+	if b2.Attempts != 1 || b2.NextAttemptAt == nil || !b2.NextAttemptAt.Equal(clock.Now().Add(time.Minute)) || b2.LastError == "" {
+		t.Fatalf("expected the failed attempt to be recorded, got %+v", b2)
+	}
+
+	// The aggregate waits for the next attempt even though the broker is back.
+	delete(pub.fail, b2.ID)
+	published := len(pub.published)
+	if _, err := r.ProcessOnce(context.Background()); err != nil {
+		t.Fatalf("process before next attempt: %v", err)
+	}
+	if len(pub.published) != published {
+		t.Fatalf("expected nothing to be published before the next attempt")
+	}
+	for _, ev := range pub.published {
+		if ev.ID == b3.ID {
+			t.Fatalf("event after the failure must not be published")
+		}
+	}
+
+	// Once the next attempt is due the held back events go out in order.
+	clock.Advance(time.Minute)
+	if _, err := r.ProcessOnce(context.Background()); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	tail := pub.published[len(pub.published)-2:]
+	if tail[0].ID != b2.ID || tail[1].ID != b3.ID {
+		t.Fatalf("expected held back events in sequence order")
+	}
+}
+
+func TestProcessOnce_DeadLettersAfterMaxAttempts(t *testing.T) {
+	agg := uuid.New()
+	poison, next := newEvent(agg, 1, "payment.created"), newEvent(agg, 2, "payment.completed")
+	repo := &mockOutboxRepo{pending: []*outbox.OutboxEvent{poison, next}, sent: make(map[uuid.UUID]bool)}
+	pub := &mockPublisher{fail: map[uuid.UUID]bool{poison.ID: true}}
+	clock := &manualClock{now: time.Now()}
+	r := NewRelay(repo, pub, clock, Config{MaxAttempts: 3, RetryBackoff: time.Second, RetryMaxBackoff: 3 * time.Second})
+
+	var delays []time.Duration
+	for attempt := 1; attempt <= 3; attempt++ {
+		if _, err := r.ProcessOnce(context.Background()); err == nil {
+			t.Fatalf("attempt %d: expected publish error", attempt)
+		}
+		if poison.Attempts != attempt {
+			t.Fatalf("expected %d attempts, got %d", attempt, poison.Attempts)
+		}
+		if poison.NextAttemptAt != nil {
+			delays = append(delays, poison.NextAttemptAt.Sub(clock.Now()))
+			clock.Advance(poison.NextAttemptAt.Sub(clock.Now()))
+		}
+	}
+	if len(delays) != 2 || delays[0] != time.Second || delays[1] != 2*time.Second {
+		t.Fatalf("expected exponential delays between attempts, got %v", delays)
+	}
+	if poison.Status() != outbox.StatusDeadLetter || poison.NextAttemptAt != nil {
+		t.Fatalf("expected the event to be dead-lettered, got %+v", poison)
+	}
+
+	// A dead-lettered event no longer holds back its aggregate.
+	if _, err := r.ProcessOnce(context.Background()); err != nil {
+		t.Fatalf("process after dead letter: %v", err)
+	}
+	if !repo.sent[next.ID] || repo.sent[poison.ID] {
+		t.Fatalf("expected only the event after the dead letter to be sent, got %v", repo.sent)
+	}
+}
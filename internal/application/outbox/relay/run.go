@@ -0,0 +1,44 @@
+package relay
+
+import (
+	"context"
+	"time"
+)
+
+// Run processes batches until ctx is done. A full batch is followed right away by the next one, so a
+// backlog drains at broker speed; otherwise Run sleeps until a wakeup arrives (e.g. a Postgres NOTIFY
+// on insert) or the poll interval elapses. The interval doubles while the outbox stays empty and resets
+// on activity. report, when set, is called after every batch that claimed events or failed.
+func (r *Relay) Run(ctx context.Context, wakeups <-chan struct{}, report func(ProcessResult, error)) {
+	interval := r.poll.initial
+	for {
+		result, err := r.ProcessOnce(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if report != nil && (result.Claimed > 0 || err != nil) {
+			report(result, err)
+		}
+
+		switch {
+		case err == nil && result.Claimed >= r.batchSize:
+			// More events are likely waiting.
+			interval = r.poll.initial
+			continue
+		case err != nil || result.Claimed > 0:
+			interval = r.poll.initial
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-wakeups:
+			timer.Stop()
+			interval = r.poll.initial
+		case <-timer.C:
+			interval = nextBackoff(interval, r.poll.max)
+		}
+	}
+}
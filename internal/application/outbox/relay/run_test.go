@@ -0,0 +1,69 @@
+package relay
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"draftea-challenge/internal/application/outbox"
+
+	"github.com/google/uuid"
+)
+
+func TestRun_DrainsBacklogAndWakesOnNotification(t *testing.T) {
+	var pending []*outbox.OutboxEvent
+	for i := 0; i < 7; i++ {
+		pending = append(pending, newEvent(uuid.Nil, 0, "schedule.failed"))
+	}
+	repo := &mockOutboxRepo{pending: pending, sent: make(map[uuid.UUID]bool)}
+	pub := &mockPublisher{}
+	// The poll interval is long enough that only draining and wakeups can make progress.
+	r := NewRelay(repo, pub, fixedClock{}, Config{BatchSize: 2, PollInterval: time.Hour})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wakeups := make(chan struct{}, 1)
+	batches := make(chan ProcessResult, 16)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.Run(ctx, wakeups, func(result ProcessResult, err error) {
+			if err != nil {
+				t.Errorf("run: %v", err)
+			}
+			batches <- result
+		})
+	}()
+
+	// 7 events in batches of 2: three full batches back to back, then a partial one.
+	for i, want := range []int{2, 2, 2, 1} {
+		select {
+		case got := <-batches:
+			if got.Claimed != want || got.Published != want {
+				t.Fatalf("batch %d: expected %d events, got %+v", i, want, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("batch %d: backlog not drained continuously", i)
+		}
+	}
+
+	repo.mu.Lock()
+	repo.pending = append(repo.pending, newEvent(uuid.Nil, 0, "schedule.failed"))
+	repo.mu.Unlock()
+	wakeups <- struct{}{}
+	select {
+	case got := <-batches:
+		if got.Published != 1 {
+			t.Fatalf("expected the notified event to be published, got %+v", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("relay did not wake on notification")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("run did not stop on cancellation")
+	}
+}
@@ -0,0 +1,92 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"draftea-challenge/internal/application/ports"
+)
+
+// RetentionConfig configura la retención del outbox.
+type RetentionConfig struct {
+	// Retention es cuánto tiempo queda un evento enviado en la tabla outbox antes de archivarse.
+	Retention time.Duration
+	// ArchiveRetention es cuánto tiempo (desde sent_at) se guarda en el archivo; 0 lo guarda para siempre.
+	ArchiveRetention time.Duration
+	BatchSize        int
+}
+
+// RetentionResult resume una pasada de RunOnce.
+type RetentionResult struct {
+	Archived int
+	Purged   int
+}
+
+// RetentionService archiva los eventos enviados y purga el archivo vencido, en lotes.
+type RetentionService struct {
+	repo             RetentionRepository
+	clock            ports.Clock
+	retention        time.Duration
+	archiveRetention time.Duration
+	batchSize        int
+}
+
+// NewRetentionService crea una nueva instancia de RetentionService.
+func NewRetentionService(repo RetentionRepository, clock ports.Clock, cfg RetentionConfig) *RetentionService {
+	retention := cfg.Retention
+	if retention <= 0 {
+		retention = 7 * 24 * time.Hour
+	}
+	archiveRetention := cfg.ArchiveRetention
+	if archiveRetention < 0 {
+		archiveRetention = 0
+	}
+	if archiveRetention > 0 && archiveRetention < retention {
+		archiveRetention = retention
+	}
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+	return &RetentionService{
+		repo:             repo,
+		clock:            clock,
+		retention:        retention,
+		archiveRetention: archiveRetention,
+		batchSize:        batchSize,
+	}
+}
+
+// RunOnce archiva todos los eventos enviados fuera de la ventana y purga el archivo vencido.
+// Trabaja en lotes de batchSize para no mantener transacciones largas; si falla a mitad de camino
+// retorna lo procesado hasta ese momento y la siguiente pasada continúa.
+func (s *RetentionService) RunOnce(ctx context.Context) (RetentionResult, error) {
+	var result RetentionResult
+	now := s.clock.Now()
+
+	archived, err := s.drain(ctx, s.repo.ArchiveSentEvents, now.Add(-s.retention))
+	result.Archived = archived
+	if err != nil {
+		return result, err
+	}
+	if s.archiveRetention == 0 {
+		return result, nil
+	}
+	purged, err := s.drain(ctx, s.repo.PurgeArchivedEvents, now.Add(-s.archiveRetention))
+	result.Purged = purged
+	return result, err
+}
+
+func (s *RetentionService) drain(ctx context.Context, step func(context.Context, time.Time, int) (int, error), before time.Time) (int, error) {
+	total := 0
+	for {
+		n, err := step(ctx, before, s.batchSize)
+		total += n
+		if err != nil || n < s.batchSize {
+			return total, err
+		}
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package outbox
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type fakeRetentionRepo struct {
+	sent, archived []time.Time
+}
+
+func (f *fakeRetentionRepo) ArchiveSentEvents(ctx context.Context, sentBefore time.Time, limit int) (int, error) {
+	n := 0
+	var keep []time.Time
+	for _, t := range f.sent {
+		if t.Before(sentBefore) && n < limit {
+			f.archived = append(f.archived, t)
+			n++
+			continue
+		}
+		keep = append(keep, t)
+	}
+	f.sent = keep
+	return n, nil
+}
+
+func (f *fakeRetentionRepo) PurgeArchivedEvents(ctx context.Context, sentBefore time.Time, limit int) (int, error) {
+	n := 0
+	var keep []time.Time
+	for _, t := range f.archived {
+		if t.Before(sentBefore) && n < limit {
+			n++
+			continue
+		}
+		keep = append(keep, t)
+	}
+	f.archived = keep
+	return n, nil
+}
+
+type fixedClock struct{ now time.Time }
+
+func (c fixedClock) Now() time.Time { return c.now }
+
+func TestRetentionRunOnceDrainsInBatches(t *testing.T) {
+	now := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	repo := &fakeRetentionRepo{}
+	for i := 0; i < 5; i++ {
+		repo.sent = append(repo.sent, now.Add(-100*day)) // past both windows
+	}
+	for i := 0; i < 3; i++ {
+		repo.sent = append(repo.sent, now.Add(-10*day)) // archived, kept in the archive
+	}
+	repo.sent = append(repo.sent, now.Add(-time.Hour)) // still in the outbox window
+
+	svc := NewRetentionService(repo, fixedClock{now: now}, RetentionConfig{Retention: 7 * day, ArchiveRetention: 90 * day, BatchSize: 2})
+	result, err := svc.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if result.Archived != 8 || result.Purged != 5 {
+		t.Fatalf("expected 8 archived and 5 purged, got %+v", result)
+	}
+	if len(repo.sent) != 1 || len(repo.archived) != 3 {
+		t.Fatalf("unexpected remaining rows: %d sent, %d archived", len(repo.sent), len(repo.archived))
+	}
+}
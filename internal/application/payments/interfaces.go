@@ -2,7 +2,12 @@ package payments
 
 import (
 	"context"
+	"draftea-challenge/internal/application/ports"
+	"draftea-challenge/internal/domain/fee"
+	"draftea-challenge/internal/domain/limit"
 	"draftea-challenge/internal/domain/payment"
+	"draftea-challenge/internal/domain/provider"
+	"draftea-challenge/internal/domain/risk"
 	"draftea-challenge/internal/domain/transaction"
 	"time"
 
@@ -13,8 +18,13 @@ import (
 type PaymentRepository interface {
 	CreateTransaction(ctx context.Context, tx *transaction.Transaction) error
 	UpdateTransactionStatus(ctx context.Context, txID uuid.UUID, status transaction.Status) error
+	// TransitionTransactionStatus cambia el estado solo si la transacción sigue en from y reporta si
+	// esta llamada lo cambió; con el ctx de una transacción, los efectos se aplican una sola vez.
+	TransitionTransactionStatus(ctx context.Context, txID uuid.UUID, from, to transaction.Status) (bool, error)
 	GetTransactionByID(ctx context.Context, txID uuid.UUID) (*transaction.Transaction, error)
 	ListTransactions(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*transaction.Transaction, error)
+	// GetFeeTransaction retorna la comisión vinculada al pago (NotFound si no se cobró).
+	GetFeeTransaction(ctx context.Context, paymentTxID uuid.UUID) (*transaction.Transaction, error)
 }
 
 // PaymentGateway define la interfaz para interactuar con la pasarela de pago externa.
@@ -22,17 +32,33 @@ type PaymentGateway interface {
 	ProcessPayment(ctx context.Context, p *payment.Payment) (string, error) // retorna status o error
 }
 
-// IdempotencyRepository define la interfaz para manejar claves de idempotencia.
-type IdempotencyRepository interface {
-	GetIdempotencyRecord(ctx context.Context, userID uuid.UUID, key string) (*IdempotencyRecord, error)
-	CreateIdempotencyRecord(ctx context.Context, record *IdempotencyRecord) error
+// ProviderRepository define la interfaz para consultar el catálogo de providers.
+type ProviderRepository interface {
+	GetProvider(ctx context.Context, providerID uuid.UUID) (*provider.Provider, error)
 }
 
-// IdempotencyRecord representa un registro de idempotencia.
-type IdempotencyRecord struct {
-	UserID    uuid.UUID `json:"user_id"`
-	Key       string    `json:"key"`
-	RequestID uuid.UUID `json:"request_id"` // ID de la transacción o pago
-	Response  string    `json:"response"`   // JSON de la respuesta original
-	CreatedAt time.Time `json:"created_at"`
+// FeeRepository define la interfaz para consultar políticas de comisión.
+type FeeRepository interface {
+	// GetFeePolicy retorna la política del provider o, en su defecto, la política por defecto de la moneda.
+	GetFeePolicy(ctx context.Context, providerID uuid.UUID, currency string) (*fee.Policy, error)
+}
+
+// LimitRepository define la interfaz para consultar límites de gasto y consumo reciente.
+type LimitRepository interface {
+	// GetSpendingLimit retorna el límite de la wallet o, en su defecto, el límite por defecto de la moneda.
+	GetSpendingLimit(ctx context.Context, userID uuid.UUID, currency string) (*limit.SpendingLimit, error)
+	// GetPaymentUsage retorna el monto y la cantidad de pagos no fallidos desde since.
+	GetPaymentUsage(ctx context.Context, userID uuid.UUID, currency string, since time.Time) (int64, int, error)
+}
+
+// RiskEvaluator define la interfaz para el screening de fraude/riesgo previo al débito.
+type RiskEvaluator interface {
+	Evaluate(ctx context.Context, p *payment.Payment) (*risk.Assessment, error)
 }
+
+// IdempotencyRepository define la interfaz para manejar claves de idempotencia.
+// Se comparte con los top-ups a través de ports.
+type IdempotencyRepository = ports.IdempotencyRepository
+
+// IdempotencyRecord representa un registro de idempotencia.
+type IdempotencyRecord = ports.IdempotencyRecord
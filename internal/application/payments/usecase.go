@@ -2,15 +2,19 @@ package payments
 
 import (
 	"context"
+	"draftea-challenge/internal/application/events"
 	"draftea-challenge/internal/application/outbox"
 	"draftea-challenge/internal/application/ports"
 	"draftea-challenge/internal/application/wallets"
 	"draftea-challenge/internal/domain/errors"
+	"draftea-challenge/internal/domain/limit"
 	"draftea-challenge/internal/domain/payment"
+	"draftea-challenge/internal/domain/risk"
 	"draftea-challenge/internal/domain/transaction"
 	"draftea-challenge/internal/domain/wallet"
 	"encoding/json"
-	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -22,6 +26,11 @@ type PaymentService struct {
 	gateway         PaymentGateway
 	idempotencyRepo IdempotencyRepository
 	outboxRepo      outbox.OutboxRepository
+	providerRepo    ProviderRepository
+	feeRepo         FeeRepository
+	limitRepo       LimitRepository
+	riskEvaluator   RiskEvaluator
+	transactor      ports.Transactor
 	idGen           ports.IDGenerator
 	clock           ports.Clock
 }
@@ -33,6 +42,11 @@ func NewPaymentService(
 	gateway PaymentGateway,
 	idempotencyRepo IdempotencyRepository,
 	outboxRepo outbox.OutboxRepository,
+	providerRepo ProviderRepository,
+	feeRepo FeeRepository,
+	limitRepo LimitRepository,
+	riskEvaluator RiskEvaluator,
+	transactor ports.Transactor,
 	idGen ports.IDGenerator,
 	clock ports.Clock,
 ) *PaymentService {
@@ -42,6 +56,11 @@ func NewPaymentService(
 		gateway:         gateway,
 		idempotencyRepo: idempotencyRepo,
 		outboxRepo:      outboxRepo,
+		providerRepo:    providerRepo,
+		feeRepo:         feeRepo,
+		limitRepo:       limitRepo,
+		riskEvaluator:   riskEvaluator,
+		transactor:      transactor,
 		idGen:           idGen,
 		clock:           clock,
 	}
@@ -59,8 +78,10 @@ type ProcessPaymentRequest struct {
 
 // ProcessPaymentResponse representa la respuesta.
 type ProcessPaymentResponse struct {
-	TransactionID uuid.UUID `json:"transaction_id"`
-	Status        string    `json:"status"`
+	TransactionID    uuid.UUID  `json:"transaction_id"`
+	Status           string     `json:"status"`
+	Fee              int64      `json:"fee"` // comisión debitada junto con el principal
+	FeeTransactionID *uuid.UUID `json:"fee_transaction_id,omitempty"`
 }
 
 // ProcessPayment ejecuta el flujo de pago con idempotencia.
@@ -87,138 +108,322 @@ func (s *PaymentService) ProcessPayment(ctx context.Context, req *ProcessPayment
 		return nil, err
 	}
 
-	// Iniciar transacción DB para lock y debit
-	// Nota: Asumimos que el repo maneja transacciones; en implementación real, usar tx.Begin()
-	w, err := s.walletRepo.GetWallet(ctx, req.UserID)
-	if err != nil {
+	// Validar el pago contra el catálogo de providers
+	if err := s.checkProvider(ctx, p); err != nil {
 		return nil, err
 	}
 
-	// Debit (con lock implícito en repo)
-	if err := w.Debit(req.Currency, req.Amount); err != nil {
+	// Screening de riesgo antes de debitar
+	assessment, err := s.screen(ctx, p)
+	if err != nil {
 		return nil, err
 	}
-	if err := s.walletRepo.UpdateBalance(ctx, req.UserID, req.Currency, w.GetBalance(req.Currency)); err != nil {
+	if err := assessment.Err(); err != nil {
 		return nil, err
 	}
 
-	// Crear transacción
-	tx, err := transaction.NewTransaction(req.UserID, transaction.TypePayment, req.Amount, req.Currency, req.ProviderID, req.ExternalReference)
+	// Calcular la comisión según la política del provider/moneda
+	feeAmount, err := s.computeFee(ctx, p)
 	if err != nil {
 		return nil, err
 	}
-	if err := s.paymentRepo.CreateTransaction(ctx, tx); err != nil {
+
+	// Límites, débito y transacciones en una transacción DB con la wallet bloqueada: los pagos
+	// concurrentes de la wallet (lotes, programados) se serializan y el consumo leído para los
+	// límites incluye todo pago confirmado antes.
+	var (
+		w         *wallet.Wallet
+		tx, feeTx *transaction.Transaction
+	)
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		if err := s.walletRepo.LockWallet(ctx, req.UserID); err != nil {
+			return err
+		}
+		if err := s.checkSpendingLimit(ctx, p); err != nil {
+			return err
+		}
+		var err error
+		if w, err = s.walletRepo.GetWallet(ctx, req.UserID); err != nil {
+			return err
+		}
+
+		// Debit de principal + comisión
+		if err := w.Debit(req.Currency, req.Amount+feeAmount); err != nil {
+			return err
+		}
+		if err := s.walletRepo.UpdateBalance(ctx, req.UserID, req.Currency, w.GetBalance(req.Currency)); err != nil {
+			return err
+		}
+
+		// Crear transacción
+		if tx, err = transaction.NewTransaction(req.UserID, transaction.TypePayment, req.Amount, req.Currency, req.ProviderID, req.ExternalReference); err != nil {
+			return err
+		}
+		if err := s.paymentRepo.CreateTransaction(ctx, tx); err != nil {
+			return err
+		}
+		if feeTx, err = s.createFeeTransaction(ctx, tx, feeAmount); err != nil {
+			return err
+		}
+		// payment.created se escribe en el outbox junto con el débito: si falla, el pago no se crea
+		return s.emit(ctx, events.PaymentCreated{Payment: paymentEvent(tx, feeTx)})
+	})
+	if err != nil {
+		return nil, err
+	}
+	// La pasarela recibe el ID de la transacción para correlacionar sus webhooks
+	p.ID = tx.ID
+
+	var resp *ProcessPaymentResponse
+	if assessment.Decision == risk.DecisionReview {
+		// Retener el pago (fondos ya debitados) hasta la aprobación manual
+		if resp, err = s.hold(ctx, tx, feeTx); err != nil {
+			return nil, err
+		}
+	} else if resp, err = s.settle(ctx, p, tx, feeTx); err != nil {
 		return nil, err
 	}
 
-	createdEvent := &outbox.OutboxEvent{
-		ID:        s.idGen.New(),
-		EventType: "payment.created",
-		Payload:   fmt.Sprintf(`{"transaction_id":"%s","status":"%s"}`, tx.ID, tx.Status),
+	// Guardar idempotencia
+	respJSON, _ := json.Marshal(resp)
+	record := &IdempotencyRecord{
+		UserID:    req.UserID,
+		Key:       req.IdempotencyKey,
+		RequestID: tx.ID,
+		Response:  string(respJSON),
 		CreatedAt: s.clock.Now(),
 	}
-	_ = s.outboxRepo.CreateEvent(ctx, createdEvent)
+	if err := s.idempotencyRepo.CreateIdempotencyRecord(ctx, record); err != nil {
+		return nil, err
+	}
 
-	// Llamar a gateway
-	status, err := s.gateway.ProcessPayment(ctx, p)
+	return resp, nil
+}
+
+// ReviewHeldPayment resuelve manualmente un pago retenido por screening de riesgo.
+// Si se aprueba, el pago continúa hacia la pasarela; si se rechaza, se declina y se reembolsa.
+func (s *PaymentService) ReviewHeldPayment(ctx context.Context, txID uuid.UUID, approve bool) (*ProcessPaymentResponse, error) {
+	tx, err := s.paymentRepo.GetTransactionByID(ctx, txID)
 	if err != nil {
-		// Gateway error: refund interno
-		if refundErr := s.refundInternal(ctx, tx, w); refundErr != nil {
-			return nil, errors.NewInternalError("refund failed")
-		}
-		if err := tx.UpdateStatus(transaction.StatusFailed); err != nil {
+		return nil, err
+	}
+	if tx.Type != transaction.TypePayment || tx.Status != transaction.StatusHeld {
+		return nil, errors.NewValidationError("transaction is not held for review", map[string]interface{}{
+			"transaction_id": txID.String(),
+			"status":         tx.Status,
+		})
+	}
+
+	feeTx, err := s.feeTransaction(ctx, tx.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if approve {
+		if err := s.releaseHold(ctx, tx, feeTx); err != nil {
 			return nil, err
 		}
-		if err := s.paymentRepo.UpdateTransactionStatus(ctx, tx.ID, transaction.StatusFailed); err != nil {
-			return nil, err
+		p := &payment.Payment{
+			ID:                tx.ID,
+			UserID:            tx.UserID,
+			ProviderID:        tx.ProviderID,
+			ExternalReference: tx.ExternalReference,
+			Amount:            tx.Amount,
+			Currency:          tx.Currency,
 		}
+		return s.settle(ctx, p, tx, feeTx)
+	}
 
-		failedEvent := &outbox.OutboxEvent{
-			ID:        s.idGen.New(),
-			EventType: "payment.failed",
-			Payload:   fmt.Sprintf(`{"transaction_id":"%s","status":"%s"}`, tx.ID, tx.Status),
-			CreatedAt: s.clock.Now(),
+	if err := s.closePayment(ctx, tx, feeTx, transaction.StatusDeclined, "rejected"); err != nil {
+		return nil, err
+	}
+	return paymentResponse(tx, feeTx), nil
+}
+
+// screen ejecuta el screening de riesgo configurado; sin evaluador, el pago se permite.
+func (s *PaymentService) screen(ctx context.Context, p *payment.Payment) (*risk.Assessment, error) {
+	if s.riskEvaluator == nil {
+		return risk.NewAssessment(), nil
+	}
+	return s.riskEvaluator.Evaluate(ctx, p)
+}
+
+// hold deja la transacción en HELD a la espera de revisión manual.
+func (s *PaymentService) hold(ctx context.Context, tx, feeTx *transaction.Transaction) (*ProcessPaymentResponse, error) {
+	if err := tx.UpdateStatus(transaction.StatusHeld); err != nil {
+		return nil, err
+	}
+	if err := s.paymentRepo.UpdateTransactionStatus(ctx, tx.ID, tx.Status); err != nil {
+		return nil, err
+	}
+	if err := s.finalizeFee(ctx, feeTx, tx.Status); err != nil {
+		return nil, err
+	}
+	if err := s.emit(ctx, events.PaymentHeld{Payment: paymentEvent(tx, feeTx)}); err != nil {
+		return nil, err
+	}
+	return paymentResponse(tx, feeTx), nil
+}
+
+// releaseHold devuelve un pago aprobado en revisión a PENDING antes de llamar a la pasarela, para
+// que si esta responde "pending" el webhook lo encuentre PENDING y lo finalice. El compare-and-set
+// evita que dos revisiones concurrentes lo envíen dos veces.
+func (s *PaymentService) releaseHold(ctx context.Context, tx, feeTx *transaction.Transaction) error {
+	if err := tx.UpdateStatus(transaction.StatusPending); err != nil {
+		return err
+	}
+	return s.inTransaction(ctx, func(ctx context.Context) error {
+		changed, err := s.paymentRepo.TransitionTransactionStatus(ctx, tx.ID, transaction.StatusHeld, transaction.StatusPending)
+		if err != nil {
+			return err
 		}
-		if err := s.outboxRepo.CreateEvent(ctx, failedEvent); err != nil {
-			return nil, err
+		if !changed {
+			return errors.NewValidationError("transaction is not held for review", map[string]interface{}{
+				"transaction_id": tx.ID.String(),
+			})
 		}
+		return s.finalizeFee(ctx, feeTx, tx.Status)
+	})
+}
 
+// settle llama a la pasarela y finaliza la transacción (reembolsando si no se aprueba).
+func (s *PaymentService) settle(ctx context.Context, p *payment.Payment, tx, feeTx *transaction.Transaction) (*ProcessPaymentResponse, error) {
+	// Llamar a gateway
+	status, err := s.gateway.ProcessPayment(ctx, p)
+	if err != nil {
+		// Gateway error: refund interno
+		gatewayErr := errors.NewGatewayError("gateway processing failed")
 		if domErr, ok := err.(errors.Error); ok {
-			return nil, domErr
+			gatewayErr = domErr
+		}
+		if err := s.closePayment(ctx, tx, feeTx, transaction.StatusFailed, gatewayErr.Code); err != nil {
+			return nil, err
 		}
-		return nil, errors.NewGatewayError("gateway processing failed")
+		return nil, gatewayErr
+	}
+
+	// La pasarela confirma de forma asíncrona: el pago queda PENDING hasta el webhook
+	if status == "pending" {
+		return paymentResponse(tx, feeTx), nil
+	}
+	return s.finalize(ctx, tx, feeTx, status)
+}
+
+// CompletePendingPayment finaliza un pago PENDING con el resultado informado por la pasarela
+// (approved, declined o failed), reembolsando si no se aprueba. Un pago ya finalizado se retorna sin cambios.
+func (s *PaymentService) CompletePendingPayment(ctx context.Context, txID uuid.UUID, status string) (*ProcessPaymentResponse, error) {
+	tx, err := s.paymentRepo.GetTransactionByID(ctx, txID)
+	if err != nil {
+		return nil, err
+	}
+	if tx.Type != transaction.TypePayment {
+		return nil, errors.NewNotFoundError("payment not found")
+	}
+	feeTx, err := s.feeTransaction(ctx, tx.ID)
+	if err != nil {
+		return nil, err
 	}
+	if tx.Status != transaction.StatusPending {
+		return paymentResponse(tx, feeTx), nil
+	}
+	return s.finalize(ctx, tx, feeTx, status)
+}
 
-	// Finalizar basado en status
+// finalize aplica el resultado de la pasarela a la transacción, reembolsa si no se aprueba y publica el evento.
+func (s *PaymentService) finalize(ctx context.Context, tx, feeTx *transaction.Transaction, status string) (*ProcessPaymentResponse, error) {
+	to := transaction.StatusFailed
 	switch status {
 	case "approved":
-		if err := tx.UpdateStatus(transaction.StatusApproved); err != nil {
-			return nil, err
-		}
+		to = transaction.StatusApproved
 	case "declined":
-		if err := tx.UpdateStatus(transaction.StatusDeclined); err != nil {
-			return nil, err
+		to = transaction.StatusDeclined
+	}
+	if err := s.closePayment(ctx, tx, feeTx, to, strings.ToLower(string(to))); err != nil {
+		return nil, err
+	}
+	return paymentResponse(tx, feeTx), nil
+}
+
+// closePayment lleva el pago a su estado final to. El cambio de estado es un compare-and-set en la
+// misma transacción DB que el reembolso, la comisión y el evento: si otro proceso (webhook, reintento
+// o revisión concurrente) ya cerró el pago, no se reembolsa de nuevo y tx/feeTx se recargan con el
+// estado vigente. reason acompaña al evento payment.failed.
+func (s *PaymentService) closePayment(ctx context.Context, tx, feeTx *transaction.Transaction, to transaction.Status, reason string) error {
+	from := tx.Status
+	if err := tx.UpdateStatus(to); err != nil {
+		return err
+	}
+	var changed bool
+	err := s.inTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if changed, err = s.paymentRepo.TransitionTransactionStatus(ctx, tx.ID, from, to); err != nil || !changed {
+			return err
 		}
-		// Refund
-		if err := s.refundInternal(ctx, tx, w); err != nil {
-			return nil, errors.NewInternalError("refund failed")
+		if to != transaction.StatusApproved {
+			if err := s.refundPayment(ctx, tx, feeTx); err != nil {
+				return errors.NewInternalError("refund failed")
+			}
 		}
-	default:
-		if err := tx.UpdateStatus(transaction.StatusFailed); err != nil {
-			return nil, err
+		if err := s.finalizeFee(ctx, feeTx, to); err != nil {
+			return err
 		}
-		if err := s.refundInternal(ctx, tx, w); err != nil {
-			return nil, errors.NewInternalError("refund failed")
+
+		// Crear evento outbox
+		var event events.Event = events.PaymentCompleted{Payment: paymentEvent(tx, feeTx)}
+		if to != transaction.StatusApproved {
+			event = events.PaymentFailed{Payment: paymentEvent(tx, feeTx), Reason: reason}
 		}
-	}
-	if err := s.paymentRepo.UpdateTransactionStatus(ctx, tx.ID, tx.Status); err != nil {
-		return nil, err
+		return s.emit(ctx, event)
+	})
+	if err != nil || changed {
+		return err
 	}
 
-	// Crear evento outbox
-	eventType := "payment.failed"
-	if tx.Status == transaction.StatusApproved {
-		eventType = "payment.completed"
-	}
-	event := &outbox.OutboxEvent{
-		ID:        s.idGen.New(),
-		EventType: eventType,
-		Payload:   fmt.Sprintf(`{"transaction_id":"%s","status":"%s"}`, tx.ID, tx.Status),
-		CreatedAt: s.clock.Now(),
+	current, err := s.paymentRepo.GetTransactionByID(ctx, tx.ID)
+	if err != nil {
+		return err
 	}
-	if err := s.outboxRepo.CreateEvent(ctx, event); err != nil {
-		return nil, err
+	*tx = *current
+	if feeTx != nil {
+		currentFee, err := s.feeTransaction(ctx, tx.ID)
+		if err != nil {
+			return err
+		}
+		if currentFee != nil {
+			*feeTx = *currentFee
+		}
 	}
+	return nil
+}
 
-	// Guardar idempotencia
-	resp := &ProcessPaymentResponse{
-		TransactionID: tx.ID,
-		Status:        string(tx.Status),
+// refundPayment reembolsa el principal y la comisión completa con la wallet bloqueada: un pago
+// fallido o declinado se devuelve entero. Debe llamarse dentro de una transacción.
+func (s *PaymentService) refundPayment(ctx context.Context, tx, feeTx *transaction.Transaction) error {
+	if err := s.walletRepo.LockWallet(ctx, tx.UserID); err != nil {
+		return err
 	}
-	respJSON, _ := json.Marshal(resp)
-	record := &IdempotencyRecord{
-		UserID:    req.UserID,
-		Key:       req.IdempotencyKey,
-		RequestID: tx.ID,
-		Response:  string(respJSON),
-		CreatedAt: s.clock.Now(),
+	w, err := s.walletRepo.GetWallet(ctx, tx.UserID)
+	if err != nil {
+		return err
 	}
-	if err := s.idempotencyRepo.CreateIdempotencyRecord(ctx, record); err != nil {
-		return nil, err
+	if err := s.refundInternal(ctx, tx, tx.Amount, w); err != nil {
+		return err
 	}
-
-	return resp, nil
+	if feeTx == nil || feeTx.Amount <= 0 {
+		return nil
+	}
+	return s.refundInternal(ctx, feeTx, feeTx.Amount, w)
 }
 
-// refundInternal realiza un reembolso interno.
-func (s *PaymentService) refundInternal(ctx context.Context, tx *transaction.Transaction, w *wallet.Wallet) error {
-	if err := w.Credit(tx.Currency, tx.Amount); err != nil {
+// refundInternal realiza un reembolso interno de amount vinculado a tx.
+func (s *PaymentService) refundInternal(ctx context.Context, tx *transaction.Transaction, amount int64, w *wallet.Wallet) error {
+	if err := w.Credit(tx.Currency, amount); err != nil {
 		return err
 	}
 	if err := s.walletRepo.UpdateBalance(ctx, tx.UserID, tx.Currency, w.GetBalance(tx.Currency)); err != nil {
 		return err
 	}
-	refundTx, _ := transaction.NewTransaction(tx.UserID, transaction.TypeRefund, tx.Amount, tx.Currency, tx.ProviderID, tx.ExternalReference)
+	refundTx, _ := transaction.NewRelatedTransaction(tx, transaction.TypeRefund, amount)
 	if err := s.paymentRepo.CreateTransaction(ctx, refundTx); err != nil {
 		return err
 	}
@@ -229,16 +434,168 @@ func (s *PaymentService) refundInternal(ctx context.Context, tx *transaction.Tra
 		return err
 	}
 
-	refundEvent := &outbox.OutboxEvent{
-		ID:        s.idGen.New(),
-		EventType: "refund.created",
-		Payload:   fmt.Sprintf(`{"transaction_id":"%s","status":"%s"}`, refundTx.ID, refundTx.Status),
-		CreatedAt: s.clock.Now(),
+	paymentID := tx.ID
+	if tx.Type == transaction.TypeFee && tx.RelatedTransactionID != nil {
+		paymentID = *tx.RelatedTransactionID
+	}
+	return s.emit(ctx, events.RefundCreated{
+		RefundID:      refundTx.ID,
+		TransactionID: tx.ID,
+		PaymentID:     paymentID,
+		UserID:        refundTx.UserID,
+		Amount:        refundTx.Amount,
+		Currency:      refundTx.Currency,
+		Status:        string(refundTx.Status),
+	})
+}
+
+// inTransaction ejecuta fn en una transacción DB; sin transactor (tests) la ejecuta directamente.
+func (s *PaymentService) inTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.transactor == nil {
+		return fn(ctx)
 	}
-	if err := s.outboxRepo.CreateEvent(ctx, refundEvent); err != nil {
+	return s.transactor.WithinTransaction(ctx, fn)
+}
+
+// emit serializa el evento tipado en su envelope y lo registra en el outbox.
+func (s *PaymentService) emit(ctx context.Context, ev events.Event) error {
+	event, err := events.NewOutboxEvent(ctx, s.idGen.New(), s.clock.Now(), ev)
+	if err != nil {
 		return err
 	}
-	return nil
+	return s.outboxRepo.CreateEvent(ctx, event)
+}
+
+// computeFee calcula la comisión del pago; sin política configurada no se cobra comisión.
+func (s *PaymentService) computeFee(ctx context.Context, p *payment.Payment) (int64, error) {
+	if s.feeRepo == nil {
+		return 0, nil
+	}
+	policy, err := s.feeRepo.GetFeePolicy(ctx, p.ProviderID, p.Currency)
+	if err != nil {
+		if isNotFoundError(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return policy.Compute(p.Amount), nil
+}
+
+// createFeeTransaction registra la comisión como transacción FEE vinculada al pago.
+func (s *PaymentService) createFeeTransaction(ctx context.Context, tx *transaction.Transaction, amount int64) (*transaction.Transaction, error) {
+	if amount <= 0 {
+		return nil, nil
+	}
+	feeTx, err := transaction.NewRelatedTransaction(tx, transaction.TypeFee, amount)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.paymentRepo.CreateTransaction(ctx, feeTx); err != nil {
+		return nil, err
+	}
+	return feeTx, nil
+}
+
+// feeTransaction obtiene la comisión de un pago existente, si la tiene.
+func (s *PaymentService) feeTransaction(ctx context.Context, paymentTxID uuid.UUID) (*transaction.Transaction, error) {
+	feeTx, err := s.paymentRepo.GetFeeTransaction(ctx, paymentTxID)
+	if err != nil {
+		if isNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return feeTx, nil
+}
+
+// finalizeFee replica en la comisión el estado del pago (final, HELD o PENDING al liberarse la retención).
+func (s *PaymentService) finalizeFee(ctx context.Context, feeTx *transaction.Transaction, status transaction.Status) error {
+	if feeTx == nil {
+		return nil
+	}
+	if err := feeTx.UpdateStatus(status); err != nil {
+		return err
+	}
+	return s.paymentRepo.UpdateTransactionStatus(ctx, feeTx.ID, feeTx.Status)
+}
+
+// paymentEvent arma los datos comunes de los eventos de pago.
+func paymentEvent(tx, feeTx *transaction.Transaction) events.Payment {
+	ev := events.Payment{
+		TransactionID:     tx.ID,
+		UserID:            tx.UserID,
+		ProviderID:        tx.ProviderID,
+		ExternalReference: tx.ExternalReference,
+		Amount:            tx.Amount,
+		Currency:          tx.Currency,
+		Status:            string(tx.Status),
+	}
+	if feeTx != nil {
+		ev.Fee = feeTx.Amount
+	}
+	return ev
+}
+
+// paymentResponse arma la respuesta del pago incluyendo la comisión cobrada.
+func paymentResponse(tx, feeTx *transaction.Transaction) *ProcessPaymentResponse {
+	resp := &ProcessPaymentResponse{TransactionID: tx.ID, Status: string(tx.Status)}
+	if feeTx != nil {
+		feeID := feeTx.ID
+		resp.Fee = feeTx.Amount
+		resp.FeeTransactionID = &feeID
+	}
+	return resp
+}
+
+// checkProvider valida que el provider exista en el catálogo y acepte el pago.
+func (s *PaymentService) checkProvider(ctx context.Context, p *payment.Payment) error {
+	if s.providerRepo == nil {
+		return nil
+	}
+	prov, err := s.providerRepo.GetProvider(ctx, p.ProviderID)
+	if err != nil {
+		if isNotFoundError(err) {
+			return errors.NewValidationError("unknown provider_id", map[string]interface{}{"provider_id": p.ProviderID})
+		}
+		return err
+	}
+	return p.ValidateProvider(prov)
+}
+
+// checkSpendingLimit evalúa los límites de gasto y velocidad configurados para la wallet.
+// Debe llamarse con la wallet bloqueada, en la misma transacción que el débito.
+func (s *PaymentService) checkSpendingLimit(ctx context.Context, p *payment.Payment) error {
+	if s.limitRepo == nil {
+		return nil
+	}
+	l, err := s.limitRepo.GetSpendingLimit(ctx, p.UserID, p.Currency)
+	if err != nil {
+		if isNotFoundError(err) {
+			return nil
+		}
+		return err
+	}
+
+	now := s.clock.Now().UTC()
+	var usage limit.Usage
+	if l.DailySpend > 0 {
+		startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		if usage.DailySpent, _, err = s.limitRepo.GetPaymentUsage(ctx, p.UserID, p.Currency, startOfDay); err != nil {
+			return err
+		}
+	}
+	if l.MonthlySpend > 0 {
+		startOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		if usage.MonthlySpent, _, err = s.limitRepo.GetPaymentUsage(ctx, p.UserID, p.Currency, startOfMonth); err != nil {
+			return err
+		}
+	}
+	if l.MaxPaymentsPerHour > 0 {
+		if _, usage.PaymentsLastHour, err = s.limitRepo.GetPaymentUsage(ctx, p.UserID, p.Currency, now.Add(-time.Hour)); err != nil {
+			return err
+		}
+	}
+	return l.Check(p.Amount, usage)
 }
 
 // isNotFoundError verifica si es error de no encontrado.
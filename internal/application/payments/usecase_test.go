@@ -7,9 +7,15 @@ import (
 	"testing"
 	"time"
 
+	"draftea-challenge/internal/application/events"
 	"draftea-challenge/internal/application/outbox"
+	"draftea-challenge/internal/application/ports"
 	"draftea-challenge/internal/domain/errors"
+	"draftea-challenge/internal/domain/fee"
+	"draftea-challenge/internal/domain/limit"
 	"draftea-challenge/internal/domain/payment"
+	"draftea-challenge/internal/domain/provider"
+	"draftea-challenge/internal/domain/risk"
 	"draftea-challenge/internal/domain/transaction"
 	"draftea-challenge/internal/domain/wallet"
 
@@ -19,6 +25,8 @@ import (
 type mockPaymentRepo struct {
 	createdTxs []*transaction.Transaction
 	updates    []transaction.Status
+	stored     *transaction.Transaction
+	persisted  map[uuid.UUID]transaction.Status // último estado escrito, para el compare-and-set
 }
 
 func (m *mockPaymentRepo) CreateTransaction(ctx context.Context, tx *transaction.Transaction) error {
@@ -28,17 +36,48 @@ func (m *mockPaymentRepo) CreateTransaction(ctx context.Context, tx *transaction
 
 func (m *mockPaymentRepo) UpdateTransactionStatus(ctx context.Context, txID uuid.UUID, status transaction.Status) error {
 	m.updates = append(m.updates, status)
+	m.persist(txID, status)
 	return nil
 }
 
+// TransitionTransactionStatus aplica el cambio salvo que el último estado escrito no sea from;
+// una transacción sin escrituras se asume en from.
+func (m *mockPaymentRepo) TransitionTransactionStatus(ctx context.Context, txID uuid.UUID, from, to transaction.Status) (bool, error) {
+	if current, ok := m.persisted[txID]; ok && current != from {
+		return false, nil
+	}
+	m.updates = append(m.updates, to)
+	m.persist(txID, to)
+	return true, nil
+}
+
+func (m *mockPaymentRepo) persist(txID uuid.UUID, status transaction.Status) {
+	if m.persisted == nil {
+		m.persisted = make(map[uuid.UUID]transaction.Status)
+	}
+	m.persisted[txID] = status
+}
+
 func (m *mockPaymentRepo) GetTransactionByID(ctx context.Context, txID uuid.UUID) (*transaction.Transaction, error) {
-	return nil, nil
+	if m.stored == nil {
+		return nil, errors.NewNotFoundError("transaction not found")
+	}
+	return m.stored, nil
 }
 
 func (m *mockPaymentRepo) ListTransactions(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*transaction.Transaction, error) {
 	return nil, nil
 }
 
+func (m *mockPaymentRepo) GetFeeTransaction(ctx context.Context, paymentTxID uuid.UUID) (*transaction.Transaction, error) {
+	for _, tx := range m.createdTxs {
+		if tx.Type == transaction.TypeFee && tx.RelatedTransactionID != nil && *tx.RelatedTransactionID == paymentTxID {
+			return tx, nil
+		}
+	}
+	return nil, errors.NewNotFoundError("fee transaction not found")
+}
+
 type mockWalletRepo struct {
 	wallet       *wallet.Wallet
 	getErr       error
@@ -64,6 +103,10 @@ func (m *mockWalletRepo) UpdateBalance(ctx context.Context, userID uuid.UUID, cu
 	return nil
 }
 
+func (m *mockWalletRepo) LockWallet(ctx context.Context, userID uuid.UUID) error {
+	return m.getErr
+}
+
 func (m *mockWalletRepo) ListWallets(ctx context.Context, limit, offset int) ([]*wallet.Wallet, int, error) {
 	return nil, 0, nil
 }
@@ -97,9 +140,13 @@ func (m *mockIdempotencyRepo) CreateIdempotencyRecord(ctx context.Context, recor
 
 type mockOutboxRepo struct {
 	events []*outbox.OutboxEvent
+	err    error
 }
 
 func (m *mockOutboxRepo) CreateEvent(ctx context.Context, event *outbox.OutboxEvent) error {
+	if m.err != nil {
+		return m.err
+	}
 	m.events = append(m.events, event)
 	return nil
 }
@@ -108,10 +155,53 @@ func (m *mockOutboxRepo) GetPendingEvents(ctx context.Context, limit int) ([]*ou
 	return nil, nil
 }
 
+func (m *mockOutboxRepo) ClaimPendingEvents(ctx context.Context, relayID string, now time.Time, limit int, lease time.Duration) ([]*outbox.OutboxEvent, error) {
+	return nil, nil
+}
+
+func (m *mockOutboxRepo) ReleaseEvents(ctx context.Context, relayID string, eventIDs []uuid.UUID) error {
+	return nil
+}
+
+func (m *mockOutboxRepo) RecordEventFailure(ctx context.Context, event *outbox.OutboxEvent) error {
+	return nil
+}
+
 func (m *mockOutboxRepo) MarkEventAsSent(ctx context.Context, eventID uuid.UUID) error {
 	return nil
 }
 
+type mockLimitRepo struct {
+	limit      *limit.SpendingLimit
+	spent      int64
+	count      int
+	usageCalls int
+}
+
+func (m *mockLimitRepo) GetSpendingLimit(ctx context.Context, userID uuid.UUID, currency string) (*limit.SpendingLimit, error) {
+	if m.limit == nil {
+		return nil, errors.NewNotFoundError("spending limit not found")
+	}
+	return m.limit, nil
+}
+
+func (m *mockLimitRepo) GetPaymentUsage(ctx context.Context, userID uuid.UUID, currency string, since time.Time) (int64, int, error) {
+	m.usageCalls++
+	return m.spent, m.count, nil
+}
+
+type mockRiskEvaluator struct {
+	decision risk.Decision
+}
+
+func (m *mockRiskEvaluator) Evaluate(ctx context.Context, p *payment.Payment) (*risk.Assessment, error) {
+	assessment := risk.NewAssessment()
+	if m.decision != risk.DecisionAllow {
+		assessment.Escalate(m.decision, "test_rule")
+	}
+	return assessment, nil
+}
+
 type fixedIDGen struct {
 	id uuid.UUID
 }
@@ -148,9 +238,10 @@ func TestProcessPayment_HappyPath(t *testing.T) {
 	outboxRepo := &mockOutboxRepo{}
 	clock := fixedClock{t: time.Now()}
 
-	svc := NewPaymentService(payRepo, walletRepo, gateway, idemRepo, outboxRepo, fixedIDGen{}, clock)
+	svc := NewPaymentService(payRepo, walletRepo, gateway, idemRepo, outboxRepo, nil, nil, nil, nil, nil, fixedIDGen{}, clock)
 
-	resp, err := svc.ProcessPayment(context.Background(), &ProcessPaymentRequest{
+	ctx := ports.WithCorrelationID(context.Background(), "req-1")
+	resp, err := svc.ProcessPayment(ctx, &ProcessPaymentRequest{
 		UserID:            userID,
 		ProviderID:        uuid.New(),
 		ExternalReference: "ref-1",
@@ -176,6 +267,20 @@ func TestProcessPayment_HappyPath(t *testing.T) {
 	if outboxRepo.events[1].EventType != "payment.completed" {
 		t.Fatalf("expected payment.completed event, got %s", outboxRepo.events[1].EventType)
 	}
+	env, err := events.Decode([]byte(outboxRepo.events[1].Payload))
+	if err != nil {
+		t.Fatalf("decode envelope: %v", err)
+	}
+	if env.CorrelationID != "req-1" || env.AggregateID != resp.TransactionID || env.Version != 1 {
+		t.Fatalf("unexpected envelope %+v", env)
+	}
+	var data events.PaymentCompleted
+	if err := json.Unmarshal(env.Data, &data); err != nil {
+		t.Fatalf("decode data: %v", err)
+	}
+	if data.UserID != userID || data.Amount != 500 || data.Status != string(transaction.StatusApproved) {
+		t.Fatalf("unexpected payment.completed data %+v", data)
+	}
 }
 
 func TestProcessPayment_InsufficientFunds(t *testing.T) {
@@ -189,7 +294,7 @@ func TestProcessPayment_InsufficientFunds(t *testing.T) {
 	idemRepo := &mockIdempotencyRepo{}
 	outboxRepo := &mockOutboxRepo{}
 
-	svc := NewPaymentService(payRepo, walletRepo, gateway, idemRepo, outboxRepo, fixedIDGen{}, fixedClock{})
+	svc := NewPaymentService(payRepo, walletRepo, gateway, idemRepo, outboxRepo, nil, nil, nil, nil, nil, fixedIDGen{}, fixedClock{})
 
 	_, err := svc.ProcessPayment(context.Background(), &ProcessPaymentRequest{
 		UserID:            userID,
@@ -212,6 +317,36 @@ func TestProcessPayment_InsufficientFunds(t *testing.T) {
 	}
 }
 
+func TestProcessPayment_OutboxErrorAbortsBeforeGateway(t *testing.T) {
+	userID := uuid.New()
+	w, _ := wallet.NewWallet(userID)
+	_ = w.SetBalance("USD", 1000)
+
+	gateway := &mockGateway{status: "approved"}
+	idemRepo := &mockIdempotencyRepo{}
+	outboxRepo := &mockOutboxRepo{err: errors.NewInternalError("outbox unavailable")}
+
+	svc := NewPaymentService(&mockPaymentRepo{}, &mockWalletRepo{wallet: w}, gateway, idemRepo, outboxRepo, nil, nil, nil, nil, nil, fixedIDGen{}, fixedClock{t: time.Now()})
+
+	_, err := svc.ProcessPayment(context.Background(), &ProcessPaymentRequest{
+		UserID:            userID,
+		ProviderID:        uuid.New(),
+		ExternalReference: "ref-1",
+		Amount:            500,
+		Currency:          "USD",
+		IdempotencyKey:    "key-1",
+	})
+	if err == nil {
+		t.Fatalf("expected the outbox error to fail the payment")
+	}
+	if gateway.calls != 0 {
+		t.Fatalf("expected gateway not called without payment.created")
+	}
+	if idemRepo.created != nil {
+		t.Fatalf("expected no idempotency record for a failed payment")
+	}
+}
+
 func TestProcessPayment_GatewayTimeout(t *testing.T) {
 	userID := uuid.New()
 	w, _ := wallet.NewWallet(userID)
@@ -223,7 +358,7 @@ func TestProcessPayment_GatewayTimeout(t *testing.T) {
 	idemRepo := &mockIdempotencyRepo{}
 	outboxRepo := &mockOutboxRepo{}
 
-	svc := NewPaymentService(payRepo, walletRepo, gateway, idemRepo, outboxRepo, fixedIDGen{}, fixedClock{t: time.Now()})
+	svc := NewPaymentService(payRepo, walletRepo, gateway, idemRepo, outboxRepo, nil, nil, nil, nil, nil, fixedIDGen{}, fixedClock{t: time.Now()})
 
 	_, err := svc.ProcessPayment(context.Background(), &ProcessPaymentRequest{
 		UserID:            userID,
@@ -258,7 +393,7 @@ func TestProcessPayment_IdempotencyHit(t *testing.T) {
 	idemRepo := &mockIdempotencyRepo{record: &IdempotencyRecord{UserID: userID, Key: "idem-1", Response: string(payload)}}
 	outboxRepo := &mockOutboxRepo{}
 
-	svc := NewPaymentService(payRepo, walletRepo, gateway, idemRepo, outboxRepo, fixedIDGen{}, fixedClock{})
+	svc := NewPaymentService(payRepo, walletRepo, gateway, idemRepo, outboxRepo, nil, nil, nil, nil, nil, fixedIDGen{}, fixedClock{})
 
 	resp, err := svc.ProcessPayment(context.Background(), &ProcessPaymentRequest{
 		UserID:            userID,
@@ -290,7 +425,7 @@ func TestProcessPayment_DeclinedCreatesRefundEvent(t *testing.T) {
 	idemRepo := &mockIdempotencyRepo{}
 	outboxRepo := &mockOutboxRepo{}
 
-	svc := NewPaymentService(payRepo, walletRepo, gateway, idemRepo, outboxRepo, fixedIDGen{}, fixedClock{t: time.Now()})
+	svc := NewPaymentService(payRepo, walletRepo, gateway, idemRepo, outboxRepo, nil, nil, nil, nil, nil, fixedIDGen{}, fixedClock{t: time.Now()})
 
 	_, err := svc.ProcessPayment(context.Background(), &ProcessPaymentRequest{
 		UserID:            userID,
@@ -315,3 +450,438 @@ func TestProcessPayment_DeclinedCreatesRefundEvent(t *testing.T) {
 		t.Fatalf("expected payment event, got %s", last.EventType)
 	}
 }
+
+func TestProcessPayment_LimitExceeded(t *testing.T) {
+	userID := uuid.New()
+	w, _ := wallet.NewWallet(userID)
+	_ = w.SetBalance("USD", 10000)
+
+	payRepo := &mockPaymentRepo{}
+	walletRepo := &mockWalletRepo{wallet: w}
+	gateway := &mockGateway{status: "approved"}
+	idemRepo := &mockIdempotencyRepo{}
+	outboxRepo := &mockOutboxRepo{}
+	dailyLimit, _ := limit.NewSpendingLimit(userID, "USD", 0, 1000, 0, 0)
+	limitRepo := &mockLimitRepo{limit: dailyLimit, spent: 800}
+
+	svc := NewPaymentService(payRepo, walletRepo, gateway, idemRepo, outboxRepo, nil, nil, limitRepo, nil, nil, fixedIDGen{}, fixedClock{t: time.Now()})
+
+	_, err := svc.ProcessPayment(context.Background(), &ProcessPaymentRequest{
+		UserID:            userID,
+		ProviderID:        uuid.New(),
+		ExternalReference: "ref-1",
+		Amount:            500,
+		Currency:          "USD",
+	})
+	domErr, ok := err.(errors.Error)
+	if !ok || domErr.Code != errors.CodeLimitExceeded {
+		t.Fatalf("expected limit exceeded error, got %v", err)
+	}
+	if domErr.Details["remaining"] != int64(200) {
+		t.Fatalf("expected remaining 200, got %v", domErr.Details["remaining"])
+	}
+	if gateway.calls != 0 || len(walletRepo.balanceCalls) != 0 {
+		t.Fatalf("expected no debit nor gateway call")
+	}
+	if limitRepo.usageCalls != 1 {
+		t.Fatalf("expected only daily usage lookup, got %d", limitRepo.usageCalls)
+	}
+}
+
+func TestProcessPayment_RiskReviewHoldsPayment(t *testing.T) {
+	userID := uuid.New()
+	w, _ := wallet.NewWallet(userID)
+	_ = w.SetBalance("USD", 1000)
+
+	payRepo := &mockPaymentRepo{}
+	walletRepo := &mockWalletRepo{wallet: w}
+	gateway := &mockGateway{status: "approved"}
+	idemRepo := &mockIdempotencyRepo{}
+	outboxRepo := &mockOutboxRepo{}
+
+	svc := NewPaymentService(payRepo, walletRepo, gateway, idemRepo, outboxRepo, nil, nil, nil, &mockRiskEvaluator{decision: risk.DecisionReview}, nil, fixedIDGen{}, fixedClock{t: time.Now()})
+
+	resp, err := svc.ProcessPayment(context.Background(), &ProcessPaymentRequest{
+		UserID:            userID,
+		ProviderID:        uuid.New(),
+		ExternalReference: "ref-1",
+		Amount:            500,
+		Currency:          "USD",
+		IdempotencyKey:    "idem-1",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Status != string(transaction.StatusHeld) {
+		t.Fatalf("expected held status, got %s", resp.Status)
+	}
+	if gateway.calls != 0 {
+		t.Fatalf("expected gateway not called")
+	}
+	if w.GetBalance("USD") != 500 {
+		t.Fatalf("expected funds to stay debited, got %d", w.GetBalance("USD"))
+	}
+	if last := outboxRepo.events[len(outboxRepo.events)-1]; last.EventType != "payment.held" {
+		t.Fatalf("expected payment.held event, got %s", last.EventType)
+	}
+
+	// Aprobación manual: continúa hacia la pasarela
+	payRepo.stored = payRepo.createdTxs[0]
+	resp, err = svc.ReviewHeldPayment(context.Background(), resp.TransactionID, true)
+	if err != nil {
+		t.Fatalf("unexpected review error: %v", err)
+	}
+	if resp.Status != string(transaction.StatusApproved) {
+		t.Fatalf("expected approved status, got %s", resp.Status)
+	}
+	if gateway.calls != 1 {
+		t.Fatalf("expected gateway call after approval, got %d", gateway.calls)
+	}
+}
+
+func TestReviewHeldPayment_ApprovePendingThenCallback(t *testing.T) {
+	userID := uuid.New()
+	w, _ := wallet.NewWallet(userID)
+	_ = w.SetBalance("USD", 1000)
+
+	payRepo := &mockPaymentRepo{}
+	gateway := &mockGateway{status: "pending"}
+	outboxRepo := &mockOutboxRepo{}
+	svc := NewPaymentService(payRepo, &mockWalletRepo{wallet: w}, gateway, &mockIdempotencyRepo{}, outboxRepo, nil, nil, nil, &mockRiskEvaluator{decision: risk.DecisionReview}, nil, fixedIDGen{}, fixedClock{t: time.Now()})
+
+	resp, err := svc.ProcessPayment(context.Background(), &ProcessPaymentRequest{
+		UserID:            userID,
+		ProviderID:        uuid.New(),
+		ExternalReference: "ref-1",
+		Amount:            500,
+		Currency:          "USD",
+		IdempotencyKey:    "idem-1",
+	})
+	if err != nil || resp.Status != string(transaction.StatusHeld) {
+		t.Fatalf("expected held payment, got %+v %v", resp, err)
+	}
+
+	// La pasarela responde 202: el pago aprobado queda PENDING a la espera del webhook.
+	payRepo.stored = payRepo.createdTxs[0]
+	resp, err = svc.ReviewHeldPayment(context.Background(), resp.TransactionID, true)
+	if err != nil {
+		t.Fatalf("unexpected review error: %v", err)
+	}
+	if resp.Status != string(transaction.StatusPending) || gateway.calls != 1 {
+		t.Fatalf("expected pending payment after approval, got %s (%d gateway calls)", resp.Status, gateway.calls)
+	}
+
+	resp, err = svc.CompletePendingPayment(context.Background(), resp.TransactionID, "approved")
+	if err != nil {
+		t.Fatalf("unexpected callback error: %v", err)
+	}
+	if resp.Status != string(transaction.StatusApproved) || w.GetBalance("USD") != 500 {
+		t.Fatalf("expected the callback to approve the payment, got %s balance %d", resp.Status, w.GetBalance("USD"))
+	}
+	if last := outboxRepo.events[len(outboxRepo.events)-1]; last.EventType != "payment.completed" {
+		t.Fatalf("expected payment.completed event, got %s", last.EventType)
+	}
+}
+
+func TestProcessPayment_RiskDenied(t *testing.T) {
+	userID := uuid.New()
+	w, _ := wallet.NewWallet(userID)
+	_ = w.SetBalance("USD", 1000)
+
+	payRepo := &mockPaymentRepo{}
+	walletRepo := &mockWalletRepo{wallet: w}
+	gateway := &mockGateway{status: "approved"}
+
+	svc := NewPaymentService(payRepo, walletRepo, gateway, &mockIdempotencyRepo{}, &mockOutboxRepo{}, nil, nil, nil, &mockRiskEvaluator{decision: risk.DecisionDeny}, nil, fixedIDGen{}, fixedClock{t: time.Now()})
+
+	_, err := svc.ProcessPayment(context.Background(), &ProcessPaymentRequest{
+		UserID:            userID,
+		ProviderID:        uuid.New(),
+		ExternalReference: "ref-1",
+		Amount:            500,
+		Currency:          "USD",
+	})
+	if domErr, ok := err.(errors.Error); !ok || domErr.Code != errors.CodeRiskDenied {
+		t.Fatalf("expected risk denied error, got %v", err)
+	}
+	if len(payRepo.createdTxs) != 0 || gateway.calls != 0 {
+		t.Fatalf("expected no transaction nor gateway call")
+	}
+}
+
+type mockProviderRepo struct {
+	providers map[uuid.UUID]*provider.Provider
+}
+
+func (m *mockProviderRepo) GetProvider(ctx context.Context, providerID uuid.UUID) (*provider.Provider, error) {
+	if p, ok := m.providers[providerID]; ok {
+		return p, nil
+	}
+	return nil, errors.NewNotFoundError("provider not found")
+}
+
+func TestProcessPayment_ProviderValidation(t *testing.T) {
+	userID := uuid.New()
+	w, _ := wallet.NewWallet(userID)
+	_ = w.SetBalance("USD", 10000)
+
+	prov, _ := provider.NewProvider(provider.Attributes{
+		Name:             "Water Co",
+		Currencies:       []string{"USD"},
+		MaxAmount:        5000,
+		ReferencePattern: `[0-9]{8}`,
+		Enabled:          true,
+	})
+	providerRepo := &mockProviderRepo{providers: map[uuid.UUID]*provider.Provider{prov.ID: prov}}
+	payRepo := &mockPaymentRepo{}
+	gateway := &mockGateway{status: "approved"}
+
+	svc := NewPaymentService(payRepo, &mockWalletRepo{wallet: w}, gateway, &mockIdempotencyRepo{}, &mockOutboxRepo{}, providerRepo, nil, nil, nil, nil, fixedIDGen{}, fixedClock{t: time.Now()})
+
+	cases := []struct {
+		name       string
+		providerID uuid.UUID
+		reference  string
+		amount     int64
+		currency   string
+	}{
+		{name: "unknown provider", providerID: uuid.New(), reference: "12345678", amount: 100, currency: "USD"},
+		{name: "unsupported currency", providerID: prov.ID, reference: "12345678", amount: 100, currency: "MXN"},
+		{name: "above max amount", providerID: prov.ID, reference: "12345678", amount: 6000, currency: "USD"},
+		{name: "reference format", providerID: prov.ID, reference: "ABC", amount: 100, currency: "USD"},
+	}
+	for _, tc := range cases {
+		_, err := svc.ProcessPayment(context.Background(), &ProcessPaymentRequest{
+			UserID:            userID,
+			ProviderID:        tc.providerID,
+			ExternalReference: tc.reference,
+			Amount:            tc.amount,
+			Currency:          tc.currency,
+		})
+		if domErr, ok := err.(errors.Error); !ok || domErr.Code != errors.CodeValidationError {
+			t.Fatalf("%s: expected validation error, got %v", tc.name, err)
+		}
+	}
+	if len(payRepo.createdTxs) != 0 || gateway.calls != 0 {
+		t.Fatalf("expected rejected payments to create no transactions")
+	}
+
+	resp, err := svc.ProcessPayment(context.Background(), &ProcessPaymentRequest{
+		UserID:            userID,
+		ProviderID:        prov.ID,
+		ExternalReference: "12345678",
+		Amount:            100,
+		Currency:          "USD",
+	})
+	if err != nil || resp.Status != string(transaction.StatusApproved) {
+		t.Fatalf("expected approved payment, got %v %v", resp, err)
+	}
+}
+
+type mockFeeRepo struct {
+	policy *fee.Policy
+}
+
+func (m *mockFeeRepo) GetFeePolicy(ctx context.Context, providerID uuid.UUID, currency string) (*fee.Policy, error) {
+	if m.policy == nil {
+		return nil, errors.NewNotFoundError("fee policy not found")
+	}
+	return m.policy, nil
+}
+
+func TestProcessPayment_ChargesFee(t *testing.T) {
+	userID := uuid.New()
+	w, _ := wallet.NewWallet(userID)
+	_ = w.SetBalance("USD", 1000)
+
+	policy, _ := fee.NewPolicy(uuid.Nil, "USD", 10, 100, 0, 0)
+	payRepo := &mockPaymentRepo{}
+	walletRepo := &mockWalletRepo{wallet: w}
+
+	svc := NewPaymentService(payRepo, walletRepo, &mockGateway{status: "approved"}, &mockIdempotencyRepo{}, &mockOutboxRepo{}, nil, &mockFeeRepo{policy: policy}, nil, nil, nil, fixedIDGen{}, fixedClock{t: time.Now()})
+
+	resp, err := svc.ProcessPayment(context.Background(), &ProcessPaymentRequest{
+		UserID:            userID,
+		ProviderID:        uuid.New(),
+		ExternalReference: "ref-1",
+		Amount:            500,
+		Currency:          "USD",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Fee != 15 || resp.FeeTransactionID == nil {
+		t.Fatalf("expected fee of 15 with fee transaction, got %d %v", resp.Fee, resp.FeeTransactionID)
+	}
+	if got := w.GetBalance("USD"); got != 485 {
+		t.Fatalf("expected principal and fee debited, got balance %d", got)
+	}
+	if len(payRepo.createdTxs) != 2 || payRepo.createdTxs[1].Type != transaction.TypeFee {
+		t.Fatalf("expected payment and fee transactions, got %d", len(payRepo.createdTxs))
+	}
+	feeTx := payRepo.createdTxs[1]
+	if feeTx.RelatedTransactionID == nil || *feeTx.RelatedTransactionID != resp.TransactionID {
+		t.Fatalf("expected fee linked to payment")
+	}
+	if feeTx.Status != transaction.StatusApproved {
+		t.Fatalf("expected fee approved with payment, got %s", feeTx.Status)
+	}
+}
+
+func TestProcessPayment_DeclinedRefundsFee(t *testing.T) {
+	userID := uuid.New()
+	w, _ := wallet.NewWallet(userID)
+	_ = w.SetBalance("USD", 1000)
+
+	policy, _ := fee.NewPolicy(uuid.Nil, "USD", 10, 100, 0, 0)
+	payRepo := &mockPaymentRepo{}
+
+	svc := NewPaymentService(payRepo, &mockWalletRepo{wallet: w}, &mockGateway{status: "declined"}, &mockIdempotencyRepo{}, &mockOutboxRepo{}, nil, &mockFeeRepo{policy: policy}, nil, nil, nil, fixedIDGen{}, fixedClock{t: time.Now()})
+
+	resp, err := svc.ProcessPayment(context.Background(), &ProcessPaymentRequest{
+		UserID:            userID,
+		ProviderID:        uuid.New(),
+		ExternalReference: "ref-1",
+		Amount:            500,
+		Currency:          "USD",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Status != string(transaction.StatusDeclined) {
+		t.Fatalf("expected declined status, got %s", resp.Status)
+	}
+	if got := w.GetBalance("USD"); got != 1000 {
+		t.Fatalf("expected principal and fee refunded, got balance %d", got)
+	}
+	var refunded int64
+	for _, tx := range payRepo.createdTxs {
+		if tx.Type == transaction.TypeRefund {
+			refunded += tx.Amount
+		}
+	}
+	if refunded != 515 {
+		t.Fatalf("expected refunds for principal and fee, got %d", refunded)
+	}
+}
+
+func TestCompletePendingPayment_RefundsOnDecline(t *testing.T) {
+	userID := uuid.New()
+	w, _ := wallet.NewWallet(userID)
+	_ = w.SetBalance("USD", 1000)
+
+	payRepo := &mockPaymentRepo{}
+	outboxRepo := &mockOutboxRepo{}
+	svc := NewPaymentService(payRepo, &mockWalletRepo{wallet: w}, &mockGateway{status: "pending"}, &mockIdempotencyRepo{}, outboxRepo, nil, nil, nil, nil, nil, fixedIDGen{}, fixedClock{t: time.Now()})
+
+	resp, err := svc.ProcessPayment(context.Background(), &ProcessPaymentRequest{
+		UserID:            userID,
+		ProviderID:        uuid.New(),
+		ExternalReference: "ref-1",
+		Amount:            500,
+		Currency:          "USD",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Status != string(transaction.StatusPending) || w.GetBalance("USD") != 500 {
+		t.Fatalf("expected pending payment with funds debited, got %s balance %d", resp.Status, w.GetBalance("USD"))
+	}
+
+	payRepo.stored = payRepo.createdTxs[0]
+	resp, err = svc.CompletePendingPayment(context.Background(), resp.TransactionID, "declined")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Status != string(transaction.StatusDeclined) || w.GetBalance("USD") != 1000 {
+		t.Fatalf("expected declined payment refunded, got %s balance %d", resp.Status, w.GetBalance("USD"))
+	}
+	if last := outboxRepo.events[len(outboxRepo.events)-1]; last.EventType != "payment.failed" {
+		t.Fatalf("expected payment.failed event, got %s", last.EventType)
+	}
+
+	// A redelivered confirmation does not refund twice.
+	if _, err := svc.CompletePendingPayment(context.Background(), resp.TransactionID, "declined"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if w.GetBalance("USD") != 1000 {
+		t.Fatalf("expected single refund, got balance %d", w.GetBalance("USD"))
+	}
+}
+
+func TestCompletePendingPayment_ConcurrentCallbacksRefundOnce(t *testing.T) {
+	userID := uuid.New()
+	w, _ := wallet.NewWallet(userID)
+	_ = w.SetBalance("USD", 1000)
+
+	payRepo := &mockPaymentRepo{}
+	outboxRepo := &mockOutboxRepo{}
+	svc := NewPaymentService(payRepo, &mockWalletRepo{wallet: w}, &mockGateway{status: "pending"}, &mockIdempotencyRepo{}, outboxRepo, nil, nil, nil, nil, nil, fixedIDGen{}, fixedClock{t: time.Now()})
+
+	resp, err := svc.ProcessPayment(context.Background(), &ProcessPaymentRequest{
+		UserID:            userID,
+		ProviderID:        uuid.New(),
+		ExternalReference: "ref-1",
+		Amount:            500,
+		Currency:          "USD",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Dos webhooks leen el pago PENDING antes de que cualquiera lo finalice.
+	pending := *payRepo.createdTxs[0]
+	for i := 0; i < 2; i++ {
+		stale := pending
+		payRepo.stored = &stale
+		if _, err := svc.CompletePendingPayment(context.Background(), resp.TransactionID, "failed"); err != nil {
+			t.Fatalf("callback %d: unexpected error: %v", i, err)
+		}
+	}
+	if w.GetBalance("USD") != 1000 {
+		t.Fatalf("expected a single refund, got balance %d", w.GetBalance("USD"))
+	}
+	failed := 0
+	for _, ev := range outboxRepo.events {
+		if ev.EventType == "payment.failed" {
+			failed++
+		}
+	}
+	if failed != 1 {
+		t.Fatalf("expected one payment.failed event, got %d", failed)
+	}
+}
+
+func TestReviewHeldPayment_RejectRefunds(t *testing.T) {
+	userID := uuid.New()
+	w, _ := wallet.NewWallet(userID)
+	_ = w.SetBalance("USD", 500)
+
+	held, _ := transaction.NewTransaction(userID, transaction.TypePayment, 500, "USD", uuid.New(), "ref-1")
+	_ = held.UpdateStatus(transaction.StatusHeld)
+
+	payRepo := &mockPaymentRepo{stored: held}
+	walletRepo := &mockWalletRepo{wallet: w}
+	gateway := &mockGateway{status: "approved"}
+	outboxRepo := &mockOutboxRepo{}
+
+	svc := NewPaymentService(payRepo, walletRepo, gateway, &mockIdempotencyRepo{}, outboxRepo, nil, nil, nil, nil, nil, fixedIDGen{}, fixedClock{t: time.Now()})
+
+	resp, err := svc.ReviewHeldPayment(context.Background(), held.ID, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Status != string(transaction.StatusDeclined) {
+		t.Fatalf("expected declined status, got %s", resp.Status)
+	}
+	if w.GetBalance("USD") != 1000 {
+		t.Fatalf("expected refund to restore balance, got %d", w.GetBalance("USD"))
+	}
+	if gateway.calls != 0 {
+		t.Fatalf("expected gateway not called")
+	}
+
+	if _, err := svc.ReviewHeldPayment(context.Background(), held.ID, true); err == nil {
+		t.Fatalf("expected error reviewing a non-held transaction")
+	}
+}
@@ -0,0 +1,19 @@
+package ports
+
+import "context"
+
+type correlationIDKey struct{}
+
+// WithCorrelationID returns a context carrying the correlation (request) ID propagated to events.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+// CorrelationID returns the correlation ID stored in ctx, or "" when none was set.
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey{}).(string)
+	return id
+}
@@ -0,0 +1,23 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdempotencyRepository stores the original response of requests sent with an Idempotency-Key.
+type IdempotencyRepository interface {
+	GetIdempotencyRecord(ctx context.Context, userID uuid.UUID, key string) (*IdempotencyRecord, error)
+	CreateIdempotencyRecord(ctx context.Context, record *IdempotencyRecord) error
+}
+
+// IdempotencyRecord represents a stored idempotent response.
+type IdempotencyRecord struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Key       string    `json:"key"`
+	RequestID uuid.UUID `json:"request_id"` // transaction or payment ID
+	Response  string    `json:"response"`   // JSON of the original response
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,10 @@
+package ports
+
+import "context"
+
+// Transactor runs fn in one database transaction. Repository calls made with the ctx passed to fn
+// take part in it: they commit together when fn returns nil and roll back when it returns an error.
+// Nested calls join the outer transaction.
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -0,0 +1,128 @@
+package projections
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"draftea-challenge/internal/application/events"
+	"draftea-challenge/internal/application/ports"
+	"draftea-challenge/internal/domain/errors"
+
+	"github.com/google/uuid"
+)
+
+// DailySpendName es el nombre (y checkpoint) de la proyección de gasto diario.
+const DailySpendName = "daily_spend"
+
+// maxDailySpendRange limita el rango consultable de días.
+const maxDailySpendRange = 366 * 24 * time.Hour
+
+// DailySpendProjection resume por usuario, día UTC y moneda los pagos completados y fallidos. Los
+// refund.created no se cuentan: sólo revierten pagos fallidos, que ya no suman gasto.
+type DailySpendProjection struct {
+	repo  DailySpendRepository
+	clock ports.Clock
+}
+
+// NewDailySpendProjection crea una nueva instancia de DailySpendProjection.
+func NewDailySpendProjection(repo DailySpendRepository, clock ports.Clock) *DailySpendProjection {
+	return &DailySpendProjection{repo: repo, clock: clock}
+}
+
+func (p *DailySpendProjection) Name() string { return DailySpendName }
+
+// Apply agrupa el lote en un delta por usuario, día y moneda y lo persiste con el checkpoint.
+func (p *DailySpendProjection) Apply(ctx context.Context, batch []*events.Envelope, from, to Position) (bool, error) {
+	type key struct {
+		userID   uuid.UUID
+		day      time.Time
+		currency string
+	}
+	deltas := make(map[key]*DailySpend)
+	order := make([]key, 0)
+	for _, env := range batch {
+		if env.Type != events.TypePaymentCompleted && env.Type != events.TypePaymentFailed {
+			continue
+		}
+		if env.Version != 1 {
+			return false, fmt.Errorf("unsupported %s version %d", env.Type, env.Version)
+		}
+		var data events.Payment
+		if err := json.Unmarshal(env.Data, &data); err != nil {
+			return false, fmt.Errorf("decode %s data of %s: %w", env.Type, env.EventID, err)
+		}
+
+		k := key{userID: data.UserID, day: env.OccurredAt.UTC().Truncate(24 * time.Hour), currency: data.Currency}
+		d, ok := deltas[k]
+		if !ok {
+			d = &DailySpend{UserID: k.userID, Day: k.day, Currency: k.currency}
+			deltas[k] = d
+			order = append(order, k)
+		}
+		if env.Type == events.TypePaymentCompleted {
+			d.Payments++
+			d.Amount += data.Amount
+			d.Fee += data.Fee
+		} else {
+			d.FailedPayments++
+		}
+	}
+
+	out := make([]DailySpend, 0, len(order))
+	for _, k := range order {
+		out = append(out, *deltas[k])
+	}
+	return p.repo.ApplyDailySpend(ctx, DailySpendName, out, from, to)
+}
+
+// RewindPosition retorna el inicio del día de since, o la posición cero para reconstruir todo.
+func (p *DailySpendProjection) RewindPosition(since time.Time) Position {
+	var pos Position
+	if !since.IsZero() {
+		pos.CreatedAt = since.UTC().Truncate(24 * time.Hour)
+	}
+	return pos
+}
+
+// Rewind borra los días desde el de since; el día se relee completo para no contar eventos dos veces.
+func (p *DailySpendProjection) Rewind(ctx context.Context, since time.Time) (Position, error) {
+	pos := p.RewindPosition(since)
+	if err := p.repo.RewindDailySpend(ctx, DailySpendName, pos.CreatedAt, pos); err != nil {
+		return Position{}, err
+	}
+	return pos, nil
+}
+
+// ListDailySpendResponse representa el gasto diario de un usuario.
+type ListDailySpendResponse struct {
+	UserID uuid.UUID    `json:"user_id"`
+	From   time.Time    `json:"from"`
+	To     time.Time    `json:"to"`
+	Days   []DailySpend `json:"days"`
+}
+
+// List retorna el gasto diario del usuario para los días en [from, to]. Sin rango usa los últimos 30 días.
+func (p *DailySpendProjection) List(ctx context.Context, userID uuid.UUID, from, to time.Time) (*ListDailySpendResponse, error) {
+	if to.IsZero() {
+		to = p.clock.Now()
+	}
+	to = to.UTC().Truncate(24 * time.Hour)
+	if from.IsZero() {
+		from = to.Add(-29 * 24 * time.Hour)
+	}
+	from = from.UTC().Truncate(24 * time.Hour)
+	if from.After(to) {
+		return nil, errors.NewValidationError("from must not be after to", map[string]interface{}{"from": from, "to": to})
+	}
+	if to.Sub(from) > maxDailySpendRange {
+		return nil, errors.NewValidationError("range too large", map[string]interface{}{"max_days": int(maxDailySpendRange / (24 * time.Hour))})
+	}
+
+	days, err := p.repo.ListDailySpend(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return &ListDailySpendResponse{UserID: userID, From: from, To: to, Days: days}, nil
+}
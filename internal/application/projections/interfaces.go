@@ -0,0 +1,85 @@
+package projections
+
+import (
+	"context"
+	"time"
+
+	"draftea-challenge/internal/application/events"
+	"draftea-challenge/internal/application/outbox"
+
+	"github.com/google/uuid"
+)
+
+// Position ubica un evento en el historial del outbox, ordenado por (created_at, id). La posición
+// cero está antes del primer evento.
+type Position struct {
+	CreatedAt time.Time `json:"created_at"`
+	EventID   string    `json:"event_id"`
+}
+
+// IsZero indica si la posición está al inicio del historial.
+func (p Position) IsZero() bool { return p.CreatedAt.IsZero() && p.EventID == "" }
+
+// Before indica si p está antes que o en el historial.
+func (p Position) Before(o Position) bool {
+	if !p.CreatedAt.Equal(o.CreatedAt) {
+		return p.CreatedAt.Before(o.CreatedAt)
+	}
+	return p.EventID < o.EventID
+}
+
+// PositionOf retorna la posición del evento.
+func PositionOf(ev *outbox.OutboxEvent) Position {
+	return Position{CreatedAt: ev.CreatedAt, EventID: ev.ID.String()}
+}
+
+// EventHistory lee el historial de eventos, incluidos los archivados en outbox_archive.
+type EventHistory interface {
+	// ListEventsAfter lista hasta limit eventos posteriores a after y creados antes de until, en
+	// orden de (created_at, id).
+	ListEventsAfter(ctx context.Context, after Position, until time.Time, limit int) ([]*outbox.OutboxEvent, error)
+	// HistoryHorizon retorna la posición del evento más nuevo purgado por la retención del archivo
+	// (cero si nunca se purgó). Desde una posición anterior el historial ya no está completo.
+	HistoryHorizon(ctx context.Context) (Position, error)
+}
+
+// CheckpointRepository lee el checkpoint de cada proyección. Cada proyección lo avanza en la misma
+// transacción en que escribe su estado.
+type CheckpointRepository interface {
+	// GetProjectionCheckpoint retorna la posición cero si la proyección nunca corrió.
+	GetProjectionCheckpoint(ctx context.Context, name string) (Position, error)
+}
+
+// Projection es un read model construido a partir del historial de eventos.
+type Projection interface {
+	Name() string
+	// Apply aplica un lote de eventos en orden y mueve el checkpoint de from a to en la misma
+	// transacción. Retorna false sin aplicar nada si el checkpoint ya no está en from (otra réplica
+	// avanzó o se rebobinó la proyección).
+	Apply(ctx context.Context, batch []*events.Envelope, from, to Position) (bool, error)
+	// RewindPosition retorna la posición desde la que Rewind(since) relee el historial.
+	RewindPosition(since time.Time) Position
+	// Rewind borra el estado derivado de los eventos desde since y retorna la posición desde la que
+	// hay que releer; since cero reconstruye la proyección completa.
+	Rewind(ctx context.Context, since time.Time) (Position, error)
+}
+
+// DailySpend es el gasto de un usuario en un día UTC y una moneda.
+type DailySpend struct {
+	UserID         uuid.UUID `json:"user_id"`
+	Day            time.Time `json:"day"`
+	Currency       string    `json:"currency"`
+	Payments       int64     `json:"payments"`        // pagos completados
+	Amount         int64     `json:"amount"`          // principal de los pagos completados, minor units
+	Fee            int64     `json:"fee"`             // comisiones de los pagos completados, minor units
+	FailedPayments int64     `json:"failed_payments"` // pagos fallidos (reembolsados)
+}
+
+// DailySpendRepository persiste la proyección de gasto diario.
+type DailySpendRepository interface {
+	// ApplyDailySpend suma los deltas y mueve el checkpoint name de from a to, de forma atómica.
+	ApplyDailySpend(ctx context.Context, name string, deltas []DailySpend, from, to Position) (bool, error)
+	// RewindDailySpend borra los días desde since y deja el checkpoint name en to.
+	RewindDailySpend(ctx context.Context, name string, since time.Time, to Position) error
+	ListDailySpend(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]DailySpend, error)
+}
@@ -0,0 +1,189 @@
+package projections
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"draftea-challenge/internal/application/events"
+	"draftea-challenge/internal/application/ports"
+	"draftea-challenge/internal/domain/errors"
+)
+
+// Config configura el Runner.
+type Config struct {
+	BatchSize int
+	// Lag deja fuera los eventos más nuevos que esto: una transacción que confirma tarde puede
+	// insertar un evento con created_at anterior a otros ya leídos, y el cursor lo saltearía.
+	Lag time.Duration
+}
+
+// Result resume una pasada del Runner sobre una proyección.
+type Result struct {
+	Projection string
+	Applied    int
+	Skipped    int // eventos sin envelope válido
+	Position   Position
+}
+
+// Runner alimenta las proyecciones con el historial del outbox desde su checkpoint.
+type Runner struct {
+	history     EventHistory
+	checkpoints CheckpointRepository
+	clock       ports.Clock
+	projections []Projection
+	batchSize   int
+	lag         time.Duration
+}
+
+// NewRunner crea una nueva instancia de Runner.
+func NewRunner(history EventHistory, checkpoints CheckpointRepository, clock ports.Clock, cfg Config, projections ...Projection) *Runner {
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	lag := cfg.Lag
+	if lag < 0 {
+		lag = 0
+	}
+	return &Runner{
+		history:     history,
+		checkpoints: checkpoints,
+		clock:       clock,
+		projections: projections,
+		batchSize:   batchSize,
+		lag:         lag,
+	}
+}
+
+// RunOnce pone al día cada proyección con los eventos nuevos.
+func (r *Runner) RunOnce(ctx context.Context) ([]Result, error) {
+	results := make([]Result, 0, len(r.projections))
+	for _, p := range r.projections {
+		res, err := r.catchUp(ctx, p)
+		results = append(results, res)
+		if err != nil {
+			return results, fmt.Errorf("projection %s: %w", p.Name(), err)
+		}
+	}
+	return results, nil
+}
+
+// Run ejecuta RunOnce cada interval hasta que ctx se cancela; report recibe cada pasada.
+func (r *Runner) Run(ctx context.Context, interval time.Duration, report func([]Result, error)) {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		results, err := r.RunOnce(ctx)
+		if report != nil && ctx.Err() == nil {
+			report(results, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Replay rebobina la proyección name a since (cero para reconstruirla completa) y la pone al día.
+// Si la retención ya purgó eventos posteriores a esa posición falla sin tocar la proyección: el
+// resultado tendría totales incompletos.
+func (r *Runner) Replay(ctx context.Context, name string, since time.Time) (Result, error) {
+	for _, p := range r.projections {
+		if p.Name() != name {
+			continue
+		}
+		if err := r.checkHorizon(ctx, p.RewindPosition(since)); err != nil {
+			return Result{Projection: name}, err
+		}
+		if _, err := p.Rewind(ctx, since); err != nil {
+			return Result{Projection: name}, err
+		}
+		return r.catchUp(ctx, p)
+	}
+	return Result{Projection: name}, errors.NewNotFoundError("projection not found")
+}
+
+// Names lista las proyecciones registradas.
+func (r *Runner) Names() []string {
+	names := make([]string, 0, len(r.projections))
+	for _, p := range r.projections {
+		names = append(names, p.Name())
+	}
+	return names
+}
+
+// checkHorizon falla si el historial posterior a from ya no está completo.
+func (r *Runner) checkHorizon(ctx context.Context, from Position) error {
+	horizon, err := r.history.HistoryHorizon(ctx)
+	if err != nil {
+		return err
+	}
+	if !horizon.IsZero() && from.Before(horizon) {
+		return errors.NewValidationError("projection history was purged by the outbox archive retention", map[string]interface{}{
+			"position": from.CreatedAt,
+			"horizon":  horizon.CreatedAt,
+		})
+	}
+	return nil
+}
+
+func (r *Runner) catchUp(ctx context.Context, p Projection) (Result, error) {
+	res := Result{Projection: p.Name()}
+	from, err := r.checkpoints.GetProjectionCheckpoint(ctx, p.Name())
+	if err != nil {
+		return res, err
+	}
+	res.Position = from
+	// Un checkpoint anterior al horizonte (proyección nueva o detenida más que la retención del
+	// archivo) se saltearía eventos purgados.
+	if err := r.checkHorizon(ctx, from); err != nil {
+		return res, err
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		from, err := r.checkpoints.GetProjectionCheckpoint(ctx, p.Name())
+		if err != nil {
+			return res, err
+		}
+		res.Position = from
+		batch, err := r.history.ListEventsAfter(ctx, from, r.clock.Now().Add(-r.lag), r.batchSize)
+		if err != nil {
+			return res, err
+		}
+		if len(batch) == 0 {
+			return res, nil
+		}
+
+		envs := make([]*events.Envelope, 0, len(batch))
+		skipped := 0
+		for _, ev := range batch {
+			env, err := events.Decode([]byte(ev.Payload))
+			if err != nil {
+				skipped++
+				continue
+			}
+			envs = append(envs, env)
+		}
+		to := PositionOf(batch[len(batch)-1])
+		applied, err := p.Apply(ctx, envs, from, to)
+		if err != nil {
+			return res, err
+		}
+		// Si otra réplica movió el checkpoint se relee desde el nuevo valor.
+		if applied {
+			res.Applied += len(envs)
+			res.Skipped += skipped
+			res.Position = to
+		}
+		if len(batch) < r.batchSize {
+			return res, nil
+		}
+	}
+}
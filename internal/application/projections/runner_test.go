@@ -0,0 +1,185 @@
+package projections
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"draftea-challenge/internal/application/events"
+	"draftea-challenge/internal/application/outbox"
+
+	"github.com/google/uuid"
+)
+
+type fakeHistory struct {
+	events  []*outbox.OutboxEvent
+	horizon Position
+}
+
+func (f *fakeHistory) HistoryHorizon(ctx context.Context) (Position, error) {
+	return f.horizon, nil
+}
+
+func (f *fakeHistory) ListEventsAfter(ctx context.Context, after Position, until time.Time, limit int) ([]*outbox.OutboxEvent, error) {
+	var out []*outbox.OutboxEvent
+	for _, ev := range f.events {
+		pos := PositionOf(ev)
+		newer := pos.CreatedAt.After(after.CreatedAt) || (pos.CreatedAt.Equal(after.CreatedAt) && pos.EventID > after.EventID)
+		if newer && ev.CreatedAt.Before(until) && len(out) < limit {
+			out = append(out, ev)
+		}
+	}
+	return out, nil
+}
+
+// fakeProjection keeps its checkpoint like the Postgres repositories do: Apply only succeeds from
+// the stored position.
+type fakeProjection struct {
+	checkpoint Position
+	applied    []string
+	// steal moves the checkpoint before the next Apply, as a concurrent replica would.
+	steal *Position
+}
+
+func (f *fakeProjection) Name() string { return "fake" }
+
+func (f *fakeProjection) GetProjectionCheckpoint(ctx context.Context, name string) (Position, error) {
+	return f.checkpoint, nil
+}
+
+func (f *fakeProjection) Apply(ctx context.Context, batch []*events.Envelope, from, to Position) (bool, error) {
+	if f.steal != nil {
+		f.checkpoint, f.steal = *f.steal, nil
+	}
+	if f.checkpoint != from {
+		return false, nil
+	}
+	for _, env := range batch {
+		f.applied = append(f.applied, env.Type)
+	}
+	f.checkpoint = to
+	return true, nil
+}
+
+func (f *fakeProjection) RewindPosition(since time.Time) Position {
+	return Position{CreatedAt: since}
+}
+
+func (f *fakeProjection) Rewind(ctx context.Context, since time.Time) (Position, error) {
+	f.applied = nil
+	f.checkpoint = f.RewindPosition(since)
+	return f.checkpoint, nil
+}
+
+type fixedClock struct{ now time.Time }
+
+func (c fixedClock) Now() time.Time { return c.now }
+
+func historyEvent(t *testing.T, at time.Time, ev events.Event) *outbox.OutboxEvent {
+	t.Helper()
+	event, err := events.NewOutboxEvent(context.Background(), uuid.New(), at, ev)
+	if err != nil {
+		t.Fatalf("outbox event: %v", err)
+	}
+	return event
+}
+
+func TestRunnerAppliesHistoryInBatchesUpToLag(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	history := &fakeHistory{}
+	for i := 0; i < 5; i++ {
+		history.events = append(history.events, historyEvent(t, now.Add(time.Duration(i-10)*time.Minute), events.PaymentCompleted{}))
+	}
+	legacy, _ := json.Marshal(map[string]string{"amount": "10"})
+	history.events = append(history.events,
+		&outbox.OutboxEvent{ID: uuid.New(), EventType: "payment.completed", Payload: string(legacy), CreatedAt: now.Add(-2 * time.Minute)},
+		historyEvent(t, now.Add(-time.Second), events.PaymentFailed{}), // inside the lag
+	)
+
+	projection := &fakeProjection{}
+	runner := NewRunner(history, projection, fixedClock{now: now}, Config{BatchSize: 2, Lag: 5 * time.Second}, projection)
+	results, err := runner.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if results[0].Applied != 5 || results[0].Skipped != 1 || len(projection.applied) != 5 {
+		t.Fatalf("unexpected result %+v, applied %v", results[0], projection.applied)
+	}
+	if projection.checkpoint != PositionOf(history.events[5]) {
+		t.Fatalf("checkpoint should stop at the last event before the lag, got %+v", projection.checkpoint)
+	}
+
+	// Once the lag passes the remaining event is picked up.
+	runner.clock = fixedClock{now: now.Add(time.Minute)}
+	if results, err = runner.RunOnce(context.Background()); err != nil || results[0].Applied != 1 {
+		t.Fatalf("second run: %+v %v", results, err)
+	}
+}
+
+func TestRunnerRereadsWhenCheckpointMoved(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	history := &fakeHistory{}
+	for i := 0; i < 4; i++ {
+		history.events = append(history.events, historyEvent(t, now.Add(time.Duration(i-10)*time.Minute), events.PaymentCompleted{}))
+	}
+
+	// Another replica applied the first two events between our read and our apply.
+	stolen := PositionOf(history.events[1])
+	projection := &fakeProjection{steal: &stolen}
+	runner := NewRunner(history, projection, fixedClock{now: now}, Config{BatchSize: 2}, projection)
+	results, err := runner.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if results[0].Applied != 2 || len(projection.applied) != 2 || projection.checkpoint != PositionOf(history.events[3]) {
+		t.Fatalf("unexpected result %+v, checkpoint %+v", results[0], projection.checkpoint)
+	}
+
+	if _, err := runner.Replay(context.Background(), "missing", time.Time{}); err == nil {
+		t.Fatalf("expected error for unknown projection")
+	}
+	if res, err := runner.Replay(context.Background(), "fake", time.Time{}); err != nil || res.Applied != 4 {
+		t.Fatalf("replay: %+v %v", res, err)
+	}
+}
+
+func TestRunnerRefusesHistoryOlderThanThePurgeHorizon(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	history := &fakeHistory{}
+	for i := 0; i < 3; i++ {
+		history.events = append(history.events, historyEvent(t, now.Add(time.Duration(i-10)*time.Minute), events.PaymentCompleted{}))
+	}
+	projection := &fakeProjection{}
+	runner := NewRunner(history, projection, fixedClock{now: now}, Config{}, projection)
+	if _, err := runner.RunOnce(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	// The retention purged the first event: the checkpoint is past it, so catching up still works.
+	history.horizon = PositionOf(history.events[0])
+	history.events = history.events[1:]
+	if _, err := runner.RunOnce(context.Background()); err != nil {
+		t.Fatalf("run after purge: %v", err)
+	}
+
+	// A full rebuild or a replay from before the horizon would miss the purged event.
+	for _, since := range []time.Time{{}, now.Add(-time.Hour)} {
+		if _, err := runner.Replay(context.Background(), "fake", since); err == nil {
+			t.Fatalf("expected replay from %v to be refused", since)
+		}
+		if len(projection.applied) != 3 {
+			t.Fatalf("refused replay must leave the projection untouched, got %v", projection.applied)
+		}
+	}
+	res, err := runner.Replay(context.Background(), "fake", now.Add(-5*time.Minute))
+	if err != nil || res.Applied != 0 {
+		t.Fatalf("replay after the horizon: %+v %v", res, err)
+	}
+
+	// A projection added after the purge starts from the zero position and is refused too.
+	fresh := &fakeProjection{}
+	if _, err := NewRunner(history, fresh, fixedClock{now: now}, Config{}, fresh).RunOnce(context.Background()); err == nil {
+		t.Fatalf("expected a new projection to be refused after a purge")
+	}
+}
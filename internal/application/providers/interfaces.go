@@ -0,0 +1,17 @@
+package providers
+
+import (
+	"context"
+	"draftea-challenge/internal/domain/provider"
+
+	"github.com/google/uuid"
+)
+
+// ProviderRepository define la interfaz para administrar el catálogo de providers.
+type ProviderRepository interface {
+	CreateProvider(ctx context.Context, p *provider.Provider) error
+	GetProvider(ctx context.Context, providerID uuid.UUID) (*provider.Provider, error)
+	ListProviders(ctx context.Context, limit, offset int) ([]*provider.Provider, int, error)
+	UpdateProvider(ctx context.Context, p *provider.Provider) error
+	DeleteProvider(ctx context.Context, providerID uuid.UUID) error
+}
@@ -0,0 +1,97 @@
+package providers
+
+import (
+	"context"
+	"draftea-challenge/internal/application/ports"
+	"draftea-challenge/internal/domain/provider"
+
+	"github.com/google/uuid"
+)
+
+// ProviderService gestiona el catálogo de providers (endpoints de administración).
+type ProviderService struct {
+	repo  ProviderRepository
+	clock ports.Clock
+}
+
+// NewProviderService crea una nueva instancia de ProviderService.
+func NewProviderService(repo ProviderRepository, clock ports.Clock) *ProviderService {
+	return &ProviderService{repo: repo, clock: clock}
+}
+
+// ProviderRequest representa el alta o modificación de un provider.
+type ProviderRequest struct {
+	Name             string   `json:"name"`
+	Currencies       []string `json:"currencies"`
+	MinAmount        int64    `json:"min_amount"`
+	MaxAmount        int64    `json:"max_amount"`
+	ReferencePattern string   `json:"reference_pattern"`
+	Enabled          *bool    `json:"enabled"` // por defecto true al crear; sin cambios al editar
+}
+
+// ListProvidersResponse representa la respuesta paginada.
+type ListProvidersResponse struct {
+	Providers []*provider.Provider `json:"providers"`
+	Total     int                  `json:"total"`
+}
+
+// CreateProvider da de alta un provider.
+func (s *ProviderService) CreateProvider(ctx context.Context, req *ProviderRequest) (*provider.Provider, error) {
+	p, err := provider.NewProvider(req.attributes(true))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateProvider(ctx, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetProvider obtiene un provider.
+func (s *ProviderService) GetProvider(ctx context.Context, providerID uuid.UUID) (*provider.Provider, error) {
+	return s.repo.GetProvider(ctx, providerID)
+}
+
+// ListProviders lista el catálogo ordenado por nombre.
+func (s *ProviderService) ListProviders(ctx context.Context, limit, offset int) (*ListProvidersResponse, error) {
+	list, total, err := s.repo.ListProviders(ctx, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return &ListProvidersResponse{Providers: list, Total: total}, nil
+}
+
+// UpdateProvider reemplaza los atributos de un provider.
+func (s *ProviderService) UpdateProvider(ctx context.Context, providerID uuid.UUID, req *ProviderRequest) (*provider.Provider, error) {
+	p, err := s.repo.GetProvider(ctx, providerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.Update(req.attributes(p.Enabled), s.clock.Now().UTC()); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateProvider(ctx, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// DeleteProvider elimina un provider del catálogo; los pagos nuevos hacia él serán rechazados.
+func (s *ProviderService) DeleteProvider(ctx context.Context, providerID uuid.UUID) error {
+	return s.repo.DeleteProvider(ctx, providerID)
+}
+
+func (r *ProviderRequest) attributes(enabledDefault bool) provider.Attributes {
+	enabled := enabledDefault
+	if r.Enabled != nil {
+		enabled = *r.Enabled
+	}
+	return provider.Attributes{
+		Name:             r.Name,
+		Currencies:       r.Currencies,
+		MinAmount:        r.MinAmount,
+		MaxAmount:        r.MaxAmount,
+		ReferencePattern: r.ReferencePattern,
+		Enabled:          enabled,
+	}
+}
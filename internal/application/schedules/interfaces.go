@@ -0,0 +1,30 @@
+package schedules
+
+import (
+	"context"
+	"draftea-challenge/internal/application/payments"
+	"draftea-challenge/internal/domain/schedule"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ScheduleRepository define la interfaz para persistir pagos programados.
+type ScheduleRepository interface {
+	CreateSchedule(ctx context.Context, s *schedule.Schedule) error
+	GetSchedule(ctx context.Context, userID, scheduleID uuid.UUID) (*schedule.Schedule, error)
+	ListSchedules(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*schedule.Schedule, int, error)
+	// UpdateSchedule persiste los cambios hechos desde la API; no toca el lease de ejecución.
+	UpdateSchedule(ctx context.Context, s *schedule.Schedule) error
+	// AdvanceSchedule registra la ejecución de occurrence y libera el lease. Relee el schedule bajo
+	// lock y lo avanza con la regla y end_date guardados, así una edición concurrente no se pierde;
+	// si ya no está ACTIVE solo libera el lease, para no revertir una cancelación o pausa.
+	AdvanceSchedule(ctx context.Context, scheduleID uuid.UUID, occurrence, now time.Time, lastStatus string) error
+	// ClaimDueSchedules reclama schedules vencidos (FOR UPDATE SKIP LOCKED) y los bloquea por lease.
+	ClaimDueSchedules(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*schedule.Schedule, error)
+}
+
+// PaymentProcessor define el flujo de pago usado en cada ocurrencia.
+type PaymentProcessor interface {
+	ProcessPayment(ctx context.Context, req *payments.ProcessPaymentRequest) (*payments.ProcessPaymentResponse, error)
+}
@@ -0,0 +1,151 @@
+package schedules
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"draftea-challenge/internal/application/events"
+	"draftea-challenge/internal/application/outbox"
+	"draftea-challenge/internal/application/payments"
+	"draftea-challenge/internal/application/ports"
+	"draftea-challenge/internal/domain/errors"
+	"draftea-challenge/internal/domain/schedule"
+	"draftea-challenge/internal/domain/transaction"
+)
+
+// Runner executes due schedules through the payment flow.
+type Runner struct {
+	repo       ScheduleRepository
+	payments   PaymentProcessor
+	outboxRepo outbox.OutboxRepository
+	idGen      ports.IDGenerator
+	clock      ports.Clock
+	batchSize  int
+	lease      time.Duration
+}
+
+// RunnerConfig configures runner behavior.
+type RunnerConfig struct {
+	BatchSize int
+	Lease     time.Duration
+}
+
+// RunResult summarizes a single RunOnce pass.
+type RunResult struct {
+	Claimed   int
+	Succeeded int
+	Failed    int
+}
+
+// NewRunner creates a new schedule runner.
+func NewRunner(repo ScheduleRepository, processor PaymentProcessor, outboxRepo outbox.OutboxRepository, idGen ports.IDGenerator, clock ports.Clock, cfg RunnerConfig) *Runner {
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = 50
+	}
+	lease := cfg.Lease
+	if lease <= 0 {
+		lease = time.Minute
+	}
+	return &Runner{
+		repo:       repo,
+		payments:   processor,
+		outboxRepo: outboxRepo,
+		idGen:      idGen,
+		clock:      clock,
+		batchSize:  batchSize,
+		lease:      lease,
+	}
+}
+
+// RunOnce claims a batch of due schedules and executes one occurrence of each.
+// Each occurrence uses a deterministic idempotency key, so re-running an
+// occurrence after a crash replays the original payment result.
+func (r *Runner) RunOnce(ctx context.Context) (RunResult, error) {
+	claimed, err := r.repo.ClaimDueSchedules(ctx, r.clock.Now(), r.batchSize, r.lease)
+	if err != nil {
+		return RunResult{}, err
+	}
+	result := RunResult{Claimed: len(claimed)}
+
+	var firstErr error
+	for _, sched := range claimed {
+		ok, err := r.runOccurrence(ctx, sched)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if ok {
+			result.Succeeded++
+		} else {
+			result.Failed++
+		}
+	}
+	return result, firstErr
+}
+
+func (r *Runner) runOccurrence(ctx context.Context, sched *schedule.Schedule) (bool, error) {
+	occurrence := sched.NextRunAt
+	resp, payErr := r.payments.ProcessPayment(ctx, &payments.ProcessPaymentRequest{
+		UserID:            sched.UserID,
+		ProviderID:        sched.ProviderID,
+		ExternalReference: sched.ExternalReference,
+		Amount:            sched.Amount,
+		Currency:          sched.Currency,
+		IdempotencyKey:    sched.OccurrenceKey(occurrence),
+	})
+
+	var lastStatus, failureCode string
+	switch {
+	case payErr != nil && !isBusinessError(payErr):
+		// Not an outcome of the occurrence: leave it due, so it is claimed again once the lease
+		// expires and retried with the same idempotency key.
+		return false, fmt.Errorf("run schedule %s occurrence %s: %w", sched.ID, occurrence.UTC().Format(time.RFC3339), payErr)
+	case payErr != nil:
+		lastStatus = string(transaction.StatusFailed)
+		failureCode = payErr.(errors.Error).Code
+	case resp.Status == string(transaction.StatusDeclined) || resp.Status == string(transaction.StatusFailed):
+		lastStatus = resp.Status
+		failureCode = resp.Status
+	default:
+		lastStatus = resp.Status
+	}
+
+	if failureCode != "" {
+		event, err := events.NewOutboxEvent(ctx, r.idGen.New(), r.clock.Now(), events.ScheduleFailed{
+			ScheduleID: sched.ID,
+			UserID:     sched.UserID,
+			Occurrence: occurrence.UTC(),
+			Code:       failureCode,
+		})
+		if err == nil {
+			err = r.outboxRepo.CreateEvent(ctx, event)
+		}
+		if err != nil {
+			return false, fmt.Errorf("create schedule.failed event for %s: %w", sched.ID, err)
+		}
+	}
+
+	if err := r.repo.AdvanceSchedule(ctx, sched.ID, occurrence, r.clock.Now(), lastStatus); err != nil {
+		return false, fmt.Errorf("advance schedule %s: %w", sched.ID, err)
+	}
+	return failureCode == "", nil
+}
+
+// isBusinessError reports whether the payment was rejected by a business rule (funds, limits,
+// risk, validation). Infrastructure errors and gateway timeouts are not: the occurrence did not
+// get an outcome and is retried.
+func isBusinessError(err error) bool {
+	domErr, ok := err.(errors.Error)
+	if !ok {
+		return false
+	}
+	switch domErr.Code {
+	case errors.CodeInternal, errors.CodeGatewayTimeout, errors.CodeGatewayError:
+		return false
+	}
+	return true
+}
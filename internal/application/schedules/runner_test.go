@@ -0,0 +1,181 @@
+package schedules
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"draftea-challenge/internal/application/outbox"
+	"draftea-challenge/internal/application/payments"
+	"draftea-challenge/internal/domain/errors"
+	"draftea-challenge/internal/domain/schedule"
+
+	"github.com/google/uuid"
+)
+
+type mockScheduleRepo struct {
+	due      []*schedule.Schedule
+	advanced []*schedule.Schedule
+}
+
+func (m *mockScheduleRepo) CreateSchedule(ctx context.Context, s *schedule.Schedule) error {
+	return nil
+}
+
+func (m *mockScheduleRepo) GetSchedule(ctx context.Context, userID, scheduleID uuid.UUID) (*schedule.Schedule, error) {
+	return nil, errors.NewNotFoundError("schedule not found")
+}
+
+func (m *mockScheduleRepo) ListSchedules(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*schedule.Schedule, int, error) {
+	return nil, 0, nil
+}
+
+func (m *mockScheduleRepo) UpdateSchedule(ctx context.Context, s *schedule.Schedule) error {
+	return nil
+}
+
+func (m *mockScheduleRepo) AdvanceSchedule(ctx context.Context, scheduleID uuid.UUID, occurrence, now time.Time, lastStatus string) error {
+	for _, s := range m.due {
+		if s.ID == scheduleID {
+			s.Advance(occurrence, now, lastStatus)
+			m.advanced = append(m.advanced, s)
+			return nil
+		}
+	}
+	return errors.NewNotFoundError("schedule not found")
+}
+
+func (m *mockScheduleRepo) ClaimDueSchedules(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*schedule.Schedule, error) {
+	return m.due, nil
+}
+
+type mockProcessor struct {
+	keys []string
+	resp *payments.ProcessPaymentResponse
+	err  error
+}
+
+func (m *mockProcessor) ProcessPayment(ctx context.Context, req *payments.ProcessPaymentRequest) (*payments.ProcessPaymentResponse, error) {
+	m.keys = append(m.keys, req.IdempotencyKey)
+	return m.resp, m.err
+}
+
+type mockOutboxRepo struct {
+	events []*outbox.OutboxEvent
+}
+
+func (m *mockOutboxRepo) CreateEvent(ctx context.Context, event *outbox.OutboxEvent) error {
+	m.events = append(m.events, event)
+	return nil
+}
+
+func (m *mockOutboxRepo) GetPendingEvents(ctx context.Context, limit int) ([]*outbox.OutboxEvent, error) {
+	return nil, nil
+}
+
+func (m *mockOutboxRepo) ClaimPendingEvents(ctx context.Context, relayID string, now time.Time, limit int, lease time.Duration) ([]*outbox.OutboxEvent, error) {
+	return nil, nil
+}
+
+func (m *mockOutboxRepo) ReleaseEvents(ctx context.Context, relayID string, eventIDs []uuid.UUID) error {
+	return nil
+}
+
+func (m *mockOutboxRepo) RecordEventFailure(ctx context.Context, event *outbox.OutboxEvent) error {
+	return nil
+}
+
+func (m *mockOutboxRepo) MarkEventAsSent(ctx context.Context, eventID uuid.UUID) error {
+	return nil
+}
+
+type fixedIDGen struct{}
+
+func (fixedIDGen) New() uuid.UUID { return uuid.New() }
+
+type fixedClock struct {
+	t time.Time
+}
+
+func (f fixedClock) Now() time.Time { return f.t }
+
+func newDueSchedule(t *testing.T, start time.Time) *schedule.Schedule {
+	t.Helper()
+	s, err := schedule.NewSchedule(uuid.New(), uuid.New(), "bill-1", 1000, "USD", schedule.Rule{Interval: 24 * time.Hour}, start, nil)
+	if err != nil {
+		t.Fatalf("new schedule: %v", err)
+	}
+	return s
+}
+
+func TestRunOnce_ApprovedAdvancesSchedule(t *testing.T) {
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	sched := newDueSchedule(t, start)
+	repo := &mockScheduleRepo{due: []*schedule.Schedule{sched}}
+	processor := &mockProcessor{resp: &payments.ProcessPaymentResponse{TransactionID: uuid.New(), Status: "APPROVED"}}
+	outboxRepo := &mockOutboxRepo{}
+
+	runner := NewRunner(repo, processor, outboxRepo, fixedIDGen{}, fixedClock{t: start.Add(time.Minute)}, RunnerConfig{})
+	result, err := runner.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Succeeded != 1 || result.Failed != 0 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if processor.keys[0] != sched.OccurrenceKey(start) {
+		t.Fatalf("expected deterministic idempotency key, got %s", processor.keys[0])
+	}
+	if want := start.Add(24 * time.Hour); !repo.advanced[0].NextRunAt.Equal(want) {
+		t.Fatalf("expected next run %s, got %s", want, repo.advanced[0].NextRunAt)
+	}
+	if len(outboxRepo.events) != 0 {
+		t.Fatalf("expected no schedule events, got %d", len(outboxRepo.events))
+	}
+}
+
+func TestRunOnce_FailureEmitsScheduleEvent(t *testing.T) {
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	sched := newDueSchedule(t, start)
+	repo := &mockScheduleRepo{due: []*schedule.Schedule{sched}}
+	processor := &mockProcessor{err: errors.NewInsufficientFundsError("insufficient funds", nil)}
+	outboxRepo := &mockOutboxRepo{}
+
+	runner := NewRunner(repo, processor, outboxRepo, fixedIDGen{}, fixedClock{t: start}, RunnerConfig{})
+	result, err := runner.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Failed != 1 {
+		t.Fatalf("expected failed occurrence, got %+v", result)
+	}
+	if len(outboxRepo.events) != 1 || outboxRepo.events[0].EventType != "schedule.failed" {
+		t.Fatalf("expected schedule.failed event, got %v", outboxRepo.events)
+	}
+	if !strings.Contains(outboxRepo.events[0].Payload, errors.CodeInsufficientFunds) {
+		t.Fatalf("expected failure code in payload, got %s", outboxRepo.events[0].Payload)
+	}
+	if repo.advanced[0].LastStatus != "FAILED" {
+		t.Fatalf("expected FAILED last status, got %s", repo.advanced[0].LastStatus)
+	}
+}
+
+func TestRunOnce_TransientErrorKeepsScheduleDue(t *testing.T) {
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	sched := newDueSchedule(t, start)
+	repo := &mockScheduleRepo{due: []*schedule.Schedule{sched}}
+	processor := &mockProcessor{err: errors.NewInternalError("database unavailable")}
+	outboxRepo := &mockOutboxRepo{}
+
+	runner := NewRunner(repo, processor, outboxRepo, fixedIDGen{}, fixedClock{t: start}, RunnerConfig{})
+	if _, err := runner.RunOnce(context.Background()); err == nil {
+		t.Fatalf("expected the transient error to be returned")
+	}
+	if len(repo.advanced) != 0 || !sched.NextRunAt.Equal(start) {
+		t.Fatalf("expected the occurrence to stay due, got next run %s", sched.NextRunAt)
+	}
+	if len(outboxRepo.events) != 0 {
+		t.Fatalf("expected no schedule.failed event, got %v", outboxRepo.events)
+	}
+}
@@ -0,0 +1,198 @@
+package schedules
+
+import (
+	"context"
+	"draftea-challenge/internal/application/ports"
+	"draftea-challenge/internal/application/wallets"
+	"draftea-challenge/internal/domain/errors"
+	"draftea-challenge/internal/domain/schedule"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ScheduleService gestiona el CRUD de pagos programados.
+type ScheduleService struct {
+	repo       ScheduleRepository
+	walletRepo wallets.WalletRepository
+	clock      ports.Clock
+}
+
+// NewScheduleService crea una nueva instancia de ScheduleService.
+func NewScheduleService(repo ScheduleRepository, walletRepo wallets.WalletRepository, clock ports.Clock) *ScheduleService {
+	return &ScheduleService{repo: repo, walletRepo: walletRepo, clock: clock}
+}
+
+// CreateScheduleRequest representa la solicitud de alta de un schedule.
+type CreateScheduleRequest struct {
+	UserID            uuid.UUID     `json:"user_id"`
+	ProviderID        uuid.UUID     `json:"provider_id"`
+	ExternalReference string        `json:"external_reference"`
+	Amount            int64         `json:"amount"`
+	Currency          string        `json:"currency"`
+	CronExpr          string        `json:"cron"`
+	Interval          time.Duration `json:"interval"`
+	StartAt           *time.Time    `json:"start_at"`
+	EndDate           *time.Time    `json:"end_date"`
+}
+
+// UpdateScheduleRequest representa la modificación de un schedule; los campos vacíos no cambian.
+type UpdateScheduleRequest struct {
+	UserID            uuid.UUID       `json:"user_id"`
+	ScheduleID        uuid.UUID       `json:"schedule_id"`
+	ExternalReference string          `json:"external_reference"`
+	Amount            int64           `json:"amount"`
+	CronExpr          string          `json:"cron"`
+	Interval          time.Duration   `json:"interval"`
+	EndDate           *time.Time      `json:"end_date"`
+	Status            schedule.Status `json:"status"` // ACTIVE o PAUSED
+}
+
+// ScheduleResponse representa un schedule en la API.
+type ScheduleResponse struct {
+	ID                uuid.UUID       `json:"id"`
+	UserID            uuid.UUID       `json:"user_id"`
+	ProviderID        uuid.UUID       `json:"provider_id"`
+	ExternalReference string          `json:"external_reference"`
+	Amount            int64           `json:"amount"`
+	Currency          string          `json:"currency"`
+	CronExpr          string          `json:"cron,omitempty"`
+	Interval          string          `json:"interval,omitempty"`
+	EndDate           *time.Time      `json:"end_date,omitempty"`
+	NextRunAt         time.Time       `json:"next_run_at"`
+	LastRunAt         *time.Time      `json:"last_run_at,omitempty"`
+	LastStatus        string          `json:"last_status,omitempty"`
+	Status            schedule.Status `json:"status"`
+	CreatedAt         time.Time       `json:"created_at"`
+	UpdatedAt         time.Time       `json:"updated_at"`
+}
+
+// ListSchedulesResponse representa la respuesta paginada.
+type ListSchedulesResponse struct {
+	Schedules []*ScheduleResponse `json:"schedules"`
+	Total     int                 `json:"total"`
+}
+
+// CreateSchedule da de alta un pago programado para una wallet existente.
+func (s *ScheduleService) CreateSchedule(ctx context.Context, req *CreateScheduleRequest) (*ScheduleResponse, error) {
+	if _, err := s.walletRepo.GetWallet(ctx, req.UserID); err != nil {
+		return nil, err
+	}
+	startAt := s.clock.Now()
+	if req.StartAt != nil {
+		startAt = *req.StartAt
+	}
+	sched, err := schedule.NewSchedule(
+		req.UserID,
+		req.ProviderID,
+		req.ExternalReference,
+		req.Amount,
+		req.Currency,
+		schedule.Rule{CronExpr: req.CronExpr, Interval: req.Interval},
+		startAt,
+		req.EndDate,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateSchedule(ctx, sched); err != nil {
+		return nil, err
+	}
+	return toResponse(sched), nil
+}
+
+// GetSchedule obtiene un schedule de la wallet.
+func (s *ScheduleService) GetSchedule(ctx context.Context, userID, scheduleID uuid.UUID) (*ScheduleResponse, error) {
+	sched, err := s.repo.GetSchedule(ctx, userID, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	return toResponse(sched), nil
+}
+
+// ListSchedules lista los schedules de la wallet.
+func (s *ScheduleService) ListSchedules(ctx context.Context, userID uuid.UUID, limit, offset int) (*ListSchedulesResponse, error) {
+	list, total, err := s.repo.ListSchedules(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*ScheduleResponse, 0, len(list))
+	for _, sched := range list {
+		out = append(out, toResponse(sched))
+	}
+	return &ListSchedulesResponse{Schedules: out, Total: total}, nil
+}
+
+// UpdateSchedule modifica monto, referencia, regla, fecha de fin o pausa/reanuda el schedule.
+func (s *ScheduleService) UpdateSchedule(ctx context.Context, req *UpdateScheduleRequest) (*ScheduleResponse, error) {
+	sched, err := s.repo.GetSchedule(ctx, req.UserID, req.ScheduleID)
+	if err != nil {
+		return nil, err
+	}
+	now := s.clock.Now()
+
+	var rule *schedule.Rule
+	if req.CronExpr != "" || req.Interval != 0 {
+		rule = &schedule.Rule{CronExpr: req.CronExpr, Interval: req.Interval}
+	}
+	if err := sched.Update(req.ExternalReference, req.Amount, rule, req.EndDate, now); err != nil {
+		return nil, err
+	}
+
+	switch req.Status {
+	case "", sched.Status:
+	case schedule.StatusPaused:
+		if err := sched.Pause(now); err != nil {
+			return nil, err
+		}
+	case schedule.StatusActive:
+		if err := sched.Resume(now); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.NewValidationError("invalid schedule status", map[string]interface{}{"status": req.Status})
+	}
+
+	if err := s.repo.UpdateSchedule(ctx, sched); err != nil {
+		return nil, err
+	}
+	return toResponse(sched), nil
+}
+
+// CancelSchedule da de baja el schedule.
+func (s *ScheduleService) CancelSchedule(ctx context.Context, userID, scheduleID uuid.UUID) (*ScheduleResponse, error) {
+	sched, err := s.repo.GetSchedule(ctx, userID, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if err := sched.Cancel(s.clock.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateSchedule(ctx, sched); err != nil {
+		return nil, err
+	}
+	return toResponse(sched), nil
+}
+
+func toResponse(s *schedule.Schedule) *ScheduleResponse {
+	resp := &ScheduleResponse{
+		ID:                s.ID,
+		UserID:            s.UserID,
+		ProviderID:        s.ProviderID,
+		ExternalReference: s.ExternalReference,
+		Amount:            s.Amount,
+		Currency:          s.Currency,
+		CronExpr:          s.CronExpr,
+		EndDate:           s.EndDate,
+		NextRunAt:         s.NextRunAt,
+		LastRunAt:         s.LastRunAt,
+		LastStatus:        s.LastStatus,
+		Status:            s.Status,
+		CreatedAt:         s.CreatedAt,
+		UpdatedAt:         s.UpdatedAt,
+	}
+	if s.Interval > 0 {
+		resp.Interval = s.Interval.String()
+	}
+	return resp
+}
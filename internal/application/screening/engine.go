@@ -0,0 +1,106 @@
+package screening
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"draftea-challenge/internal/application/ports"
+	"draftea-challenge/internal/domain/payment"
+	domainrisk "draftea-challenge/internal/domain/risk"
+
+	"github.com/google/uuid"
+)
+
+// RuleEngine es el evaluador de riesgo integrado. Cada regla solo puede escalar la
+// decisión, por lo que gana el resultado más severo entre todas las reglas.
+type RuleEngine struct {
+	history            HistoryRepository
+	clock              ports.Clock
+	reviewAmounts      map[string]int64
+	denyAmounts        map[string]int64
+	newProviderAmounts map[string]int64
+	burstWindow        time.Duration
+	burstMaxPayments   int
+	deniedProviders    map[uuid.UUID]struct{}
+}
+
+// Config define los umbrales de las reglas. Los mapas de montos se indexan por moneda y
+// están en unidades menores; una moneda ausente desactiva la regla para ella.
+type Config struct {
+	ReviewAmounts            map[string]int64
+	DenyAmounts              map[string]int64
+	NewProviderReviewAmounts map[string]int64
+	BurstWindow              time.Duration
+	BurstMaxPayments         int
+	DeniedProviderIDs        []uuid.UUID
+}
+
+// NewRuleEngine crea una nueva instancia de RuleEngine a partir de la configuración.
+func NewRuleEngine(history HistoryRepository, clock ports.Clock, cfg Config) *RuleEngine {
+	denied := make(map[uuid.UUID]struct{}, len(cfg.DeniedProviderIDs))
+	for _, id := range cfg.DeniedProviderIDs {
+		denied[id] = struct{}{}
+	}
+	return &RuleEngine{
+		history:            history,
+		clock:              clock,
+		reviewAmounts:      normalizeCurrencies(cfg.ReviewAmounts),
+		denyAmounts:        normalizeCurrencies(cfg.DenyAmounts),
+		newProviderAmounts: normalizeCurrencies(cfg.NewProviderReviewAmounts),
+		burstWindow:        cfg.BurstWindow,
+		burstMaxPayments:   cfg.BurstMaxPayments,
+		deniedProviders:    denied,
+	}
+}
+
+// Evaluate ejecuta todas las reglas sobre el pago.
+func (e *RuleEngine) Evaluate(ctx context.Context, p *payment.Payment) (*domainrisk.Assessment, error) {
+	assessment := domainrisk.NewAssessment()
+	currency := strings.ToUpper(p.Currency)
+
+	if _, denied := e.deniedProviders[p.ProviderID]; denied {
+		assessment.Escalate(domainrisk.DecisionDeny, "provider_denied")
+	}
+
+	if threshold, ok := e.denyAmounts[currency]; ok && p.Amount >= threshold {
+		assessment.Escalate(domainrisk.DecisionDeny, "amount_over_deny_threshold")
+	} else if threshold, ok := e.reviewAmounts[currency]; ok && p.Amount >= threshold {
+		assessment.Escalate(domainrisk.DecisionReview, "amount_over_review_threshold")
+	}
+
+	if threshold, ok := e.newProviderAmounts[currency]; ok && p.Amount >= threshold {
+		paid, err := e.history.HasPaidProvider(ctx, p.UserID, p.ProviderID)
+		if err != nil {
+			return nil, fmt.Errorf("risk new provider check: %w", err)
+		}
+		if !paid {
+			assessment.Escalate(domainrisk.DecisionReview, "new_provider")
+		}
+	}
+
+	if e.burstMaxPayments > 0 && e.burstWindow > 0 {
+		count, err := e.history.CountPaymentsSince(ctx, p.UserID, e.clock.Now().Add(-e.burstWindow))
+		if err != nil {
+			return nil, fmt.Errorf("risk burst check: %w", err)
+		}
+		if count >= e.burstMaxPayments {
+			assessment.Escalate(domainrisk.DecisionReview, "payment_burst")
+		}
+	}
+
+	return assessment, nil
+}
+
+// normalizeCurrencies pasa a mayúsculas las monedas (viper pasa a minúsculas las claves de mapas YAML).
+func normalizeCurrencies(in map[string]int64) map[string]int64 {
+	out := make(map[string]int64, len(in))
+	for currency, amount := range in {
+		if amount <= 0 {
+			continue
+		}
+		out[strings.ToUpper(currency)] = amount
+	}
+	return out
+}
@@ -0,0 +1,90 @@
+package screening
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"draftea-challenge/internal/domain/payment"
+	domainrisk "draftea-challenge/internal/domain/risk"
+
+	"github.com/google/uuid"
+)
+
+type mockHistory struct {
+	count int
+	paid  bool
+}
+
+func (m *mockHistory) CountPaymentsSince(ctx context.Context, userID uuid.UUID, since time.Time) (int, error) {
+	return m.count, nil
+}
+
+func (m *mockHistory) HasPaidProvider(ctx context.Context, userID, providerID uuid.UUID) (bool, error) {
+	return m.paid, nil
+}
+
+type fixedClock struct{}
+
+func (fixedClock) Now() time.Time { return time.Now() }
+
+func newTestPayment(t *testing.T, providerID uuid.UUID, amount int64) *payment.Payment {
+	t.Helper()
+	p, err := payment.NewPayment(uuid.New(), providerID, "ref-1", amount, "USD")
+	if err != nil {
+		t.Fatalf("new payment: %v", err)
+	}
+	return p
+}
+
+func TestRuleEngine_AllowsBelowThresholds(t *testing.T) {
+	engine := NewRuleEngine(&mockHistory{paid: true}, fixedClock{}, Config{
+		ReviewAmounts: map[string]int64{"usd": 10000},
+		DenyAmounts:   map[string]int64{"usd": 50000},
+	})
+
+	assessment, err := engine.Evaluate(context.Background(), newTestPayment(t, uuid.New(), 500))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if assessment.Decision != domainrisk.DecisionAllow {
+		t.Fatalf("expected allow, got %s", assessment.Decision)
+	}
+}
+
+func TestRuleEngine_DenyWinsOverReview(t *testing.T) {
+	providerID := uuid.New()
+	engine := NewRuleEngine(&mockHistory{count: 10}, fixedClock{}, Config{
+		DeniedProviderIDs: []uuid.UUID{providerID},
+		BurstWindow:       time.Minute,
+		BurstMaxPayments:  5,
+	})
+
+	assessment, err := engine.Evaluate(context.Background(), newTestPayment(t, providerID, 500))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if assessment.Decision != domainrisk.DecisionDeny {
+		t.Fatalf("expected deny, got %s", assessment.Decision)
+	}
+	if len(assessment.Reasons) != 2 {
+		t.Fatalf("expected 2 reasons, got %v", assessment.Reasons)
+	}
+	if assessment.Err() == nil {
+		t.Fatalf("expected deny error")
+	}
+}
+
+func TestRuleEngine_NewProviderReview(t *testing.T) {
+	engine := NewRuleEngine(&mockHistory{paid: false}, fixedClock{}, Config{
+		NewProviderReviewAmounts: map[string]int64{"USD": 1000},
+	})
+
+	assessment, err := engine.Evaluate(context.Background(), newTestPayment(t, uuid.New(), 2000))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if assessment.Decision != domainrisk.DecisionReview {
+		t.Fatalf("expected review, got %s", assessment.Decision)
+	}
+}
@@ -0,0 +1,14 @@
+package screening
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// HistoryRepository expone el historial de pagos que necesitan las reglas de riesgo.
+type HistoryRepository interface {
+	CountPaymentsSince(ctx context.Context, userID uuid.UUID, since time.Time) (int, error)
+	HasPaidProvider(ctx context.Context, userID, providerID uuid.UUID) (bool, error)
+}
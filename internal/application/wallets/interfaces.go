@@ -2,6 +2,7 @@ package wallets
 
 import (
 	"context"
+	"draftea-challenge/internal/domain/transaction"
 	"draftea-challenge/internal/domain/wallet"
 	"time"
 
@@ -13,9 +14,48 @@ type WalletRepository interface {
 	GetWallet(ctx context.Context, userID uuid.UUID) (*wallet.Wallet, error)
 	CreateWallet(ctx context.Context, w *wallet.Wallet) error
 	UpdateBalance(ctx context.Context, userID uuid.UUID, currency string, newBalance int64) error
+	// LockWallet bloquea la wallet hasta el fin de la transacción del ctx (ver ports.Transactor);
+	// los movimientos de saldo que leen y luego escriben el balance deben hacerse con el lock tomado.
+	LockWallet(ctx context.Context, userID uuid.UUID) error
 	ListWallets(ctx context.Context, limit, offset int) ([]*wallet.Wallet, int, error)
 }
 
+// TopUpTransactionRepository define el acceso a las transacciones de top-up.
+type TopUpTransactionRepository interface {
+	CreateTransaction(ctx context.Context, tx *transaction.Transaction) error
+	UpdateTransactionStatus(ctx context.Context, txID uuid.UUID, status transaction.Status) error
+	// TransitionTransactionStatus cambia el estado solo si la transacción sigue en from y reporta si
+	// esta llamada lo cambió; con el ctx de una transacción, los efectos se aplican una sola vez.
+	TransitionTransactionStatus(ctx context.Context, txID uuid.UUID, from, to transaction.Status) (bool, error)
+	GetTransactionByID(ctx context.Context, txID uuid.UUID) (*transaction.Transaction, error)
+}
+
+// FundingGateway define la interfaz para cobrar una fuente de fondeo externa (tarjeta/cuenta bancaria).
+// El cobro se identifica por el ID de la transacción de top-up, que actúa como clave de idempotencia.
+type FundingGateway interface {
+	Charge(ctx context.Context, charge *FundingCharge) (FundingStatus, error)
+	ChargeStatus(ctx context.Context, transactionID uuid.UUID) (FundingStatus, error)
+}
+
+// FundingCharge representa un cobro a la fuente de fondeo.
+type FundingCharge struct {
+	TransactionID uuid.UUID
+	UserID        uuid.UUID
+	Amount        int64
+	Currency      string
+	Source        string // token de la tarjeta/cuenta a cobrar
+}
+
+// FundingStatus es el estado de un cobro informado por la pasarela de fondeo.
+type FundingStatus string
+
+const (
+	FundingPending  FundingStatus = "pending"
+	FundingApproved FundingStatus = "approved"
+	FundingDeclined FundingStatus = "declined"
+	FundingFailed   FundingStatus = "failed" // el cobro no pudo realizarse (error de la pasarela)
+)
+
 // Clock define la interfaz para obtener el tiempo actual (para testabilidad).
 type Clock interface {
 	Now() time.Time
@@ -2,9 +2,13 @@ package wallets
 
 import (
 	"context"
+	"draftea-challenge/internal/application/events"
+	"draftea-challenge/internal/application/outbox"
+	"draftea-challenge/internal/application/ports"
 	"draftea-challenge/internal/domain/errors"
 	"draftea-challenge/internal/domain/transaction"
 	"draftea-challenge/internal/domain/wallet"
+	"encoding/json"
 
 	"github.com/google/uuid"
 )
@@ -82,77 +86,304 @@ func (s *GetTransactionsService) GetTransactions(ctx context.Context, req *GetTr
 	}, nil
 }
 
-// TopUpService credits balances for testing purposes.
+// topUpKeyPrefix namespaces top-up idempotency keys so they never collide with payment keys.
+const topUpKeyPrefix = "top-up:"
+
+// TopUpService funds wallets by charging an external source through the FundingGateway.
+// Without a gateway (instant mode, meant for local runs) balances are credited immediately.
 type TopUpService struct {
-	walletRepo WalletRepository
-	txRepo     interface {
-		CreateTransaction(ctx context.Context, tx *transaction.Transaction) error
-		UpdateTransactionStatus(ctx context.Context, txID uuid.UUID, status transaction.Status) error
-	}
+	walletRepo      WalletRepository
+	txRepo          TopUpTransactionRepository
+	funding         FundingGateway
+	idempotencyRepo ports.IdempotencyRepository
+	outboxRepo      outbox.OutboxRepository
+	transactor      ports.Transactor
+	idGen           ports.IDGenerator
+	clock           ports.Clock
 }
 
-// NewTopUpService creates a new top-up service.
-func NewTopUpService(walletRepo WalletRepository, txRepo interface {
-	CreateTransaction(ctx context.Context, tx *transaction.Transaction) error
-	UpdateTransactionStatus(ctx context.Context, txID uuid.UUID, status transaction.Status) error
-}) *TopUpService {
-	return &TopUpService{walletRepo: walletRepo, txRepo: txRepo}
+// NewTopUpService creates a new top-up service. A nil funding gateway enables instant mode.
+func NewTopUpService(
+	walletRepo WalletRepository,
+	txRepo TopUpTransactionRepository,
+	funding FundingGateway,
+	idempotencyRepo ports.IdempotencyRepository,
+	outboxRepo outbox.OutboxRepository,
+	transactor ports.Transactor,
+	idGen ports.IDGenerator,
+	clock ports.Clock,
+) *TopUpService {
+	return &TopUpService{
+		walletRepo:      walletRepo,
+		txRepo:          txRepo,
+		funding:         funding,
+		idempotencyRepo: idempotencyRepo,
+		outboxRepo:      outboxRepo,
+		transactor:      transactor,
+		idGen:           idGen,
+		clock:           clock,
+	}
 }
 
 // TopUpRequest represents a top-up request.
 type TopUpRequest struct {
-	UserID   uuid.UUID `json:"user_id"`
-	Amount   int64     `json:"amount"`
-	Currency string    `json:"currency"`
+	UserID         uuid.UUID `json:"user_id"`
+	Amount         int64     `json:"amount"`
+	Currency       string    `json:"currency"`
+	Source         string    `json:"source"` // card/bank token charged by the funding gateway
+	IdempotencyKey string    `json:"idempotency_key"`
 }
 
 // TopUpResponse represents a top-up response.
 type TopUpResponse struct {
 	TransactionID uuid.UUID `json:"transaction_id"`
+	Status        string    `json:"status"`
 	Balance       int64     `json:"balance"`
 }
 
-// TopUp credits the wallet balance and records a transaction.
+// TopUp records a PENDING top-up and charges the funding source; the wallet is only
+// credited once the charge is approved, either right away or on confirmation.
 func (s *TopUpService) TopUp(ctx context.Context, req *TopUpRequest) (*TopUpResponse, error) {
+	if req.IdempotencyKey != "" && s.idempotencyRepo != nil {
+		record, err := s.idempotencyRepo.GetIdempotencyRecord(ctx, req.UserID, topUpKeyPrefix+req.IdempotencyKey)
+		if err != nil && !isNotFoundError(err) {
+			return nil, err
+		}
+		if record != nil {
+			var resp TopUpResponse
+			if err := json.Unmarshal([]byte(record.Response), &resp); err != nil {
+				return nil, errors.NewInternalError("failed to unmarshal idempotency response")
+			}
+			return &resp, nil
+		}
+	}
+
 	if req.Amount <= 0 {
 		return nil, errors.NewValidationError("amount must be positive", map[string]interface{}{"amount": req.Amount})
 	}
 	if req.Currency == "" {
 		return nil, errors.NewValidationError("currency cannot be empty", nil)
 	}
+	if s.funding != nil && req.Source == "" {
+		return nil, errors.NewValidationError("source is required", map[string]interface{}{"source": "required"})
+	}
 
 	w, err := s.walletRepo.GetWallet(ctx, req.UserID)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := w.Credit(req.Currency, req.Amount); err != nil {
-		return nil, err
+	reference := req.Source
+	if reference == "" {
+		reference = "top-up"
 	}
-	if err := s.walletRepo.UpdateBalance(ctx, req.UserID, req.Currency, w.GetBalance(req.Currency)); err != nil {
+	tx, err := transaction.NewTransaction(req.UserID, transaction.TypeTopUp, req.Amount, req.Currency, uuid.Nil, reference)
+	if err != nil {
 		return nil, err
 	}
-
-	tx, err := transaction.NewTransaction(req.UserID, transaction.TypeTopUp, req.Amount, req.Currency, uuid.Nil, "top-up")
-	if err != nil {
+	if err := s.txRepo.CreateTransaction(ctx, tx); err != nil {
 		return nil, err
 	}
-	if err := tx.UpdateStatus(transaction.StatusApproved); err != nil {
+	if err := s.emit(ctx, events.TopUpCreated{TopUp: topUpEvent(tx)}); err != nil {
 		return nil, err
 	}
-	if err := s.txRepo.CreateTransaction(ctx, tx); err != nil {
+
+	status := FundingApproved
+	if s.funding != nil {
+		status, err = s.funding.Charge(ctx, &FundingCharge{
+			TransactionID: tx.ID,
+			UserID:        tx.UserID,
+			Amount:        tx.Amount,
+			Currency:      tx.Currency,
+			Source:        req.Source,
+		})
+		if err != nil {
+			status = FundingFailed
+			if isGatewayTimeout(err) {
+				// The charge may still go through: keep the top-up PENDING until it is confirmed.
+				status = FundingPending
+			}
+		}
+	}
+	if err := s.finalize(ctx, tx, w, status); err != nil {
 		return nil, err
 	}
-	if err := s.txRepo.UpdateTransactionStatus(ctx, tx.ID, transaction.StatusApproved); err != nil {
+
+	resp := &TopUpResponse{
+		TransactionID: tx.ID,
+		Status:        string(tx.Status),
+		Balance:       w.GetBalance(req.Currency),
+	}
+	if req.IdempotencyKey != "" && s.idempotencyRepo != nil {
+		respJSON, _ := json.Marshal(resp)
+		record := &ports.IdempotencyRecord{
+			UserID:    req.UserID,
+			Key:       topUpKeyPrefix + req.IdempotencyKey,
+			RequestID: tx.ID,
+			Response:  string(respJSON),
+			CreatedAt: s.clock.Now(),
+		}
+		if err := s.idempotencyRepo.CreateIdempotencyRecord(ctx, record); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// ConfirmTopUp asks the funding gateway for the outcome of a PENDING top-up and finalizes it.
+// Top-ups that are already final are returned as they are.
+func (s *TopUpService) ConfirmTopUp(ctx context.Context, userID, txID uuid.UUID) (*TopUpResponse, error) {
+	tx, w, err := s.loadTopUp(ctx, txID)
+	if err != nil {
 		return nil, err
 	}
+	if tx.UserID != userID {
+		return nil, errors.NewNotFoundError("top-up not found")
+	}
+	if tx.Status == transaction.StatusPending && s.funding != nil {
+		status, err := s.funding.ChargeStatus(ctx, tx.ID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.finalize(ctx, tx, w, status); err != nil {
+			return nil, err
+		}
+	}
+	return &TopUpResponse{
+		TransactionID: tx.ID,
+		Status:        string(tx.Status),
+		Balance:       w.GetBalance(tx.Currency),
+	}, nil
+}
 
+// CompleteTopUp finalizes a PENDING top-up with the outcome reported asynchronously by the
+// funding gateway. Top-ups that are already final are returned as they are.
+func (s *TopUpService) CompleteTopUp(ctx context.Context, txID uuid.UUID, status FundingStatus) (*TopUpResponse, error) {
+	tx, w, err := s.loadTopUp(ctx, txID)
+	if err != nil {
+		return nil, err
+	}
+	if tx.Status == transaction.StatusPending {
+		if err := s.finalize(ctx, tx, w, status); err != nil {
+			return nil, err
+		}
+	}
 	return &TopUpResponse{
 		TransactionID: tx.ID,
-		Balance:       w.GetBalance(req.Currency),
+		Status:        string(tx.Status),
+		Balance:       w.GetBalance(tx.Currency),
 	}, nil
 }
 
+func (s *TopUpService) loadTopUp(ctx context.Context, txID uuid.UUID) (*transaction.Transaction, *wallet.Wallet, error) {
+	tx, err := s.txRepo.GetTransactionByID(ctx, txID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if tx.Type != transaction.TypeTopUp {
+		return nil, nil, errors.NewNotFoundError("top-up not found")
+	}
+	w, err := s.walletRepo.GetWallet(ctx, tx.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tx, w, nil
+}
+
+// finalize applies the funding outcome to a PENDING top-up. Approved charges credit the
+// wallet; declined charges close the top-up without touching the balance. The status moves
+// with a compare-and-set in the same database transaction as the credit, so when a poll and
+// a callback race only the first one credits; the other just reloads the final state.
+func (s *TopUpService) finalize(ctx context.Context, tx *transaction.Transaction, w *wallet.Wallet, status FundingStatus) error {
+	from := tx.Status
+	switch status {
+	case FundingPending:
+		return nil
+	case FundingApproved:
+		if err := tx.UpdateStatus(transaction.StatusApproved); err != nil {
+			return err
+		}
+	case FundingDeclined:
+		if err := tx.UpdateStatus(transaction.StatusDeclined); err != nil {
+			return err
+		}
+	default: // FundingFailed or an unexpected gateway status
+		if err := tx.UpdateStatus(transaction.StatusFailed); err != nil {
+			return err
+		}
+	}
+	return s.inTransaction(ctx, func(ctx context.Context) error {
+		changed, err := s.txRepo.TransitionTransactionStatus(ctx, tx.ID, from, tx.Status)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			return s.reload(ctx, tx, w)
+		}
+		if tx.Status != transaction.StatusApproved {
+			return s.emit(ctx, events.TopUpFailed{TopUp: topUpEvent(tx)})
+		}
+		if err := s.walletRepo.LockWallet(ctx, tx.UserID); err != nil {
+			return err
+		}
+		current, err := s.walletRepo.GetWallet(ctx, tx.UserID)
+		if err != nil {
+			return err
+		}
+		if err := current.Credit(tx.Currency, tx.Amount); err != nil {
+			return err
+		}
+		if err := s.walletRepo.UpdateBalance(ctx, tx.UserID, tx.Currency, current.GetBalance(tx.Currency)); err != nil {
+			return err
+		}
+		*w = *current
+		return s.emit(ctx, events.TopUpCompleted{TopUp: topUpEvent(tx)})
+	})
+}
+
+// reload refreshes a top-up that another request finalized first, along with its wallet.
+func (s *TopUpService) reload(ctx context.Context, tx *transaction.Transaction, w *wallet.Wallet) error {
+	current, err := s.txRepo.GetTransactionByID(ctx, tx.ID)
+	if err != nil {
+		return err
+	}
+	latest, err := s.walletRepo.GetWallet(ctx, tx.UserID)
+	if err != nil {
+		return err
+	}
+	*tx, *w = *current, *latest
+	return nil
+}
+
+// inTransaction runs fn in a database transaction; without a transactor (tests) it runs fn directly.
+func (s *TopUpService) inTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.transactor == nil {
+		return fn(ctx)
+	}
+	return s.transactor.WithinTransaction(ctx, fn)
+}
+
+func (s *TopUpService) emit(ctx context.Context, ev events.Event) error {
+	if s.outboxRepo == nil {
+		return nil
+	}
+	event, err := events.NewOutboxEvent(ctx, s.idGen.New(), s.clock.Now(), ev)
+	if err != nil {
+		return err
+	}
+	return s.outboxRepo.CreateEvent(ctx, event)
+}
+
+func topUpEvent(tx *transaction.Transaction) events.TopUp {
+	return events.TopUp{
+		TransactionID: tx.ID,
+		UserID:        tx.UserID,
+		Amount:        tx.Amount,
+		Currency:      tx.Currency,
+		Status:        string(tx.Status),
+	}
+}
+
 // ListWalletsService lists wallets for testing visibility.
 type ListWalletsService struct {
 	walletRepo WalletRepository
@@ -251,6 +482,14 @@ func (s *CreateWalletService) CreateWallet(ctx context.Context, req *CreateWalle
 	}, nil
 }
 
+// isGatewayTimeout verifica si el error indica que no se conoce el resultado del cobro.
+func isGatewayTimeout(err error) bool {
+	if domErr, ok := err.(errors.Error); ok && domErr.Code == errors.CodeGatewayTimeout {
+		return true
+	}
+	return false
+}
+
 // isNotFoundError verifica si es error de no encontrado.
 func isNotFoundError(err error) bool {
 	if domErr, ok := err.(errors.Error); ok && domErr.Code == errors.CodeNotFound {
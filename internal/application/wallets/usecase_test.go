@@ -3,7 +3,10 @@ package wallets
 import (
 	"context"
 	"testing"
+	"time"
 
+	"draftea-challenge/internal/application/outbox"
+	"draftea-challenge/internal/application/ports"
 	"draftea-challenge/internal/domain/errors"
 	"draftea-challenge/internal/domain/transaction"
 	"draftea-challenge/internal/domain/wallet"
@@ -35,6 +38,10 @@ func (m *mockWalletRepo) UpdateBalance(ctx context.Context, userID uuid.UUID, cu
 	return nil
 }
 
+func (m *mockWalletRepo) LockWallet(ctx context.Context, userID uuid.UUID) error {
+	return m.err
+}
+
 func (m *mockWalletRepo) ListWallets(ctx context.Context, limit, offset int) ([]*wallet.Wallet, int, error) {
 	return nil, 0, nil
 }
@@ -50,7 +57,8 @@ func (m *mockPaymentRepo) ListTransactions(ctx context.Context, userID uuid.UUID
 }
 
 func (m *mockPaymentRepo) CreateTransaction(ctx context.Context, tx *transaction.Transaction) error {
-	m.createdTxs = append(m.createdTxs, tx)
+	cp := *tx
+	m.createdTxs = append(m.createdTxs, &cp)
 	return nil
 }
 
@@ -59,6 +67,103 @@ func (m *mockPaymentRepo) UpdateTransactionStatus(ctx context.Context, txID uuid
 	return nil
 }
 
+func (m *mockPaymentRepo) TransitionTransactionStatus(ctx context.Context, txID uuid.UUID, from, to transaction.Status) (bool, error) {
+	for _, tx := range m.createdTxs {
+		if tx.ID == txID && tx.Status == from {
+			tx.Status = to
+			m.statuses = append(m.statuses, to)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockPaymentRepo) GetTransactionByID(ctx context.Context, txID uuid.UUID) (*transaction.Transaction, error) {
+	for _, tx := range m.createdTxs {
+		if tx.ID == txID {
+			cp := *tx
+			return &cp, nil
+		}
+	}
+	return nil, errors.NewNotFoundError("transaction not found")
+}
+
+type mockFundingGateway struct {
+	chargeStatus FundingStatus
+	chargeErr    error
+	status       FundingStatus
+	charges      int
+	onStatus     func() // runs while ChargeStatus is in flight
+}
+
+func (m *mockFundingGateway) Charge(ctx context.Context, charge *FundingCharge) (FundingStatus, error) {
+	m.charges++
+	return m.chargeStatus, m.chargeErr
+}
+
+func (m *mockFundingGateway) ChargeStatus(ctx context.Context, transactionID uuid.UUID) (FundingStatus, error) {
+	if m.onStatus != nil {
+		m.onStatus()
+	}
+	return m.status, nil
+}
+
+type mockIdempotencyRepo struct {
+	records map[string]*ports.IdempotencyRecord
+}
+
+func (m *mockIdempotencyRepo) GetIdempotencyRecord(ctx context.Context, userID uuid.UUID, key string) (*ports.IdempotencyRecord, error) {
+	if record, ok := m.records[key]; ok {
+		return record, nil
+	}
+	return nil, errors.NewNotFoundError("idempotency record not found")
+}
+
+func (m *mockIdempotencyRepo) CreateIdempotencyRecord(ctx context.Context, record *ports.IdempotencyRecord) error {
+	if m.records == nil {
+		m.records = make(map[string]*ports.IdempotencyRecord)
+	}
+	m.records[record.Key] = record
+	return nil
+}
+
+type mockOutboxRepo struct {
+	events []*outbox.OutboxEvent
+}
+
+func (m *mockOutboxRepo) CreateEvent(ctx context.Context, event *outbox.OutboxEvent) error {
+	m.events = append(m.events, event)
+	return nil
+}
+
+func (m *mockOutboxRepo) GetPendingEvents(ctx context.Context, limit int) ([]*outbox.OutboxEvent, error) {
+	return nil, nil
+}
+
+func (m *mockOutboxRepo) ClaimPendingEvents(ctx context.Context, relayID string, now time.Time, limit int, lease time.Duration) ([]*outbox.OutboxEvent, error) {
+	return nil, nil
+}
+
+func (m *mockOutboxRepo) ReleaseEvents(ctx context.Context, relayID string, eventIDs []uuid.UUID) error {
+	return nil
+}
+
+func (m *mockOutboxRepo) RecordEventFailure(ctx context.Context, event *outbox.OutboxEvent) error {
+	return nil
+}
+
+func (m *mockOutboxRepo) MarkEventAsSent(ctx context.Context, eventID uuid.UUID) error {
+	return nil
+}
+
+type fixedClock struct{}
+
+func (fixedClock) Now() time.Time { return time.Unix(0, 0).UTC() }
+
+type randomIDGen struct{}
+
+func (randomIDGen) New() uuid.UUID { return uuid.New() }
+
 func TestGetBalance_NotFoundReturnsEmpty(t *testing.T) {
 	userID := uuid.New()
 	repo := &mockWalletRepo{err: errors.NewNotFoundError("wallet not found")}
@@ -105,7 +210,7 @@ func TestTopUpCreatesBalance(t *testing.T) {
 	repo := &mockWalletRepo{wallet: w}
 	txRepo := &mockPaymentRepo{}
 
-	svc := NewTopUpService(repo, txRepo)
+	svc := NewTopUpService(repo, txRepo, nil, nil, nil, nil, randomIDGen{}, fixedClock{})
 	resp, err := svc.TopUp(context.Background(), &TopUpRequest{UserID: userID, Amount: 1000, Currency: "USD"})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -113,11 +218,119 @@ func TestTopUpCreatesBalance(t *testing.T) {
 	if resp.Balance != 1100 {
 		t.Fatalf("expected balance 1100, got %d", resp.Balance)
 	}
+	if resp.Status != string(transaction.StatusApproved) {
+		t.Fatalf("expected approved top-up, got %s", resp.Status)
+	}
 	if len(txRepo.createdTxs) != 1 {
 		t.Fatalf("expected transaction created")
 	}
 }
 
+func TestTopUpPendingUntilConfirmed(t *testing.T) {
+	userID := uuid.New()
+	w, _ := wallet.NewWallet(userID)
+	_ = w.SetBalance("USD", 100)
+	repo := &mockWalletRepo{wallet: w}
+	txRepo := &mockPaymentRepo{}
+	funding := &mockFundingGateway{chargeStatus: FundingPending, status: FundingPending}
+	outboxRepo := &mockOutboxRepo{}
+
+	svc := NewTopUpService(repo, txRepo, funding, &mockIdempotencyRepo{}, outboxRepo, nil, randomIDGen{}, fixedClock{})
+	resp, err := svc.TopUp(context.Background(), &TopUpRequest{UserID: userID, Amount: 1000, Currency: "USD", Source: "card_tok"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Status != string(transaction.StatusPending) || resp.Balance != 100 {
+		t.Fatalf("expected pending top-up without credit, got %+v", resp)
+	}
+
+	resp, err = svc.ConfirmTopUp(context.Background(), userID, resp.TransactionID)
+	if err != nil || resp.Status != string(transaction.StatusPending) {
+		t.Fatalf("expected top-up still pending, got %+v %v", resp, err)
+	}
+
+	funding.status = FundingApproved
+	resp, err = svc.ConfirmTopUp(context.Background(), userID, resp.TransactionID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Status != string(transaction.StatusApproved) || resp.Balance != 1100 {
+		t.Fatalf("expected approved top-up credited, got %+v", resp)
+	}
+	if len(outboxRepo.events) != 2 || outboxRepo.events[0].EventType != "topup.created" || outboxRepo.events[1].EventType != "topup.completed" {
+		t.Fatalf("expected topup.created and topup.completed events, got %d", len(outboxRepo.events))
+	}
+
+	// Confirming an already final top-up does not credit twice.
+	resp, err = svc.ConfirmTopUp(context.Background(), userID, resp.TransactionID)
+	if err != nil || resp.Balance != 1100 {
+		t.Fatalf("expected idempotent confirmation, got %+v %v", resp, err)
+	}
+}
+
+func TestTopUpCallbackDuringConfirmCreditsOnce(t *testing.T) {
+	userID := uuid.New()
+	w, _ := wallet.NewWallet(userID)
+	_ = w.SetBalance("USD", 100)
+	repo := &mockWalletRepo{wallet: w}
+	txRepo := &mockPaymentRepo{}
+	funding := &mockFundingGateway{chargeStatus: FundingPending, status: FundingApproved}
+	outboxRepo := &mockOutboxRepo{}
+
+	svc := NewTopUpService(repo, txRepo, funding, &mockIdempotencyRepo{}, outboxRepo, nil, randomIDGen{}, fixedClock{})
+	resp, err := svc.TopUp(context.Background(), &TopUpRequest{UserID: userID, Amount: 1000, Currency: "USD", Source: "card_tok"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The gateway callback lands after the poll loaded the PENDING top-up and before it finalizes.
+	funding.onStatus = func() {
+		funding.onStatus = nil
+		if _, err := svc.CompleteTopUp(context.Background(), resp.TransactionID, FundingApproved); err != nil {
+			t.Fatalf("callback: %v", err)
+		}
+	}
+	resp, err = svc.ConfirmTopUp(context.Background(), userID, resp.TransactionID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Status != string(transaction.StatusApproved) || resp.Balance != 1100 || w.GetBalance("USD") != 1100 {
+		t.Fatalf("expected a single credit, got %+v (wallet %d)", resp, w.GetBalance("USD"))
+	}
+	if len(outboxRepo.events) != 2 || outboxRepo.events[1].EventType != "topup.completed" {
+		t.Fatalf("expected one topup.completed event, got %d events", len(outboxRepo.events))
+	}
+}
+
+func TestTopUpDeclinedAndIdempotent(t *testing.T) {
+	userID := uuid.New()
+	w, _ := wallet.NewWallet(userID)
+	repo := &mockWalletRepo{wallet: w}
+	funding := &mockFundingGateway{chargeStatus: FundingDeclined}
+	outboxRepo := &mockOutboxRepo{}
+
+	svc := NewTopUpService(repo, &mockPaymentRepo{}, funding, &mockIdempotencyRepo{}, outboxRepo, nil, randomIDGen{}, fixedClock{})
+	req := &TopUpRequest{UserID: userID, Amount: 1000, Currency: "USD", Source: "card_tok", IdempotencyKey: "key-1"}
+	first, err := svc.TopUp(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first.Status != string(transaction.StatusDeclined) || first.Balance != 0 {
+		t.Fatalf("expected declined top-up without credit, got %+v", first)
+	}
+	if outboxRepo.events[len(outboxRepo.events)-1].EventType != "topup.failed" {
+		t.Fatalf("expected topup.failed event")
+	}
+
+	second, err := svc.TopUp(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if second.TransactionID != first.TransactionID || funding.charges != 1 {
+		t.Fatalf("expected idempotent replay, got %+v after %d charges", second, funding.charges)
+	}
+}
+
 func TestListWallets(t *testing.T) {
 	userID := uuid.New()
 	w, _ := wallet.NewWallet(userID)
@@ -154,6 +367,10 @@ func (m *mockListWalletRepo) UpdateBalance(ctx context.Context, userID uuid.UUID
 	return nil
 }
 
+func (m *mockListWalletRepo) LockWallet(ctx context.Context, userID uuid.UUID) error {
+	return nil
+}
+
 func (m *mockListWalletRepo) ListWallets(ctx context.Context, limit, offset int) ([]*wallet.Wallet, int, error) {
 	return m.wallets, m.total, nil
 }
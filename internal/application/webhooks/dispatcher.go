@@ -0,0 +1,287 @@
+package webhooks
+
+import (
+	"context"
+	"draftea-challenge/internal/application/ports"
+	"draftea-challenge/internal/domain/errors"
+	"draftea-challenge/internal/domain/webhook"
+	"draftea-challenge/internal/platform/signature"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Cabeceras enviadas en cada entrega.
+const (
+	HeaderEventID   = "X-Webhook-Event-Id"
+	HeaderEventType = "X-Webhook-Event-Type"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Config define la política de reintentos y deshabilitado de las entregas.
+type Config struct {
+	MaxAttempts    int           // intentos por evento y suscripción
+	InitialBackoff time.Duration // espera antes del segundo intento; se duplica en cada reintento
+	MaxBackoff     time.Duration // tope de la espera entre intentos
+	DisableAfter   int           // eventos fallidos consecutivos que deshabilitan la suscripción; 0 = nunca
+	RetryBatchSize int           // reintentos vencidos ejecutados por ronda de RetryDue
+	RetryLease     time.Duration // reserva de un reintento reclamado; vencida, otro worker lo retoma
+}
+
+// Event representa un evento de dominio a entregar.
+type Event struct {
+	ID         uuid.UUID
+	Type       string
+	Payload    []byte // envelope JSON del evento (events.Envelope), se envía tal cual al merchant
+	OccurredAt time.Time
+}
+
+// Dispatcher entrega eventos a las suscripciones habilitadas, con reintentos exponenciales,
+// registro de cada intento y deshabilitado automático tras fallos repetidos. Los reintentos no
+// bloquean al consumidor: se programan en webhook_deliveries (next_attempt_at) y los ejecuta RetryDue.
+type Dispatcher struct {
+	subs       SubscriptionRepository
+	deliveries DeliveryRepository
+	events     EventRepository
+	sender     Sender
+	idGen      ports.IDGenerator
+	clock      ports.Clock
+	cfg        Config
+}
+
+// NewDispatcher crea una nueva instancia de Dispatcher.
+func NewDispatcher(subs SubscriptionRepository, deliveries DeliveryRepository, events EventRepository, sender Sender, idGen ports.IDGenerator, clock ports.Clock, cfg Config) *Dispatcher {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 5
+	}
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = time.Second
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = time.Minute
+	}
+	if cfg.DisableAfter < 0 {
+		cfg.DisableAfter = 0
+	}
+	if cfg.RetryBatchSize <= 0 {
+		cfg.RetryBatchSize = 100
+	}
+	if cfg.RetryLease <= 0 {
+		cfg.RetryLease = time.Minute
+	}
+	return &Dispatcher{
+		subs:       subs,
+		deliveries: deliveries,
+		events:     events,
+		sender:     sender,
+		idGen:      idGen,
+		clock:      clock,
+		cfg:        cfg,
+	}
+}
+
+// Dispatch hace el primer intento de entrega del evento a cada suscripción habilitada que lo acepte.
+// Las entregas fallidas quedan registradas con su reintento programado y no generan error; solo se
+// retorna error ante fallas de persistencia, para que el mensaje vuelva a la cola.
+func (d *Dispatcher) Dispatch(ctx context.Context, ev *Event) error {
+	subs, err := d.subs.ListEnabledWebhookSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	for _, sub := range subs {
+		if !sub.Matches(ev.Type) {
+			continue
+		}
+		wg.Add(1)
+		go func(sub *webhook.Subscription) {
+			defer wg.Done()
+			if err := d.deliver(ctx, sub, ev); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}(sub)
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// Replay reenvía un evento del outbox a una suscripción con un único intento.
+func (d *Dispatcher) Replay(ctx context.Context, subscriptionID, eventID uuid.UUID) (*webhook.Delivery, error) {
+	sub, err := d.subs.GetWebhookSubscription(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if !sub.Enabled {
+		return nil, errors.NewValidationError("webhook subscription is disabled", map[string]interface{}{"subscription_id": subscriptionID})
+	}
+	stored, err := d.events.GetEventByID(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	ev := &Event{ID: stored.ID, Type: stored.EventType, Payload: []byte(stored.Payload), OccurredAt: stored.CreatedAt}
+
+	delivery, err := d.attempt(ctx, sub, ev, 1, true)
+	if err != nil {
+		return nil, err
+	}
+	// Un replay exitoso demuestra que el endpoint volvió a responder; los fallos no cuentan
+	// para el deshabilitado porque son disparados manualmente.
+	if delivery.Status == webhook.DeliverySucceeded && sub.ConsecutiveFailures > 0 {
+		if err := d.subs.ResetWebhookFailures(ctx, sub.ID, d.clock.Now().UTC()); err != nil {
+			return nil, err
+		}
+	}
+	return delivery, nil
+}
+
+// RetryDue ejecuta los reintentos programados que ya vencieron y retorna cuántos procesó. El worker
+// la llama periódicamente; varias réplicas reclaman lotes disjuntos.
+func (d *Dispatcher) RetryDue(ctx context.Context) (int, error) {
+	due, err := d.deliveries.ClaimDueWebhookRetries(ctx, d.clock.Now().UTC(), d.cfg.RetryBatchSize, d.cfg.RetryLease)
+	if err != nil {
+		return 0, err
+	}
+	for i, prev := range due {
+		if err := d.retry(ctx, prev); err != nil {
+			return i, err
+		}
+	}
+	return len(due), nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, sub *webhook.Subscription, ev *Event) error {
+	// Un mensaje redelivered por el broker no debe volver a enviarse a quien ya lo recibió.
+	delivered, err := d.deliveries.HasSuccessfulWebhookDelivery(ctx, sub.ID, ev.ID)
+	if err != nil {
+		return err
+	}
+	if delivered {
+		return nil
+	}
+	delivery, err := d.attempt(ctx, sub, ev, 1, false)
+	if err != nil {
+		return err
+	}
+	return d.recordHealth(ctx, sub, delivery)
+}
+
+// retry ejecuta el intento siguiente a prev. Si la suscripción ya no existe o está deshabilitada,
+// o el evento ya se entregó o dejó de estar en el outbox, el reintento se descarta.
+func (d *Dispatcher) retry(ctx context.Context, prev *webhook.Delivery) error {
+	sub, err := d.subs.GetWebhookSubscription(ctx, prev.SubscriptionID)
+	if err != nil && !isNotFoundError(err) {
+		return err
+	}
+	if err == nil && sub.Enabled {
+		delivered, err := d.deliveries.HasSuccessfulWebhookDelivery(ctx, sub.ID, prev.EventID)
+		if err != nil {
+			return err
+		}
+		stored, err := d.events.GetEventByID(ctx, prev.EventID)
+		if err != nil && !isNotFoundError(err) {
+			return err
+		}
+		if !delivered && err == nil {
+			ev := &Event{ID: stored.ID, Type: stored.EventType, Payload: []byte(stored.Payload), OccurredAt: stored.CreatedAt}
+			delivery, err := d.attempt(ctx, sub, ev, prev.Attempt+1, false)
+			if err != nil {
+				return err
+			}
+			if err := d.recordHealth(ctx, sub, delivery); err != nil {
+				return err
+			}
+		}
+	}
+	return d.deliveries.CompleteWebhookRetry(ctx, prev.ID)
+}
+
+// recordHealth actualiza el contador de fallos de la suscripción: un éxito lo reinicia y un evento
+// que agotó sus intentos lo incrementa (y puede deshabilitarla). Un fallo con reintento pendiente no cuenta.
+func (d *Dispatcher) recordHealth(ctx context.Context, sub *webhook.Subscription, delivery *webhook.Delivery) error {
+	switch {
+	case delivery.Status == webhook.DeliverySucceeded && sub.ConsecutiveFailures > 0:
+		return d.subs.ResetWebhookFailures(ctx, sub.ID, delivery.CreatedAt)
+	case delivery.Status == webhook.DeliveryFailed && delivery.NextAttemptAt == nil:
+		return d.subs.RecordWebhookFailure(ctx, sub.ID, d.cfg.DisableAfter, delivery.CreatedAt)
+	}
+	return nil
+}
+
+// attempt firma y envía el evento una vez, registrando el resultado.
+func (d *Dispatcher) attempt(ctx context.Context, sub *webhook.Subscription, ev *Event, attempt int, replay bool) (*webhook.Delivery, error) {
+	body := ev.Payload
+	start := d.clock.Now()
+	timestamp := strconv.FormatInt(start.Unix(), 10)
+	headers := map[string]string{
+		"Content-Type":  "application/json",
+		HeaderEventID:   ev.ID.String(),
+		HeaderEventType: ev.Type,
+		HeaderTimestamp: timestamp,
+		HeaderSignature: hex.EncodeToString(signature.Sign([]byte(sub.Secret), timestamp, body)),
+	}
+	status, sendErr := d.sender.Send(ctx, sub.URL, headers, body)
+	end := d.clock.Now()
+
+	delivery := &webhook.Delivery{
+		ID:             d.idGen.New(),
+		SubscriptionID: sub.ID,
+		EventID:        ev.ID,
+		EventType:      ev.Type,
+		Attempt:        attempt,
+		Status:         webhook.DeliverySucceeded,
+		ResponseStatus: status,
+		DurationMs:     end.Sub(start).Milliseconds(),
+		Replay:         replay,
+		CreatedAt:      end.UTC(),
+	}
+	switch {
+	case sendErr != nil:
+		delivery.Status = webhook.DeliveryFailed
+		delivery.Error = sendErr.Error()
+	case status < 200 || status > 299:
+		delivery.Status = webhook.DeliveryFailed
+		delivery.Error = fmt.Sprintf("unexpected status %d", status)
+	}
+	if delivery.Status == webhook.DeliveryFailed && !replay && attempt < d.cfg.MaxAttempts {
+		next := delivery.CreatedAt.Add(d.backoff(attempt))
+		delivery.NextAttemptAt = &next
+	}
+	if err := d.deliveries.CreateWebhookDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// backoff retorna la espera tras el intento fallido attempt: InitialBackoff duplicado en cada
+// reintento, con tope MaxBackoff.
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	wait := d.cfg.InitialBackoff
+	for i := 1; i < attempt && wait < d.cfg.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > d.cfg.MaxBackoff {
+		return d.cfg.MaxBackoff
+	}
+	return wait
+}
+
+func isNotFoundError(err error) bool {
+	if domErr, ok := err.(errors.Error); ok && domErr.Code == errors.CodeNotFound {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,400 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"draftea-challenge/internal/application/outbox"
+	"draftea-challenge/internal/domain/errors"
+	"draftea-challenge/internal/domain/webhook"
+	"draftea-challenge/internal/platform/signature"
+
+	"github.com/google/uuid"
+)
+
+type mockSubRepo struct {
+	mu   sync.Mutex
+	subs map[uuid.UUID]*webhook.Subscription
+}
+
+func newMockSubRepo(subs ...*webhook.Subscription) *mockSubRepo {
+	m := &mockSubRepo{subs: make(map[uuid.UUID]*webhook.Subscription)}
+	for _, s := range subs {
+		m.subs[s.ID] = s
+	}
+	return m
+}
+
+func (m *mockSubRepo) CreateWebhookSubscription(ctx context.Context, s *webhook.Subscription) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subs[s.ID] = s
+	return nil
+}
+
+func (m *mockSubRepo) GetWebhookSubscription(ctx context.Context, subscriptionID uuid.UUID) (*webhook.Subscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.subs[subscriptionID]
+	if !ok {
+		return nil, errors.NewNotFoundError("webhook subscription not found")
+	}
+	cp := *s
+	return &cp, nil
+}
+
+func (m *mockSubRepo) ListWebhookSubscriptions(ctx context.Context, limit, offset int) ([]*webhook.Subscription, int, error) {
+	list, _ := m.ListEnabledWebhookSubscriptions(ctx)
+	return list, len(list), nil
+}
+
+func (m *mockSubRepo) ListEnabledWebhookSubscriptions(ctx context.Context) ([]*webhook.Subscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []*webhook.Subscription
+	for _, s := range m.subs {
+		if s.Enabled {
+			cp := *s
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockSubRepo) UpdateWebhookSubscription(ctx context.Context, s *webhook.Subscription) error {
+	return m.CreateWebhookSubscription(ctx, s)
+}
+
+func (m *mockSubRepo) RecordWebhookFailure(ctx context.Context, subscriptionID uuid.UUID, disableAfter int, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored := m.subs[subscriptionID]
+	stored.ConsecutiveFailures++
+	if disableAfter > 0 && stored.Enabled && stored.ConsecutiveFailures >= disableAfter {
+		stored.Enabled = false
+		stored.DisabledAt = &now
+	}
+	return nil
+}
+
+func (m *mockSubRepo) ResetWebhookFailures(ctx context.Context, subscriptionID uuid.UUID, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subs[subscriptionID].ConsecutiveFailures = 0
+	return nil
+}
+
+func (m *mockSubRepo) DeleteWebhookSubscription(ctx context.Context, subscriptionID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.subs, subscriptionID)
+	return nil
+}
+
+type mockDeliveryRepo struct {
+	mu         sync.Mutex
+	deliveries []*webhook.Delivery
+}
+
+func (m *mockDeliveryRepo) CreateWebhookDelivery(ctx context.Context, d *webhook.Delivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deliveries = append(m.deliveries, d)
+	return nil
+}
+
+func (m *mockDeliveryRepo) ListWebhookDeliveries(ctx context.Context, subscriptionID uuid.UUID, limit, offset int) ([]*webhook.Delivery, int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.deliveries, len(m.deliveries), nil
+}
+
+func (m *mockDeliveryRepo) HasSuccessfulWebhookDelivery(ctx context.Context, subscriptionID, eventID uuid.UUID) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, d := range m.deliveries {
+		if d.SubscriptionID == subscriptionID && d.EventID == eventID && d.Status == webhook.DeliverySucceeded && !d.Replay {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockDeliveryRepo) ClaimDueWebhookRetries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*webhook.Delivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []*webhook.Delivery
+	for _, d := range m.deliveries {
+		if len(out) == limit {
+			break
+		}
+		if d.Status == webhook.DeliveryFailed && !d.Replay && d.NextAttemptAt != nil && !d.NextAttemptAt.After(now) {
+			cp := *d
+			out = append(out, &cp)
+			leased := now.Add(lease)
+			d.NextAttemptAt = &leased
+		}
+	}
+	return out, nil
+}
+
+func (m *mockDeliveryRepo) CompleteWebhookRetry(ctx context.Context, deliveryID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, d := range m.deliveries {
+		if d.ID == deliveryID {
+			d.NextAttemptAt = nil
+		}
+	}
+	return nil
+}
+
+type mockEventRepo struct {
+	events map[uuid.UUID]*outbox.OutboxEvent
+}
+
+func newMockEventRepo(events ...*outbox.OutboxEvent) *mockEventRepo {
+	m := &mockEventRepo{events: make(map[uuid.UUID]*outbox.OutboxEvent)}
+	for _, ev := range events {
+		m.events[ev.ID] = ev
+	}
+	return m
+}
+
+func (m *mockEventRepo) GetEventByID(ctx context.Context, eventID uuid.UUID) (*outbox.OutboxEvent, error) {
+	ev, ok := m.events[eventID]
+	if !ok {
+		return nil, errors.NewNotFoundError("event not found")
+	}
+	return ev, nil
+}
+
+type sentRequest struct {
+	url     string
+	headers map[string]string
+	body    []byte
+}
+
+type mockSender struct {
+	mu     sync.Mutex
+	status int
+	sent   []sentRequest
+}
+
+func (m *mockSender) Send(ctx context.Context, url string, headers map[string]string, body []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, sentRequest{url: url, headers: headers, body: body})
+	return m.status, nil
+}
+
+type manualClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *manualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+type randomIDGen struct{}
+
+func (randomIDGen) New() uuid.UUID {
+	return uuid.New()
+}
+
+func newSubscription(t *testing.T, url string, eventTypes ...string) *webhook.Subscription {
+	t.Helper()
+	sub, err := webhook.NewSubscription(webhook.Attributes{URL: url, EventTypes: eventTypes, Enabled: true}, "sub-secret", time.Now())
+	if err != nil {
+		t.Fatalf("new subscription: %v", err)
+	}
+	return sub
+}
+
+func newTestDispatcher(subs *mockSubRepo, deliveries *mockDeliveryRepo, events *mockEventRepo, sender *mockSender, cfg Config) (*Dispatcher, *manualClock) {
+	clock := &manualClock{t: time.Now()}
+	return NewDispatcher(subs, deliveries, events, sender, randomIDGen{}, clock, cfg), clock
+}
+
+func TestDispatch_SignsAndFiltersByEventType(t *testing.T) {
+	payments := newSubscription(t, "https://merchant.example/payments", "payment.*")
+	topUps := newSubscription(t, "https://merchant.example/top-ups", "topup.completed")
+	deliveries := &mockDeliveryRepo{}
+	sender := &mockSender{status: http.StatusOK}
+	d, _ := newTestDispatcher(newMockSubRepo(payments, topUps), deliveries, newMockEventRepo(), sender, Config{})
+
+	ev := &Event{ID: uuid.New(), Type: "payment.completed", Payload: []byte(`{"event_id":"e-1","type":"payment.completed","data":{"transaction_id":"tx-1"}}`), OccurredAt: time.Now()}
+	if err := d.Dispatch(context.Background(), ev); err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+
+	if len(sender.sent) != 1 || sender.sent[0].url != payments.URL {
+		t.Fatalf("expected a single delivery to the payments endpoint, got %+v", sender.sent)
+	}
+	req := sender.sent[0]
+	expected := hex.EncodeToString(signature.Sign([]byte("sub-secret"), req.headers[HeaderTimestamp], req.body))
+	if req.headers[HeaderSignature] != expected {
+		t.Fatalf("signature mismatch: got %s want %s", req.headers[HeaderSignature], expected)
+	}
+	if string(req.body) != string(ev.Payload) {
+		t.Fatalf("expected the stored envelope as body, got %s", req.body)
+	}
+	if len(deliveries.deliveries) != 1 || deliveries.deliveries[0].Status != webhook.DeliverySucceeded {
+		t.Fatalf("expected one succeeded attempt, got %+v", deliveries.deliveries)
+	}
+
+	// A broker redelivery of the same event must not be sent again.
+	if err := d.Dispatch(context.Background(), ev); err != nil {
+		t.Fatalf("redispatch: %v", err)
+	}
+	if len(sender.sent) != 1 {
+		t.Fatalf("expected redelivered event to be skipped, got %d sends", len(sender.sent))
+	}
+}
+
+func TestDispatch_SchedulesRetriesWithBackoffAndDisablesAfterRepeatedFailures(t *testing.T) {
+	sub := newSubscription(t, "https://merchant.example/hook")
+	subs := newMockSubRepo(sub)
+	deliveries := &mockDeliveryRepo{}
+	events := newMockEventRepo()
+	sender := &mockSender{status: http.StatusInternalServerError}
+	d, clock := newTestDispatcher(subs, deliveries, events, sender, Config{
+		MaxAttempts:    3,
+		InitialBackoff: time.Second,
+		MaxBackoff:     90 * time.Second,
+		DisableAfter:   2,
+	})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		stored := &outbox.OutboxEvent{ID: uuid.New(), EventType: "payment.failed", Payload: `{}`, CreatedAt: clock.Now()}
+		events.events[stored.ID] = stored
+		ev := &Event{ID: stored.ID, Type: stored.EventType, Payload: []byte(stored.Payload), OccurredAt: stored.CreatedAt}
+		if err := d.Dispatch(ctx, ev); err != nil {
+			t.Fatalf("dispatch %d: %v", i, err)
+		}
+	}
+	// Dispatch makes only the first attempt; the retry waits in the deliveries table.
+	if len(deliveries.deliveries) != 2 {
+		t.Fatalf("expected one attempt per event before retries are due, got %d", len(deliveries.deliveries))
+	}
+	if n, err := d.RetryDue(ctx); err != nil || n != 0 {
+		t.Fatalf("expected no retries due yet, got %d %v", n, err)
+	}
+
+	clock.Advance(time.Second)
+	if n, err := d.RetryDue(ctx); err != nil || n != 2 {
+		t.Fatalf("expected 2 retries after the first backoff, got %d %v", n, err)
+	}
+	clock.Advance(time.Second)
+	if n, err := d.RetryDue(ctx); err != nil || n != 0 {
+		t.Fatalf("expected the second backoff to double, got %d %v", n, err)
+	}
+	clock.Advance(time.Second)
+	if n, err := d.RetryDue(ctx); err != nil || n != 2 {
+		t.Fatalf("expected 2 final retries, got %d %v", n, err)
+	}
+
+	if len(deliveries.deliveries) != 6 {
+		t.Fatalf("expected 3 attempts per event, got %d", len(deliveries.deliveries))
+	}
+	for _, del := range deliveries.deliveries {
+		if del.Status != webhook.DeliveryFailed || del.ResponseStatus != http.StatusInternalServerError {
+			t.Fatalf("unexpected attempt %+v", del)
+		}
+		if del.Attempt == 3 {
+			if del.NextAttemptAt != nil {
+				t.Fatalf("expected no retry after the last attempt, got %+v", del)
+			}
+			continue
+		}
+		if del.NextAttemptAt != nil {
+			t.Fatalf("expected retried delivery to be completed, got %+v", del)
+		}
+	}
+	first, second, third := deliveries.deliveries[0], deliveries.deliveries[2], deliveries.deliveries[4]
+	if second.CreatedAt.Sub(first.CreatedAt) != time.Second || third.CreatedAt.Sub(second.CreatedAt) != 2*time.Second {
+		t.Fatalf("unexpected backoff sequence %v %v", second.CreatedAt.Sub(first.CreatedAt), third.CreatedAt.Sub(second.CreatedAt))
+	}
+
+	stored, _ := subs.GetWebhookSubscription(ctx, sub.ID)
+	if stored.Enabled || stored.DisabledAt == nil || stored.ConsecutiveFailures != 2 {
+		t.Fatalf("expected subscription auto-disabled, got %+v", stored)
+	}
+
+	// Disabled subscriptions no longer receive events.
+	if err := d.Dispatch(ctx, &Event{ID: uuid.New(), Type: "payment.failed", Payload: []byte(`{}`)}); err != nil {
+		t.Fatalf("dispatch after disable: %v", err)
+	}
+	if len(sender.sent) != 6 {
+		t.Fatalf("expected no sends after disable, got %d", len(sender.sent))
+	}
+}
+
+func TestRetryDue_DropsRetriesForDisabledSubscriptions(t *testing.T) {
+	sub := newSubscription(t, "https://merchant.example/hook")
+	subs := newMockSubRepo(sub)
+	deliveries := &mockDeliveryRepo{}
+	stored := &outbox.OutboxEvent{ID: uuid.New(), EventType: "payment.failed", Payload: `{}`, CreatedAt: time.Now()}
+	sender := &mockSender{status: http.StatusBadGateway}
+	d, clock := newTestDispatcher(subs, deliveries, newMockEventRepo(stored), sender, Config{MaxAttempts: 3, InitialBackoff: time.Second, MaxBackoff: time.Minute})
+	ctx := context.Background()
+
+	if err := d.Dispatch(ctx, &Event{ID: stored.ID, Type: stored.EventType, Payload: []byte(stored.Payload)}); err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+	sub.Enabled = false
+	if err := subs.UpdateWebhookSubscription(ctx, sub); err != nil {
+		t.Fatalf("disable: %v", err)
+	}
+
+	clock.Advance(time.Second)
+	if n, err := d.RetryDue(ctx); err != nil || n != 1 {
+		t.Fatalf("expected the due retry to be claimed, got %d %v", n, err)
+	}
+	if len(sender.sent) != 1 || deliveries.deliveries[0].NextAttemptAt != nil {
+		t.Fatalf("expected retry dropped without sending, got %d sends %+v", len(sender.sent), deliveries.deliveries[0])
+	}
+}
+
+func TestReplay_ResendsStoredEvent(t *testing.T) {
+	sub := newSubscription(t, "https://merchant.example/hook")
+	sub.ConsecutiveFailures = 3
+	subs := newMockSubRepo(sub)
+	deliveries := &mockDeliveryRepo{}
+	stored := &outbox.OutboxEvent{ID: uuid.New(), EventType: "refund.created", Payload: `{"amount":100}`, CreatedAt: time.Now()}
+	sender := &mockSender{status: http.StatusNoContent}
+	d, _ := newTestDispatcher(subs, deliveries, newMockEventRepo(stored), sender, Config{})
+
+	delivery, err := d.Replay(context.Background(), sub.ID, stored.ID)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if !delivery.Replay || delivery.Status != webhook.DeliverySucceeded || delivery.EventID != stored.ID {
+		t.Fatalf("unexpected delivery %+v", delivery)
+	}
+	if sender.sent[0].headers[HeaderEventType] != "refund.created" {
+		t.Fatalf("unexpected headers %+v", sender.sent[0].headers)
+	}
+	updated, _ := subs.GetWebhookSubscription(context.Background(), sub.ID)
+	if updated.ConsecutiveFailures != 0 {
+		t.Fatalf("expected successful replay to reset failures, got %d", updated.ConsecutiveFailures)
+	}
+
+	if _, err := d.Replay(context.Background(), sub.ID, uuid.New()); err == nil {
+		t.Fatalf("expected not found for unknown event")
+	}
+}
@@ -0,0 +1,50 @@
+package webhooks
+
+import (
+	"context"
+	"draftea-challenge/internal/application/outbox"
+	"draftea-challenge/internal/domain/webhook"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SubscriptionRepository define la persistencia de suscripciones de webhooks de merchants.
+type SubscriptionRepository interface {
+	CreateWebhookSubscription(ctx context.Context, s *webhook.Subscription) error
+	GetWebhookSubscription(ctx context.Context, subscriptionID uuid.UUID) (*webhook.Subscription, error)
+	ListWebhookSubscriptions(ctx context.Context, limit, offset int) ([]*webhook.Subscription, int, error)
+	ListEnabledWebhookSubscriptions(ctx context.Context) ([]*webhook.Subscription, error)
+	UpdateWebhookSubscription(ctx context.Context, s *webhook.Subscription) error
+	DeleteWebhookSubscription(ctx context.Context, subscriptionID uuid.UUID) error
+	// RecordWebhookFailure suma un evento fallido al contador en SQL (consecutive_failures + 1) y
+	// deshabilita la suscripción al alcanzar disableAfter (0 = nunca). Nunca la re-habilita ni pisa
+	// cambios hechos en paralelo desde la administración.
+	RecordWebhookFailure(ctx context.Context, subscriptionID uuid.UUID, disableAfter int, now time.Time) error
+	// ResetWebhookFailures pone en cero el contador de fallos consecutivos.
+	ResetWebhookFailures(ctx context.Context, subscriptionID uuid.UUID, now time.Time) error
+}
+
+// DeliveryRepository registra los intentos de entrega.
+type DeliveryRepository interface {
+	CreateWebhookDelivery(ctx context.Context, d *webhook.Delivery) error
+	ListWebhookDeliveries(ctx context.Context, subscriptionID uuid.UUID, limit, offset int) ([]*webhook.Delivery, int, error)
+	// HasSuccessfulWebhookDelivery indica si el evento ya fue entregado a la suscripción (sin contar replays).
+	HasSuccessfulWebhookDelivery(ctx context.Context, subscriptionID, eventID uuid.UUID) (bool, error)
+	// ClaimDueWebhookRetries reclama (FOR UPDATE SKIP LOCKED) hasta limit entregas fallidas cuyo
+	// reintento venció y corre next_attempt_at por lease: si el worker cae, vuelven a estar disponibles.
+	ClaimDueWebhookRetries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*webhook.Delivery, error)
+	// CompleteWebhookRetry marca como ejecutado el reintento programado en la entrega.
+	CompleteWebhookRetry(ctx context.Context, deliveryID uuid.UUID) error
+}
+
+// EventRepository define la lectura de eventos del outbox para los replays.
+type EventRepository interface {
+	GetEventByID(ctx context.Context, eventID uuid.UUID) (*outbox.OutboxEvent, error)
+}
+
+// Sender envía la petición HTTP firmada al endpoint del merchant.
+type Sender interface {
+	// Send retorna el status HTTP recibido; err solo indica fallas de transporte.
+	Send(ctx context.Context, url string, headers map[string]string, body []byte) (int, error)
+}
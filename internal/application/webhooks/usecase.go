@@ -0,0 +1,117 @@
+package webhooks
+
+import (
+	"context"
+	"draftea-challenge/internal/application/ports"
+	"draftea-challenge/internal/domain/webhook"
+
+	"github.com/google/uuid"
+)
+
+// SubscriptionService gestiona las suscripciones de webhooks (endpoints de administración).
+type SubscriptionService struct {
+	repo       SubscriptionRepository
+	deliveries DeliveryRepository
+	clock      ports.Clock
+}
+
+// NewSubscriptionService crea una nueva instancia de SubscriptionService.
+func NewSubscriptionService(repo SubscriptionRepository, deliveries DeliveryRepository, clock ports.Clock) *SubscriptionService {
+	return &SubscriptionService{repo: repo, deliveries: deliveries, clock: clock}
+}
+
+// SubscriptionRequest representa el alta o modificación de una suscripción.
+type SubscriptionRequest struct {
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Secret     string   `json:"secret"`  // opcional al crear; se genera si está vacío. Se ignora al editar
+	Enabled    *bool    `json:"enabled"` // por defecto true al crear; sin cambios al editar
+}
+
+// CreateSubscriptionResponse incluye el secreto, que solo se devuelve al crear la suscripción.
+type CreateSubscriptionResponse struct {
+	*webhook.Subscription
+	Secret string `json:"secret"`
+}
+
+// ListSubscriptionsResponse representa la respuesta paginada.
+type ListSubscriptionsResponse struct {
+	Subscriptions []*webhook.Subscription `json:"subscriptions"`
+	Total         int                     `json:"total"`
+}
+
+// ListDeliveriesResponse representa la respuesta paginada de intentos de entrega.
+type ListDeliveriesResponse struct {
+	Deliveries []*webhook.Delivery `json:"deliveries"`
+	Total      int                 `json:"total"`
+}
+
+// CreateSubscription da de alta una suscripción.
+func (s *SubscriptionService) CreateSubscription(ctx context.Context, req *SubscriptionRequest) (*CreateSubscriptionResponse, error) {
+	sub, err := webhook.NewSubscription(req.attributes(true), req.Secret, s.clock.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateWebhookSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+	return &CreateSubscriptionResponse{Subscription: sub, Secret: sub.Secret}, nil
+}
+
+// GetSubscription obtiene una suscripción.
+func (s *SubscriptionService) GetSubscription(ctx context.Context, subscriptionID uuid.UUID) (*webhook.Subscription, error) {
+	return s.repo.GetWebhookSubscription(ctx, subscriptionID)
+}
+
+// ListSubscriptions lista las suscripciones ordenadas por fecha de alta.
+func (s *SubscriptionService) ListSubscriptions(ctx context.Context, limit, offset int) (*ListSubscriptionsResponse, error) {
+	list, total, err := s.repo.ListWebhookSubscriptions(ctx, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return &ListSubscriptionsResponse{Subscriptions: list, Total: total}, nil
+}
+
+// UpdateSubscription reemplaza los atributos de una suscripción; re-habilitarla reinicia el contador de fallos.
+func (s *SubscriptionService) UpdateSubscription(ctx context.Context, subscriptionID uuid.UUID, req *SubscriptionRequest) (*webhook.Subscription, error) {
+	sub, err := s.repo.GetWebhookSubscription(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := sub.Update(req.attributes(sub.Enabled), s.clock.Now().UTC()); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateWebhookSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// DeleteSubscription elimina una suscripción; sus intentos de entrega se conservan.
+func (s *SubscriptionService) DeleteSubscription(ctx context.Context, subscriptionID uuid.UUID) error {
+	return s.repo.DeleteWebhookSubscription(ctx, subscriptionID)
+}
+
+// ListDeliveries lista los intentos de entrega de una suscripción, del más reciente al más antiguo.
+func (s *SubscriptionService) ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, limit, offset int) (*ListDeliveriesResponse, error) {
+	if _, err := s.repo.GetWebhookSubscription(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+	list, total, err := s.deliveries.ListWebhookDeliveries(ctx, subscriptionID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return &ListDeliveriesResponse{Deliveries: list, Total: total}, nil
+}
+
+func (r *SubscriptionRequest) attributes(enabledDefault bool) webhook.Attributes {
+	enabled := enabledDefault
+	if r.Enabled != nil {
+		enabled = *r.Enabled
+	}
+	return webhook.Attributes{
+		URL:        r.URL,
+		EventTypes: r.EventTypes,
+		Enabled:    enabled,
+	}
+}
@@ -0,0 +1,157 @@
+package batch
+
+import (
+	"draftea-challenge/internal/domain/errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Batch representa un lote de pagos enviados juntos por la misma wallet.
+type Batch struct {
+	ID             uuid.UUID  `json:"id"`
+	UserID         uuid.UUID  `json:"user_id"`
+	IdempotencyKey string     `json:"idempotency_key,omitempty"`
+	Status         Status     `json:"status"`
+	Items          []*Item    `json:"items"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	CompletedAt    *time.Time `json:"completed_at,omitempty"`
+}
+
+// Status define el estado del lote.
+type Status string
+
+const (
+	StatusProcessing Status = "PROCESSING"
+	StatusCompleted  Status = "COMPLETED"
+)
+
+// ItemStatus define el resultado de un ítem; además de los estados de la transacción
+// puede quedar PENDING (sin procesar) o ERROR (rechazado antes de llegar a la pasarela).
+type ItemStatus string
+
+const (
+	ItemPending  ItemStatus = "PENDING"
+	ItemApproved ItemStatus = "APPROVED"
+	ItemHeld     ItemStatus = "HELD"
+	ItemDeclined ItemStatus = "DECLINED"
+	ItemFailed   ItemStatus = "FAILED"
+	ItemError    ItemStatus = "ERROR"
+)
+
+// Item representa un pago dentro del lote y su resultado.
+type Item struct {
+	Index             int        `json:"index"`
+	ProviderID        uuid.UUID  `json:"provider_id"`
+	ExternalReference string     `json:"external_reference"`
+	Amount            int64      `json:"amount"` // en minor units
+	Currency          string     `json:"currency"`
+	IdempotencyKey    string     `json:"idempotency_key"`
+	Status            ItemStatus `json:"status"`
+	TransactionID     *uuid.UUID `json:"transaction_id,omitempty"`
+	ErrorCode         string     `json:"error_code,omitempty"`
+	ErrorMessage      string     `json:"error_message,omitempty"`
+}
+
+// NewBatch crea un lote en PROCESSING con sus ítems pendientes.
+// Los ítems sin idempotency key reciben una derivada del lote para que reenviarlo no duplique pagos.
+func NewBatch(userID uuid.UUID, idempotencyKey string, items []*Item, maxItems int) (*Batch, error) {
+	if userID == uuid.Nil {
+		return nil, errors.NewValidationError("user_id cannot be nil", nil)
+	}
+	if len(items) == 0 {
+		return nil, errors.NewValidationError("batch must contain at least one item", nil)
+	}
+	if maxItems > 0 && len(items) > maxItems {
+		return nil, errors.NewValidationError("batch exceeds the maximum number of items", map[string]interface{}{
+			"items":     len(items),
+			"max_items": maxItems,
+		})
+	}
+
+	now := time.Now().UTC()
+	b := &Batch{
+		ID:             uuid.New(),
+		UserID:         userID,
+		IdempotencyKey: idempotencyKey,
+		Status:         StatusProcessing,
+		Items:          items,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	seen := make(map[string]int, len(items))
+	for i, item := range items {
+		item.Index = i
+		item.Status = ItemPending
+		if item.IdempotencyKey == "" {
+			item.IdempotencyKey = b.itemKey(i)
+		}
+		if prev, dup := seen[item.IdempotencyKey]; dup {
+			return nil, errors.NewValidationError("duplicate idempotency_key in batch", map[string]interface{}{
+				"idempotency_key": item.IdempotencyKey,
+				"items":           []int{prev, i},
+			})
+		}
+		seen[item.IdempotencyKey] = i
+	}
+	return b, nil
+}
+
+// itemKey deriva la clave de idempotencia del ítem a partir de la clave del lote (o de su ID).
+func (b *Batch) itemKey(index int) string {
+	base := b.IdempotencyKey
+	if base == "" {
+		base = b.ID.String()
+	}
+	return fmt.Sprintf("batch:%s:%d", base, index)
+}
+
+// Pending retorna los ítems que todavía no fueron procesados.
+func (b *Batch) Pending() []*Item {
+	var out []*Item
+	for _, item := range b.Items {
+		if item.Status == ItemPending {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// Refresh recalcula el estado del lote: COMPLETED cuando no quedan ítems pendientes.
+func (b *Batch) Refresh(now time.Time) {
+	b.UpdatedAt = now
+	if len(b.Pending()) > 0 {
+		b.Status = StatusProcessing
+		return
+	}
+	if b.Status != StatusCompleted {
+		b.Status = StatusCompleted
+		b.CompletedAt = &now
+	}
+}
+
+// Summary cuenta los ítems por estado.
+func (b *Batch) Summary() map[ItemStatus]int {
+	out := make(map[ItemStatus]int)
+	for _, item := range b.Items {
+		out[item.Status]++
+	}
+	return out
+}
+
+// Succeed registra el resultado de un pago procesado.
+func (i *Item) Succeed(transactionID uuid.UUID, status string) {
+	i.TransactionID = &transactionID
+	i.Status = ItemStatus(status)
+	i.ErrorCode = ""
+	i.ErrorMessage = ""
+}
+
+// Fail registra el error que impidió procesar el pago.
+func (i *Item) Fail(code, message string) {
+	i.Status = ItemError
+	i.ErrorCode = code
+	i.ErrorMessage = message
+}
@@ -20,6 +20,8 @@ const (
 	CodeUnauthorized       = "UNAUTHORIZED"
 	CodeNotFound           = "NOT_FOUND"
 	CodeInsufficientFunds  = "INSUFFICIENT_FUNDS"
+	CodeLimitExceeded      = "LIMIT_EXCEEDED"
+	CodeRiskDenied         = "RISK_DENIED"
 	CodeGatewayTimeout     = "GATEWAY_TIMEOUT"
 	CodeGatewayError       = "GATEWAY_ERROR"
 	CodeInternal           = "INTERNAL"
@@ -56,6 +58,22 @@ func NewInsufficientFundsError(message string, details map[string]interface{}) E
 	}
 }
 
+func NewLimitExceededError(message string, details map[string]interface{}) Error {
+	return Error{
+		Code:    CodeLimitExceeded,
+		Message: message,
+		Details: details,
+	}
+}
+
+func NewRiskDeniedError(message string, details map[string]interface{}) Error {
+	return Error{
+		Code:    CodeRiskDenied,
+		Message: message,
+		Details: details,
+	}
+}
+
 func NewGatewayTimeoutError(message string) Error {
 	return Error{
 		Code:    CodeGatewayTimeout,
@@ -0,0 +1,72 @@
+package fee
+
+import (
+	"draftea-challenge/internal/domain/errors"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// bpsDenominator expresa los porcentajes en basis points (1% = 100 bps).
+const bpsDenominator = 10000
+
+// Policy representa la política de comisión para un provider y moneda.
+// ProviderID en uuid.Nil indica la política por defecto de la moneda.
+type Policy struct {
+	ID            uuid.UUID `json:"id"`
+	ProviderID    uuid.UUID `json:"provider_id,omitempty"`
+	Currency      string    `json:"currency"`
+	FixedAmount   int64     `json:"fixed_amount"`   // en minor units
+	PercentageBps int64     `json:"percentage_bps"` // basis points sobre el principal
+	MinFee        int64     `json:"min_fee"`        // 0 = sin mínimo
+	MaxFee        int64     `json:"max_fee"`        // 0 = sin tope
+}
+
+// NewPolicy crea una política de comisión validando sus valores.
+func NewPolicy(providerID uuid.UUID, currency string, fixedAmount, percentageBps, minFee, maxFee int64) (*Policy, error) {
+	details := make(map[string]interface{})
+	if strings.TrimSpace(currency) == "" {
+		details["currency"] = "required"
+	}
+	if fixedAmount < 0 {
+		details["fixed_amount"] = fixedAmount
+	}
+	if percentageBps < 0 || percentageBps > bpsDenominator {
+		details["percentage_bps"] = percentageBps
+	}
+	if minFee < 0 {
+		details["min_fee"] = minFee
+	}
+	if maxFee < 0 || (maxFee > 0 && maxFee < minFee) {
+		details["max_fee"] = maxFee
+	}
+	if len(details) > 0 {
+		return nil, errors.NewValidationError("invalid fee policy", details)
+	}
+	return &Policy{
+		ID:            uuid.New(),
+		ProviderID:    providerID,
+		Currency:      strings.ToUpper(strings.TrimSpace(currency)),
+		FixedAmount:   fixedAmount,
+		PercentageBps: percentageBps,
+		MinFee:        minFee,
+		MaxFee:        maxFee,
+	}, nil
+}
+
+// Compute calcula la comisión para el monto: fijo + porcentaje (redondeo half-up), acotada por min/max.
+func (p *Policy) Compute(amount int64) int64 {
+	if amount <= 0 {
+		return 0
+	}
+	// Se separa el monto para evitar overflow al multiplicar por los bps.
+	variable := (amount/bpsDenominator)*p.PercentageBps + ((amount%bpsDenominator)*p.PercentageBps+bpsDenominator/2)/bpsDenominator
+	fee := p.FixedAmount + variable
+	if p.MinFee > 0 && fee < p.MinFee {
+		fee = p.MinFee
+	}
+	if p.MaxFee > 0 && fee > p.MaxFee {
+		fee = p.MaxFee
+	}
+	return fee
+}
@@ -0,0 +1,52 @@
+package fee
+
+import (
+	"testing"
+
+	"draftea-challenge/internal/domain/errors"
+
+	"github.com/google/uuid"
+)
+
+func TestCompute(t *testing.T) {
+	cases := []struct {
+		name   string
+		policy Policy
+		amount int64
+		want   int64
+	}{
+		{name: "fixed only", policy: Policy{FixedAmount: 30}, amount: 1000, want: 30},
+		{name: "percentage rounds half up", policy: Policy{PercentageBps: 250}, amount: 1010, want: 25},
+		{name: "fixed plus percentage", policy: Policy{FixedAmount: 30, PercentageBps: 100}, amount: 10000, want: 130},
+		{name: "min fee", policy: Policy{PercentageBps: 100, MinFee: 50}, amount: 1000, want: 50},
+		{name: "max fee", policy: Policy{PercentageBps: 100, MaxFee: 500}, amount: 1000000, want: 500},
+		{name: "non positive amount", policy: Policy{FixedAmount: 30}, amount: 0, want: 0},
+	}
+	for _, tc := range cases {
+		if got := tc.policy.Compute(tc.amount); got != tc.want {
+			t.Fatalf("%s: expected %d, got %d", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestNewPolicyValidates(t *testing.T) {
+	_, err := NewPolicy(uuid.Nil, "usd", 0, 20000, 100, 50)
+	domErr, ok := err.(errors.Error)
+	if !ok || domErr.Code != errors.CodeValidationError {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	if _, ok := domErr.Details["percentage_bps"]; !ok {
+		t.Fatalf("expected percentage_bps detail, got %v", domErr.Details)
+	}
+	if _, ok := domErr.Details["max_fee"]; !ok {
+		t.Fatalf("expected max_fee detail, got %v", domErr.Details)
+	}
+
+	p, err := NewPolicy(uuid.Nil, " usd ", 10, 100, 0, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Currency != "USD" {
+		t.Fatalf("expected normalized currency, got %s", p.Currency)
+	}
+}
@@ -0,0 +1,99 @@
+package limit
+
+import (
+	"draftea-challenge/internal/domain/errors"
+
+	"github.com/google/uuid"
+)
+
+// SpendingLimit representa los límites de gasto aplicables a una wallet en una moneda.
+// Un valor en cero significa "sin límite" para ese criterio.
+type SpendingLimit struct {
+	ID                 uuid.UUID `json:"id"`
+	UserID             uuid.UUID `json:"user_id,omitempty"` // uuid.Nil para el límite por defecto
+	Currency           string    `json:"currency"`
+	MaxSinglePayment   int64     `json:"max_single_payment"` // en minor units
+	DailySpend         int64     `json:"daily_spend"`        // en minor units
+	MonthlySpend       int64     `json:"monthly_spend"`      // en minor units
+	MaxPaymentsPerHour int       `json:"max_payments_per_hour"`
+}
+
+// Usage resume el consumo reciente de una wallet en una moneda.
+type Usage struct {
+	DailySpent       int64 `json:"daily_spent"`
+	MonthlySpent     int64 `json:"monthly_spent"`
+	PaymentsLastHour int   `json:"payments_last_hour"`
+}
+
+// Reglas evaluadas, usadas en los detalles del error.
+const (
+	RuleMaxSinglePayment   = "max_single_payment"
+	RuleDailySpend         = "daily_spend"
+	RuleMonthlySpend       = "monthly_spend"
+	RuleMaxPaymentsPerHour = "max_payments_per_hour"
+)
+
+// NewSpendingLimit crea un límite de gasto validando sus valores.
+func NewSpendingLimit(userID uuid.UUID, currency string, maxSingle, daily, monthly int64, perHour int) (*SpendingLimit, error) {
+	if currency == "" {
+		return nil, errors.NewValidationError("currency cannot be empty", nil)
+	}
+	if maxSingle < 0 || daily < 0 || monthly < 0 || perHour < 0 {
+		return nil, errors.NewValidationError("limits cannot be negative", map[string]interface{}{
+			RuleMaxSinglePayment:   maxSingle,
+			RuleDailySpend:         daily,
+			RuleMonthlySpend:       monthly,
+			RuleMaxPaymentsPerHour: perHour,
+		})
+	}
+	return &SpendingLimit{
+		ID:                 uuid.New(),
+		UserID:             userID,
+		Currency:           currency,
+		MaxSinglePayment:   maxSingle,
+		DailySpend:         daily,
+		MonthlySpend:       monthly,
+		MaxPaymentsPerHour: perHour,
+	}, nil
+}
+
+// Check valida que un pago de amount respete el límite dado el consumo actual.
+// Retorna un error LIMIT_EXCEEDED con el remanente disponible en Details.
+func (l *SpendingLimit) Check(amount int64, usage Usage) error {
+	if l.MaxSinglePayment > 0 && amount > l.MaxSinglePayment {
+		return l.exceeded(RuleMaxSinglePayment, amount, l.MaxSinglePayment, l.MaxSinglePayment)
+	}
+	if l.DailySpend > 0 && usage.DailySpent+amount > l.DailySpend {
+		return l.exceeded(RuleDailySpend, amount, l.DailySpend, remaining(l.DailySpend, usage.DailySpent))
+	}
+	if l.MonthlySpend > 0 && usage.MonthlySpent+amount > l.MonthlySpend {
+		return l.exceeded(RuleMonthlySpend, amount, l.MonthlySpend, remaining(l.MonthlySpend, usage.MonthlySpent))
+	}
+	if l.MaxPaymentsPerHour > 0 && usage.PaymentsLastHour >= l.MaxPaymentsPerHour {
+		return errors.NewLimitExceededError("payments per hour limit exceeded", map[string]interface{}{
+			"rule":      RuleMaxPaymentsPerHour,
+			"currency":  l.Currency,
+			"limit":     l.MaxPaymentsPerHour,
+			"remaining": 0,
+		})
+	}
+	return nil
+}
+
+func (l *SpendingLimit) exceeded(rule string, requested, limitAmount, remainingAmount int64) error {
+	return errors.NewLimitExceededError("spending limit exceeded", map[string]interface{}{
+		"rule":      rule,
+		"currency":  l.Currency,
+		"requested": requested,
+		"limit":     limitAmount,
+		"remaining": remainingAmount,
+	})
+}
+
+// remaining calcula el remanente disponible sin devolver valores negativos.
+func remaining(limitAmount, spent int64) int64 {
+	if spent >= limitAmount {
+		return 0
+	}
+	return limitAmount - spent
+}
@@ -0,0 +1,55 @@
+package limit
+
+import (
+	"testing"
+
+	"draftea-challenge/internal/domain/errors"
+
+	"github.com/google/uuid"
+)
+
+func TestSpendingLimitCheckDailyRemaining(t *testing.T) {
+	l, err := NewSpendingLimit(uuid.New(), "USD", 0, 1000, 0, 0)
+	if err != nil {
+		t.Fatalf("new limit: %v", err)
+	}
+
+	err = l.Check(300, Usage{DailySpent: 800})
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	domErr, ok := err.(errors.Error)
+	if !ok || domErr.Code != errors.CodeLimitExceeded {
+		t.Fatalf("expected limit exceeded error, got %v", err)
+	}
+	if domErr.Details["rule"] != RuleDailySpend {
+		t.Fatalf("expected daily rule, got %v", domErr.Details["rule"])
+	}
+	if domErr.Details["remaining"] != int64(200) {
+		t.Fatalf("expected remaining 200, got %v", domErr.Details["remaining"])
+	}
+}
+
+func TestSpendingLimitCheckZeroMeansUnlimited(t *testing.T) {
+	l, err := NewSpendingLimit(uuid.Nil, "USD", 0, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("new limit: %v", err)
+	}
+	if err := l.Check(1_000_000, Usage{DailySpent: 1_000_000, PaymentsLastHour: 100}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSpendingLimitCheckVelocity(t *testing.T) {
+	l, err := NewSpendingLimit(uuid.New(), "USD", 500, 0, 0, 3)
+	if err != nil {
+		t.Fatalf("new limit: %v", err)
+	}
+	if err := l.Check(600, Usage{}); err == nil {
+		t.Fatalf("expected single payment limit error")
+	}
+	err = l.Check(100, Usage{PaymentsLastHour: 3})
+	if domErr, ok := err.(errors.Error); !ok || domErr.Details["rule"] != RuleMaxPaymentsPerHour {
+		t.Fatalf("expected per hour limit error, got %v", err)
+	}
+}
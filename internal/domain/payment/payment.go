@@ -3,6 +3,7 @@ package payment
 import (
 	"github.com/google/uuid"
 	"draftea-challenge/internal/domain/errors"
+	"draftea-challenge/internal/domain/provider"
 )
 
 // Payment representa una solicitud de pago de servicios.
@@ -41,3 +42,12 @@ func NewPayment(userID uuid.UUID, providerID uuid.UUID, externalRef string, amou
 		Currency:          currency,
 	}, nil
 }
+
+// ValidateProvider valida el pago contra el catálogo: provider habilitado, moneda soportada,
+// monto dentro de min/max y referencia con el formato esperado.
+func (p *Payment) ValidateProvider(prov *provider.Provider) error {
+	if prov == nil || prov.ID != p.ProviderID {
+		return errors.NewValidationError("unknown provider_id", map[string]interface{}{"provider_id": p.ProviderID})
+	}
+	return prov.Accept(p.Amount, p.Currency, p.ExternalReference)
+}
@@ -0,0 +1,144 @@
+package provider
+
+import (
+	"draftea-challenge/internal/domain/errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Provider representa un biller del catálogo (empresa de servicios que recibe pagos).
+type Provider struct {
+	ID               uuid.UUID `json:"id"`
+	Name             string    `json:"name"`
+	Currencies       []string  `json:"currencies"`
+	MinAmount        int64     `json:"min_amount"` // en minor units, 0 = sin mínimo
+	MaxAmount        int64     `json:"max_amount"` // en minor units, 0 = sin máximo
+	ReferencePattern string    `json:"reference_pattern,omitempty"`
+	Enabled          bool      `json:"enabled"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// Attributes agrupa los campos editables de un provider.
+type Attributes struct {
+	Name             string
+	Currencies       []string
+	MinAmount        int64
+	MaxAmount        int64
+	ReferencePattern string
+	Enabled          bool
+}
+
+// NewProvider crea un provider validando sus atributos.
+func NewProvider(attrs Attributes) (*Provider, error) {
+	now := time.Now().UTC()
+	p := &Provider{
+		ID:        uuid.New(),
+		CreatedAt: now,
+	}
+	if err := p.apply(attrs, now); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Update reemplaza los atributos editables del provider.
+func (p *Provider) Update(attrs Attributes, now time.Time) error {
+	return p.apply(attrs, now)
+}
+
+func (p *Provider) apply(attrs Attributes, now time.Time) error {
+	details := make(map[string]interface{})
+	name := strings.TrimSpace(attrs.Name)
+	if name == "" {
+		details["name"] = "required"
+	}
+	currencies := normalizeCurrencies(attrs.Currencies)
+	if len(currencies) == 0 {
+		details["currencies"] = "at least one currency required"
+	}
+	if attrs.MinAmount < 0 {
+		details["min_amount"] = attrs.MinAmount
+	}
+	if attrs.MaxAmount < 0 || (attrs.MaxAmount > 0 && attrs.MaxAmount < attrs.MinAmount) {
+		details["max_amount"] = attrs.MaxAmount
+	}
+	if attrs.ReferencePattern != "" {
+		if _, err := regexp.Compile(attrs.ReferencePattern); err != nil {
+			details["reference_pattern"] = err.Error()
+		}
+	}
+	if len(details) > 0 {
+		return errors.NewValidationError("invalid provider", details)
+	}
+
+	p.Name = name
+	p.Currencies = currencies
+	p.MinAmount = attrs.MinAmount
+	p.MaxAmount = attrs.MaxAmount
+	p.ReferencePattern = attrs.ReferencePattern
+	p.Enabled = attrs.Enabled
+	p.UpdatedAt = now
+	return nil
+}
+
+// Supports indica si el provider acepta pagos en la moneda.
+func (p *Provider) Supports(currency string) bool {
+	currency = strings.ToUpper(currency)
+	for _, c := range p.Currencies {
+		if c == currency {
+			return true
+		}
+	}
+	return false
+}
+
+// Accept valida que un pago pueda enviarse al provider.
+func (p *Provider) Accept(amount int64, currency, externalRef string) error {
+	if !p.Enabled {
+		return errors.NewValidationError("provider is disabled", map[string]interface{}{"provider_id": p.ID})
+	}
+	details := make(map[string]interface{})
+	if !p.Supports(currency) {
+		details["currency"] = currency
+		details["supported_currencies"] = p.Currencies
+	}
+	if p.MinAmount > 0 && amount < p.MinAmount {
+		details["amount"] = amount
+		details["min_amount"] = p.MinAmount
+	}
+	if p.MaxAmount > 0 && amount > p.MaxAmount {
+		details["amount"] = amount
+		details["max_amount"] = p.MaxAmount
+	}
+	if p.ReferencePattern != "" {
+		// El patrón se valida al crear/editar; se ancla para exigir coincidencia completa.
+		re := regexp.MustCompile(`^(?:` + p.ReferencePattern + `)$`)
+		if !re.MatchString(externalRef) {
+			details["external_reference"] = externalRef
+			details["reference_pattern"] = p.ReferencePattern
+		}
+	}
+	if len(details) > 0 {
+		details["provider_id"] = p.ID
+		return errors.NewValidationError("payment not accepted by provider", details)
+	}
+	return nil
+}
+
+func normalizeCurrencies(in []string) []string {
+	out := make([]string, 0, len(in))
+	seen := make(map[string]bool, len(in))
+	for _, c := range in {
+		c = strings.ToUpper(strings.TrimSpace(c))
+		if c == "" || seen[c] {
+			continue
+		}
+		seen[c] = true
+		out = append(out, c)
+	}
+	return out
+}
@@ -0,0 +1,61 @@
+package provider
+
+import (
+	"testing"
+
+	"draftea-challenge/internal/domain/errors"
+)
+
+func TestNewProviderValidatesAttributes(t *testing.T) {
+	_, err := NewProvider(Attributes{Name: "Water Co", Currencies: []string{"usd"}, MinAmount: 500, MaxAmount: 100, ReferencePattern: "("})
+	domErr, ok := err.(errors.Error)
+	if !ok || domErr.Code != errors.CodeValidationError {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	if _, ok := domErr.Details["max_amount"]; !ok {
+		t.Fatalf("expected max_amount detail, got %v", domErr.Details)
+	}
+	if _, ok := domErr.Details["reference_pattern"]; !ok {
+		t.Fatalf("expected reference_pattern detail, got %v", domErr.Details)
+	}
+}
+
+func TestAccept(t *testing.T) {
+	p, err := NewProvider(Attributes{
+		Name:             "Water Co",
+		Currencies:       []string{"usd", "USD", "mxn"},
+		MinAmount:        100,
+		MaxAmount:        1000,
+		ReferencePattern: `[0-9]{4}`,
+		Enabled:          true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(p.Currencies) != 2 {
+		t.Fatalf("expected normalized currencies, got %v", p.Currencies)
+	}
+
+	if err := p.Accept(500, "usd", "1234"); err != nil {
+		t.Fatalf("expected payment accepted, got %v", err)
+	}
+	for _, tc := range []struct {
+		amount   int64
+		currency string
+		ref      string
+	}{
+		{amount: 50, currency: "USD", ref: "1234"},
+		{amount: 5000, currency: "USD", ref: "1234"},
+		{amount: 500, currency: "ARS", ref: "1234"},
+		{amount: 500, currency: "USD", ref: "12345"},
+	} {
+		if err := p.Accept(tc.amount, tc.currency, tc.ref); err == nil {
+			t.Fatalf("expected rejection for %+v", tc)
+		}
+	}
+
+	p.Enabled = false
+	if err := p.Accept(500, "USD", "1234"); err == nil {
+		t.Fatalf("expected disabled provider to reject payments")
+	}
+}
@@ -0,0 +1,53 @@
+package risk
+
+import "draftea-challenge/internal/domain/errors"
+
+// Decision representa el resultado de la evaluación de riesgo de un pago.
+type Decision string
+
+const (
+	DecisionAllow  Decision = "ALLOW"
+	DecisionReview Decision = "REVIEW"
+	DecisionDeny   Decision = "DENY"
+)
+
+// Assessment agrupa la decisión y los motivos que la originaron.
+type Assessment struct {
+	Decision Decision `json:"decision"`
+	Reasons  []string `json:"reasons,omitempty"`
+}
+
+// NewAssessment crea una evaluación que permite el pago.
+func NewAssessment() *Assessment {
+	return &Assessment{Decision: DecisionAllow}
+}
+
+// Escalate registra un motivo y eleva la decisión si es más severa que la actual.
+func (a *Assessment) Escalate(decision Decision, reason string) {
+	if severity(decision) > severity(a.Decision) {
+		a.Decision = decision
+	}
+	a.Reasons = append(a.Reasons, reason)
+}
+
+// Err retorna un error de dominio si la decisión es DENY.
+func (a *Assessment) Err() error {
+	if a.Decision != DecisionDeny {
+		return nil
+	}
+	return errors.NewRiskDeniedError("payment denied by risk screening", map[string]interface{}{
+		"reasons": a.Reasons,
+	})
+}
+
+// severity ordena las decisiones de menor a mayor severidad.
+func severity(d Decision) int {
+	switch d {
+	case DecisionDeny:
+		return 2
+	case DecisionReview:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,223 @@
+package schedule
+
+import (
+	"draftea-challenge/internal/domain/errors"
+	"draftea-challenge/internal/domain/payment"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+)
+
+// Schedule representa un pago programado/recurrente de servicios.
+type Schedule struct {
+	ID                uuid.UUID     `json:"id"`
+	UserID            uuid.UUID     `json:"user_id"`
+	ProviderID        uuid.UUID     `json:"provider_id"`
+	ExternalReference string        `json:"external_reference"`
+	Amount            int64         `json:"amount"` // en minor units
+	Currency          string        `json:"currency"`
+	CronExpr          string        `json:"cron,omitempty"`
+	Interval          time.Duration `json:"-"`
+	EndDate           *time.Time    `json:"end_date,omitempty"`
+	NextRunAt         time.Time     `json:"next_run_at"`
+	LastRunAt         *time.Time    `json:"last_run_at,omitempty"`
+	LastStatus        string        `json:"last_status,omitempty"`
+	Status            Status        `json:"status"`
+	CreatedAt         time.Time     `json:"created_at"`
+	UpdatedAt         time.Time     `json:"updated_at"`
+}
+
+// Status define el estado del schedule.
+type Status string
+
+const (
+	StatusActive    Status = "ACTIVE"
+	StatusPaused    Status = "PAUSED"
+	StatusCancelled Status = "CANCELLED"
+	StatusCompleted Status = "COMPLETED"
+)
+
+// MinInterval es el intervalo mínimo permitido entre ocurrencias.
+const MinInterval = time.Minute
+
+// cronParser acepta expresiones estándar de 5 campos y descriptores (@daily, @monthly).
+var cronParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// Rule define cuándo se repite un schedule: una expresión cron o un intervalo fijo.
+type Rule struct {
+	CronExpr string
+	Interval time.Duration
+}
+
+// NewSchedule crea un schedule activo cuya primera ocurrencia es la primera a partir de startAt.
+func NewSchedule(userID, providerID uuid.UUID, externalRef string, amount int64, currency string, rule Rule, startAt time.Time, endDate *time.Time) (*Schedule, error) {
+	// Reutiliza las validaciones del pago que se ejecutará en cada ocurrencia.
+	if _, err := payment.NewPayment(userID, providerID, externalRef, amount, currency); err != nil {
+		return nil, err
+	}
+	if err := rule.validate(); err != nil {
+		return nil, err
+	}
+	startAt = startAt.UTC()
+	now := time.Now().UTC()
+	s := &Schedule{
+		ID:                uuid.New(),
+		UserID:            userID,
+		ProviderID:        providerID,
+		ExternalReference: externalRef,
+		Amount:            amount,
+		Currency:          currency,
+		CronExpr:          rule.CronExpr,
+		Interval:          rule.Interval,
+		Status:            StatusActive,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	if err := s.setEndDate(endDate, startAt); err != nil {
+		return nil, err
+	}
+	s.NextRunAt = s.firstRunAt(startAt)
+	return s, nil
+}
+
+// Next retorna la ocurrencia siguiente estrictamente posterior a after.
+func (s *Schedule) Next(after time.Time) time.Time {
+	after = after.UTC()
+	if s.CronExpr != "" {
+		sched, err := cronParser.Parse(s.CronExpr)
+		if err != nil {
+			// La expresión se valida al crear/actualizar; no debería ocurrir.
+			return after.Add(MinInterval)
+		}
+		return sched.Next(after)
+	}
+	return after.Add(s.Interval)
+}
+
+// Advance registra la ejecución de occurrence y calcula la próxima ocurrencia posterior a now,
+// omitiendo ocurrencias perdidas (p. ej. si el scheduler estuvo caído). Si la regla se editó
+// durante la ejecución, NextRunAt ya apunta a la regla nueva y se parte de ahí.
+func (s *Schedule) Advance(occurrence, now time.Time, lastStatus string) {
+	ranAt := occurrence.UTC()
+	s.LastRunAt = &ranAt
+	s.LastStatus = lastStatus
+	next := s.NextRunAt
+	if !next.After(occurrence) {
+		next = s.Next(occurrence)
+	}
+	for !next.After(now) {
+		next = s.Next(next)
+	}
+	s.NextRunAt = next
+	if s.EndDate != nil && s.NextRunAt.After(*s.EndDate) {
+		s.Status = StatusCompleted
+	}
+	s.UpdatedAt = now.UTC()
+}
+
+// Update modifica los datos editables del schedule y recalcula la próxima ocurrencia si cambia la regla.
+func (s *Schedule) Update(externalRef string, amount int64, rule *Rule, endDate *time.Time, now time.Time) error {
+	if s.Status == StatusCancelled || s.Status == StatusCompleted {
+		return errors.NewValidationError("schedule can no longer be modified", map[string]interface{}{"status": s.Status})
+	}
+	if externalRef == "" {
+		externalRef = s.ExternalReference
+	}
+	if amount == 0 {
+		amount = s.Amount
+	}
+	if _, err := payment.NewPayment(s.UserID, s.ProviderID, externalRef, amount, s.Currency); err != nil {
+		return err
+	}
+	if rule != nil {
+		if err := rule.validate(); err != nil {
+			return err
+		}
+	}
+	if endDate != nil {
+		if err := s.setEndDate(endDate, now); err != nil {
+			return err
+		}
+	}
+	s.ExternalReference = externalRef
+	s.Amount = amount
+	if rule != nil {
+		s.CronExpr = rule.CronExpr
+		s.Interval = rule.Interval
+		s.NextRunAt = s.firstRunAt(now)
+	}
+	s.UpdatedAt = now.UTC()
+	return nil
+}
+
+// Pause suspende las ejecuciones hasta que se reanude.
+func (s *Schedule) Pause(now time.Time) error {
+	if s.Status != StatusActive {
+		return errors.NewValidationError("only active schedules can be paused", map[string]interface{}{"status": s.Status})
+	}
+	s.Status = StatusPaused
+	s.UpdatedAt = now.UTC()
+	return nil
+}
+
+// Resume reactiva un schedule pausado desde la próxima ocurrencia posterior a now.
+func (s *Schedule) Resume(now time.Time) error {
+	if s.Status != StatusPaused {
+		return errors.NewValidationError("only paused schedules can be resumed", map[string]interface{}{"status": s.Status})
+	}
+	s.Status = StatusActive
+	s.NextRunAt = s.Next(now)
+	s.UpdatedAt = now.UTC()
+	return nil
+}
+
+// Cancel da de baja el schedule de forma definitiva.
+func (s *Schedule) Cancel(now time.Time) error {
+	if s.Status == StatusCancelled {
+		return errors.NewValidationError("schedule already cancelled", nil)
+	}
+	s.Status = StatusCancelled
+	s.UpdatedAt = now.UTC()
+	return nil
+}
+
+// OccurrenceKey retorna la clave de idempotencia determinística de una ocurrencia.
+func (s *Schedule) OccurrenceKey(occurrence time.Time) string {
+	return "schedule:" + s.ID.String() + ":" + occurrence.UTC().Format(time.RFC3339)
+}
+
+func (s *Schedule) firstRunAt(from time.Time) time.Time {
+	if s.CronExpr != "" {
+		return s.Next(from.Add(-time.Second))
+	}
+	return from.UTC()
+}
+
+func (s *Schedule) setEndDate(endDate *time.Time, from time.Time) error {
+	if endDate == nil {
+		return nil
+	}
+	end := endDate.UTC()
+	if !end.After(from) {
+		return errors.NewValidationError("end_date must be in the future", map[string]interface{}{"end_date": end})
+	}
+	s.EndDate = &end
+	return nil
+}
+
+func (r Rule) validate() error {
+	if (r.CronExpr == "") == (r.Interval == 0) {
+		return errors.NewValidationError("exactly one of cron or interval is required", nil)
+	}
+	if r.CronExpr != "" {
+		if _, err := cronParser.Parse(r.CronExpr); err != nil {
+			return errors.NewValidationError("invalid cron expression", map[string]interface{}{"cron": r.CronExpr, "error": err.Error()})
+		}
+		return nil
+	}
+	if r.Interval < MinInterval {
+		return errors.NewValidationError("interval must be at least 1m", map[string]interface{}{"interval": r.Interval.String()})
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"draftea-challenge/internal/domain/errors"
+
+	"github.com/google/uuid"
+)
+
+func TestNewScheduleCronFirstRun(t *testing.T) {
+	start := time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)
+	s, err := NewSchedule(uuid.New(), uuid.New(), "bill-1", 1000, "USD", Rule{CronExpr: "0 9 1 * *"}, start, nil)
+	if err != nil {
+		t.Fatalf("new schedule: %v", err)
+	}
+	want := time.Date(2026, 2, 1, 9, 0, 0, 0, time.UTC)
+	if !s.NextRunAt.Equal(want) {
+		t.Fatalf("expected next run %s, got %s", want, s.NextRunAt)
+	}
+}
+
+func TestNewScheduleRequiresSingleRule(t *testing.T) {
+	_, err := NewSchedule(uuid.New(), uuid.New(), "bill-1", 1000, "USD", Rule{CronExpr: "@daily", Interval: time.Hour}, time.Now(), nil)
+	if domErr, ok := err.(errors.Error); !ok || domErr.Code != errors.CodeValidationError {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	_, err = NewSchedule(uuid.New(), uuid.New(), "bill-1", 1000, "USD", Rule{Interval: time.Second}, time.Now(), nil)
+	if err == nil {
+		t.Fatalf("expected interval validation error")
+	}
+}
+
+func TestAdvanceSkipsMissedOccurrencesAndCompletes(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(72 * time.Hour)
+	s, err := NewSchedule(uuid.New(), uuid.New(), "bill-1", 1000, "USD", Rule{Interval: 24 * time.Hour}, start, &end)
+	if err != nil {
+		t.Fatalf("new schedule: %v", err)
+	}
+
+	// El scheduler estuvo caído dos días: la próxima ocurrencia es la posterior a now.
+	now := start.Add(50 * time.Hour)
+	s.Advance(s.NextRunAt, now, "APPROVED")
+	if want := start.Add(72 * time.Hour); !s.NextRunAt.Equal(want) {
+		t.Fatalf("expected next run %s, got %s", want, s.NextRunAt)
+	}
+	if s.Status != StatusActive {
+		t.Fatalf("expected active schedule, got %s", s.Status)
+	}
+
+	s.Advance(s.NextRunAt, s.NextRunAt, "APPROVED")
+	if s.Status != StatusCompleted {
+		t.Fatalf("expected completed schedule, got %s", s.Status)
+	}
+}
+
+func TestAdvanceKeepsRuleEditedDuringRun(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s, err := NewSchedule(uuid.New(), uuid.New(), "bill-1", 1000, "USD", Rule{Interval: 24 * time.Hour}, start, nil)
+	if err != nil {
+		t.Fatalf("new schedule: %v", err)
+	}
+
+	// La regla cambia mientras corre la ocurrencia: la próxima ejecución sale de la regla nueva.
+	occurrence := s.NextRunAt
+	editedAt := start.Add(10 * time.Second)
+	if err := s.Update("", 0, &Rule{Interval: time.Hour}, nil, editedAt); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	s.Advance(occurrence, start.Add(time.Minute), "APPROVED")
+	if want := editedAt.Add(time.Hour); !s.NextRunAt.Equal(want) {
+		t.Fatalf("expected next run %s, got %s", want, s.NextRunAt)
+	}
+	if s.LastRunAt == nil || !s.LastRunAt.Equal(occurrence) {
+		t.Fatalf("expected last run %s, got %v", occurrence, s.LastRunAt)
+	}
+}
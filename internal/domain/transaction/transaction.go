@@ -9,16 +9,18 @@ import (
 
 // Transaction representa una transacción inmutable en el ledger.
 type Transaction struct {
-	ID                uuid.UUID `json:"id"`
-	UserID            uuid.UUID `json:"user_id"`
-	Type              Type      `json:"type"`
-	Amount            int64     `json:"amount"` // en minor units
-	Currency          string    `json:"currency"`
-	Status            Status    `json:"status"`
-	ProviderID        uuid.UUID `json:"provider_id,omitempty"`
-	ExternalReference string    `json:"external_reference,omitempty"`
-	CreatedAt         time.Time `json:"created_at"`
-	UpdatedAt         time.Time `json:"updated_at"`
+	ID                   uuid.UUID  `json:"id"`
+	UserID               uuid.UUID  `json:"user_id"`
+	Type                 Type       `json:"type"`
+	Amount               int64      `json:"amount"` // en minor units
+	Currency             string     `json:"currency"`
+	Status               Status     `json:"status"`
+	ProviderID           uuid.UUID  `json:"provider_id,omitempty"`
+	ExternalReference    string     `json:"external_reference,omitempty"`
+	ProviderName         string     `json:"provider_name,omitempty"`          // solo lectura, se completa en los listados
+	RelatedTransactionID *uuid.UUID `json:"related_transaction_id,omitempty"` // pago que originó la comisión o el reembolso
+	CreatedAt            time.Time  `json:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at"`
 }
 
 // Type define el tipo de transacción.
@@ -28,6 +30,7 @@ const (
 	TypePayment Type = "PAYMENT"
 	TypeRefund  Type = "REFUND"
 	TypeTopUp   Type = "TOP_UP"
+	TypeFee     Type = "FEE" // comisión cobrada junto con un pago
 )
 
 // Status define el estado de la transacción.
@@ -38,8 +41,20 @@ const (
 	StatusApproved Status = "APPROVED"
 	StatusDeclined Status = "DECLINED"
 	StatusFailed   Status = "FAILED"
+	StatusHeld     Status = "HELD" // retenida por screening de riesgo, pendiente de aprobación manual
 )
 
+// NewRelatedTransaction crea una transacción (comisión o reembolso) vinculada a parent.
+func NewRelatedTransaction(parent *Transaction, txType Type, amount int64) (*Transaction, error) {
+	tx, err := NewTransaction(parent.UserID, txType, amount, parent.Currency, parent.ProviderID, parent.ExternalReference)
+	if err != nil {
+		return nil, err
+	}
+	parentID := parent.ID
+	tx.RelatedTransactionID = &parentID
+	return tx, nil
+}
+
 // NewTransaction crea una nueva transacción.
 func NewTransaction(userID uuid.UUID, txType Type, amount int64, currency string, providerID uuid.UUID, externalRef string) (*Transaction, error) {
 	if userID == uuid.Nil {
@@ -67,9 +82,11 @@ func NewTransaction(userID uuid.UUID, txType Type, amount int64, currency string
 }
 
 // UpdateStatus actualiza el estado de la transacción (solo para cambios válidos).
+// HELD vuelve a PENDING cuando la revisión aprueba el pago y este sigue hacia la pasarela.
 func (t *Transaction) UpdateStatus(newStatus Status) error {
 	validTransitions := map[Status][]Status{
-		StatusPending:  {StatusApproved, StatusDeclined, StatusFailed},
+		StatusPending:  {StatusApproved, StatusDeclined, StatusFailed, StatusHeld},
+		StatusHeld:     {StatusPending, StatusApproved, StatusDeclined, StatusFailed},
 		StatusApproved: {},
 		StatusDeclined: {},
 		StatusFailed:   {},
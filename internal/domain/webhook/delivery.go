@@ -0,0 +1,31 @@
+package webhook
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DeliveryStatus representa el resultado de un intento de entrega.
+type DeliveryStatus string
+
+const (
+	DeliverySucceeded DeliveryStatus = "SUCCEEDED"
+	DeliveryFailed    DeliveryStatus = "FAILED"
+)
+
+// Delivery registra un intento de entrega de un evento a una suscripción.
+type Delivery struct {
+	ID             uuid.UUID      `json:"id"`
+	SubscriptionID uuid.UUID      `json:"subscription_id"`
+	EventID        uuid.UUID      `json:"event_id"`
+	EventType      string         `json:"event_type"`
+	Attempt        int            `json:"attempt"`
+	Status         DeliveryStatus `json:"status"`
+	ResponseStatus int            `json:"response_status,omitempty"` // 0 = sin respuesta HTTP
+	Error          string         `json:"error,omitempty"`
+	DurationMs     int64          `json:"duration_ms"`
+	Replay         bool           `json:"replay"`
+	NextAttemptAt  *time.Time     `json:"next_attempt_at,omitempty"` // reintento programado tras un fallo; nil si no quedan
+	CreatedAt      time.Time      `json:"created_at"`
+}
@@ -0,0 +1,133 @@
+package webhook
+
+import (
+	"crypto/rand"
+	"draftea-challenge/internal/domain/errors"
+	"encoding/hex"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// secretBytes define la longitud del secreto generado cuando no se informa uno.
+const secretBytes = 32
+
+// Subscription representa un endpoint de un merchant que recibe eventos de pagos.
+type Subscription struct {
+	ID                  uuid.UUID  `json:"id"`
+	URL                 string     `json:"url"`
+	EventTypes          []string   `json:"event_types"` // vacío = todos los eventos
+	Secret              string     `json:"-"`           // solo se expone al crear la suscripción
+	Enabled             bool       `json:"enabled"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// Attributes agrupa los campos editables de una suscripción.
+type Attributes struct {
+	URL        string
+	EventTypes []string
+	Enabled    bool
+}
+
+// NewSubscription crea una suscripción validando sus atributos; si secret está vacío se genera uno.
+func NewSubscription(attrs Attributes, secret string, now time.Time) (*Subscription, error) {
+	if strings.TrimSpace(secret) == "" {
+		generated, err := GenerateSecret()
+		if err != nil {
+			return nil, errors.NewInternalError("failed to generate webhook secret")
+		}
+		secret = generated
+	}
+	s := &Subscription{
+		ID:        uuid.New(),
+		Secret:    strings.TrimSpace(secret),
+		CreatedAt: now,
+	}
+	if err := s.apply(attrs, now); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Update reemplaza los atributos editables; al re-habilitar se reinicia el contador de fallos.
+func (s *Subscription) Update(attrs Attributes, now time.Time) error {
+	wasEnabled := s.Enabled
+	if err := s.apply(attrs, now); err != nil {
+		return err
+	}
+	if s.Enabled && !wasEnabled {
+		s.ConsecutiveFailures = 0
+		s.DisabledAt = nil
+	}
+	return nil
+}
+
+func (s *Subscription) apply(attrs Attributes, now time.Time) error {
+	details := make(map[string]interface{})
+	rawURL := strings.TrimSpace(attrs.URL)
+	u, err := url.Parse(rawURL)
+	if rawURL == "" || err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		details["url"] = attrs.URL
+	}
+	eventTypes := normalizeEventTypes(attrs.EventTypes)
+	for _, t := range eventTypes {
+		if strings.Contains(t, " ") || strings.HasPrefix(t, ".") || strings.HasSuffix(t, ".") {
+			details["event_types"] = attrs.EventTypes
+			break
+		}
+	}
+	if len(details) > 0 {
+		return errors.NewValidationError("invalid webhook subscription", details)
+	}
+
+	s.URL = rawURL
+	s.EventTypes = eventTypes
+	s.Enabled = attrs.Enabled
+	s.UpdatedAt = now
+	return nil
+}
+
+// Matches indica si la suscripción debe recibir el tipo de evento.
+// Acepta tipos exactos ("payment.completed"), comodín por prefijo ("payment.*") y "*".
+func (s *Subscription) Matches(eventType string) bool {
+	if len(s.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range s.EventTypes {
+		switch {
+		case t == "*" || t == eventType:
+			return true
+		case strings.HasSuffix(t, ".*") && strings.HasPrefix(eventType, strings.TrimSuffix(t, "*")):
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateSecret genera un secreto aleatorio para firmar las entregas.
+func GenerateSecret() (string, error) {
+	buf := make([]byte, secretBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+func normalizeEventTypes(in []string) []string {
+	out := make([]string, 0, len(in))
+	seen := make(map[string]bool, len(in))
+	for _, t := range in {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	return out
+}
@@ -0,0 +1,72 @@
+package webhook
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"draftea-challenge/internal/domain/errors"
+)
+
+func TestNewSubscriptionValidatesAndGeneratesSecret(t *testing.T) {
+	_, err := NewSubscription(Attributes{URL: "ftp://merchant.example", EventTypes: []string{"payment."}}, "", time.Now())
+	domErr, ok := err.(errors.Error)
+	if !ok || domErr.Code != errors.CodeValidationError {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	if _, ok := domErr.Details["url"]; !ok {
+		t.Fatalf("expected url detail, got %v", domErr.Details)
+	}
+	if _, ok := domErr.Details["event_types"]; !ok {
+		t.Fatalf("expected event_types detail, got %v", domErr.Details)
+	}
+
+	s, err := NewSubscription(Attributes{URL: "https://merchant.example/hook", EventTypes: []string{" Payment.* ", "payment.*"}, Enabled: true}, "", time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(s.Secret, "whsec_") {
+		t.Fatalf("expected generated secret, got %q", s.Secret)
+	}
+	if len(s.EventTypes) != 1 || s.EventTypes[0] != "payment.*" {
+		t.Fatalf("expected normalized event types, got %v", s.EventTypes)
+	}
+}
+
+func TestMatches(t *testing.T) {
+	cases := []struct {
+		filter    []string
+		eventType string
+		want      bool
+	}{
+		{nil, "payment.completed", true},
+		{[]string{"*"}, "topup.failed", true},
+		{[]string{"payment.*"}, "payment.completed", true},
+		{[]string{"payment.*"}, "payments.completed", false},
+		{[]string{"refund.created"}, "refund.created", true},
+		{[]string{"refund.created"}, "payment.completed", false},
+	}
+	for _, tc := range cases {
+		s := &Subscription{EventTypes: tc.filter}
+		if got := s.Matches(tc.eventType); got != tc.want {
+			t.Fatalf("Matches(%v, %q) = %v, want %v", tc.filter, tc.eventType, got, tc.want)
+		}
+	}
+}
+
+func TestUpdateReenablesAndResetsFailures(t *testing.T) {
+	now := time.Now()
+	s, err := NewSubscription(Attributes{URL: "https://merchant.example/hook", Enabled: true}, "secret", now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Estado tras el deshabilitado automático del worker.
+	s.Enabled, s.ConsecutiveFailures, s.DisabledAt = false, 2, &now
+
+	if err := s.Update(Attributes{URL: s.URL, Enabled: true}, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !s.Enabled || s.ConsecutiveFailures != 0 || s.DisabledAt != nil {
+		t.Fatalf("expected re-enabled subscription to reset failures, got %+v", s)
+	}
+}
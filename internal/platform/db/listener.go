@@ -0,0 +1,71 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"draftea-challenge/internal/platform/config"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// Listener holds a dedicated connection that LISTENs on a Postgres channel.
+type Listener struct {
+	dsn     string
+	channel string
+	log     *zap.Logger
+	retry   time.Duration
+}
+
+// NewListener creates a Listener for channel. It does not connect until Listen is called.
+func NewListener(cfg config.DBConfig, channel string, log *zap.Logger) *Listener {
+	return &Listener{dsn: DSN(cfg), channel: channel, log: log, retry: 5 * time.Second}
+}
+
+// Listen sends to wakeups on every notification until ctx is done, reconnecting after connection
+// errors. Sends never block: several notifications collapse into one pending wakeup. Delivery is
+// best effort, so consumers must keep polling as a fallback.
+func (l *Listener) Listen(ctx context.Context, wakeups chan<- struct{}) {
+	for {
+		err := l.listen(ctx, wakeups)
+		if ctx.Err() != nil {
+			return
+		}
+		l.log.Warn("postgres listener disconnected", zap.String("channel", l.channel), zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(l.retry):
+		}
+	}
+}
+
+func (l *Listener) listen(ctx context.Context, wakeups chan<- struct{}) error {
+	conn, err := pgx.Connect(ctx, l.dsn)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close(context.Background()) }()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{l.channel}.Sanitize()); err != nil {
+		return err
+	}
+	l.log.Info("postgres listener started", zap.String("channel", l.channel))
+	// Events inserted while disconnected were not notified.
+	notify(wakeups)
+
+	for {
+		if _, err := conn.WaitForNotification(ctx); err != nil {
+			return err
+		}
+		notify(wakeups)
+	}
+}
+
+func notify(wakeups chan<- struct{}) {
+	select {
+	case wakeups <- struct{}{}:
+	default:
+	}
+}
@@ -10,9 +10,9 @@ import (
 	"gorm.io/gorm"
 )
 
-// NewPostgres opens a Postgres connection using GORM.
-func NewPostgres(cfg config.DBConfig, log *zap.Logger) (*gorm.DB, func() error, error) {
-	dsn := fmt.Sprintf(
+// DSN builds the Postgres connection string for cfg.
+func DSN(cfg config.DBConfig) string {
+	return fmt.Sprintf(
 		"host=%s user=%s password=%s dbname=%s port=%d sslmode=%s",
 		cfg.Host,
 		cfg.User,
@@ -21,8 +21,11 @@ func NewPostgres(cfg config.DBConfig, log *zap.Logger) (*gorm.DB, func() error,
 		cfg.Port,
 		cfg.SSLMode,
 	)
+}
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+// NewPostgres opens a Postgres connection using GORM.
+func NewPostgres(cfg config.DBConfig, log *zap.Logger) (*gorm.DB, func() error, error) {
+	db, err := gorm.Open(postgres.Open(DSN(cfg)), &gorm.Config{})
 	if err != nil {
 		return nil, nil, err
 	}
@@ -1,12 +1,24 @@
 package factory
 
 import (
+	"fmt"
+	"time"
+
 	"draftea-challenge/internal/adapters/gateway/httpclient"
 	httpapi "draftea-challenge/internal/adapters/http"
 	"draftea-challenge/internal/adapters/http/handlers"
 	"draftea-challenge/internal/adapters/persistence/postgres"
+	"draftea-challenge/internal/adapters/webhook/httpsender"
+	"draftea-challenge/internal/application/batches"
+	"draftea-challenge/internal/application/callbacks"
+	"draftea-challenge/internal/application/notifications"
+	"draftea-challenge/internal/application/outbox"
 	"draftea-challenge/internal/application/payments"
+	"draftea-challenge/internal/application/providers"
+	"draftea-challenge/internal/application/schedules"
+	"draftea-challenge/internal/application/screening"
 	"draftea-challenge/internal/application/wallets"
+	"draftea-challenge/internal/application/webhooks"
 	"draftea-challenge/internal/platform/clock"
 	"draftea-challenge/internal/platform/config"
 	"draftea-challenge/internal/platform/db"
@@ -14,6 +26,7 @@ import (
 	"draftea-challenge/internal/platform/logger"
 	"draftea-challenge/internal/platform/server"
 
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
@@ -41,41 +54,72 @@ func Build(cfg config.Config) (*App, error) {
 	}
 
 	persistence := postgres.NewPostgresPersistence(dbConn)
-	gateway := httpclient.New(httpclient.Config{
-		BaseURL:                cfg.Gateway.URL,
-		Timeout:                cfg.Gateway.Timeout,
-		MaxRetries:             cfg.Gateway.MaxRetries,
-		RetryInitialBackoff:    cfg.Gateway.RetryInitialBackoff,
-		RetryMaxBackoff:        cfg.Gateway.RetryMaxBackoff,
-		CircuitBreakerFailures: cfg.Gateway.CircuitBreakerFailures,
-		CircuitBreakerCooldown: cfg.Gateway.CircuitBreakerCooldown,
-		MaxInFlight:            cfg.Gateway.MaxInFlight,
-	})
-
-	paymentService := payments.NewPaymentService(
+	paymentService, err := buildPaymentService(cfg, persistence)
+	if err != nil {
+		_ = dbCleanup()
+		_ = zapLogger.Sync()
+		return nil, err
+	}
+	balanceService := wallets.NewGetBalanceService(persistence)
+	transactionsService := wallets.NewGetTransactionsService(persistence)
+	fundingGateway, err := buildFundingGateway(cfg.Funding)
+	if err != nil {
+		_ = dbCleanup()
+		_ = zapLogger.Sync()
+		return nil, err
+	}
+	topUpService := wallets.NewTopUpService(
 		persistence,
 		persistence,
-		gateway,
+		fundingGateway,
+		persistence,
 		persistence,
 		persistence,
 		idgen.UUIDGenerator{},
 		clock.SystemClock{},
 	)
-	balanceService := wallets.NewGetBalanceService(persistence)
-	transactionsService := wallets.NewGetTransactionsService(persistence)
-	topUpService := wallets.NewTopUpService(persistence, persistence)
 	listService := wallets.NewListWalletsService(persistence)
 	createWalletService := wallets.NewCreateWalletService(persistence)
+	callbackService := callbacks.NewGatewayCallbackService(persistence, persistence, paymentService, topUpService, clock.SystemClock{}, callbacks.Config{
+		Secret:    cfg.Gateway.WebhookSecret,
+		Tolerance: cfg.Gateway.WebhookTolerance,
+	})
+	scheduleService := schedules.NewScheduleService(persistence, persistence, clock.SystemClock{})
+	providerService := providers.NewProviderService(persistence, clock.SystemClock{})
+	batchService := batches.NewBatchService(persistence, persistence, paymentService, clock.SystemClock{}, batches.Config{
+		MaxItems:    cfg.Batch.MaxItems,
+		Concurrency: cfg.Batch.Concurrency,
+	})
+	subscriptionService := webhooks.NewSubscriptionService(persistence, persistence, clock.SystemClock{})
+	webhookDispatcher := buildWebhookDispatcher(cfg.Webhooks, persistence)
+	deadLetterService := outbox.NewDeadLetterService(persistence)
+	preferencesService := notifications.NewPreferencesService(persistence, clock.SystemClock{})
 
 	paymentHandler := handlers.NewPaymentHandler(paymentService)
 	walletHandler := handlers.NewWalletHandler(balanceService, transactionsService, topUpService, listService, createWalletService)
+	reviewHandler := handlers.NewPaymentReviewHandler(paymentService)
+	scheduleHandler := handlers.NewScheduleHandler(scheduleService)
+	batchHandler := handlers.NewBatchHandler(batchService)
+	providerHandler := handlers.NewProviderHandler(providerService)
+	webhookHandler := handlers.NewGatewayWebhookHandler(callbackService)
+	subscriptionHandler := handlers.NewWebhookSubscriptionHandler(subscriptionService, webhookDispatcher)
+	outboxHandler := handlers.NewOutboxHandler(deadLetterService)
+	notificationHandler := handlers.NewNotificationPreferencesHandler(preferencesService)
 
 	router := httpapi.NewRouter(httpapi.RouterDeps{
-		Logger:         zapLogger,
-		APIKey:         cfg.App.APIKey,
-		RequestTimeout: cfg.App.RequestTimeout,
-		PaymentHandler: paymentHandler,
-		WalletHandler:  walletHandler,
+		Logger:              zapLogger,
+		APIKey:              cfg.App.APIKey,
+		RequestTimeout:      cfg.App.RequestTimeout,
+		PaymentHandler:      paymentHandler,
+		WalletHandler:       walletHandler,
+		ReviewHandler:       reviewHandler,
+		ScheduleHandler:     scheduleHandler,
+		BatchHandler:        batchHandler,
+		ProviderHandler:     providerHandler,
+		WebhookHandler:      webhookHandler,
+		SubscriptionHandler: subscriptionHandler,
+		OutboxHandler:       outboxHandler,
+		NotificationHandler: notificationHandler,
 	})
 
 	srv := server.New(cfg.App.HTTPAddr, router, cfg.App.ShutdownTimeout)
@@ -97,3 +141,97 @@ func Build(cfg config.Config) (*App, error) {
 		Cleanup: cleanup,
 	}, nil
 }
+
+// buildPaymentService wires the payment usecase shared by the API and workers.
+func buildPaymentService(cfg config.Config, persistence *postgres.PostgresPersistence) (*payments.PaymentService, error) {
+	gateway := httpclient.New(httpclient.Config{
+		BaseURL:                cfg.Gateway.URL,
+		Timeout:                cfg.Gateway.Timeout,
+		MaxRetries:             cfg.Gateway.MaxRetries,
+		RetryInitialBackoff:    cfg.Gateway.RetryInitialBackoff,
+		RetryMaxBackoff:        cfg.Gateway.RetryMaxBackoff,
+		CircuitBreakerFailures: cfg.Gateway.CircuitBreakerFailures,
+		CircuitBreakerCooldown: cfg.Gateway.CircuitBreakerCooldown,
+		MaxInFlight:            cfg.Gateway.MaxInFlight,
+	})
+
+	riskEvaluator, err := buildRiskEvaluator(cfg.Risk, persistence)
+	if err != nil {
+		return nil, err
+	}
+
+	return payments.NewPaymentService(
+		persistence,
+		persistence,
+		gateway,
+		persistence,
+		persistence,
+		persistence,
+		persistence,
+		persistence,
+		riskEvaluator,
+		persistence,
+		idgen.UUIDGenerator{},
+		clock.SystemClock{},
+	), nil
+}
+
+// buildWebhookDispatcher wires the merchant webhook delivery usecase shared by the API (replays) and the worker.
+func buildWebhookDispatcher(cfg config.WebhooksConfig, persistence *postgres.PostgresPersistence) *webhooks.Dispatcher {
+	return webhooks.NewDispatcher(
+		persistence,
+		persistence,
+		persistence,
+		httpsender.New(cfg.Timeout),
+		idgen.UUIDGenerator{},
+		clock.SystemClock{},
+		webhooks.Config{
+			MaxAttempts:    cfg.MaxAttempts,
+			InitialBackoff: cfg.InitialBackoff,
+			MaxBackoff:     cfg.MaxBackoff,
+			DisableAfter:   cfg.DisableAfter,
+			RetryBatchSize: cfg.RetryBatchSize,
+			// A claimed batch is sent sequentially, so its lease must outlast every request timing out.
+			RetryLease: time.Duration(cfg.RetryBatchSize) * cfg.Timeout,
+		},
+	)
+}
+
+// buildFundingGateway returns the funding gateway client, or nil in instant mode.
+func buildFundingGateway(cfg config.FundingConfig) (wallets.FundingGateway, error) {
+	switch cfg.Mode {
+	case "", "instant":
+		return nil, nil
+	case "gateway":
+		return httpclient.NewFunding(httpclient.Config{
+			BaseURL:    cfg.URL,
+			Timeout:    cfg.Timeout,
+			MaxRetries: cfg.MaxRetries,
+		}), nil
+	default:
+		return nil, fmt.Errorf("unknown funding mode %q", cfg.Mode)
+	}
+}
+
+// buildRiskEvaluator returns the rule engine when risk screening is enabled.
+func buildRiskEvaluator(cfg config.RiskConfig, history screening.HistoryRepository) (payments.RiskEvaluator, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	denied := make([]uuid.UUID, 0, len(cfg.DeniedProviderIDs))
+	for _, raw := range cfg.DeniedProviderIDs {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid risk denied provider id %q: %w", raw, err)
+		}
+		denied = append(denied, id)
+	}
+	return screening.NewRuleEngine(history, clock.SystemClock{}, screening.Config{
+		ReviewAmounts:            cfg.ReviewAmounts,
+		DenyAmounts:              cfg.DenyAmounts,
+		NewProviderReviewAmounts: cfg.NewProviderReviewAmounts,
+		BurstWindow:              cfg.BurstWindow,
+		BurstMaxPayments:         cfg.BurstMaxPayments,
+		DeniedProviderIDs:        denied,
+	}), nil
+}
@@ -0,0 +1,200 @@
+package factory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"draftea-challenge/internal/adapters/messaging"
+	"draftea-challenge/internal/adapters/messaging/kafka"
+	"draftea-challenge/internal/adapters/messaging/nats"
+	"draftea-challenge/internal/adapters/messaging/rabbitmq"
+	"draftea-challenge/internal/application/outbox"
+	"draftea-challenge/internal/platform/config"
+
+	"go.uber.org/zap"
+)
+
+// BuildPublisher returns the publisher of the configured broker.
+func BuildPublisher(cfg config.Config, log *zap.Logger) (outbox.MessagePublisher, func() error, error) {
+	switch brokerKind(cfg) {
+	case messaging.KindRabbit:
+		publisher, cleanup, err := rabbitmq.NewPublisher(RabbitConfig(cfg), log)
+		if err != nil {
+			return nil, nil, err
+		}
+		return publisher, cleanup, nil
+	case messaging.KindKafka:
+		publisher, cleanup, err := kafka.NewPublisher(KafkaConfig(cfg), log)
+		if err != nil {
+			return nil, nil, err
+		}
+		return publisher, cleanup, nil
+	case messaging.KindNATS:
+		publisher, cleanup, err := nats.NewPublisher(NATSConfig(cfg), log)
+		if err != nil {
+			return nil, nil, err
+		}
+		return publisher, cleanup, nil
+	default:
+		return nil, nil, unsupportedBroker(cfg)
+	}
+}
+
+// BuildConsumer returns a consumer of queue on the configured broker.
+func BuildConsumer(cfg config.Config, queue string, consumer config.ConsumerConfig, log *zap.Logger) (messaging.Consumer, func() error, error) {
+	switch brokerKind(cfg) {
+	case messaging.KindRabbit:
+		rabbitConsumer, cleanup, err := rabbitmq.NewConsumer(RabbitConfig(cfg), queue, rabbitmq.ConsumerOptions{
+			Prefetch:     consumer.Prefetch,
+			Workers:      consumer.Workers,
+			DrainTimeout: cfg.Rabbit.ConsumerDrainTimeout,
+		}, log)
+		if err != nil {
+			return nil, nil, err
+		}
+		return rabbitConsumer, cleanup, nil
+	case messaging.KindKafka:
+		kafkaConsumer, cleanup, err := kafka.NewConsumer(KafkaConfig(cfg), queue, kafka.ConsumerOptions{
+			Workers:      consumer.Workers,
+			DrainTimeout: cfg.Rabbit.ConsumerDrainTimeout,
+		}, log)
+		if err != nil {
+			return nil, nil, err
+		}
+		return kafkaConsumer, cleanup, nil
+	case messaging.KindNATS:
+		natsConsumer, cleanup, err := nats.NewConsumer(NATSConfig(cfg), queue, nats.ConsumerOptions{
+			Prefetch:     consumer.Prefetch,
+			Workers:      consumer.Workers,
+			DrainTimeout: cfg.Rabbit.ConsumerDrainTimeout,
+		}, log)
+		if err != nil {
+			return nil, nil, err
+		}
+		return natsConsumer, cleanup, nil
+	default:
+		return nil, nil, unsupportedBroker(cfg)
+	}
+}
+
+// ConnectConsumer builds a consumer of queue like BuildConsumer, retrying with backoff while the
+// broker is not reachable yet (rabbit.relay_max_retries, starting at rabbit.relay_initial_backoff and
+// doubling up to rabbit.relay_max_backoff).
+func ConnectConsumer(ctx context.Context, cfg config.Config, queue string, consumer config.ConsumerConfig, log *zap.Logger) (messaging.Consumer, func() error, error) {
+	attempts := cfg.Rabbit.RelayMaxRetries + 1
+	if attempts <= 0 {
+		attempts = 1
+	}
+	backoff := cfg.Rabbit.RelayInitialBackoff
+	if backoff <= 0 {
+		backoff = 200 * time.Millisecond
+	}
+	maxBackoff := cfg.Rabbit.RelayMaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = 2 * time.Second
+	}
+
+	var lastErr error
+	for i := 0; i < attempts; i++ {
+		c, cleanup, err := BuildConsumer(cfg, queue, consumer, log)
+		if err == nil {
+			return c, cleanup, nil
+		}
+		lastErr = err
+		log.Warn("consumer connect failed", zap.Error(err), zap.String("queue", queue))
+		if i == attempts-1 {
+			break
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+	return nil, nil, lastErr
+}
+
+// RabbitConfig maps the rabbit settings to the RabbitMQ adapter configuration.
+func RabbitConfig(cfg config.Config) rabbitmq.Config {
+	return rabbitmq.Config{
+		URL:                     cfg.Rabbit.URL,
+		Exchange:                cfg.Rabbit.Exchange,
+		MetricsQueue:            cfg.Rabbit.MetricsQueue,
+		AuditQueue:              cfg.Rabbit.AuditQueue,
+		WebhooksQueue:           cfg.Rabbit.WebhooksQueue,
+		NotificationsQueue:      cfg.Rabbit.NotificationsQueue,
+		PublishConfirmTimeout:   cfg.Rabbit.PublishConfirmTimeout,
+		CloudEventsMode:         cfg.Rabbit.CloudEventsMode,
+		CloudEventsSource:       cfg.Rabbit.CloudEventsSource,
+		ChannelPoolSize:         cfg.Rabbit.PublisherChannels,
+		ReconnectInitialBackoff: cfg.Rabbit.ReconnectInitialBackoff,
+		ReconnectMaxBackoff:     cfg.Rabbit.ReconnectMaxBackoff,
+		ConsumerMaxAttempts:     cfg.Rabbit.ConsumerMaxAttempts,
+		ConsumerRetryDelay:      cfg.Rabbit.ConsumerRetryDelay,
+	}
+}
+
+// IsRabbitBroker reports whether the configured broker is RabbitMQ, which the dead-letter replay needs.
+func IsRabbitBroker(cfg config.Config) bool {
+	return brokerKind(cfg) == messaging.KindRabbit
+}
+
+func brokerKind(cfg config.Config) string {
+	if cfg.Broker.Kind == "" {
+		return messaging.KindRabbit
+	}
+	return cfg.Broker.Kind
+}
+
+// KafkaConfig maps the kafka settings, plus the queues and consumer retries of the rabbit settings,
+// to the Kafka adapter configuration.
+func KafkaConfig(cfg config.Config) kafka.Config {
+	return kafka.Config{
+		Brokers:           cfg.Kafka.Brokers,
+		Topic:             cfg.Kafka.Topic,
+		Partitions:        cfg.Kafka.Partitions,
+		ReplicationFactor: cfg.Kafka.ReplicationFactor,
+		Bindings:          queueBindings(cfg),
+		Source:            cfg.Rabbit.CloudEventsSource,
+		MaxAttempts:       cfg.Rabbit.ConsumerMaxAttempts,
+		RetryDelay:        cfg.Rabbit.ConsumerRetryDelay,
+	}
+}
+
+// NATSConfig maps the nats settings, plus the queues and consumer retries of the rabbit settings,
+// to the NATS adapter configuration.
+func NATSConfig(cfg config.Config) nats.Config {
+	return nats.Config{
+		URL:            cfg.NATS.URL,
+		Stream:         cfg.NATS.Stream,
+		SubjectPrefix:  cfg.NATS.SubjectPrefix,
+		Bindings:       queueBindings(cfg),
+		Source:         cfg.Rabbit.CloudEventsSource,
+		PublishTimeout: cfg.NATS.PublishTimeout,
+		MaxAttempts:    cfg.Rabbit.ConsumerMaxAttempts,
+		RetryDelay:     cfg.Rabbit.ConsumerRetryDelay,
+	}
+}
+
+func queueBindings(cfg config.Config) []messaging.Binding {
+	return messaging.Bindings(messaging.Queues{
+		Metrics:       cfg.Rabbit.MetricsQueue,
+		Audit:         cfg.Rabbit.AuditQueue,
+		Webhooks:      cfg.Rabbit.WebhooksQueue,
+		Notifications: cfg.Rabbit.NotificationsQueue,
+	})
+}
+
+// unsupportedBroker reports a broker kind with no adapter. The in-memory broker is rejected too: the
+// relay and the consumers run as separate processes, so events published to it would be lost.
+func unsupportedBroker(cfg config.Config) error {
+	if brokerKind(cfg) == "memory" {
+		return fmt.Errorf("broker %q only works inside one process; build memory.NewBroker directly in tests", brokerKind(cfg))
+	}
+	return fmt.Errorf("unknown broker %q (want %s, %s or %s)", brokerKind(cfg), messaging.KindRabbit, messaging.KindKafka, messaging.KindNATS)
+}
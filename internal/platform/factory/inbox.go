@@ -0,0 +1,31 @@
+package factory
+
+import (
+	"context"
+	"time"
+
+	"draftea-challenge/internal/application/inbox"
+	"draftea-challenge/internal/platform/config"
+
+	"go.uber.org/zap"
+)
+
+// RunInboxRetention purges processed message IDs older than inbox.retention every
+// inbox.retention_interval until ctx is done. Callers start it only when both are set.
+func RunInboxRetention[R any](ctx context.Context, in *inbox.Inbox[R], cfg config.InboxConfig, log *zap.Logger) {
+	ticker := time.NewTicker(cfg.RetentionInterval)
+	defer ticker.Stop()
+	for {
+		purged, err := in.Purge(ctx, cfg.Retention)
+		if err != nil && ctx.Err() == nil {
+			log.Error("inbox retention error", zap.Error(err), zap.Int("purged", purged))
+		} else if purged > 0 {
+			log.Info("inbox retention", zap.Int("purged", purged))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
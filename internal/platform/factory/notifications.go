@@ -0,0 +1,97 @@
+package factory
+
+import (
+	"fmt"
+
+	"draftea-challenge/internal/adapters/notifier/filesink"
+	"draftea-challenge/internal/adapters/notifier/smtpsender"
+	"draftea-challenge/internal/adapters/persistence/postgres"
+	"draftea-challenge/internal/application/inbox"
+	"draftea-challenge/internal/application/notifications"
+	"draftea-challenge/internal/platform/clock"
+	"draftea-challenge/internal/platform/config"
+	"draftea-challenge/internal/platform/db"
+	"draftea-challenge/internal/platform/logger"
+
+	"go.uber.org/zap"
+)
+
+// NotificationWorker bundles the user notifications worker components.
+type NotificationWorker struct {
+	Dispatcher *notifications.Dispatcher
+	Inbox      *inbox.Inbox[*postgres.PostgresPersistence]
+	Logger     *zap.Logger
+	Cleanup    func() error
+}
+
+// BuildNotificationWorker wires the notifications worker with the configured notifier.
+func BuildNotificationWorker(cfg config.Config) (*NotificationWorker, error) {
+	templates, err := notifications.NewTemplates(cfg.Notifications.DefaultLocale)
+	if err != nil {
+		return nil, err
+	}
+	notifier, err := buildNotifier(cfg.Notifications)
+	if err != nil {
+		return nil, err
+	}
+
+	zapLogger, err := logger.New(logger.Config{
+		Level:       cfg.Logger.Level,
+		Development: cfg.Logger.Development,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dbConn, dbCleanup, err := db.NewPostgres(cfg.DB, zapLogger)
+	if err != nil {
+		_ = zapLogger.Sync()
+		return nil, err
+	}
+
+	persistence := postgres.NewPostgresPersistence(dbConn)
+
+	cleanup := func() error {
+		var firstErr error
+		if err := dbCleanup(); err != nil {
+			firstErr = err
+		}
+		if err := zapLogger.Sync(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		return firstErr
+	}
+
+	return &NotificationWorker{
+		Dispatcher: notifications.NewDispatcher(persistence, notifier, templates),
+		Inbox:      inbox.NewInbox[*postgres.PostgresPersistence](persistence, clock.SystemClock{}, cfg.Rabbit.NotificationsQueue),
+		Logger:     zapLogger,
+		Cleanup:    cleanup,
+	}, nil
+}
+
+func buildNotifier(cfg config.NotificationsConfig) (notifications.Notifier, error) {
+	switch cfg.Sink {
+	case "file":
+		sink, err := filesink.New(cfg.FilePath)
+		if err != nil {
+			return nil, err
+		}
+		return sink, nil
+	case "smtp":
+		sender, err := smtpsender.New(smtpsender.Config{
+			Host:     cfg.SMTP.Host,
+			Port:     cfg.SMTP.Port,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+			From:     cfg.SMTP.From,
+			Timeout:  cfg.SMTP.Timeout,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return sender, nil
+	default:
+		return nil, fmt.Errorf("unknown notifications sink %q (want file or smtp)", cfg.Sink)
+	}
+}
@@ -0,0 +1,67 @@
+package factory
+
+import (
+	"draftea-challenge/internal/adapters/persistence/postgres"
+	"draftea-challenge/internal/application/schedules"
+	"draftea-challenge/internal/platform/clock"
+	"draftea-challenge/internal/platform/config"
+	"draftea-challenge/internal/platform/db"
+	"draftea-challenge/internal/platform/idgen"
+	"draftea-challenge/internal/platform/logger"
+
+	"go.uber.org/zap"
+)
+
+// Scheduler bundles the scheduled payments worker components.
+type Scheduler struct {
+	Runner  *schedules.Runner
+	Logger  *zap.Logger
+	Cleanup func() error
+}
+
+// BuildScheduler wires the scheduler worker with concrete dependencies.
+func BuildScheduler(cfg config.Config) (*Scheduler, error) {
+	zapLogger, err := logger.New(logger.Config{
+		Level:       cfg.Logger.Level,
+		Development: cfg.Logger.Development,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dbConn, dbCleanup, err := db.NewPostgres(cfg.DB, zapLogger)
+	if err != nil {
+		_ = zapLogger.Sync()
+		return nil, err
+	}
+
+	persistence := postgres.NewPostgresPersistence(dbConn)
+	paymentService, err := buildPaymentService(cfg, persistence)
+	if err != nil {
+		_ = dbCleanup()
+		_ = zapLogger.Sync()
+		return nil, err
+	}
+
+	runner := schedules.NewRunner(persistence, paymentService, persistence, idgen.UUIDGenerator{}, clock.SystemClock{}, schedules.RunnerConfig{
+		BatchSize: cfg.Scheduler.BatchSize,
+		Lease:     cfg.Scheduler.Lease,
+	})
+
+	cleanup := func() error {
+		var firstErr error
+		if err := dbCleanup(); err != nil {
+			firstErr = err
+		}
+		if err := zapLogger.Sync(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		return firstErr
+	}
+
+	return &Scheduler{
+		Runner:  runner,
+		Logger:  zapLogger,
+		Cleanup: cleanup,
+	}, nil
+}
@@ -0,0 +1,54 @@
+package factory
+
+import (
+	"draftea-challenge/internal/adapters/persistence/postgres"
+	"draftea-challenge/internal/application/webhooks"
+	"draftea-challenge/internal/platform/config"
+	"draftea-challenge/internal/platform/db"
+	"draftea-challenge/internal/platform/logger"
+
+	"go.uber.org/zap"
+)
+
+// WebhookWorker bundles the merchant webhook delivery worker components.
+type WebhookWorker struct {
+	Dispatcher *webhooks.Dispatcher
+	Logger     *zap.Logger
+	Cleanup    func() error
+}
+
+// BuildWebhookWorker wires the webhook delivery worker with concrete dependencies.
+func BuildWebhookWorker(cfg config.Config) (*WebhookWorker, error) {
+	zapLogger, err := logger.New(logger.Config{
+		Level:       cfg.Logger.Level,
+		Development: cfg.Logger.Development,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dbConn, dbCleanup, err := db.NewPostgres(cfg.DB, zapLogger)
+	if err != nil {
+		_ = zapLogger.Sync()
+		return nil, err
+	}
+
+	persistence := postgres.NewPostgresPersistence(dbConn)
+
+	cleanup := func() error {
+		var firstErr error
+		if err := dbCleanup(); err != nil {
+			firstErr = err
+		}
+		if err := zapLogger.Sync(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		return firstErr
+	}
+
+	return &WebhookWorker{
+		Dispatcher: buildWebhookDispatcher(cfg.Webhooks, persistence),
+		Logger:     zapLogger,
+		Cleanup:    cleanup,
+	}, nil
+}
@@ -0,0 +1,27 @@
+package signature
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Sign returns HMAC-SHA256(secret, timestamp + "." + body). Outgoing webhooks and gateway
+// callbacks carry its hex value in their signature header.
+func Sign(secret []byte, timestamp string, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// Verify reports whether the hex signature matches Sign(secret, timestamp, body), comparing in
+// constant time.
+func Verify(secret []byte, timestamp string, body []byte, signature string) bool {
+	decoded, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(decoded, Sign(secret, timestamp, body))
+}
@@ -0,0 +1,5 @@
+-- 0006_spending_limits.down.sql
+-- Remove spending limits and the usage index.
+
+DROP INDEX IF EXISTS idx_transactions_user_currency_created;
+DROP TABLE IF EXISTS spending_limits;
@@ -0,0 +1,26 @@
+-- 0006_spending_limits.up.sql
+-- Per-wallet and default (user_id NULL) spending limits. A value of 0 means "no limit".
+
+CREATE TABLE IF NOT EXISTS spending_limits (
+  id VARCHAR(36) PRIMARY KEY,
+  user_id VARCHAR(36),
+  currency VARCHAR(8) NOT NULL,
+  max_single_payment BIGINT NOT NULL DEFAULT 0,
+  daily_spend BIGINT NOT NULL DEFAULT 0,
+  monthly_spend BIGINT NOT NULL DEFAULT 0,
+  max_payments_per_hour INT NOT NULL DEFAULT 0,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  CONSTRAINT chk_spending_limits_non_negative CHECK (
+    max_single_payment >= 0 AND daily_spend >= 0 AND monthly_spend >= 0 AND max_payments_per_hour >= 0
+  )
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_spending_limits_user_currency
+  ON spending_limits(user_id, currency) WHERE user_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_spending_limits_default_currency
+  ON spending_limits(currency) WHERE user_id IS NULL;
+
+-- Usage lookups filter payments by user, currency and creation time.
+CREATE INDEX IF NOT EXISTS idx_transactions_user_currency_created
+  ON transactions(user_id, currency, created_at);
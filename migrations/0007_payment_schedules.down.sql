@@ -0,0 +1,4 @@
+-- 0007_payment_schedules.down.sql
+-- Remove scheduled payments.
+
+DROP TABLE IF EXISTS payment_schedules;
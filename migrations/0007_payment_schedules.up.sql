@@ -0,0 +1,28 @@
+-- 0007_payment_schedules.up.sql
+-- Scheduled and recurring payments executed by the scheduler worker.
+
+CREATE TABLE IF NOT EXISTS payment_schedules (
+  id VARCHAR(36) PRIMARY KEY,
+  wallet_id VARCHAR(36) NOT NULL,
+  user_id VARCHAR(36) NOT NULL,
+  provider_id VARCHAR(36) NOT NULL,
+  external_reference TEXT NOT NULL,
+  amount BIGINT NOT NULL CHECK (amount > 0),
+  currency VARCHAR(8) NOT NULL,
+  cron_expr VARCHAR(128) NOT NULL DEFAULT '',
+  interval_seconds BIGINT NOT NULL DEFAULT 0,
+  end_date TIMESTAMPTZ,
+  next_run_at TIMESTAMPTZ NOT NULL,
+  last_run_at TIMESTAMPTZ,
+  last_status VARCHAR(32) NOT NULL DEFAULT '',
+  status VARCHAR(16) NOT NULL,
+  locked_until TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  CONSTRAINT fk_payment_schedules_wallet FOREIGN KEY (wallet_id) REFERENCES wallets(id) ON DELETE CASCADE,
+  CONSTRAINT chk_payment_schedules_rule CHECK ((cron_expr <> '') <> (interval_seconds > 0))
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_schedules_user ON payment_schedules(user_id);
+-- Scheduler claims scan only active schedules ordered by due time.
+CREATE INDEX IF NOT EXISTS idx_payment_schedules_due ON payment_schedules(next_run_at) WHERE status = 'ACTIVE';
@@ -0,0 +1,5 @@
+-- 0008_payment_batches.down.sql
+-- Remove batch payment submissions.
+
+DROP TABLE IF EXISTS payment_batch_items;
+DROP TABLE IF EXISTS payment_batches;
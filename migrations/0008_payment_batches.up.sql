@@ -0,0 +1,38 @@
+-- 0008_payment_batches.up.sql
+-- Batch payment submissions and their per-item results.
+
+CREATE TABLE IF NOT EXISTS payment_batches (
+  id VARCHAR(36) PRIMARY KEY,
+  wallet_id VARCHAR(36) NOT NULL,
+  user_id VARCHAR(36) NOT NULL,
+  idempotency_key TEXT,
+  status VARCHAR(16) NOT NULL,
+  total_items INT NOT NULL CHECK (total_items > 0),
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  completed_at TIMESTAMPTZ,
+  CONSTRAINT fk_payment_batches_wallet FOREIGN KEY (wallet_id) REFERENCES wallets(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_batches_user ON payment_batches(user_id);
+-- Resubmitting a batch with the same Idempotency-Key resumes the original batch.
+CREATE UNIQUE INDEX IF NOT EXISTS uq_payment_batches_user_key
+  ON payment_batches(user_id, idempotency_key)
+  WHERE idempotency_key IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS payment_batch_items (
+  batch_id VARCHAR(36) NOT NULL,
+  item_index INT NOT NULL,
+  provider_id VARCHAR(36) NOT NULL,
+  external_reference TEXT NOT NULL DEFAULT '',
+  amount BIGINT NOT NULL,
+  currency VARCHAR(8) NOT NULL DEFAULT '',
+  idempotency_key TEXT NOT NULL,
+  status VARCHAR(32) NOT NULL,
+  transaction_id VARCHAR(36),
+  error_code VARCHAR(64) NOT NULL DEFAULT '',
+  error_message TEXT NOT NULL DEFAULT '',
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (batch_id, item_index),
+  CONSTRAINT fk_payment_batch_items_batch FOREIGN KEY (batch_id) REFERENCES payment_batches(id) ON DELETE CASCADE
+);
@@ -0,0 +1,4 @@
+-- 0009_providers.down.sql
+-- Remove the provider catalog.
+
+DROP TABLE IF EXISTS providers;
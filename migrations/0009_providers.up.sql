@@ -0,0 +1,21 @@
+-- 0009_providers.up.sql
+-- Provider (biller) catalog used to validate payments.
+
+CREATE TABLE IF NOT EXISTS providers (
+  id VARCHAR(36) PRIMARY KEY,
+  name VARCHAR(128) NOT NULL,
+  currencies JSONB NOT NULL DEFAULT '[]',
+  min_amount BIGINT NOT NULL DEFAULT 0 CHECK (min_amount >= 0),
+  max_amount BIGINT NOT NULL DEFAULT 0 CHECK (max_amount >= 0),
+  reference_pattern TEXT NOT NULL DEFAULT '',
+  enabled BOOLEAN NOT NULL DEFAULT TRUE,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Providers referenced by the seed data and the Postman collection.
+INSERT INTO providers (id, name, currencies, min_amount, max_amount, reference_pattern, enabled)
+VALUES
+  ('3fa85f64-5717-4562-b3fc-2c963f66afa6', 'Demo Utilities', '["USD","MXN"]', 0, 0, '', TRUE),
+  ('99999999-9999-9999-9999-999999999999', 'Seed Provider', '["USD"]', 0, 0, '', TRUE)
+ON CONFLICT DO NOTHING;
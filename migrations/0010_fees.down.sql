@@ -0,0 +1,5 @@
+-- Remove fee policies and the related transaction link.
+
+DROP INDEX IF EXISTS idx_transactions_related_transaction_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS related_transaction_id;
+DROP TABLE IF EXISTS fee_policies;
@@ -0,0 +1,27 @@
+-- Fee policies (fixed + percentage with optional caps) and the link between
+-- FEE/REFUND transactions and the payment they belong to.
+
+CREATE TABLE IF NOT EXISTS fee_policies (
+  id VARCHAR(36) PRIMARY KEY,
+  provider_id VARCHAR(36),
+  currency VARCHAR(8) NOT NULL,
+  fixed_amount BIGINT NOT NULL DEFAULT 0,
+  percentage_bps BIGINT NOT NULL DEFAULT 0,
+  min_fee BIGINT NOT NULL DEFAULT 0,
+  max_fee BIGINT NOT NULL DEFAULT 0,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  CONSTRAINT chk_fee_policies_values CHECK (
+    fixed_amount >= 0 AND percentage_bps BETWEEN 0 AND 10000 AND min_fee >= 0 AND max_fee >= 0
+  )
+);
+
+-- Provider-specific policies take precedence over the currency default (provider_id NULL).
+CREATE UNIQUE INDEX IF NOT EXISTS uq_fee_policies_provider_currency
+  ON fee_policies(provider_id, currency) WHERE provider_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_fee_policies_default_currency
+  ON fee_policies(currency) WHERE provider_id IS NULL;
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS related_transaction_id VARCHAR(36);
+CREATE INDEX IF NOT EXISTS idx_transactions_related_transaction_id
+  ON transactions(related_transaction_id);
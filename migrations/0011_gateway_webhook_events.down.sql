@@ -0,0 +1,3 @@
+-- Remove the gateway webhook dedupe table.
+
+DROP TABLE IF EXISTS gateway_webhook_events;
@@ -0,0 +1,11 @@
+-- Gateway webhook event IDs already processed, used to drop redelivered callbacks.
+
+CREATE TABLE IF NOT EXISTS gateway_webhook_events (
+  event_id VARCHAR(128) PRIMARY KEY,
+  transaction_id VARCHAR(36) NOT NULL,
+  status VARCHAR(32) NOT NULL,
+  received_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_gateway_webhook_events_transaction_id
+  ON gateway_webhook_events(transaction_id);
@@ -0,0 +1,4 @@
+-- Remove merchant webhook subscriptions and their delivery log.
+
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_subscriptions;
@@ -0,0 +1,42 @@
+-- 0012_webhook_subscriptions.up.sql
+-- Merchant webhook subscriptions and the log of every delivery attempt.
+
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+  id VARCHAR(36) PRIMARY KEY,
+  url TEXT NOT NULL,
+  event_types JSONB NOT NULL DEFAULT '[]',
+  secret VARCHAR(128) NOT NULL,
+  enabled BOOLEAN NOT NULL DEFAULT TRUE,
+  consecutive_failures INT NOT NULL DEFAULT 0 CHECK (consecutive_failures >= 0),
+  disabled_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_enabled
+  ON webhook_subscriptions(enabled);
+
+-- Deliveries keep no foreign key so the history survives a deleted subscription.
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+  id VARCHAR(36) PRIMARY KEY,
+  subscription_id VARCHAR(36) NOT NULL,
+  event_id VARCHAR(36) NOT NULL,
+  event_type VARCHAR(128) NOT NULL,
+  attempt INT NOT NULL CHECK (attempt > 0),
+  status VARCHAR(16) NOT NULL,
+  response_status INT NOT NULL DEFAULT 0,
+  error TEXT NOT NULL DEFAULT '',
+  duration_ms BIGINT NOT NULL DEFAULT 0,
+  replay BOOLEAN NOT NULL DEFAULT FALSE,
+  -- When the next retry of a failed delivery is due; cleared once it is sent or dropped.
+  next_attempt_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription_event
+  ON webhook_deliveries(subscription_id, event_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription_created_at
+  ON webhook_deliveries(subscription_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_next_attempt_at
+  ON webhook_deliveries(next_attempt_at)
+  WHERE next_attempt_at IS NOT NULL;
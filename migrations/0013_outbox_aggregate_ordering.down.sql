@@ -0,0 +1,5 @@
+-- Remove per-aggregate ordering columns from the outbox.
+
+DROP INDEX IF EXISTS idx_outbox_aggregate_sequence;
+ALTER TABLE outbox DROP COLUMN IF EXISTS sequence;
+ALTER TABLE outbox DROP COLUMN IF EXISTS aggregate_id;
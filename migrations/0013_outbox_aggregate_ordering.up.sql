@@ -0,0 +1,19 @@
+-- 0013_outbox_aggregate_ordering.up.sql
+-- Per-aggregate ordering for the outbox relay: aggregate_id plus a sequence within the aggregate.
+
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS aggregate_id VARCHAR(36);
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS sequence BIGINT NOT NULL DEFAULT 0;
+
+-- Backfill rows written with the versioned event envelope.
+UPDATE outbox o
+SET aggregate_id = s.aggregate_id, sequence = s.seq
+FROM (
+  SELECT id,
+         payload->>'aggregate_id' AS aggregate_id,
+         ROW_NUMBER() OVER (PARTITION BY payload->>'aggregate_id' ORDER BY created_at, id) AS seq
+  FROM outbox
+  WHERE payload ? 'aggregate_id'
+) s
+WHERE o.id = s.id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_outbox_aggregate_sequence ON outbox (aggregate_id, sequence);
@@ -0,0 +1,5 @@
+-- Remove outbox claim columns.
+
+DROP INDEX IF EXISTS idx_outbox_pending;
+ALTER TABLE outbox DROP COLUMN IF EXISTS locked_until;
+ALTER TABLE outbox DROP COLUMN IF EXISTS locked_by;
@@ -0,0 +1,7 @@
+-- 0014_outbox_claims.up.sql
+-- Lease columns so several relay replicas can claim disjoint outbox batches.
+
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS locked_by VARCHAR(128);
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS locked_until TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox (created_at, sequence) WHERE sent_at IS NULL;
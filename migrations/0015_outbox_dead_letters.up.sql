@@ -0,0 +1,11 @@
+-- 0015_outbox_dead_letters.up.sql
+-- Publish attempt tracking and dead letter state for outbox events.
+
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS last_error TEXT;
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMPTZ;
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS dead_lettered_at TIMESTAMPTZ;
+
+DROP INDEX IF EXISTS idx_outbox_pending;
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox (created_at, sequence) WHERE sent_at IS NULL AND dead_lettered_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_dead_letters ON outbox (dead_lettered_at DESC) WHERE sent_at IS NULL AND dead_lettered_at IS NOT NULL;
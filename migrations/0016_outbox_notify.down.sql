@@ -0,0 +1,4 @@
+-- Remove the outbox insert notification.
+
+DROP TRIGGER IF EXISTS outbox_notify_insert ON outbox;
+DROP FUNCTION IF EXISTS notify_outbox_insert();
@@ -0,0 +1,15 @@
+-- 0016_outbox_notify.up.sql
+-- Wake relays with a NOTIFY on outbox inserts (delivered on commit) instead of waiting for the next poll.
+
+CREATE OR REPLACE FUNCTION notify_outbox_insert() RETURNS trigger AS $$
+BEGIN
+  PERFORM pg_notify('outbox_events', '');
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS outbox_notify_insert ON outbox;
+CREATE TRIGGER outbox_notify_insert
+  AFTER INSERT ON outbox
+  FOR EACH STATEMENT
+  EXECUTE FUNCTION notify_outbox_insert();
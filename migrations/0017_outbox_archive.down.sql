@@ -0,0 +1,4 @@
+-- Drop the outbox archive. Archived events are lost; move them back to outbox first if needed.
+
+DROP INDEX IF EXISTS idx_outbox_sent_at;
+DROP TABLE IF EXISTS outbox_archive;
@@ -0,0 +1,20 @@
+-- 0017_outbox_archive.up.sql
+-- Archive table for sent outbox events, filled by the retention job in cmd/relay.
+
+CREATE TABLE IF NOT EXISTS outbox_archive (
+  id VARCHAR(36) PRIMARY KEY,
+  event_type VARCHAR(128) NOT NULL,
+  payload JSONB NOT NULL,
+  aggregate_id VARCHAR(36),
+  sequence BIGINT NOT NULL DEFAULT 0,
+  attempts INT NOT NULL DEFAULT 0,
+  created_at TIMESTAMPTZ NOT NULL,
+  sent_at TIMESTAMPTZ NOT NULL,
+  archived_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_archive_aggregate_sequence ON outbox_archive (aggregate_id, sequence);
+CREATE INDEX IF NOT EXISTS idx_outbox_archive_sent_at ON outbox_archive (sent_at);
+
+-- The retention job scans sent rows by age; pending rows are covered by idx_outbox_pending.
+CREATE INDEX IF NOT EXISTS idx_outbox_sent_at ON outbox (sent_at) WHERE sent_at IS NOT NULL;
@@ -0,0 +1,3 @@
+-- Drop the hourly payment KPI rollups.
+
+DROP TABLE IF EXISTS payment_metrics_hourly;
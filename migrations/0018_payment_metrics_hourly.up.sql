@@ -0,0 +1,14 @@
+-- 0018_payment_metrics_hourly.up.sql
+-- Hourly payment KPI rollups per status, currency and provider, maintained by the metrics consumer.
+
+CREATE TABLE IF NOT EXISTS payment_metrics_hourly (
+  bucket_start TIMESTAMPTZ NOT NULL,
+  status VARCHAR(32) NOT NULL,
+  currency VARCHAR(8) NOT NULL,
+  provider_id VARCHAR(36) NOT NULL,
+  count BIGINT NOT NULL DEFAULT 0,
+  amount BIGINT NOT NULL DEFAULT 0,
+  fee BIGINT NOT NULL DEFAULT 0,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (bucket_start, status, currency, provider_id)
+);
@@ -0,0 +1,39 @@
+-- 0019_audit_log.up.sql
+-- Append-only audit log of payment and refund events, chained by SHA-256 (see `consumer verify-audit`).
+
+CREATE TABLE IF NOT EXISTS audit_log (
+  seq BIGSERIAL PRIMARY KEY,
+  event_id VARCHAR(36) NOT NULL,
+  event_type VARCHAR(128) NOT NULL,
+  aggregate_type VARCHAR(32) NOT NULL,
+  aggregate_id VARCHAR(36) NOT NULL,
+  occurred_at TIMESTAMPTZ NOT NULL,
+  recorded_at TIMESTAMPTZ NOT NULL,
+  payload TEXT NOT NULL,
+  prev_hash CHAR(64) NOT NULL,
+  hash CHAR(64) NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_log_event_id ON audit_log (event_id);
+-- A hash can only be extended once, so concurrent writers cannot fork the chain.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_log_prev_hash ON audit_log (prev_hash);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_log_hash ON audit_log (hash);
+CREATE INDEX IF NOT EXISTS idx_audit_log_aggregate_id ON audit_log (aggregate_id);
+
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_log_no_update ON audit_log;
+CREATE TRIGGER audit_log_no_update
+  BEFORE UPDATE OR DELETE ON audit_log
+  FOR EACH ROW
+  EXECUTE FUNCTION audit_log_append_only();
+
+DROP TRIGGER IF EXISTS audit_log_no_truncate ON audit_log;
+CREATE TRIGGER audit_log_no_truncate
+  BEFORE TRUNCATE ON audit_log
+  FOR EACH STATEMENT
+  EXECUTE FUNCTION audit_log_append_only();
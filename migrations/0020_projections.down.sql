@@ -0,0 +1,7 @@
+-- Drop projection checkpoints, the purge horizon, the daily spend read model and the history indexes.
+
+DROP INDEX IF EXISTS idx_outbox_archive_created_at_id;
+DROP INDEX IF EXISTS idx_outbox_created_at_id;
+DROP TABLE IF EXISTS user_daily_spend;
+DROP TABLE IF EXISTS outbox_purge_horizon;
+DROP TABLE IF EXISTS projection_checkpoints;
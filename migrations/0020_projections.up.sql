@@ -0,0 +1,36 @@
+-- 0020_projections.up.sql
+-- Projection checkpoints over the outbox history and the per-user daily spend read model.
+
+CREATE TABLE IF NOT EXISTS projection_checkpoints (
+  name VARCHAR(64) PRIMARY KEY,
+  position_at TIMESTAMPTZ NOT NULL,
+  position_event_id VARCHAR(36) NOT NULL DEFAULT '',
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Newest outbox history position deleted by the archive purge. Projections refuse to rebuild
+-- from an earlier position because the events after it are no longer complete.
+CREATE TABLE IF NOT EXISTS outbox_purge_horizon (
+  id INT PRIMARY KEY CHECK (id = 1),
+  position_at TIMESTAMPTZ NOT NULL,
+  position_event_id VARCHAR(36) NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS user_daily_spend (
+  user_id VARCHAR(36) NOT NULL,
+  day DATE NOT NULL,
+  currency VARCHAR(8) NOT NULL,
+  payments BIGINT NOT NULL DEFAULT 0,
+  amount BIGINT NOT NULL DEFAULT 0,
+  fee BIGINT NOT NULL DEFAULT 0,
+  failed_payments BIGINT NOT NULL DEFAULT 0,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (user_id, day, currency)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_daily_spend_day ON user_daily_spend (day);
+
+-- Projections read the history in (created_at, id) order from both tables.
+CREATE INDEX IF NOT EXISTS idx_outbox_created_at_id ON outbox (created_at, id);
+CREATE INDEX IF NOT EXISTS idx_outbox_archive_created_at_id ON outbox_archive (created_at, id);
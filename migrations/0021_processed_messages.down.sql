@@ -0,0 +1,3 @@
+-- Drop the consumer inbox. Consumers may apply redelivered messages again afterwards.
+
+DROP TABLE IF EXISTS processed_messages;
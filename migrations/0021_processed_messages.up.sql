@@ -0,0 +1,12 @@
+-- 0021_processed_messages.up.sql
+-- Consumer inbox: messages each consumer applied, written in the same transaction as the handler's effects.
+
+CREATE TABLE IF NOT EXISTS processed_messages (
+  consumer VARCHAR(128) NOT NULL,
+  message_id VARCHAR(128) NOT NULL,
+  event_type VARCHAR(128) NOT NULL,
+  processed_at TIMESTAMPTZ NOT NULL,
+  PRIMARY KEY (consumer, message_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_processed_messages_processed_at ON processed_messages (processed_at);
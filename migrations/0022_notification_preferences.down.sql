@@ -0,0 +1,3 @@
+-- Drop notification preferences. The notifications worker skips every user afterwards.
+
+DROP TABLE IF EXISTS notification_preferences;
@@ -0,0 +1,13 @@
+-- 0022_notification_preferences.up.sql
+-- Per-user notification preferences: email, template locale and an opt-in per event type.
+
+CREATE TABLE IF NOT EXISTS notification_preferences (
+  user_id VARCHAR(36) PRIMARY KEY,
+  email VARCHAR(320) NOT NULL,
+  locale VARCHAR(8) NOT NULL,
+  payment_completed BOOLEAN NOT NULL,
+  payment_failed BOOLEAN NOT NULL,
+  refund_created BOOLEAN NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL
+);
@@ -1,16 +1,68 @@
 from flask import Flask, request, jsonify
+import hashlib
+import hmac
+import json
+import os
+import threading
 import time
 import random
+import urllib.request
+import uuid
 
 app = Flask(__name__)
 
+# Funding charges keyed by top-up transaction id (also used as Idempotency-Key).
+charges = {}
+charges_lock = threading.Lock()
+# Seconds a pending charge takes to settle (reported via GET /fund/<id> and a callback).
+FUND_SETTLE_SECONDS = float(os.environ.get('FUND_SETTLE_SECONDS', '5'))
+# Mode used by /pay when the request does not set one.
+PAY_MODE = os.environ.get('PAY_MODE', 'random')
+# Signed asynchronous callbacks (POST /webhooks/gateway on the API); disabled without a URL.
+CALLBACK_URL = os.environ.get('CALLBACK_URL', '')
+CALLBACK_SECRET = os.environ.get('WEBHOOK_SECRET', '')
+CALLBACK_DELAY_SECONDS = float(os.environ.get('CALLBACK_DELAY_SECONDS', '3'))
+
+
+def send_callback(transaction_id, status):
+    """Posts an HMAC-SHA256 signed callback: hex(hmac(secret, "<timestamp>.<body>"))."""
+    if not CALLBACK_URL or not transaction_id:
+        return
+    body = json.dumps({
+        "event_id": str(uuid.uuid4()),
+        "transaction_id": transaction_id,
+        "status": status,
+    }).encode()
+    timestamp = str(int(time.time()))
+    signature = hmac.new(CALLBACK_SECRET.encode(), timestamp.encode() + b"." + body, hashlib.sha256).hexdigest()
+    req = urllib.request.Request(CALLBACK_URL, data=body, method='POST', headers={
+        "Content-Type": "application/json",
+        "X-Gateway-Timestamp": timestamp,
+        "X-Gateway-Signature": signature,
+    })
+    try:
+        urllib.request.urlopen(req, timeout=5).close()
+    except Exception as exc:  # the API may be down; real gateways retry, the mock just logs
+        app.logger.warning("callback for %s failed: %s", transaction_id, exc)
+
+
+def schedule_callback(delay, transaction_id, status_fn):
+    def run():
+        send_callback(transaction_id, status_fn())
+    threading.Timer(delay, run).start()
+
 @app.route('/pay', methods=['POST'])
 def pay():
     data = request.json
     #chamge this mode to test different scenarios
-    mode = data.get('mode', 'random')
-    
-    if mode == 'timeout':
+    mode = data.get('mode', PAY_MODE)
+
+    if mode == 'async':
+        # Accept now and confirm later through a signed callback.
+        outcome = random.choices(['approved', 'declined', 'failed'], weights=[0.8, 0.15, 0.05], k=1)[0]
+        schedule_callback(CALLBACK_DELAY_SECONDS, data.get('payment_id'), lambda: outcome)
+        return jsonify({"status": "pending"}), 202
+    elif mode == 'timeout':
         time.sleep(10)  # Simulate timeout
         return jsonify({"status": "timeout"}), 504
     elif mode == 'error':
@@ -36,5 +88,53 @@ def pay():
     else:  # happy
         return jsonify({"status": "approved"}), 200
 
+@app.route('/fund', methods=['POST'])
+def fund():
+    data = request.json
+    charge_id = request.headers.get('Idempotency-Key') or data.get('transaction_id')
+    # pending (default): settles after FUND_SETTLE_SECONDS; approved/declined: immediate outcome.
+    # A source containing "decline" settles as declined.
+    mode = data.get('mode', 'pending')
+
+    with charges_lock:
+        if charge_id in charges:
+            charge = charges[charge_id]
+            return jsonify({"status": charge['status']}), 200
+
+    if mode == 'timeout':
+        time.sleep(10)
+        return jsonify({"status": "timeout"}), 504
+    elif mode == 'error':
+        return jsonify({"status": "error"}), 500
+
+    outcome = 'declined' if 'decline' in data.get('source', '') else 'approved'
+    status = 'pending'
+    if mode in ('approved', 'declined'):
+        status = mode
+    with charges_lock:
+        charges[charge_id] = {"status": status, "outcome": outcome, "created_at": time.time()}
+    if status == 'pending':
+        schedule_callback(FUND_SETTLE_SECONDS, charge_id, lambda: settle_charge(charge_id))
+    if status == 'declined':
+        return jsonify({"status": status}), 402
+    return jsonify({"status": status}), 202 if status == 'pending' else 200
+
+def settle_charge(charge_id):
+    with charges_lock:
+        charge = charges[charge_id]
+        if charge['status'] == 'pending':
+            charge['status'] = charge['outcome']
+        return charge['status']
+
+@app.route('/fund/<charge_id>', methods=['GET'])
+def fund_status(charge_id):
+    with charges_lock:
+        charge = charges.get(charge_id)
+        if charge is None:
+            return jsonify({"status": "not_found"}), 404
+        if charge['status'] == 'pending' and time.time() - charge['created_at'] >= FUND_SETTLE_SECONDS:
+            charge['status'] = charge['outcome']
+        return jsonify({"status": charge['status']}), 200
+
 if __name__ == '__main__':
     app.run(host='0.0.0.0', port=8080)
\ No newline at end of file